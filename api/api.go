@@ -0,0 +1,38 @@
+package api
+
+import (
+	"context"
+	_ "embed"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Spec - спецификация OpenAPI 3 в формате YAML, источник истины для HTTP API сервиса
+//
+//go:embed openapi.yaml
+var Spec []byte
+
+// Load разбирает спецификацию и проверяет ее на соответствие OpenAPI 3
+func Load(ctx context.Context) (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+
+	doc, err := loader.LoadFromData(Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	err = doc.Validate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// ServeSpec отдает спецификацию в формате YAML
+func ServeSpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(Spec)
+}
@@ -0,0 +1,16 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLoad - спецификация разбирается и соответствует OpenAPI 3
+func TestLoad(t *testing.T) {
+	doc, err := Load(context.Background())
+
+	assert.NoError(t, err)
+	assert.NotNil(t, doc.Paths.Find("/subscription/{id}"))
+}
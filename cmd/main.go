@@ -4,29 +4,38 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
 	"syscall"
+	"text/tabwriter"
 
-	_ "github.com/Ararat25/subscription-aggregation-service/docs"
+	"github.com/Ararat25/subscription-aggregation-service/api"
+	"github.com/Ararat25/subscription-aggregation-service/internal/auth"
 	"github.com/Ararat25/subscription-aggregation-service/internal/config"
 	"github.com/Ararat25/subscription-aggregation-service/internal/controller"
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
 	"github.com/Ararat25/subscription-aggregation-service/internal/logger"
 	middle "github.com/Ararat25/subscription-aggregation-service/internal/middleware"
+	"github.com/Ararat25/subscription-aggregation-service/internal/migrate"
 	"github.com/Ararat25/subscription-aggregation-service/internal/model"
+	"github.com/Ararat25/subscription-aggregation-service/internal/publisher"
+	"github.com/Ararat25/subscription-aggregation-service/internal/ratelimit"
 	"github.com/Ararat25/subscription-aggregation-service/internal/repository"
+	"github.com/Ararat25/subscription-aggregation-service/internal/tenant"
+	"github.com/Ararat25/subscription-aggregation-service/migrations"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/jackc/pgx/v5"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"go.uber.org/zap"
 )
 
-// @title Subscription aggregation service API
-// @version 1.0
-// @description API для управления подписками и расчета их стоимости.
-// @BasePath /api/v1
 func main() {
 	conf, err := config.Init()
 	if err != nil {
@@ -46,8 +55,25 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
-	db := repository.PGRepo{}
-	err = db.ConnectDB(ctx, conf.Database.Host, conf.Database.User, conf.Database.Password, conf.Database.Name, conf.Database.Port)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err = runMigrate(ctx, conf.Database, os.Args[2:], os.Stdout)
+		if err != nil {
+			log.Fatalf("error running migrations: %v\n", err)
+		}
+		return
+	}
+
+	receiptSigner, err := initReceiptSigner(conf.Privacy)
+	if err != nil {
+		log.Fatalf("error init erasure receipts: %v\n", err)
+	}
+
+	db, err := initStorage(conf.Database)
+	if err != nil {
+		log.Fatalf("error init storage: %v\n", err)
+	}
+
+	err = db.ConnectDB(ctx, conf.Database)
 	if err != nil {
 		log.Fatalf("error connecting to database: %v\n", err)
 	}
@@ -57,13 +83,61 @@ func main() {
 		}
 	}()
 
+	if conf.Database.AutoMigrate {
+		err = autoMigrate(ctx, conf.Database)
+		if err != nil {
+			log.Fatalf("error applying migrations: %v\n", err)
+		}
+	}
+
 	logger.Log.Info("Successful connection to the database",
+		zap.String("driver", conf.Database.Driver),
 		zap.String("host", conf.Database.Host),
 		zap.Int("port", conf.Database.Port),
+		zap.Int32("max_conns", conf.Database.MaxConns),
+		zap.Strings("replicas", conf.Database.ReplicaHosts),
 	)
 
-	handler := initApp(&db)
-	router := initRouter(handler)
+	pub, closePub, err := initPublisher(conf.Outbox)
+	if err != nil {
+		log.Fatalf("error init event publisher: %v\n", err)
+	}
+	defer func() {
+		if err := closePub(); err != nil {
+			logger.Log.Error("failed to close event publisher", zap.Error(err))
+		}
+	}()
+
+	spec, err := api.Load(ctx)
+	if err != nil {
+		log.Fatalf("error loading openapi spec: %v\n", err)
+	}
+
+	// в режиме разработки ответы тоже проверяются по спецификации, чтобы расхождения находились сразу
+	openAPIValidator, err := middle.OpenAPIValidator(spec, conf.Server.Logging == "dev", controller.SendOpenAPIError)
+	if err != nil {
+		log.Fatalf("error init openapi validator: %v\n", err)
+	}
+
+	apiKeyManager := model.NewAPIKeyManager(db)
+	authenticate, err := initAuth(conf.Auth, apiKeyManager)
+	if err != nil {
+		log.Fatalf("error init authentication: %v\n", err)
+	}
+
+	limits, err := initRateLimits(ctx, conf.RateLimit, db)
+	if err != nil {
+		log.Fatalf("error init rate limits: %v\n", err)
+	}
+
+	handlers := initApp(ctx, conf, db, pub, apiKeyManager, receiptSigner)
+	router := initRouter(handlers, routerMiddlewares{
+		authenticate:       authenticate,
+		openAPIValidator:   openAPIValidator,
+		ipRateLimit:        limits.ip,
+		rateLimit:          limits.client,
+		expensiveRateLimit: limits.expensive,
+	})
 	runApp(ctx, conf, router)
 }
 
@@ -98,29 +172,446 @@ func runApp(ctx context.Context, conf *config.Config, router *chi.Mux) {
 	logger.Log.Info("Server stopped")
 }
 
-// initApp инициализирует сервисы приложения
-func initApp(db repository.Repo) *controller.Handler {
-	authService := model.NewAggregationService(db)
+// appHandlers - набор обработчиков запросов приложения
+type appHandlers struct {
+	subscription *controller.Handler           // обработчики подписок
+	webhook      *controller.WebhookHandler    // обработчики вебхуков
+	catalog      *controller.CatalogHandler    // обработчики каталога сервисов
+	apiKey       *controller.APIKeyHandler     // обработчики API-ключей
+	tenant       *controller.TenantHandler     // обработчики тенантов
+	system       *controller.SystemHandler     // обработчики состояния развертывания
+	costRollup   *controller.CostRollupHandler // обработчики помесячных итогов стоимости
+	privacy      *controller.PrivacyHandler    // обработчики выгрузки и удаления данных пользователя
+}
+
+// initStorage создает хранилище, выбранное в DB_DRIVER. Хранилище sqlite хранит данные в одном файле
+// для однопользовательских развертываний, хранилище memory не сохраняет данные между запусками
+// и предназначено для локальных демонстраций и тестов
+func initStorage(conf config.DatabaseConfig) (repository.Storage, error) {
+	switch conf.Driver {
+	case "postgres":
+		return &repository.PGRepo{}, nil
+	case "sqlite":
+		return repository.NewSQLiteRepo(), nil
+	case "memory":
+		logger.Log.Warn("DB_DRIVER is memory: data is lost on restart")
+		return repository.NewMemRepo(), nil
+	default:
+		return nil, fmt.Errorf("unknown DB_DRIVER: %s", conf.Driver)
+	}
+}
+
+// migrateUsage - синтаксис команды migrate
+const migrateUsage = "usage: migrate up | down [N] | status | version"
+
+// autoMigrate применяет встроенные миграции Postgres при запуске. Экземпляры сервиса, запущенные одновременно,
+// применяют их по очереди под advisory-блокировкой. Хранилище sqlite применяет свои миграции при подключении,
+// хранилищу memory миграции не нужны
+func autoMigrate(ctx context.Context, conf config.DatabaseConfig) error {
+	if conf.Driver != "postgres" {
+		return nil
+	}
+
+	return withMigrator(ctx, conf, func(m *migrate.Migrator) error {
+		applied, err := m.Up(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range applied {
+			logger.Log.Info("Migration applied", zap.Int64("version", migration.Version), zap.String("name", migration.Name))
+		}
+
+		return nil
+	})
+}
+
+// runMigrate выполняет команду migrate со встроенными миграциями Postgres и пишет результат в w:
+// up применяет все новые миграции, down [N] откатывает N последних (по умолчанию одну),
+// status выводит список миграций, version - текущую версию схемы
+func runMigrate(ctx context.Context, conf config.DatabaseConfig, args []string, w io.Writer) error {
+	if conf.Driver != "postgres" {
+		return fmt.Errorf("migrate requires DB_DRIVER=postgres, sqlite storage applies its migrations on connect")
+	}
+
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	steps := 1
+	switch {
+	case !slices.Contains([]string{"up", "down", "status", "version"}, args[0]):
+		return errors.New(migrateUsage)
+	case args[0] == "down" && len(args) == 2:
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid number of migrations to revert: %s", args[1])
+		}
+		steps = n
+	case len(args) > 1:
+		return errors.New(migrateUsage)
+	}
+
+	return withMigrator(ctx, conf, func(m *migrate.Migrator) error {
+		switch args[0] {
+		case "up":
+			applied, err := m.Up(ctx)
+			if err != nil {
+				return err
+			}
+
+			for _, migration := range applied {
+				fmt.Fprintf(w, "applied %d_%s\n", migration.Version, migration.Name)
+			}
+			if len(applied) == 0 {
+				fmt.Fprintln(w, "no change")
+			}
+		case "down":
+			reverted, err := m.Down(ctx, steps)
+			if err != nil {
+				return err
+			}
+
+			for _, migration := range reverted {
+				fmt.Fprintf(w, "reverted %d_%s\n", migration.Version, migration.Name)
+			}
+			if len(reverted) == 0 {
+				fmt.Fprintln(w, "no change")
+			}
+		case "status":
+			statuses, dirty, err := m.Status(ctx)
+			if err != nil {
+				return err
+			}
+
+			tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS")
+			for _, s := range statuses {
+				status := "pending"
+				if s.Applied {
+					status = "applied"
+				}
+				fmt.Fprintf(tw, "%d\t%s\t%s\n", s.Version, s.Name, status)
+			}
+			err = tw.Flush()
+			if err != nil {
+				return err
+			}
+
+			if dirty {
+				fmt.Fprintln(w, "database schema is dirty: the last migration failed and must be fixed manually")
+			}
+		case "version":
+			version, dirty, err := m.Version(ctx)
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case version == migrate.NoVersion:
+				fmt.Fprintln(w, "no migrations applied")
+			case dirty:
+				fmt.Fprintf(w, "%d (dirty)\n", version)
+			default:
+				fmt.Fprintln(w, version)
+			}
+		default:
+			return errors.New(migrateUsage)
+		}
+
+		return nil
+	})
+}
+
+// withMigrator открывает отдельное соединение с Postgres и выполняет fn с объектом применения миграций на нем
+func withMigrator(ctx context.Context, conf config.DatabaseConfig, fn func(m *migrate.Migrator) error) error {
+	conn, err := pgx.Connect(ctx, conf.PostgresDSN())
+	if err != nil {
+		return fmt.Errorf("unable to connect to database: %w", err)
+	}
+	defer func() {
+		if err := conn.Close(context.WithoutCancel(ctx)); err != nil {
+			logger.Log.Error("failed to close migration connection", zap.Error(err))
+		}
+	}()
+
+	m, err := migrate.New(conn, migrations.Postgres)
+	if err != nil {
+		return err
+	}
+
+	return fn(m)
+}
+
+// initPublisher создает публикатор событий из outbox и функцию для его закрытия
+func initPublisher(conf config.OutboxConfig) (publisher.Publisher, func() error, error) {
+	switch conf.Publisher {
+	case "memory":
+		return publisher.NewMemory(conf.MemoryBuffer), func() error { return nil }, nil
+	case "nats":
+		pub, err := publisher.NewNATS(conf.NatsURL, conf.NatsSubject, conf.NatsJetStream)
+		if err != nil {
+			return nil, nil, err
+		}
+		return pub, pub.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown OUTBOX_PUBLISHER: %s", conf.Publisher)
+	}
+}
 
-	handler := controller.NewHandler(authService)
+// initAuth создает middleware аутентификации по JWT и API-ключам. Если источник ключей JWT не задан, принимаются
+// только API-ключи. Если аутентификация выключена, запросы пропускаются без клиента в контексте
+// и работают с данными тенанта по умолчанию без ограничения по пользователю
+func initAuth(conf config.AuthConfig, apiKeys middle.TokenVerifier) (func(http.Handler) http.Handler, error) {
+	if !conf.Enabled {
+		logger.Log.Warn("authentication is disabled: API is open to everyone")
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				next.ServeHTTP(w, r.WithContext(tenant.WithID(r.Context(), tenant.DefaultID)))
+			})
+		}, nil
+	}
+
+	verifiers := map[string]middle.TokenVerifier{middle.SchemeAPIKey: apiKeys}
+	if conf.JWTSecret != "" || conf.JWKSFile != "" {
+		jwtVerifier, err := auth.NewJWTVerifier(conf)
+		if err != nil {
+			return nil, err
+		}
+		verifiers[middle.SchemeBearer] = jwtVerifier
+	} else {
+		logger.Log.Warn("AUTH_JWT_SECRET and AUTH_JWKS_FILE are not set: only API keys are accepted")
+	}
 
-	return handler
+	return middle.Authenticate(verifiers, controller.SendUnauthorized), nil
 }
 
-// initRouter настраивает маршруты и middleware для сервера
-func initRouter(handler *controller.Handler) *chi.Mux {
+// rateLimits - middleware ограничения частоты запросов
+type rateLimits struct {
+	ip        func(http.Handler) http.Handler // ограничение по IP до аутентификации
+	client    func(http.Handler) http.Handler // общее ограничение по клиенту после аутентификации
+	expensive func(http.Handler) http.Handler // более строгое ограничение для дорогих ручек
+}
+
+// initRateLimits создает middleware ограничения частоты запросов по IP до аутентификации, общего ограничения
+// по клиенту и более строгого ограничения для дорогих ручек и запускает очистку хранилища корзин
+func initRateLimits(ctx context.Context, conf config.RateLimitConfig, db repository.RateLimitRepo) (*rateLimits, error) {
+	if !conf.Enabled {
+		noop := func(next http.Handler) http.Handler { return next }
+		return &rateLimits{ip: noop, client: noop, expensive: noop}, nil
+	}
+
+	ipLimit := ratelimit.Limit{Rate: conf.IPRate, Burst: conf.IPBurst}
+	limit := ratelimit.Limit{Rate: conf.Rate, Burst: conf.Burst}
+	expensiveLimit := ratelimit.Limit{Rate: conf.ExpensiveRate, Burst: conf.ExpensiveBurst}
+	for _, l := range []ratelimit.Limit{ipLimit, limit, expensiveLimit} {
+		if l.Rate <= 0 || l.Burst < 1 {
+			return nil, fmt.Errorf("rate limit rate and burst must be positive")
+		}
+	}
+
+	keys := make([]middle.KeyFunc, 0, len(conf.KeyBy))
+	for _, name := range conf.KeyBy {
+		switch name {
+		case "api_key":
+			keys = append(keys, middle.ByAPIKey)
+		case "user":
+			keys = append(keys, middle.ByUser)
+		case "ip":
+			keys = append(keys, middle.ByIP(conf.TrustProxy))
+		default:
+			return nil, fmt.Errorf("unknown RATE_LIMIT_KEY_BY: %s", name)
+		}
+	}
+
+	var store ratelimit.Store
+	switch conf.Store {
+	case "memory":
+		store = ratelimit.NewMemory()
+	case "postgres":
+		store = ratelimit.NewPostgres(db, max(ipLimit.FillTime(), limit.FillTime(), expensiveLimit.FillTime()))
+	default:
+		return nil, fmt.Errorf("unknown RATE_LIMIT_STORE: %s", conf.Store)
+	}
+	go store.Run(ctx)
+
+	return &rateLimits{
+		// запросы с неверными учетными данными отклоняются аутентификацией, поэтому до нее клиента можно определить
+		// только по IP: так подбор ключей и токенов тоже расходует корзину и не нагружает бд без ограничений
+		ip:        middle.RateLimit(store, "ip", ipLimit, []middle.KeyFunc{middle.ByIP(conf.TrustProxy)}, controller.SendRateLimited),
+		client:    middle.RateLimit(store, "default", limit, keys, controller.SendRateLimited),
+		expensive: middle.RateLimit(store, "expensive", expensiveLimit, keys, controller.SendRateLimited),
+	}, nil
+}
+
+// initApp инициализирует сервисы приложения и запускает фоновые задачи.
+// Без подписи квитанций receiptSigner обработчики данных пользователя не создаются
+func initApp(ctx context.Context, conf *config.Config, db repository.Storage, pub publisher.Publisher, apiKeys *model.APIKeyManager, receiptSigner *entity.ReceiptSigner) *appHandlers {
+	webhookDispatcher := model.NewWebhookDispatcher(db, conf.Webhook)
+	go webhookDispatcher.Run(ctx)
+
+	// события из outbox уходят и во внешний публикатор, и в журнал доставок вебхуков
+	outboxRelay := model.NewOutboxRelay(db, publisher.NewMulti(pub, webhookDispatcher), conf.Outbox)
+	go outboxRelay.Run(ctx)
+
+	costRollup := model.NewCostRollup(db, conf.Rollup)
+	go costRollup.Run(ctx)
+
+	go model.NewSubscriptionArchiver(db, conf.Archive).Run(ctx)
+
+	aggregationService := model.NewAggregationService(db)
+
+	var privacy *controller.PrivacyHandler
+	if receiptSigner != nil {
+		privacy = controller.NewPrivacyHandler(model.NewUserPrivacy(db, receiptSigner))
+	}
+
+	return &appHandlers{
+		subscription: controller.NewHandler(aggregationService),
+		webhook:      controller.NewWebhookHandler(webhookDispatcher),
+		catalog:      controller.NewCatalogHandler(model.NewServiceCatalog(db)),
+		apiKey:       controller.NewAPIKeyHandler(apiKeys),
+		tenant:       controller.NewTenantHandler(model.NewTenantManager(db)),
+		system:       controller.NewSystemHandler(model.NewSystemMonitor(db)),
+		costRollup:   controller.NewCostRollupHandler(costRollup),
+		privacy:      privacy,
+	}
+}
+
+// minReceiptKeyLen - минимальная длина ключа подписи квитанций об удалении данных, байт
+const minReceiptKeyLen = 32
+
+// placeholderSecrets - значения-заглушки из примеров конфигурации, известные всем
+var placeholderSecrets = []string{"change-me", "changeme", "secret"}
+
+// initReceiptSigner создает подпись квитанций об удалении данных. Если ключ не задан, возвращает nil:
+// ручки выгрузки и удаления данных пользователя выключены. Заглушка из примера конфигурации или короткий ключ
+// отклоняются, потому что с известным ключом квитанции можно подделать
+func initReceiptSigner(conf config.PrivacyConfig) (*entity.ReceiptSigner, error) {
+	if conf.ReceiptKey == "" {
+		logger.Log.Warn("PRIVACY_RECEIPT_KEY is not set: user data export and erasure are disabled")
+		return nil, nil
+	}
+
+	for _, placeholder := range placeholderSecrets {
+		if strings.EqualFold(conf.ReceiptKey, placeholder) {
+			return nil, fmt.Errorf("PRIVACY_RECEIPT_KEY is a placeholder value, generate a random secret")
+		}
+	}
+
+	if len(conf.ReceiptKey) < minReceiptKeyLen {
+		return nil, fmt.Errorf("PRIVACY_RECEIPT_KEY must be at least %d bytes long", minReceiptKeyLen)
+	}
+
+	return entity.NewReceiptSigner([]byte(conf.ReceiptKey)), nil
+}
+
+// routerMiddlewares - middleware роутера, зависящие от конфигурации
+type routerMiddlewares struct {
+	authenticate       func(http.Handler) http.Handler // аутентификация клиента
+	openAPIValidator   func(http.Handler) http.Handler // проверка запросов по спецификации
+	ipRateLimit        func(http.Handler) http.Handler // ограничение частоты запросов по IP до аутентификации
+	rateLimit          func(http.Handler) http.Handler // общее ограничение частоты запросов
+	expensiveRateLimit func(http.Handler) http.Handler // более строгое ограничение для дорогих ручек
+}
+
+// rolePolicy - права ролей на операции API. Наблюдатель и редактор работают только со своими подписками
+// (ограничение по пользователю задает auth.UserScope), администратор - с подписками всех пользователей тенанта.
+// Оператор управляет тенантами и не имеет доступа к их данным
+var rolePolicy = auth.Policy{
+	auth.RoleViewer: {auth.PermSubscriptionsRead, auth.PermCostRead},
+	auth.RoleEditor: {auth.PermSubscriptionsRead, auth.PermSubscriptionsWrite, auth.PermCostRead},
+	auth.RoleAdmin: {
+		auth.PermSubscriptionsRead, auth.PermSubscriptionsWrite, auth.PermCostRead,
+		auth.PermWebhooksManage, auth.PermCatalogManage, auth.PermAPIKeysManage, auth.PermCostMaintain,
+		auth.PermPrivacyManage,
+	},
+	auth.RoleOperator: {auth.PermTenantsManage, auth.PermSystemRead},
+}
+
+// initRouter настраивает маршруты и middleware для сервера. Каждый маршрут объявляет право из rolePolicy,
+// необходимое для операции
+func initRouter(handlers *appHandlers, mw routerMiddlewares) *chi.Mux {
+	handler := handlers.subscription
+	can := func(perm auth.Permission) func(http.Handler) http.Handler {
+		return middle.Authorize(rolePolicy, perm, controller.SendForbidden)
+	}
+
 	r := chi.NewRouter()
 	r.Use(middle.ZapLogger(logger.Log))
 	r.Use(middleware.Recoverer)
 	r.Use(middle.JsonHeader)
 
-	r.Get("/api/v1/doc/*", httpSwagger.WrapHandler)
-	r.Post("/api/v1/subscription", handler.CreateSubscription)
-	r.Get("/api/v1/subscription/{id}", handler.ReadSubscription)
-	r.Put("/api/v1/subscription/update", handler.UpdateSubscription)
-	r.Delete("/api/v1/subscription/delete/{id}", handler.DeleteSubscription)
-	r.Get("/api/v1/subscriptions", handler.ListSubscriptions)
-	r.Get("/api/v1/subscriptions/cost", handler.TotalCost)
+	r.Get("/api/v1/openapi.yaml", api.ServeSpec)
+	r.Get("/api/v1/doc/*", httpSwagger.Handler(httpSwagger.URL("/api/v1/openapi.yaml")))
+
+	r.Group(func(r chi.Router) {
+		// до аутентификации запросы ограничиваются по IP, чтобы подбор учетных данных не проходил без ограничений,
+		// а после нее - по клиенту, чтобы корзины можно было вести по ключу и пользователю
+		r.Use(mw.ipRateLimit)
+		r.Use(mw.authenticate)
+		r.Use(mw.rateLimit)
+		r.Use(mw.openAPIValidator)
+
+		read := r.With(can(auth.PermSubscriptionsRead))
+		write := r.With(can(auth.PermSubscriptionsWrite))
+		// агрегирующие запросы и поиск нагружают бд сильнее остальных, поэтому ограничены дополнительно
+		cost := r.With(mw.expensiveRateLimit, can(auth.PermCostRead))
+		search := r.With(mw.expensiveRateLimit, can(auth.PermSubscriptionsRead))
+
+		write.Post("/api/v1/subscription", handler.CreateSubscription)
+		read.Get("/api/v1/subscription/{id}", handler.ReadSubscription)
+		write.Put("/api/v1/subscription/update", handler.UpdateSubscription)
+		write.Delete("/api/v1/subscription/delete/{id}", handler.DeleteSubscription)
+		write.Post("/api/v1/subscription/{id}/cancel", handler.CancelSubscription)
+		read.Get("/api/v1/subscriptions", handler.ListSubscriptions)
+		cost.Get("/api/v1/subscriptions/cost", handler.TotalCost)
+		search.Get("/api/v1/subscriptions/search", handler.SearchSubscriptions)
+		read.Get("/api/v1/services/suggest", handler.SuggestServiceNames)
+		read.Get("/api/v1/users/{user_id}/subscriptions", handler.ListUserSubscriptions)
+		cost.Get("/api/v1/users/{user_id}/summary", handler.UserSummary)
+
+		// вебхуки получают события по подпискам всех пользователей, а API-ключи дают доступ к ним,
+		// поэтому эти права есть только у администраторов
+		webhooks := r.With(can(auth.PermWebhooksManage))
+		webhooks.Post("/api/v1/webhooks", handlers.webhook.CreateWebhook)
+		webhooks.Get("/api/v1/webhooks", handlers.webhook.ListWebhooks)
+		webhooks.Delete("/api/v1/webhooks/{id}", handlers.webhook.DeleteWebhook)
+		webhooks.Get("/api/v1/webhooks/{id}/deliveries", handlers.webhook.ListDeliveries)
+		webhooks.Post("/api/v1/webhooks/deliveries/{id}/redeliver", handlers.webhook.Redeliver)
+
+		catalog := r.With(can(auth.PermCatalogManage))
+		catalog.Post("/api/v1/admin/services", handlers.catalog.CreateService)
+		catalog.Get("/api/v1/admin/services", handlers.catalog.ListServices)
+		catalog.Get("/api/v1/admin/services/{id}", handlers.catalog.ReadService)
+		catalog.Put("/api/v1/admin/services/{id}", handlers.catalog.UpdateService)
+		catalog.Delete("/api/v1/admin/services/{id}", handlers.catalog.DeleteService)
+
+		apiKeys := r.With(can(auth.PermAPIKeysManage))
+		apiKeys.Post("/api/v1/admin/api-keys", handlers.apiKey.CreateAPIKey)
+		apiKeys.Get("/api/v1/admin/api-keys", handlers.apiKey.ListAPIKeys)
+		apiKeys.Delete("/api/v1/admin/api-keys/{id}", handlers.apiKey.RevokeAPIKey)
+
+		costRollup := r.With(can(auth.PermCostMaintain))
+		costRollup.Post("/api/v1/admin/monthly-costs/rebuild", handlers.costRollup.RebuildMonthlyCosts)
+		costRollup.Get("/api/v1/admin/monthly-costs/check", handlers.costRollup.CheckMonthlyCosts)
+
+		// без ключа подписи квитанций ручки данных пользователя не регистрируются
+		if handlers.privacy != nil {
+			privacy := r.With(can(auth.PermPrivacyManage))
+			privacy.Get("/api/v1/admin/users/{user_id}/export", handlers.privacy.ExportUserData)
+			privacy.Post("/api/v1/admin/users/{user_id}/erase", handlers.privacy.EraseUserData)
+			privacy.Get("/api/v1/admin/erasure-receipts/{id}", handlers.privacy.ReadErasureReceipt)
+		}
+
+		tenants := r.With(can(auth.PermTenantsManage))
+		tenants.Post("/api/v1/tenants", handlers.tenant.CreateTenant)
+		tenants.Get("/api/v1/tenants", handlers.tenant.ListTenants)
+		tenants.Get("/api/v1/tenants/cost", handlers.tenant.TenantCosts)
+
+		system := r.With(can(auth.PermSystemRead))
+		system.Get("/api/v1/system/db-pool", handlers.system.PoolStats)
+	})
 
 	return r
 }
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Ararat25/subscription-aggregation-service/api"
+	"github.com/Ararat25/subscription-aggregation-service/internal/auth"
+	"github.com/Ararat25/subscription-aggregation-service/internal/config"
+	"github.com/Ararat25/subscription-aggregation-service/internal/controller"
+	"github.com/Ararat25/subscription-aggregation-service/internal/logger"
+	middle "github.com/Ararat25/subscription-aggregation-service/internal/middleware"
+	"github.com/Ararat25/subscription-aggregation-service/internal/ratelimit"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// TestRoutesMatchSpec - каждый маршрут API описан в спецификации OpenAPI, и каждая операция спецификации имеет маршрут
+func TestRoutesMatchSpec(t *testing.T) {
+	doc, err := api.Load(context.Background())
+	require.NoError(t, err)
+
+	handlers := &appHandlers{
+		subscription: controller.NewHandler(nil),
+		webhook:      controller.NewWebhookHandler(nil),
+		catalog:      controller.NewCatalogHandler(nil),
+		apiKey:       controller.NewAPIKeyHandler(nil),
+		tenant:       controller.NewTenantHandler(nil),
+		system:       controller.NewSystemHandler(nil),
+		costRollup:   controller.NewCostRollupHandler(nil),
+		privacy:      controller.NewPrivacyHandler(nil),
+	}
+
+	// обработчик, не созданный здесь, остается nil, и его маршруты не сверялись бы со спецификацией
+	fields := reflect.ValueOf(handlers).Elem()
+	for i := range fields.NumField() {
+		require.False(t, fields.Field(i).IsNil(), "handler %s is not set", fields.Type().Field(i).Name)
+	}
+
+	noop := func(next http.Handler) http.Handler { return next }
+	router := initRouter(handlers, routerMiddlewares{authenticate: noop, openAPIValidator: noop, ipRateLimit: noop, rateLimit: noop, expensiveRateLimit: noop})
+
+	routes := make(map[string]bool)
+	err = chi.Walk(router, func(method string, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		path, ok := strings.CutPrefix(route, "/api/v1")
+		if !ok || path == "/openapi.yaml" || strings.HasPrefix(path, "/doc/") {
+			return nil
+		}
+
+		routes[method+" "+path] = true
+
+		item := doc.Paths.Find(path)
+		if assert.NotNil(t, item, "route %s %s is not in the spec", method, route) {
+			assert.NotNil(t, item.GetOperation(method), "route %s %s is not in the spec", method, route)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, routes)
+
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			assert.True(t, routes[method+" "+path], "spec operation %s %s has no route", method, path)
+		}
+	}
+}
+
+// TestIPRateLimitBeforeAuthentication - запросы с неверными учетными данными расходуют корзину IP до аутентификации
+func TestIPRateLimitBeforeAuthentication(t *testing.T) {
+	logger.Log = zap.NewNop()
+	noop := func(next http.Handler) http.Handler { return next }
+	authenticated := 0
+	reject := func(http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authenticated++
+			controller.SendUnauthorized(w, r)
+		})
+	}
+	ipRateLimit := middle.RateLimit(ratelimit.NewMemory(), "ip", ratelimit.Limit{Rate: 1, Burst: 1},
+		[]middle.KeyFunc{middle.ByIP(false)}, controller.SendRateLimited)
+
+	router := initRouter(&appHandlers{}, routerMiddlewares{
+		authenticate: reject, openAPIValidator: noop, ipRateLimit: ipRateLimit, rateLimit: noop, expensiveRateLimit: noop})
+
+	codes := make([]int, 0, 2)
+	for _, key := range []string{"guess-1", "guess-2"} {
+		req := httptest.NewRequest("GET", "/api/v1/subscriptions", nil)
+		req.RemoteAddr = "10.0.0.1:5000"
+		req.Header.Set("X-API-Key", key)
+		rw := httptest.NewRecorder()
+
+		router.ServeHTTP(rw, req)
+		codes = append(codes, rw.Code)
+	}
+
+	assert.Equal(t, []int{http.StatusUnauthorized, http.StatusTooManyRequests}, codes)
+	assert.Equal(t, 1, authenticated)
+}
+
+// TestRolePolicy - права ролей на операции API
+func TestRolePolicy(t *testing.T) {
+	// Тестовый случай 1: Наблюдатель только читает подписки и их стоимость
+	assert.True(t, rolePolicy.Allows(auth.RoleViewer, auth.PermSubscriptionsRead))
+	assert.True(t, rolePolicy.Allows(auth.RoleViewer, auth.PermCostRead))
+	assert.False(t, rolePolicy.Allows(auth.RoleViewer, auth.PermSubscriptionsWrite))
+
+	// Тестовый случай 2: Редактор изменяет подписки, но не управляет сервисом
+	assert.True(t, rolePolicy.Allows(auth.RoleEditor, auth.PermSubscriptionsWrite))
+	assert.True(t, rolePolicy.Allows(auth.RoleEditor, auth.PermCostRead))
+	for _, perm := range []auth.Permission{auth.PermWebhooksManage, auth.PermCatalogManage, auth.PermAPIKeysManage} {
+		assert.False(t, rolePolicy.Allows(auth.RoleEditor, perm), perm)
+		assert.False(t, rolePolicy.Allows(auth.RoleViewer, perm), perm)
+	}
+
+	// Тестовый случай 3: Администратору разрешены все операции
+	for _, perm := range []auth.Permission{
+		auth.PermSubscriptionsRead, auth.PermSubscriptionsWrite, auth.PermCostRead,
+		auth.PermWebhooksManage, auth.PermCatalogManage, auth.PermAPIKeysManage,
+	} {
+		assert.True(t, rolePolicy.Allows(auth.RoleAdmin, perm), perm)
+	}
+
+	// Тестовый случай 4: Тенантами и состоянием сервиса управляет только оператор, к данным тенантов у него доступа нет
+	assert.True(t, rolePolicy.Allows(auth.RoleOperator, auth.PermTenantsManage))
+	assert.True(t, rolePolicy.Allows(auth.RoleOperator, auth.PermSystemRead))
+	for _, role := range []auth.Role{auth.RoleViewer, auth.RoleEditor, auth.RoleAdmin} {
+		assert.False(t, rolePolicy.Allows(role, auth.PermTenantsManage), role)
+		assert.False(t, rolePolicy.Allows(role, auth.PermSystemRead), role)
+	}
+	assert.False(t, rolePolicy.Allows(auth.RoleOperator, auth.PermSubscriptionsRead))
+}
+
+// TestRunMigrateArgs - неверные аргументы команды migrate отклоняются до подключения к бд
+func TestRunMigrateArgs(t *testing.T) {
+	ctx := context.Background()
+	conf := config.DatabaseConfig{Driver: "postgres"}
+
+	// Тестовый случай 1: Хранилище sqlite применяет миграции само
+	err := runMigrate(ctx, config.DatabaseConfig{Driver: "sqlite"}, []string{"up"}, io.Discard)
+	assert.ErrorContains(t, err, "DB_DRIVER=postgres")
+
+	// Тестовый случай 2: Команда не указана, неизвестна или указана с лишними аргументами
+	for _, args := range [][]string{nil, {"redo"}, {"up", "1"}, {"down", "1", "2"}} {
+		err = runMigrate(ctx, conf, args, io.Discard)
+		assert.EqualError(t, err, migrateUsage, args)
+	}
+
+	// Тестовый случай 3: Количество откатываемых миграций должно быть положительным числом
+	for _, n := range []string{"0", "-1", "all"} {
+		err = runMigrate(ctx, conf, []string{"down", n}, io.Discard)
+		assert.ErrorContains(t, err, "invalid number of migrations to revert", n)
+	}
+}
+
+// TestInitReceiptSigner - ключ подписи квитанций об удалении данных
+func TestInitReceiptSigner(t *testing.T) {
+	logger.Log = zap.NewNop()
+
+	// Тестовый случай 1: Без ключа ручки данных пользователя выключены, но приложение запускается
+	signer, err := initReceiptSigner(config.PrivacyConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, signer)
+
+	// Тестовый случай 2: Заглушка из примера конфигурации отклоняется
+	for _, key := range []string{"change-me", "CHANGE-ME", "secret"} {
+		_, err = initReceiptSigner(config.PrivacyConfig{ReceiptKey: key})
+		assert.ErrorContains(t, err, "placeholder", key)
+	}
+
+	// Тестовый случай 3: Короткий ключ отклоняется
+	_, err = initReceiptSigner(config.PrivacyConfig{ReceiptKey: "0123456789abcdef"})
+	assert.ErrorContains(t, err, "at least 32 bytes")
+
+	// Тестовый случай 4: Случайный ключ достаточной длины принимается
+	signer, err = initReceiptSigner(config.PrivacyConfig{ReceiptKey: strings.Repeat("k", minReceiptKeyLen)})
+	require.NoError(t, err)
+	assert.NotNil(t, signer)
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Способы аутентификации клиента
+const (
+	MethodJWT    = "jwt"     // bearer-токен JWT
+	MethodAPIKey = "api_key" // API-ключ
+)
+
+// Identity - аутентифицированный клиент
+type Identity struct {
+	Subject string    // идентификатор клиента: claim sub токена или "api_key:<id>"
+	UserID  uuid.UUID // id пользователя, для обычного пользователя совпадает с sub
+	Role    Role      // роль клиента
+	Method  string    // способ аутентификации
+	KeyID   int64     // id API-ключа, если клиент аутентифицирован ключом
+	// TenantID - тенант, данными которого ограничен клиент. uuid.Nil - у клиента нет тенанта (оператор)
+	TenantID uuid.UUID
+}
+
+// IsAdmin проверяет, является ли клиент администратором с доступом к подпискам всех пользователей
+func (id *Identity) IsAdmin() bool {
+	return id.Role == RoleAdmin
+}
+
+// identityKey - ключ Identity в контексте запроса
+type identityKey struct{}
+
+// WithIdentity возвращает контекст с клиентом id
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext возвращает клиента из контекста запроса
+func FromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(*Identity)
+	return id, ok && id != nil
+}
+
+// UserScope возвращает id пользователя, подписками которого ограничен клиент.
+// nil - ограничения нет: клиент - администратор или запрос выполняется без аутентификации (она выключена)
+func UserScope(ctx context.Context) *uuid.UUID {
+	id, ok := FromContext(ctx)
+	if !ok || id.IsAdmin() {
+		return nil
+	}
+
+	userID := id.UserID
+	return &userID
+}
@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/config"
+	"github.com/Ararat25/subscription-aggregation-service/internal/tenant"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidToken - токен не прошел проверку
+var ErrInvalidToken = errors.New("invalid token")
+
+// claims - поля JWT, которые использует сервис
+type claims struct {
+	jwt.RegisteredClaims
+	Roles    []string `json:"roles"`     // роли клиента
+	TenantID string   `json:"tenant_id"` // тенант клиента
+}
+
+// JWTVerifier - проверка JWT, подписанных общим секретом (HS*) или ключами из JWKS-файла (RS*, PS*, ES*)
+type JWTVerifier struct {
+	parser      *jwt.Parser // парсер с допустимыми алгоритмами и проверкой issuer и audience
+	keyFunc     jwt.Keyfunc // выбор ключа для проверки подписи
+	roleClaims  []roleClaim // соответствие значений claim roles ролям по убыванию приоритета
+	defaultRole Role        // роль токена без известных ролей, пусто - такой токен отклоняется
+}
+
+// roleClaim - значение claim roles, соответствующее роли
+type roleClaim struct {
+	claim string // значение в claim roles
+	role  Role   // роль клиента
+}
+
+// NewJWTVerifier создает новый объект JWTVerifier по конфигурации. Должен быть указан ровно один источник ключей:
+// общий секрет или JWKS-файл
+func NewJWTVerifier(conf config.AuthConfig) (*JWTVerifier, error) {
+	opts := []jwt.ParserOption{jwt.WithExpirationRequired(), jwt.WithLeeway(conf.Leeway)}
+	if conf.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(conf.Issuer))
+	}
+	if conf.Audience != "" {
+		opts = append(opts, jwt.WithAudience(conf.Audience))
+	}
+
+	v := &JWTVerifier{roleClaims: []roleClaim{
+		{claim: conf.OperatorRole, role: RoleOperator},
+		{claim: conf.AdminRole, role: RoleAdmin},
+		{claim: conf.EditorRole, role: RoleEditor},
+		{claim: conf.ViewerRole, role: RoleViewer},
+	}}
+	switch conf.DefaultRole {
+	case "none":
+	case string(RoleViewer), string(RoleEditor):
+		v.defaultRole = Role(conf.DefaultRole)
+	default:
+		return nil, fmt.Errorf("AUTH_DEFAULT_ROLE must be viewer, editor or none")
+	}
+
+	switch {
+	case conf.JWTSecret != "" && conf.JWKSFile != "":
+		return nil, fmt.Errorf("only one of AUTH_JWT_SECRET and AUTH_JWKS_FILE must be set")
+	case conf.JWTSecret != "":
+		secret := []byte(conf.JWTSecret)
+		v.keyFunc = func(*jwt.Token) (interface{}, error) { return secret, nil }
+		opts = append(opts, jwt.WithValidMethods([]string{"HS256", "HS384", "HS512"}))
+	case conf.JWKSFile != "":
+		data, err := os.ReadFile(conf.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading jwks file: %w", err)
+		}
+		keys, err := parseJWKS(data)
+		if err != nil {
+			return nil, err
+		}
+		v.keyFunc = keys.keyFunc
+		opts = append(opts, jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}))
+	default:
+		return nil, fmt.Errorf("AUTH_JWT_SECRET or AUTH_JWKS_FILE must be set")
+	}
+
+	v.parser = jwt.NewParser(opts...)
+
+	return v, nil
+}
+
+// Verify проверяет подпись и сроки действия токена и возвращает клиента.
+// Роль определяется claim roles, при нескольких ролях выбирается роль с наибольшими правами.
+// У не администратора sub должен быть UUID - это id пользователя, которым ограничен доступ к подпискам.
+// Тенант берется из claim tenant_id, токен без него относится к тенанту по умолчанию; у оператора тенанта может не быть
+func (v *JWTVerifier) Verify(_ context.Context, token string) (*Identity, error) {
+	var c claims
+	_, err := v.parser.ParseWithClaims(token, &c, v.keyFunc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	if c.Subject == "" {
+		return nil, fmt.Errorf("%w: sub claim is required", ErrInvalidToken)
+	}
+
+	role := v.role(c.Roles)
+	if role == "" {
+		return nil, fmt.Errorf("%w: roles claim has no known role", ErrInvalidToken)
+	}
+
+	id := &Identity{
+		Subject: c.Subject,
+		Role:    role,
+		Method:  MethodJWT,
+	}
+
+	userID, err := uuid.Parse(c.Subject)
+	if err == nil {
+		id.UserID = userID
+	} else if !id.IsAdmin() && role != RoleOperator {
+		return nil, fmt.Errorf("%w: sub claim must be a user UUID", ErrInvalidToken)
+	}
+
+	switch {
+	case c.TenantID != "":
+		id.TenantID, err = uuid.Parse(c.TenantID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: tenant_id claim must be a UUID", ErrInvalidToken)
+		}
+	case role != RoleOperator:
+		id.TenantID = tenant.DefaultID
+	}
+
+	return id, nil
+}
+
+// role возвращает роль с наибольшими правами из claim roles или роль по умолчанию
+func (v *JWTVerifier) role(roles []string) Role {
+	for _, rc := range v.roleClaims {
+		if slices.Contains(roles, rc.claim) {
+			return rc.role
+		}
+	}
+
+	return v.defaultRole
+}
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/config"
+	"github.com/Ararat25/subscription-aggregation-service/internal/tenant"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newToken подписывает токен с claims sub, roles и сроком действия ttl
+func newToken(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, sub string, roles []string, ttl time.Duration) string {
+	token := jwt.NewWithClaims(method, jwt.MapClaims{
+		"sub":   sub,
+		"roles": roles,
+		"iss":   "issuer",
+		"exp":   time.Now().Add(ttl).Unix(),
+	})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+// TestJWTVerifierSecret тестирует проверку токенов, подписанных общим секретом
+func TestJWTVerifierSecret(t *testing.T) {
+	conf := config.AuthConfig{JWTSecret: "secret", Issuer: "issuer", AdminRole: "admin", EditorRole: "editor", ViewerRole: "viewer", DefaultRole: "editor"}
+	verifier, err := NewJWTVerifier(conf)
+	require.NoError(t, err)
+	userID := uuid.New()
+
+	// Тестовый пример 1: Токен без известных ролей получает роль по умолчанию, без tenant_id - тенанта по умолчанию
+	id, err := verifier.Verify(context.Background(), newToken(t, jwt.SigningMethodHS256, []byte("secret"), "", userID.String(), nil, time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, &Identity{Subject: userID.String(), UserID: userID, Role: RoleEditor, Method: MethodJWT, TenantID: tenant.DefaultID}, id)
+
+	// Тестовый пример 2: Администратор может иметь sub не в формате UUID
+	id, err = verifier.Verify(context.Background(), newToken(t, jwt.SigningMethodHS256, []byte("secret"), "", "batch-job", []string{"viewer", "admin"}, time.Minute))
+	require.NoError(t, err)
+	assert.True(t, id.IsAdmin())
+	assert.Equal(t, "batch-job", id.Subject)
+
+	id, err = verifier.Verify(context.Background(), newToken(t, jwt.SigningMethodHS256, []byte("secret"), "", userID.String(), []string{"viewer"}, time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, RoleViewer, id.Role)
+
+	// Тестовый пример 3: sub обычного пользователя должен быть UUID
+	_, err = verifier.Verify(context.Background(), newToken(t, jwt.SigningMethodHS256, []byte("secret"), "", "batch-job", nil, time.Minute))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// Тестовый пример 4: Истекший токен, чужой секрет и неподходящий алгоритм отклоняются
+	_, err = verifier.Verify(context.Background(), newToken(t, jwt.SigningMethodHS256, []byte("secret"), "", userID.String(), nil, -time.Hour))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = verifier.Verify(context.Background(), newToken(t, jwt.SigningMethodHS256, []byte("other"), "", userID.String(), nil, time.Minute))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = verifier.Verify(context.Background(), newToken(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "", userID.String(), nil, time.Minute))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// Тестовый пример 5: Без роли по умолчанию токен без известных ролей отклоняется
+	conf.DefaultRole = "none"
+	strict, err := NewJWTVerifier(conf)
+	require.NoError(t, err)
+	_, err = strict.Verify(context.Background(), newToken(t, jwt.SigningMethodHS256, []byte("secret"), "", userID.String(), []string{"guest"}, time.Minute))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = NewJWTVerifier(config.AuthConfig{JWTSecret: "secret", DefaultRole: "admin"})
+	assert.Error(t, err)
+
+	// Тестовый пример 6: Должен быть указан ровно один источник ключей
+	_, err = NewJWTVerifier(config.AuthConfig{DefaultRole: "editor"})
+	assert.Error(t, err)
+
+	_, err = NewJWTVerifier(config.AuthConfig{JWTSecret: "secret", JWKSFile: "jwks.json", DefaultRole: "editor"})
+	assert.Error(t, err)
+}
+
+// TestJWTVerifierTenant тестирует определение тенанта и роль оператора
+func TestJWTVerifierTenant(t *testing.T) {
+	verifier, err := NewJWTVerifier(config.AuthConfig{JWTSecret: "secret", OperatorRole: "operator", AdminRole: "admin", DefaultRole: "editor"})
+	require.NoError(t, err)
+	sign := func(claims jwt.MapClaims) string {
+		claims["exp"] = time.Now().Add(time.Minute).Unix()
+		signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("secret"))
+		require.NoError(t, err)
+		return signed
+	}
+	tenantID := uuid.New()
+
+	// Тестовый пример 1: Тенант берется из claim tenant_id
+	id, err := verifier.Verify(context.Background(), sign(jwt.MapClaims{"sub": uuid.NewString(), "tenant_id": tenantID.String()}))
+	require.NoError(t, err)
+	assert.Equal(t, tenantID, id.TenantID)
+
+	// Тестовый пример 2: tenant_id не в формате UUID отклоняется
+	_, err = verifier.Verify(context.Background(), sign(jwt.MapClaims{"sub": uuid.NewString(), "tenant_id": "acme"}))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// Тестовый пример 3: Оператор может не иметь тенанта и sub в формате UUID, его роль важнее администратора
+	id, err = verifier.Verify(context.Background(), sign(jwt.MapClaims{"sub": "ops", "roles": []string{"admin", "operator"}}))
+	require.NoError(t, err)
+	assert.Equal(t, RoleOperator, id.Role)
+	assert.Equal(t, uuid.Nil, id.TenantID)
+}
+
+// TestJWTVerifierJWKS тестирует проверку токенов, подписанных ключами из JWKS-файла
+func TestJWTVerifierJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	encode := func(i *big.Int) string { return base64.RawURLEncoding.EncodeToString(i.Bytes()) }
+	doc, err := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": encode(rsaKey.N), "e": encode(big.NewInt(int64(rsaKey.E)))},
+			{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": encode(ecKey.X), "y": encode(ecKey.Y)},
+			{"kty": "RSA", "kid": "enc-1", "use": "enc", "n": encode(rsaKey.N), "e": encode(big.NewInt(int64(rsaKey.E)))},
+		},
+	})
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, doc, 0o600))
+
+	verifier, err := NewJWTVerifier(config.AuthConfig{JWKSFile: path, AdminRole: "admin", DefaultRole: "editor"})
+	require.NoError(t, err)
+	userID := uuid.New().String()
+
+	// Тестовый пример 1: Ключ выбирается по kid
+	_, err = verifier.Verify(context.Background(), newToken(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", userID, nil, time.Minute))
+	assert.NoError(t, err)
+
+	_, err = verifier.Verify(context.Background(), newToken(t, jwt.SigningMethodES256, ecKey, "ec-1", userID, nil, time.Minute))
+	assert.NoError(t, err)
+
+	// Тестовый пример 2: Неизвестный kid и ключ не для подписи отклоняются
+	_, err = verifier.Verify(context.Background(), newToken(t, jwt.SigningMethodRS256, rsaKey, "unknown", userID, nil, time.Minute))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = verifier.Verify(context.Background(), newToken(t, jwt.SigningMethodRS256, rsaKey, "enc-1", userID, nil, time.Minute))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// Тестовый пример 3: Токен с общим секретом не принимается, если настроен JWKS
+	_, err = verifier.Verify(context.Background(), newToken(t, jwt.SigningMethodHS256, []byte("secret"), "rsa-1", userID, nil, time.Minute))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
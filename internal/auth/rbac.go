@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+)
+
+// Role - роль клиента
+type Role string
+
+const (
+	RoleViewer Role = "viewer" // только чтение своих подписок и их стоимости
+	RoleEditor Role = "editor" // чтение и изменение своих подписок
+	RoleAdmin  Role = "admin"  // подписки всех пользователей тенанта, вебхуки, каталог и API-ключи
+	// RoleOperator - оператор развертывания: создает тенантов, видит их стоимость и состояние сервиса,
+	// но не данные тенантов
+	RoleOperator Role = "operator"
+)
+
+// Permission - право на группу операций API
+type Permission string
+
+const (
+	PermSubscriptionsRead  Permission = "subscriptions:read"  // чтение и поиск подписок
+	PermSubscriptionsWrite Permission = "subscriptions:write" // создание, изменение, отмена и удаление подписок
+	PermCostRead           Permission = "cost:read"           // расчет стоимости подписок и сводка пользователя
+	PermWebhooksManage     Permission = "webhooks:manage"     // управление вебхуками и журналом доставок
+	PermCatalogManage      Permission = "catalog:manage"      // управление каталогом сервисов
+	PermAPIKeysManage      Permission = "api_keys:manage"     // управление API-ключами
+	PermCostMaintain       Permission = "cost:maintain"       // пересборка и сверка помесячных итогов стоимости
+	PermPrivacyManage      Permission = "privacy:manage"      // выгрузка и удаление данных пользователя по его запросу
+	PermTenantsManage      Permission = "tenants:manage"      // создание тенантов и стоимость по тенантам
+	PermSystemRead         Permission = "system:read"         // состояние развертывания: пул соединений с бд
+)
+
+// Policy сопоставляет ролям их права. Какими подписками ограничен клиент, определяет не политика, а UserScope
+type Policy map[Role][]Permission
+
+// Allows проверяет, есть ли у роли право perm
+func (p Policy) Allows(role Role, perm Permission) bool {
+	return slices.Contains(p[role], perm)
+}
+
+// Authorize проверяет право perm клиента из контекста. Если клиента в контексте нет (аутентификация выключена),
+// проверка проходит; недостаток прав возвращается как myError.ErrForbidden
+func (p Policy) Authorize(ctx context.Context, perm Permission) error {
+	id, ok := FromContext(ctx)
+	if !ok || p.Allows(id.Role, perm) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: role %q has no %q permission", myError.ErrForbidden, id.Role, perm)
+}
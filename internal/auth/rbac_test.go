@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPolicyAuthorize тестирует проверку прав клиента по политике
+func TestPolicyAuthorize(t *testing.T) {
+	policy := Policy{
+		RoleViewer: {PermSubscriptionsRead},
+		RoleEditor: {PermSubscriptionsRead, PermSubscriptionsWrite},
+	}
+
+	// Тестовый пример 1: Право есть только у ролей, которым оно выдано
+	assert.True(t, policy.Allows(RoleEditor, PermSubscriptionsWrite))
+	assert.False(t, policy.Allows(RoleViewer, PermSubscriptionsWrite))
+	assert.False(t, policy.Allows(RoleAdmin, PermSubscriptionsRead))
+
+	// Тестовый пример 2: Недостаток прав клиента из контекста - ErrForbidden
+	ctx := WithIdentity(context.Background(), &Identity{Role: RoleViewer})
+	assert.NoError(t, policy.Authorize(ctx, PermSubscriptionsRead))
+	assert.ErrorIs(t, policy.Authorize(ctx, PermSubscriptionsWrite), myError.ErrForbidden)
+
+	// Тестовый пример 3: Без клиента в контексте (аутентификация выключена) проверка проходит
+	assert.NoError(t, policy.Authorize(context.Background(), PermCatalogManage))
+}
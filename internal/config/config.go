@@ -2,6 +2,9 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/caarlos0/env/v6"
@@ -10,8 +13,15 @@ import (
 
 // Config - структура для парсинга файла конфигурации
 type Config struct {
-	Server   ServerConfig   `envPrefix:"SERVER_"` // объект конфигурации сервера
-	Database DatabaseConfig `envPrefix:"DB_"`     // объект конфигурации базы данных
+	Server    ServerConfig    `envPrefix:"SERVER_"`     // объект конфигурации сервера
+	Database  DatabaseConfig  `envPrefix:"DB_"`         // объект конфигурации базы данных
+	Webhook   WebhookConfig   `envPrefix:"WEBHOOK_"`    // объект конфигурации доставки вебхуков
+	Outbox    OutboxConfig    `envPrefix:"OUTBOX_"`     // объект конфигурации публикации событий из outbox
+	RateLimit RateLimitConfig `envPrefix:"RATE_LIMIT_"` // объект конфигурации ограничения частоты запросов
+	Auth      AuthConfig      `envPrefix:"AUTH_"`       // объект конфигурации аутентификации
+	Rollup    RollupConfig    `envPrefix:"ROLLUP_"`     // объект конфигурации помесячных итогов стоимости
+	Archive   ArchiveConfig   `envPrefix:"ARCHIVE_"`    // объект конфигурации архивации закончившихся подписок
+	Privacy   PrivacyConfig   `envPrefix:"PRIVACY_"`    // объект конфигурации выгрузки и удаления данных пользователя
 }
 
 // ServerConfig - структура для конфигурации сервера
@@ -23,13 +33,127 @@ type ServerConfig struct {
 	LogPath string        `env:"SERVER_LOG_FILE_PATH" envDefault:"logs/app.log"` // путь файла для логов
 }
 
-// DatabaseConfig - структура для конфигурации базы данных
+// DatabaseConfig - структура для конфигурации базы данных и пула соединений
 type DatabaseConfig struct {
-	Host     string `env:"HOST" envDefault:"db"`                       // хост базы данных
-	User     string `env:"USER" envDefault:"postgres"`                 // пользователь базы данных
-	Password string `env:"PASSWORD" envDefault:"postgres"`             // пароль базы данных
-	Name     string `env:"NAME" envDefault:"subscription_aggregation"` // название базы данных
-	Port     int    `env:"PORT" envDefault:"5432"`                     // порт базы данных
+	Driver             string        `env:"DRIVER" envDefault:"postgres"`               // хранилище: postgres, sqlite или memory
+	SQLitePath         string        `env:"SQLITE_PATH" envDefault:"subscriptions.db"`  // путь к файлу бд для хранилища sqlite
+	Host               string        `env:"HOST" envDefault:"db"`                       // хост базы данных
+	User               string        `env:"USER" envDefault:"postgres"`                 // пользователь базы данных
+	Password           string        `env:"PASSWORD" envDefault:"postgres"`             // пароль базы данных
+	Name               string        `env:"NAME" envDefault:"subscription_aggregation"` // название базы данных
+	Port               int           `env:"PORT" envDefault:"5432"`                     // порт базы данных
+	MaxConns           int32         `env:"MAX_CONNS" envDefault:"10"`                  // максимальное число соединений в пуле
+	MinConns           int32         `env:"MIN_CONNS" envDefault:"0"`                   // сколько соединений пул держит открытыми всегда
+	MaxConnLifetime    time.Duration `env:"MAX_CONN_LIFETIME" envDefault:"1h"`          // через сколько после открытия соединение закрывается
+	MaxConnIdleTime    time.Duration `env:"MAX_CONN_IDLE_TIME" envDefault:"30m"`        // через сколько простоя соединение закрывается
+	HealthCheckPeriod  time.Duration `env:"HEALTH_CHECK_PERIOD" envDefault:"1m"`        // как часто проверяются простаивающие соединения
+	ConnectTimeout     time.Duration `env:"CONNECT_TIMEOUT" envDefault:"30s"`           // сколько ждать доступности бд при запуске
+	AutoMigrate        bool          `env:"AUTO_MIGRATE" envDefault:"false"`            // применять миграции Postgres при запуске
+	TxIsolation        string        `env:"TX_ISOLATION" envDefault:"serializable"`     // уровень изоляции транзакций WithTx в Postgres
+	TxMaxRetries       int           `env:"TX_MAX_RETRIES" envDefault:"3"`              // сколько раз повторять транзакцию после конфликта сериализации
+	ReplicaHosts       []string      `env:"REPLICA_HOSTS" envSeparator:","`             // реплики Postgres для чтения в виде host или host:port
+	ReplicaStickiness  time.Duration `env:"REPLICA_STICKINESS" envDefault:"5s"`         // сколько после записи клиент читает с основной бд
+	ReplicaCheckPeriod time.Duration `env:"REPLICA_CHECK_PERIOD" envDefault:"5s"`       // как часто проверяется доступность реплик
+}
+
+// PostgresDSN возвращает строку подключения к основной бд Postgres
+func (c DatabaseConfig) PostgresDSN() string {
+	return c.postgresDSN(net.JoinHostPort(c.Host, strconv.Itoa(c.Port)))
+}
+
+// ReplicaDSN возвращает строку подключения к реплике host или host:port. Порт по умолчанию, пользователь
+// и название бд - те же, что у основной бд
+func (c DatabaseConfig) ReplicaDSN(replica string) string {
+	if _, _, err := net.SplitHostPort(replica); err != nil {
+		replica = net.JoinHostPort(replica, strconv.Itoa(c.Port))
+	}
+
+	return c.postgresDSN(replica)
+}
+
+// postgresDSN возвращает строку подключения к Postgres по адресу hostPort
+func (c DatabaseConfig) postgresDSN(hostPort string) string {
+	return fmt.Sprintf(
+		"postgres://%s:%s@%s/%s",
+		url.QueryEscape(c.User),
+		url.QueryEscape(c.Password),
+		hostPort,
+		c.Name,
+	)
+}
+
+// WebhookConfig - структура для конфигурации доставки вебхуков
+type WebhookConfig struct {
+	MaxAttempts          int           `env:"MAX_ATTEMPTS" envDefault:"8"`               // максимальное количество попыток доставки
+	BaseBackoff          time.Duration `env:"BASE_BACKOFF" envDefault:"10s"`             // задержка перед первой повторной попыткой
+	MaxBackoff           time.Duration `env:"MAX_BACKOFF" envDefault:"1h"`               // максимальная задержка между попытками
+	Timeout              time.Duration `env:"TIMEOUT" envDefault:"10s"`                  // таймаут запроса к получателю
+	PollInterval         time.Duration `env:"POLL_INTERVAL" envDefault:"5s"`             // период проверки очереди доставок
+	BatchSize            int           `env:"BATCH_SIZE" envDefault:"20"`                // количество доставок, обрабатываемых за один проход
+	AllowPrivateNetworks bool          `env:"ALLOW_PRIVATE_NETWORKS" envDefault:"false"` // разрешить адреса loopback, link-local и частных сетей
+	DeliveryRetention    time.Duration `env:"DELIVERY_RETENTION" envDefault:"720h"`      // сколько хранятся завершенные доставки, 0 - бессрочно
+	PurgeInterval        time.Duration `env:"PURGE_INTERVAL" envDefault:"1h"`            // как часто удаляются устаревшие доставки
+}
+
+// OutboxConfig - структура для конфигурации публикации событий из outbox
+type OutboxConfig struct {
+	Publisher     string        `env:"PUBLISHER" envDefault:"memory"`           // публикатор событий: memory или nats
+	MemoryBuffer  int           `env:"MEMORY_BUFFER" envDefault:"1000"`         // количество событий, хранимых публикатором memory
+	NatsURL       string        `env:"NATS_URL" envDefault:"nats://nats:4222"`  // адрес NATS
+	NatsSubject   string        `env:"NATS_SUBJECT" envDefault:"subscriptions"` // префикс темы NATS
+	NatsJetStream bool          `env:"NATS_JETSTREAM" envDefault:"true"`        // ждать подтверждения публикации от JetStream
+	PollInterval  time.Duration `env:"POLL_INTERVAL" envDefault:"1s"`           // период проверки outbox
+	BatchSize     int           `env:"BATCH_SIZE" envDefault:"100"`             // количество событий, публикуемых за один проход
+	Lease         time.Duration `env:"LEASE" envDefault:"30s"`                  // время, на которое событие закрепляется за ретранслятором
+	MaxBackoff    time.Duration `env:"MAX_BACKOFF" envDefault:"5m"`             // максимальная задержка между попытками публикации
+	Retention     time.Duration `env:"RETENTION" envDefault:"168h"`             // сколько хранятся опубликованные события, 0 - бессрочно
+	PurgeInterval time.Duration `env:"PURGE_INTERVAL" envDefault:"1h"`          // как часто удаляются устаревшие события
+}
+
+// RateLimitConfig - структура для конфигурации ограничения частоты запросов
+type RateLimitConfig struct {
+	Enabled        bool     `env:"ENABLED" envDefault:"true"`                            // включить ограничение частоты запросов
+	Store          string   `env:"STORE" envDefault:"memory"`                            // хранилище корзин: memory или postgres
+	KeyBy          []string `env:"KEY_BY" envSeparator:"," envDefault:"api_key,user,ip"` // способы определения клиента в порядке приоритета
+	TrustProxy     bool     `env:"TRUST_PROXY" envDefault:"false"`                       // брать IP клиента из X-Forwarded-For и X-Real-IP
+	IPRate         float64  `env:"IP_RATE" envDefault:"50"`                              // запросов в секунду с одного IP до аутентификации
+	IPBurst        int      `env:"IP_BURST" envDefault:"100"`                            // запросов подряд с одного IP до аутентификации
+	Rate           float64  `env:"RATE" envDefault:"10"`                                 // запросов в секунду для всех ручек
+	Burst          int      `env:"BURST" envDefault:"20"`                                // запросов подряд для всех ручек
+	ExpensiveRate  float64  `env:"EXPENSIVE_RATE" envDefault:"0.5"`                      // запросов в секунду для дорогих ручек
+	ExpensiveBurst int      `env:"EXPENSIVE_BURST" envDefault:"5"`                       // запросов подряд для дорогих ручек
+}
+
+// AuthConfig - структура для конфигурации аутентификации по JWT
+type AuthConfig struct {
+	Enabled      bool          `env:"ENABLED" envDefault:"true"`           // требовать аутентификацию для запросов к API
+	JWTSecret    string        `env:"JWT_SECRET"`                          // общий секрет для токенов, подписанных HS256/384/512
+	JWKSFile     string        `env:"JWKS_FILE"`                           // путь к JWKS-файлу с открытыми ключами RSA или EC
+	Issuer       string        `env:"ISSUER"`                              // ожидаемый издатель токена (claim iss), пусто - не проверяется
+	Audience     string        `env:"AUDIENCE"`                            // ожидаемый получатель токена (claim aud), пусто - не проверяется
+	OperatorRole string        `env:"OPERATOR_ROLE" envDefault:"operator"` // роль оператора, управляющего тенантами, в claim roles
+	AdminRole    string        `env:"ADMIN_ROLE" envDefault:"admin"`       // роль администратора в claim roles
+	EditorRole   string        `env:"EDITOR_ROLE" envDefault:"editor"`     // роль редактора в claim roles
+	ViewerRole   string        `env:"VIEWER_ROLE" envDefault:"viewer"`     // роль наблюдателя в claim roles
+	DefaultRole  string        `env:"DEFAULT_ROLE" envDefault:"editor"`    // роль токена без известных ролей: viewer, editor или none - токен отклоняется
+	Leeway       time.Duration `env:"LEEWAY" envDefault:"30s"`             // допустимое расхождение часов при проверке сроков токена
+}
+
+// RollupConfig - структура для конфигурации помесячных итогов стоимости
+type RollupConfig struct {
+	AdvanceInterval time.Duration `env:"ADVANCE_INTERVAL" envDefault:"1h"` // как часто итоги продлеваются на наступивший месяц
+}
+
+// ArchiveConfig - структура для конфигурации архивации закончившихся подписок
+type ArchiveConfig struct {
+	AfterMonths int           `env:"AFTER_MONTHS" envDefault:"24"` // через сколько месяцев после окончания подписка уходит в архив, 0 - не уходит
+	Interval    time.Duration `env:"INTERVAL" envDefault:"24h"`    // как часто запускается архивация
+	BatchSize   int           `env:"BATCH_SIZE" envDefault:"1000"` // количество подписок, переносимых в архив одной транзакцией
+}
+
+// PrivacyConfig - структура для конфигурации выгрузки и удаления данных пользователя
+type PrivacyConfig struct {
+	ReceiptKey string `env:"RECEIPT_KEY"` // секретный ключ подписи квитанций об удалении данных (HMAC-SHA256), без него ручки данных пользователя выключены
 }
 
 // Init получает данные из переменных окружения и возвращает объект Config
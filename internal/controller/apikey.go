@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/Ararat25/subscription-aggregation-service/internal/model"
+)
+
+// APIKeyHandler структура для обработчиков запросов управления API-ключами
+type APIKeyHandler struct {
+	apiKeyService model.APIKeyService // объект для работы с сервисом API-ключей
+}
+
+// NewAPIKeyHandler создает новый объект APIKeyHandler
+func NewAPIKeyHandler(apiKeyService model.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// CreateAPIKey - создать API-ключ (POST /api/v1/admin/api-keys)
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	req := &entity.APIKeyRequest{}
+	err := decodeBody(r, req)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	key, err := h.apiKeyService.CreateAPIKey(ctx, req)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	sendSuccess(w, key, http.StatusOK)
+}
+
+// ListAPIKeys - получить список API-ключей (GET /api/v1/admin/api-keys)
+func (h *APIKeyHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	keys, err := h.apiKeyService.ListAPIKeys(ctx)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	if keys == nil {
+		keys = []*entity.APIKey{}
+	}
+
+	sendSuccess(w, keys, http.StatusOK)
+}
+
+// RevokeAPIKey - отозвать API-ключ (DELETE /api/v1/admin/api-keys/{id})
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIdParam(r, "id")
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	err = h.apiKeyService.RevokeAPIKey(ctx, id)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	sendSuccess(w, StatusResponse{Status: "success"}, http.StatusOK)
+}
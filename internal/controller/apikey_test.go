@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAPIKeyService - мок для интерфейса APIKeyService
+type MockAPIKeyService struct {
+	mock.Mock
+}
+
+// CreateAPIKey - мок метод для создания API-ключа
+func (m *MockAPIKeyService) CreateAPIKey(ctx context.Context, req *entity.APIKeyRequest) (*entity.APIKey, error) {
+	args := m.Called(ctx, req)
+	key, _ := args.Get(0).(*entity.APIKey)
+	return key, args.Error(1)
+}
+
+// ListAPIKeys - мок метод для получения списка API-ключей
+func (m *MockAPIKeyService) ListAPIKeys(ctx context.Context) ([]*entity.APIKey, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*entity.APIKey), args.Error(1)
+}
+
+// RevokeAPIKey - мок метод для отзыва API-ключа
+func (m *MockAPIKeyService) RevokeAPIKey(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// TestCreateAPIKey - тест для CreateAPIKey контроллера
+func TestCreateAPIKey(t *testing.T) {
+	mockService := new(MockAPIKeyService)
+	handler := NewAPIKeyHandler(mockService)
+
+	// Тестовый случай 1: Успешное создание, ключ возвращается в ответе
+	{
+		body := []byte(`{"name":"nightly-export","scopes":["read"],"user_id":"550e8400-e29b-41d4-a716-446655440000"}`)
+		req := httptest.NewRequest("POST", "/admin/api-keys", bytes.NewBuffer(body))
+		rw := httptest.NewRecorder()
+
+		mockService.On("CreateAPIKey", mock.Anything, mock.AnythingOfType("*entity.APIKeyRequest")).
+			Return(&entity.APIKey{Id: 1, Name: "nightly-export", Key: "sas_secret", Scopes: []entity.Scope{entity.ScopeRead}}, nil).Once()
+
+		handler.CreateAPIKey(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		var resp entity.APIKey
+		_ = json.Unmarshal(rw.Body.Bytes(), &resp)
+		assert.Equal(t, int64(1), resp.Id)
+		assert.Equal(t, "sas_secret", resp.Key)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 2: Неизвестное право
+	{
+		body := []byte(`{"name":"nightly-export","scopes":["delete"]}`)
+		req := httptest.NewRequest("POST", "/admin/api-keys", bytes.NewBuffer(body))
+		rw := httptest.NewRecorder()
+
+		handler.CreateAPIKey(rw, req)
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		mockService.AssertNumberOfCalls(t, "CreateAPIKey", 1)
+	}
+
+	// Тестовый случай 3: Ключ без права admin и без пользователя
+	{
+		body := []byte(`{"name":"nightly-export","scopes":["read"]}`)
+		req := httptest.NewRequest("POST", "/admin/api-keys", bytes.NewBuffer(body))
+		rw := httptest.NewRecorder()
+
+		mockService.On("CreateAPIKey", mock.Anything, mock.AnythingOfType("*entity.APIKeyRequest")).
+			Return(nil, myError.ErrAPIKeyUserRequired).Once()
+
+		handler.CreateAPIKey(rw, req)
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		var errResp Problem
+		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
+		assert.Equal(t, CodeAPIKeyUserRequired, errResp.Code)
+	}
+}
+
+// TestListAPIKeys - тест для ListAPIKeys контроллера
+func TestListAPIKeys(t *testing.T) {
+	mockService := new(MockAPIKeyService)
+	handler := NewAPIKeyHandler(mockService)
+
+	// Тестовый случай 1: Пустой список возвращается как массив
+	req := httptest.NewRequest("GET", "/admin/api-keys", nil)
+	rw := httptest.NewRecorder()
+
+	mockService.On("ListAPIKeys", mock.Anything).Return([]*entity.APIKey(nil), nil).Once()
+
+	handler.ListAPIKeys(rw, req)
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.JSONEq(t, `[]`, rw.Body.String())
+}
+
+// TestRevokeAPIKey - тест для RevokeAPIKey контроллера
+func TestRevokeAPIKey(t *testing.T) {
+	mockService := new(MockAPIKeyService)
+	handler := NewAPIKeyHandler(mockService)
+
+	// Тестовый случай 1: Успешный отзыв
+	{
+		req := withURLParam(httptest.NewRequest("DELETE", "/admin/api-keys/1", nil), "id", "1")
+		rw := httptest.NewRecorder()
+
+		mockService.On("RevokeAPIKey", mock.Anything, int64(1)).Return(nil).Once()
+
+		handler.RevokeAPIKey(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 2: Ключ не найден
+	{
+		req := withURLParam(httptest.NewRequest("DELETE", "/admin/api-keys/2", nil), "id", "2")
+		rw := httptest.NewRecorder()
+
+		mockService.On("RevokeAPIKey", mock.Anything, int64(2)).Return(myError.ErrAPIKeyNotFound).Once()
+
+		handler.RevokeAPIKey(rw, req)
+
+		assert.Equal(t, http.StatusNotFound, rw.Code)
+		var errResp Problem
+		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
+		assert.Equal(t, CodeAPIKeyNotFound, errResp.Code)
+	}
+}
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+)
+
+// cacheControl - клиент может хранить ответ, но перед каждым использованием должен проверить его актуальность
+const cacheControl = "private, no-cache"
+
+// subscriptionsVersion получает версию данных о подписках для валидаторов кэша ответа.
+// Версия читается до запроса данных: если данные изменятся между этими запросами, клиент получит
+// более новые данные со старым ETag и просто загрузит их еще раз при следующем запросе.
+// При ошибке отправляет ответ с ней и возвращает признак того, что ответ уже отправлен
+func (h *Handler) subscriptionsVersion(w http.ResponseWriter, r *http.Request) (*entity.TableVersion, bool) {
+	version, err := h.aggregationService.SubscriptionsVersion(r.Context())
+	if err != nil {
+		sendError(w, r, err)
+		return nil, true
+	}
+
+	return version, false
+}
+
+// cacheValidators - валидаторы кэша ответа
+type cacheValidators struct {
+	etag         string    // слабый ETag
+	lastModified time.Time // время последнего изменения с точностью до секунды, как в заголовке Last-Modified
+}
+
+// versionValidators возвращает валидаторы ответа, который зависит только от данных о подписках.
+// ETag слабый: ответы с одной версией равнозначны, но не побайтово одинаковы
+func versionValidators(version *entity.TableVersion) cacheValidators {
+	return cacheValidators{
+		etag:         `W/"` + strconv.FormatInt(version.Version, 10) + `"`,
+		lastModified: version.UpdatedAt.UTC().Truncate(time.Second),
+	}
+}
+
+// monthValidators возвращает валидаторы ответа, который зависит еще и от текущего месяца (UTC): стоимость подписок
+// без даты окончания считается по текущую дату и с началом месяца меняется без изменения данных.
+// Месяц входит в ETag, а Last-Modified не раньше его начала
+func monthValidators(version *entity.TableVersion, now time.Time) cacheValidators {
+	now = now.UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	v := versionValidators(version)
+	v.etag = `W/"` + strconv.FormatInt(version.Version, 10) + "-" + month.Format("200601") + `"`
+	if month.After(v.lastModified) {
+		v.lastModified = month
+	}
+
+	return v
+}
+
+// forRequest возвращает валидаторы, различающие ответы на разные запросы к одной ручке: к ETag добавляется хэш
+// частей parts, например параметров запроса и пользователя, которым сервис ограничил выборку
+func (v cacheValidators) forRequest(parts ...string) cacheValidators {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	v.etag = strings.TrimSuffix(v.etag, `"`) + "-" + hex.EncodeToString(sum[:8]) + `"`
+
+	return v
+}
+
+// notModified отправляет 304, если копия ответа у клиента актуальна для валидаторов v,
+// и возвращает признак того, что ответ уже отправлен. Ответ, доступность которого зависит от запрошенной записи,
+// проверяется только после ее чтения: иначе 304 выдавал бы существование чужих и удаленных записей
+func notModified(w http.ResponseWriter, r *http.Request, v cacheValidators) bool {
+	if !isFresh(r, v) {
+		return false
+	}
+
+	setCacheHeaders(w, v)
+	w.WriteHeader(http.StatusNotModified)
+
+	return true
+}
+
+// setCacheHeaders устанавливает заголовки ETag, Last-Modified и Cache-Control по валидаторам ответа.
+// Ответ зависит от пользователя из токена, поэтому кэш должен различать ответы по заголовку Authorization
+func setCacheHeaders(w http.ResponseWriter, v cacheValidators) {
+	w.Header().Set("ETag", v.etag)
+	w.Header().Set("Last-Modified", v.lastModified.Format(http.TimeFormat))
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("Vary", "Authorization")
+}
+
+// isFresh проверяет условные заголовки запроса, If-None-Match имеет приоритет над If-Modified-Since (RFC 9110)
+func isFresh(r *http.Request, v cacheValidators) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etagMatches(inm, v.etag)
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		since, err := http.ParseTime(ims)
+		if err != nil {
+			return false
+		}
+		return !v.lastModified.After(since)
+	}
+
+	return false
+}
+
+// etagMatches проверяет список ETag из If-None-Match слабым сравнением
+func etagMatches(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(tag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,279 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/auth"
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/Ararat25/subscription-aggregation-service/internal/model"
+	"github.com/Ararat25/subscription-aggregation-service/internal/repository"
+	"github.com/Ararat25/subscription-aggregation-service/internal/tenant"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHTTPCaching - тест условных запросов к ручкам чтения подписок и подсчета стоимости
+func TestHTTPCaching(t *testing.T) {
+	version := &entity.TableVersion{Version: 7, UpdatedAt: time.Date(2024, time.March, 1, 12, 30, 15, 500, time.UTC)}
+	lastModified := "Fri, 01 Mar 2024 12:30:15 GMT"
+	marchDay := time.Date(2024, time.March, 20, 0, 0, 0, 0, time.UTC)
+	march := func() time.Time { return marchDay.Add(9 * time.Hour) }
+	marchFilter := &entity.CostFilter{Today: marchDay}
+	costQuery := "from=01-2023&to=12-2023"
+	marchETag := monthValidators(version, marchDay).forRequest(costQuery, "").etag
+
+	// Тестовый случай 1: Успешный ответ содержит валидаторы кэша, ETag стоимости включает текущий месяц и запрос
+	{
+		mockService := new(MockAggregationService)
+		handler := NewHandler(mockService)
+		handler.now = march
+		mockService.On("SubscriptionsVersion", mock.Anything).Return(version, nil).Once()
+		mockService.On("ScopeCostFilter", mock.Anything, marchFilter).Return(marchFilter, nil).Once()
+		mockService.On("TotalCost", mock.Anything, mock.Anything, mock.Anything, marchFilter).Return(1000, nil).Once()
+
+		req := httptest.NewRequest("GET", "/subscriptions/cost?"+costQuery, nil)
+		rw := httptest.NewRecorder()
+
+		handler.TotalCost(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Regexp(t, `^W/"7-202403-[0-9a-f]{16}"$`, rw.Header().Get("ETag"))
+		assert.Equal(t, marchETag, rw.Header().Get("ETag"))
+		assert.Equal(t, lastModified, rw.Header().Get("Last-Modified"))
+		assert.Equal(t, "private, no-cache", rw.Header().Get("Cache-Control"))
+		assert.Equal(t, "Authorization", rw.Header().Get("Vary"))
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 2: Совпадающий If-None-Match - 304 без запроса стоимости
+	{
+		mockService := new(MockAggregationService)
+		handler := NewHandler(mockService)
+		handler.now = march
+		mockService.On("SubscriptionsVersion", mock.Anything).Return(version, nil).Once()
+		mockService.On("ScopeCostFilter", mock.Anything, marchFilter).Return(marchFilter, nil).Once()
+
+		req := httptest.NewRequest("GET", "/subscriptions/cost?"+costQuery, nil)
+		req.Header.Set("If-None-Match", `"6-202403", `+marchETag)
+		rw := httptest.NewRecorder()
+
+		handler.TotalCost(rw, req)
+
+		assert.Equal(t, http.StatusNotModified, rw.Code)
+		assert.Empty(t, rw.Body.Bytes())
+		assert.Equal(t, marchETag, rw.Header().Get("ETag"))
+		mockService.AssertNotCalled(t, "TotalCost")
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 3: Устаревший If-None-Match имеет приоритет над If-Modified-Since
+	{
+		mockService := new(MockAggregationService)
+		handler := NewHandler(mockService)
+		mockService.On("SubscriptionsVersion", mock.Anything).Return(version, nil).Once()
+		mockService.On("ListSubscriptions", mock.Anything, &entity.SubscriptionFilter{}).Return([]*entity.Subscription{}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/subscriptions", nil)
+		req.Header.Set("If-None-Match", `W/"6"`)
+		req.Header.Set("If-Modified-Since", lastModified)
+		rw := httptest.NewRecorder()
+
+		handler.ListSubscriptions(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 4: If-Modified-Since не раньше последнего изменения - 304 после проверки доступа к подписке
+	{
+		mockService := new(MockAggregationService)
+		handler := NewHandler(mockService)
+		mockService.On("SubscriptionsVersion", mock.Anything).Return(version, nil).Once()
+		mockService.On("ReadSubscription", mock.Anything, int64(1)).Return(&entity.Subscription{Id: 1}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/subscription/{id}", nil)
+		routeContext := chi.NewRouteContext()
+		routeContext.URLParams.Add("id", "1")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeContext))
+		req.Header.Set("If-Modified-Since", lastModified)
+		rw := httptest.NewRecorder()
+
+		handler.ReadSubscription(rw, req)
+
+		assert.Equal(t, http.StatusNotModified, rw.Code)
+		assert.Empty(t, rw.Body.Bytes())
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 5: Ошибка получения версии
+	{
+		mockService := new(MockAggregationService)
+		handler := NewHandler(mockService)
+		mockService.On("SubscriptionsVersion", mock.Anything).Return((*entity.TableVersion)(nil), errors.New("db error")).Once()
+
+		req := httptest.NewRequest("GET", "/subscriptions", nil)
+		rw := httptest.NewRecorder()
+
+		handler.ListSubscriptions(rw, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rw.Code)
+		assert.Empty(t, rw.Header().Get("ETag"))
+		mockService.AssertNotCalled(t, "ListSubscriptions")
+	}
+
+	// Тестовый случай 6: С началом месяца стоимость пересчитывается без изменения данных: стоимость открытых
+	// подписок считается по текущую дату, поэтому мартовские валидаторы в апреле устаревают
+	{
+		mockService := new(MockAggregationService)
+		handler := NewHandler(mockService)
+		april := time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)
+		handler.now = func() time.Time { return april.Add(time.Second) }
+		aprilFilter := &entity.CostFilter{Today: april}
+		mockService.On("SubscriptionsVersion", mock.Anything).Return(version, nil).Twice()
+		mockService.On("ScopeCostFilter", mock.Anything, aprilFilter).Return(aprilFilter, nil).Twice()
+		mockService.On("TotalCost", mock.Anything, mock.Anything, mock.Anything, aprilFilter).Return(1500, nil).Twice()
+
+		for _, header := range [][2]string{{"If-None-Match", marchETag}, {"If-Modified-Since", lastModified}} {
+			req := httptest.NewRequest("GET", "/subscriptions/cost?"+costQuery, nil)
+			req.Header.Set(header[0], header[1])
+			rw := httptest.NewRecorder()
+
+			handler.TotalCost(rw, req)
+
+			assert.Equal(t, http.StatusOK, rw.Code, header[0])
+			assert.Equal(t, monthValidators(version, april).forRequest(costQuery, "").etag, rw.Header().Get("ETag"), header[0])
+			assert.Equal(t, "Mon, 01 Apr 2024 00:00:00 GMT", rw.Header().Get("Last-Modified"), header[0])
+		}
+		mockService.AssertExpectations(t)
+
+		// список подписок от месяца не зависит, и его валидаторы остаются прежними
+		mockService.On("SubscriptionsVersion", mock.Anything).Return(version, nil).Once()
+
+		req := httptest.NewRequest("GET", "/subscriptions", nil)
+		req.Header.Set("If-None-Match", `W/"7"`)
+		rw := httptest.NewRecorder()
+
+		handler.ListSubscriptions(rw, req)
+
+		assert.Equal(t, http.StatusNotModified, rw.Code)
+		mockService.AssertNotCalled(t, "ListSubscriptions")
+	}
+
+	// Тестовый случай 7: If-None-Match: * для несуществующей подписки - 404, а не 304
+	{
+		mockService := new(MockAggregationService)
+		handler := NewHandler(mockService)
+		mockService.On("SubscriptionsVersion", mock.Anything).Return(version, nil).Once()
+		mockService.On("ReadSubscription", mock.Anything, int64(404)).Return((*entity.Subscription)(nil), myError.ErrSubscriptionNotFound).Once()
+
+		req := withURLParam(httptest.NewRequest("GET", "/subscription/404", nil), "id", "404")
+		req.Header.Set("If-None-Match", "*")
+		rw := httptest.NewRecorder()
+
+		handler.ReadSubscription(rw, req)
+
+		assert.Equal(t, http.StatusNotFound, rw.Code)
+		assert.Empty(t, rw.Header().Get("ETag"))
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 8: Актуальные валидаторы не открывают пользователю чужую подписку
+	{
+		storage := repository.NewMemRepo()
+		tn := &entity.Tenant{Name: "cache"}
+		require.NoError(t, storage.CreateTenant(context.Background(), tn))
+		ctx := tenant.WithID(context.Background(), tn.Id)
+
+		owner, other := uuid.New(), uuid.New()
+		foreignID, err := storage.CreateSubscription(ctx, &entity.Subscription{
+			ServiceName: "Netflix", Price: 500, UserId: other, StartDate: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC), Tags: []string{}})
+		require.NoError(t, err)
+		current, err := storage.SubscriptionsVersion(ctx)
+		require.NoError(t, err)
+
+		handler := NewHandler(model.NewAggregationService(storage))
+		userCtx := auth.WithIdentity(ctx, &auth.Identity{Subject: owner.String(), UserID: owner, Role: auth.RoleEditor})
+		adminCtx := auth.WithIdentity(ctx, &auth.Identity{Subject: "ops", Role: auth.RoleAdmin})
+
+		for _, inm := range []string{"*", versionValidators(current).etag} {
+			id := strconv.FormatInt(foreignID, 10)
+			req := withURLParam(httptest.NewRequestWithContext(userCtx, "GET", "/subscription/"+id, nil), "id", id)
+			req.Header.Set("If-None-Match", inm)
+			rw := httptest.NewRecorder()
+
+			handler.ReadSubscription(rw, req)
+
+			assert.Equal(t, http.StatusNotFound, rw.Code, inm)
+			assert.Empty(t, rw.Header().Get("ETag"), inm)
+
+			// администратору тенанта подписка доступна, и его копия ответа актуальна
+			req = withURLParam(httptest.NewRequestWithContext(adminCtx, "GET", "/subscription/"+id, nil), "id", id)
+			req.Header.Set("If-None-Match", inm)
+			rw = httptest.NewRecorder()
+
+			handler.ReadSubscription(rw, req)
+
+			assert.Equal(t, http.StatusNotModified, rw.Code, inm)
+		}
+	}
+
+	// Тестовый случай 9: Стоимость чужих подписок недоступна и с If-None-Match: *, а ETag различает
+	// пользователей и запросы
+	{
+		storage := repository.NewMemRepo()
+		tn := &entity.Tenant{Name: "cost-cache"}
+		require.NoError(t, storage.CreateTenant(context.Background(), tn))
+		ctx := tenant.WithID(context.Background(), tn.Id)
+
+		owner, other := uuid.New(), uuid.New()
+		handler := NewHandler(model.NewAggregationService(storage))
+		handler.now = march
+		ownerCtx := auth.WithIdentity(ctx, &auth.Identity{Subject: owner.String(), UserID: owner, Role: auth.RoleViewer})
+		otherCtx := auth.WithIdentity(ctx, &auth.Identity{Subject: other.String(), UserID: other, Role: auth.RoleViewer})
+		adminCtx := auth.WithIdentity(ctx, &auth.Identity{Subject: "ops", Role: auth.RoleAdmin})
+
+		send := func(ctx context.Context, query, inm string) *httptest.ResponseRecorder {
+			req := httptest.NewRequestWithContext(ctx, "GET", "/subscriptions/cost?"+query, nil)
+			if inm != "" {
+				req.Header.Set("If-None-Match", inm)
+			}
+			rw := httptest.NewRecorder()
+			handler.TotalCost(rw, req)
+			return rw
+		}
+
+		rw := send(ownerCtx, costQuery+"&id="+other.String(), "*")
+		assert.Equal(t, http.StatusForbidden, rw.Code)
+		assert.Empty(t, rw.Header().Get("ETag"))
+
+		etags := make(map[string]bool)
+		for _, request := range []struct {
+			ctx   context.Context
+			query string
+		}{
+			{ownerCtx, costQuery},
+			{otherCtx, costQuery},
+			{adminCtx, costQuery},
+			{adminCtx, costQuery + "&id=" + other.String()},
+			{adminCtx, costQuery + "&group_by=tag"},
+		} {
+			rw := send(request.ctx, request.query, "")
+			require.Equal(t, http.StatusOK, rw.Code, request.query)
+			etags[rw.Header().Get("ETag")] = true
+
+			// копия ответа актуальна только для того же пользователя и запроса
+			assert.Equal(t, http.StatusNotModified, send(request.ctx, request.query, rw.Header().Get("ETag")).Code, request.query)
+		}
+		assert.Len(t, etags, 5)
+	}
+}
@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+)
+
+// CancelSubscription - отменить подписку (POST /api/v1/subscription/{id}/cancel)
+func (h *Handler) CancelSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIdParam(r, "id")
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(r.Body)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	req := &entity.CancelRequest{}
+	if len(bytes.TrimSpace(buf.Bytes())) > 0 {
+		err = json.Unmarshal(buf.Bytes(), req)
+		if err != nil {
+			sendError(w, r, malformedBody())
+			return
+		}
+	}
+
+	err = validate.Struct(req)
+	if err != nil {
+		sendError(w, r, validationFailed(err))
+		return
+	}
+
+	ctx := r.Context()
+	subscription, err := h.aggregationService.CancelSubscription(ctx, id, req)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	sendSuccess(w, entity.ParseSubscriptionToRequest(subscription), http.StatusOK)
+}
@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// TestCancelSubscription - тест для CancelSubscription контроллера
+func TestCancelSubscription(t *testing.T) {
+	mockService := new(MockAggregationService)
+
+	handler := NewHandler(mockService)
+
+	endDate := time.Date(2025, time.September, 1, 0, 0, 0, 0, time.UTC)
+	reason := "too expensive"
+	cancelled := &entity.Subscription{
+		Id:                 1,
+		ServiceName:        "Netflix",
+		Price:              499,
+		UserId:             uuid.New(),
+		StartDate:          time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:            &endDate,
+		CancellationReason: &reason,
+	}
+
+	// Тестовый случай 1: Успешная отмена с месяцем и причиной
+	{
+		body := []byte(`{"end_date":"09-2025","reason":"too expensive"}`)
+		req := withURLParam(httptest.NewRequest("POST", "/subscription/1/cancel", bytes.NewBuffer(body)), "id", "1")
+		rw := httptest.NewRecorder()
+
+		mockService.On("CancelSubscription", mock.Anything, int64(1), mock.MatchedBy(func(r *entity.CancelRequest) bool {
+			return r.EndDate != nil && *r.EndDate == "09-2025" && r.Reason != nil && *r.Reason == reason
+		})).Return(cancelled, nil).Once()
+
+		handler.CancelSubscription(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		var resp entity.SubscriptionRequest
+		_ = json.Unmarshal(rw.Body.Bytes(), &resp)
+		assert.Equal(t, "09-2025", *resp.EndDate)
+		assert.Equal(t, reason, *resp.CancellationReason)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 2: Пустое тело запроса - отмена текущим месяцем
+	{
+		req := withURLParam(httptest.NewRequest("POST", "/subscription/1/cancel", nil), "id", "1")
+		rw := httptest.NewRecorder()
+
+		mockService.On("CancelSubscription", mock.Anything, int64(1), &entity.CancelRequest{}).Return(cancelled, nil).Once()
+
+		handler.CancelSubscription(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 3: Некорректный формат месяца
+	{
+		body := []byte(`{"end_date":"2025-09"}`)
+		req := withURLParam(httptest.NewRequest("POST", "/subscription/1/cancel", bytes.NewBuffer(body)), "id", "1")
+		rw := httptest.NewRecorder()
+
+		handler.CancelSubscription(rw, req)
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		mockService.AssertNumberOfCalls(t, "CancelSubscription", 2)
+	}
+
+	// Тестовый случай 4: Месяц окончания раньше начала подписки
+	{
+		body := []byte(`{"end_date":"12-2024"}`)
+		req := withURLParam(httptest.NewRequest("POST", "/subscription/1/cancel", bytes.NewBuffer(body)), "id", "1")
+		rw := httptest.NewRecorder()
+
+		mockService.On("CancelSubscription", mock.Anything, int64(1), mock.Anything).Return((*entity.Subscription)(nil), myError.ErrDateRange).Once()
+
+		handler.CancelSubscription(rw, req)
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		var errResp Problem
+		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
+		assert.Equal(t, myError.ErrDateRange.Error(), errResp.Detail)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 5: Подписка не найдена
+	{
+		req := withURLParam(httptest.NewRequest("POST", "/subscription/2/cancel", nil), "id", "2")
+		rw := httptest.NewRecorder()
+
+		mockService.On("CancelSubscription", mock.Anything, int64(2), mock.Anything).Return((*entity.Subscription)(nil), myError.ErrSubscriptionNotFound).Once()
+
+		handler.CancelSubscription(rw, req)
+
+		assert.Equal(t, http.StatusNotFound, rw.Code)
+		mockService.AssertExpectations(t)
+	}
+}
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/Ararat25/subscription-aggregation-service/internal/model"
+)
+
+// CatalogHandler структура для обработчиков запросов администрирования каталога сервисов
+type CatalogHandler struct {
+	catalogService model.CatalogService // объект для работы с каталогом сервисов
+}
+
+// NewCatalogHandler создает новый объект CatalogHandler
+func NewCatalogHandler(catalogService model.CatalogService) *CatalogHandler {
+	return &CatalogHandler{
+		catalogService: catalogService,
+	}
+}
+
+// CreateService - добавить сервис в каталог (POST /api/v1/admin/services)
+func (h *CatalogHandler) CreateService(w http.ResponseWriter, r *http.Request) {
+	req := &entity.ServiceRequest{}
+	err := decodeBody(r, req)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	service, err := h.catalogService.CreateService(ctx, req)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	sendSuccess(w, service, http.StatusOK)
+}
+
+// ReadService - получить сервис из каталога (GET /api/v1/admin/services/{id})
+func (h *CatalogHandler) ReadService(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIdParam(r, "id")
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	service, err := h.catalogService.ReadService(ctx, id)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	sendSuccess(w, service, http.StatusOK)
+}
+
+// UpdateService - обновить сервис в каталоге (PUT /api/v1/admin/services/{id})
+func (h *CatalogHandler) UpdateService(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIdParam(r, "id")
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	req := &entity.ServiceRequest{}
+	err = decodeBody(r, req)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	service, err := h.catalogService.UpdateService(ctx, id, req)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	sendSuccess(w, service, http.StatusOK)
+}
+
+// DeleteService - удалить сервис из каталога (DELETE /api/v1/admin/services/{id})
+func (h *CatalogHandler) DeleteService(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIdParam(r, "id")
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	err = h.catalogService.DeleteService(ctx, id)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	sendSuccess(w, StatusResponse{Status: "success"}, http.StatusOK)
+}
+
+// ListServices - получить каталог сервисов (GET /api/v1/admin/services)
+func (h *CatalogHandler) ListServices(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	services, err := h.catalogService.ListServices(ctx)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	if services == nil {
+		services = []*entity.Service{}
+	}
+
+	sendSuccess(w, services, http.StatusOK)
+}
@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockCatalogService - мок для интерфейса CatalogService
+type MockCatalogService struct {
+	mock.Mock
+}
+
+// CreateService - мок метод для добавления сервиса в каталог
+func (m *MockCatalogService) CreateService(ctx context.Context, s *entity.ServiceRequest) (*entity.Service, error) {
+	args := m.Called(ctx, s)
+	return args.Get(0).(*entity.Service), args.Error(1)
+}
+
+// ReadService - мок метод для получения сервиса из каталога
+func (m *MockCatalogService) ReadService(ctx context.Context, id int64) (*entity.Service, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*entity.Service), args.Error(1)
+}
+
+// UpdateService - мок метод для обновления сервиса в каталоге
+func (m *MockCatalogService) UpdateService(ctx context.Context, id int64, s *entity.ServiceRequest) (*entity.Service, error) {
+	args := m.Called(ctx, id, s)
+	return args.Get(0).(*entity.Service), args.Error(1)
+}
+
+// DeleteService - мок метод для удаления сервиса из каталога
+func (m *MockCatalogService) DeleteService(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// ListServices - мок метод для получения каталога сервисов
+func (m *MockCatalogService) ListServices(ctx context.Context) ([]*entity.Service, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*entity.Service), args.Error(1)
+}
+
+// TestCreateService - тест для CreateService контроллера
+func TestCreateService(t *testing.T) {
+	mockService := new(MockCatalogService)
+	handler := NewCatalogHandler(mockService)
+
+	// Тестовый случай 1: Успешное добавление
+	{
+		body := []byte(`{"name":"Netflix","aliases":["netflix"],"default_price":499}`)
+		req := httptest.NewRequest("POST", "/admin/services", bytes.NewBuffer(body))
+		rw := httptest.NewRecorder()
+
+		mockService.On("CreateService", mock.Anything, mock.AnythingOfType("*entity.ServiceRequest")).
+			Return(&entity.Service{Id: 1, Name: "Netflix", Aliases: []string{"netflix"}}, nil).Once()
+
+		handler.CreateService(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		var resp entity.Service
+		_ = json.Unmarshal(rw.Body.Bytes(), &resp)
+		assert.Equal(t, int64(1), resp.Id)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 2: Название занято другим сервисом
+	{
+		body := []byte(`{"name":"Netflix"}`)
+		req := httptest.NewRequest("POST", "/admin/services", bytes.NewBuffer(body))
+		rw := httptest.NewRecorder()
+
+		mockService.On("CreateService", mock.Anything, mock.AnythingOfType("*entity.ServiceRequest")).
+			Return((*entity.Service)(nil), myError.ErrServiceNameTaken).Once()
+
+		handler.CreateService(rw, req)
+
+		assert.Equal(t, http.StatusConflict, rw.Code)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 3: Ошибка валидации
+	{
+		body := []byte(`{"name":"","default_price":0}`)
+		req := httptest.NewRequest("POST", "/admin/services", bytes.NewBuffer(body))
+		rw := httptest.NewRecorder()
+
+		handler.CreateService(rw, req)
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		mockService.AssertNumberOfCalls(t, "CreateService", 2)
+	}
+}
+
+// TestUpdateService - тест для UpdateService контроллера
+func TestUpdateService(t *testing.T) {
+	mockService := new(MockCatalogService)
+	handler := NewCatalogHandler(mockService)
+
+	// Тестовый случай 1: Успешное обновление
+	{
+		body := []byte(`{"name":"Netflix","category":"streaming"}`)
+		req := withURLParam(httptest.NewRequest("PUT", "/admin/services/1", bytes.NewBuffer(body)), "id", "1")
+		rw := httptest.NewRecorder()
+
+		mockService.On("UpdateService", mock.Anything, int64(1), mock.AnythingOfType("*entity.ServiceRequest")).
+			Return(&entity.Service{Id: 1, Name: "Netflix"}, nil).Once()
+
+		handler.UpdateService(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 2: Сервис не найден
+	{
+		body := []byte(`{"name":"Netflix"}`)
+		req := withURLParam(httptest.NewRequest("PUT", "/admin/services/2", bytes.NewBuffer(body)), "id", "2")
+		rw := httptest.NewRecorder()
+
+		mockService.On("UpdateService", mock.Anything, int64(2), mock.AnythingOfType("*entity.ServiceRequest")).
+			Return((*entity.Service)(nil), myError.ErrServiceNotFound).Once()
+
+		handler.UpdateService(rw, req)
+
+		assert.Equal(t, http.StatusNotFound, rw.Code)
+		mockService.AssertExpectations(t)
+	}
+}
+
+// TestDeleteService - тест для DeleteService контроллера
+func TestDeleteService(t *testing.T) {
+	mockService := new(MockCatalogService)
+	handler := NewCatalogHandler(mockService)
+
+	// Тестовый случай 1: Успешное удаление
+	{
+		req := withURLParam(httptest.NewRequest("DELETE", "/admin/services/1", nil), "id", "1")
+		rw := httptest.NewRecorder()
+
+		mockService.On("DeleteService", mock.Anything, int64(1)).Return(nil).Once()
+
+		handler.DeleteService(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 2: Сервис не найден
+	{
+		req := withURLParam(httptest.NewRequest("DELETE", "/admin/services/2", nil), "id", "2")
+		rw := httptest.NewRecorder()
+
+		mockService.On("DeleteService", mock.Anything, int64(2)).Return(myError.ErrServiceNotFound).Once()
+
+		handler.DeleteService(rw, req)
+
+		assert.Equal(t, http.StatusNotFound, rw.Code)
+		mockService.AssertExpectations(t)
+	}
+}
@@ -1,60 +1,29 @@
 package controller
 
 import (
-	"bytes"
-	"encoding/json"
-	"errors"
 	"net/http"
 
 	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
-	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
 )
 
 // CreateControllerResponse - структура для ответа от контроллера CreateSubscription
 type CreateControllerResponse struct {
-	Id int64 `example:"15"` // id созданной подписки
+	Id int64 `json:"id" example:"15"` // id созданной подписки
 }
 
-// CreateSubscription godoc
-// @Summary Создать новую подписку
-// @Description Добавляет новую подписку в систему
-// @Tags subscriptions
-// @Accept json
-// @Produce json
-// @Param subscription body entity.SubscriptionRequest true "Данные подписки"
-// @Success 200 {object} CreateControllerResponse "ID созданной подписки"
-// @Failure 400 {object} ErrorResponse "Некорректные данные"
-// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
-// @Router /subscription [post]
+// CreateSubscription - создать новую подписку (POST /api/v1/subscription)
 func (h *Handler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
-	var buf bytes.Buffer
-	_, err := buf.ReadFrom(r.Body)
+	newSub := &entity.SubscriptionRequest{}
+	err := decodeBody(r, newSub)
 	if err != nil {
-		sendError(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	var newSub *entity.SubscriptionRequest
-	err = json.Unmarshal(buf.Bytes(), &newSub)
-	if err != nil {
-		sendError(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	err = validate.Struct(newSub)
-	if err != nil {
-		sendError(w, err.Error(), http.StatusBadRequest)
+		sendError(w, r, err)
 		return
 	}
 
 	ctx := r.Context()
 	id, err := h.aggregationService.CreateSubscription(ctx, newSub)
-	if errors.Is(err, myError.ErrDateRange) {
-		sendError(w, err.Error(), http.StatusBadRequest)
-		return
-	}
 	if err != nil {
-		sendError(w, err.Error(), http.StatusInternalServerError)
+		sendError(w, r, err)
 		return
 	}
 
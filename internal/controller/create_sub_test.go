@@ -11,7 +11,6 @@ import (
 	"time"
 
 	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
-	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -46,22 +45,63 @@ func (m *MockAggregationService) DeleteSubscription(ctx context.Context, id int6
 	return args.Error(0)
 }
 
+// CancelSubscription - мок метод для отмены подписки
+func (m *MockAggregationService) CancelSubscription(ctx context.Context, id int64, req *entity.CancelRequest) (*entity.Subscription, error) {
+	args := m.Called(ctx, id, req)
+	return args.Get(0).(*entity.Subscription), args.Error(1)
+}
+
 // ListSubscriptions - мок метод для получения списка подписок
-func (m *MockAggregationService) ListSubscriptions(ctx context.Context) ([]*entity.Subscription, error) {
-	args := m.Called(ctx)
+func (m *MockAggregationService) ListSubscriptions(ctx context.Context, filter *entity.SubscriptionFilter) ([]*entity.Subscription, error) {
+	args := m.Called(ctx, filter)
 	return args.Get(0).([]*entity.Subscription), args.Error(1)
 }
 
 // TotalCost - мок метод для подсчета общей стоимости подписок
-func (m *MockAggregationService) TotalCost(ctx context.Context, from, to time.Time, userID *uuid.UUID, serviceName *string) (int, error) {
-	args := m.Called(ctx, from, to, userID, serviceName)
+func (m *MockAggregationService) TotalCost(ctx context.Context, from, to time.Time, filter *entity.CostFilter) (int, error) {
+	args := m.Called(ctx, from, to, filter)
 	return args.Int(0), args.Error(1)
 }
 
+// CostBreakdown - мок метод для подсчета стоимости подписок в разрезе категорий или тегов
+func (m *MockAggregationService) CostBreakdown(ctx context.Context, from, to time.Time, filter *entity.CostFilter, groupBy entity.GroupBy) ([]*entity.CostGroup, error) {
+	args := m.Called(ctx, from, to, filter, groupBy)
+	return args.Get(0).([]*entity.CostGroup), args.Error(1)
+}
+
+// ScopeCostFilter - мок метод для ограничения фильтра стоимости пользователем клиента
+func (m *MockAggregationService) ScopeCostFilter(ctx context.Context, filter *entity.CostFilter) (*entity.CostFilter, error) {
+	args := m.Called(ctx, filter)
+	scoped, _ := args.Get(0).(*entity.CostFilter)
+	return scoped, args.Error(1)
+}
+
+// SearchSubscriptions - мок метод для поиска подписок по названию сервиса
+func (m *MockAggregationService) SearchSubscriptions(ctx context.Context, query string, limit int) ([]*entity.SubscriptionMatch, error) {
+	args := m.Called(ctx, query, limit)
+	return args.Get(0).([]*entity.SubscriptionMatch), args.Error(1)
+}
+
+// SuggestServiceNames - мок метод для автодополнения названия сервиса
+func (m *MockAggregationService) SuggestServiceNames(ctx context.Context, query string, limit int) ([]*entity.ServiceSuggestion, error) {
+	args := m.Called(ctx, query, limit)
+	return args.Get(0).([]*entity.ServiceSuggestion), args.Error(1)
+}
+
+// UserSummary - мок метод для получения сводки по подпискам пользователя
+func (m *MockAggregationService) UserSummary(ctx context.Context, userID uuid.UUID) (*entity.UserSummary, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(*entity.UserSummary), args.Error(1)
+}
+
+// SubscriptionsVersion - мок метод для получения версии данных о подписках
+func (m *MockAggregationService) SubscriptionsVersion(ctx context.Context) (*entity.TableVersion, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(*entity.TableVersion), args.Error(1)
+}
+
 // TestCreateSubscription - тест для CreateSubscription контроллера
 func TestCreateSubscription(t *testing.T) {
-	validate = validator.New()
-
 	mockService := new(MockAggregationService)
 
 	handler := NewHandler(mockService)
@@ -97,9 +137,9 @@ func TestCreateSubscription(t *testing.T) {
 		handler.CreateSubscription(rw, req)
 
 		assert.Equal(t, http.StatusBadRequest, rw.Code)
-		var errResp ErrorResponse
+		var errResp Problem
 		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
-		assert.Contains(t, errResp.Error, "invalid character")
+		assert.Equal(t, CodeMalformedBody, errResp.Code)
 		mockService.AssertNotCalled(t, "CreateSubscription")
 	}
 
@@ -117,9 +157,10 @@ func TestCreateSubscription(t *testing.T) {
 		handler.CreateSubscription(rw, req)
 
 		assert.Equal(t, http.StatusBadRequest, rw.Code)
-		var errResp ErrorResponse
+		var errResp Problem
 		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
-		assert.Contains(t, errResp.Error, "ServiceName")
+		assert.Equal(t, CodeValidationFailed, errResp.Code)
+		assert.Equal(t, []InvalidParam{{Name: "service_name", Reason: "service_name is a required field"}}, errResp.InvalidParams)
 		mockService.AssertNotCalled(t, "CreateSubscription")
 	}
 
@@ -140,9 +181,10 @@ func TestCreateSubscription(t *testing.T) {
 		handler.CreateSubscription(rw, req)
 
 		assert.Equal(t, http.StatusInternalServerError, rw.Code)
-		var errResp ErrorResponse
+		var errResp Problem
 		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
-		assert.Contains(t, errResp.Error, "internal service error")
+		assert.Equal(t, CodeInternal, errResp.Code)
+		assert.Equal(t, "internal server error", errResp.Detail)
 		mockService.AssertExpectations(t)
 	}
 }
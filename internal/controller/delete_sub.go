@@ -1,45 +1,21 @@
 package controller
 
 import (
-	"errors"
 	"net/http"
-	"strconv"
-
-	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
-	"github.com/go-chi/chi/v5"
 )
 
-// DeleteSubscription godoc
-// @Summary Удалить подписку
-// @Description Удаляет подписку по её идентификатору
-// @Tags subscriptions
-// @Produce json
-// @Param id path int true "ID подписки"
-// @Success 200 {object} StatusResponse "Статус выполнения"
-// @Failure 400 {object} ErrorResponse "Некорректный ID или ошибка удаления"
-// @Router /subscription/delete/{id} [delete]
+// DeleteSubscription - удалить подписку (DELETE /api/v1/subscription/delete/{id})
 func (h *Handler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
-	idString := chi.URLParam(r, "id")
-
-	if idString == "" {
-		sendError(w, "id parameter not set", http.StatusBadRequest)
-		return
-	}
-
-	id, err := strconv.ParseInt(idString, 10, 64)
+	id, err := parseIdParam(r, "id")
 	if err != nil {
-		sendError(w, "invalid id parameter", http.StatusBadRequest)
+		sendError(w, r, err)
 		return
 	}
 
 	ctx := r.Context()
 	err = h.aggregationService.DeleteSubscription(ctx, id)
-	if errors.Is(err, myError.ErrSubscriptionNotFound) {
-		sendError(w, err.Error(), http.StatusNotFound)
-		return
-	}
 	if err != nil {
-		sendError(w, err.Error(), http.StatusBadRequest)
+		sendError(w, r, err)
 		return
 	}
 
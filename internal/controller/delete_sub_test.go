@@ -48,9 +48,9 @@ func TestDeleteSubscription(t *testing.T) {
 		handler.DeleteSubscription(rw, req)
 
 		assert.Equal(t, http.StatusBadRequest, rw.Code)
-		var errResp ErrorResponse
+		var errResp Problem
 		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
-		assert.Contains(t, errResp.Error, "id parameter not set")
+		assert.Contains(t, errResp.Detail, "id parameter not set")
 		mockService.AssertNotCalled(t, "DeleteSubscription")
 	}
 
@@ -66,9 +66,9 @@ func TestDeleteSubscription(t *testing.T) {
 		handler.DeleteSubscription(rw, req)
 
 		assert.Equal(t, http.StatusBadRequest, rw.Code)
-		var errResp ErrorResponse
+		var errResp Problem
 		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
-		assert.Contains(t, errResp.Error, "invalid id parameter")
+		assert.Contains(t, errResp.Detail, "invalid id parameter")
 		mockService.AssertNotCalled(t, "DeleteSubscription")
 	}
 
@@ -86,9 +86,9 @@ func TestDeleteSubscription(t *testing.T) {
 		handler.DeleteSubscription(rw, req)
 
 		assert.Equal(t, http.StatusNotFound, rw.Code)
-		var errResp ErrorResponse
+		var errResp Problem
 		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
-		assert.Contains(t, errResp.Error, myError.ErrSubscriptionNotFound.Error())
+		assert.Contains(t, errResp.Detail, myError.ErrSubscriptionNotFound.Error())
 		mockService.AssertExpectations(t)
 	}
 
@@ -105,10 +105,11 @@ func TestDeleteSubscription(t *testing.T) {
 
 		handler.DeleteSubscription(rw, req)
 
-		assert.Equal(t, http.StatusBadRequest, rw.Code)
-		var errResp ErrorResponse
+		assert.Equal(t, http.StatusInternalServerError, rw.Code)
+		var errResp Problem
 		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
-		assert.Contains(t, errResp.Error, "some internal error")
+		assert.Equal(t, CodeInternal, errResp.Code)
+		assert.Equal(t, "internal server error", errResp.Detail)
 		mockService.AssertExpectations(t)
 	}
 }
@@ -1,20 +1,81 @@
 package controller
 
 import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/i18n"
 	"github.com/Ararat25/subscription-aggregation-service/internal/model"
+	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 )
 
-var validate = validator.New()
+var validate = newValidator()
 
 // Handler структура для обработчиков запросов
 type Handler struct {
-	aggregationService model.Service // объект для работы с сервисом агрегации подписок
+	aggregationService model.Service    // объект для работы с сервисом агрегации подписок
+	now                func() time.Time // текущее время, от месяца которого зависят валидаторы стоимости
 }
 
 // NewHandler создает новый объект Handler
 func NewHandler(aggregationService model.Service) *Handler {
 	return &Handler{
 		aggregationService: aggregationService,
+		now:                time.Now,
+	}
+}
+
+// parseIdParam получает числовой идентификатор из параметра пути
+func parseIdParam(r *http.Request, name string) (int64, error) {
+	idString := chi.URLParam(r, name)
+
+	if idString == "" {
+		return 0, missingParam(name)
+	}
+
+	id, err := strconv.ParseInt(idString, 10, 64)
+	if err != nil {
+		return 0, invalidParam(name, i18n.ReasonInteger)
+	}
+
+	return id, nil
+}
+
+// decodeBody читает JSON из тела запроса в dst и валидирует результат
+func decodeBody(r *http.Request, dst any) error {
+	var buf bytes.Buffer
+	_, err := buf.ReadFrom(r.Body)
+	if err != nil {
+		return err
 	}
+
+	err = json.Unmarshal(buf.Bytes(), dst)
+	if err != nil {
+		return malformedBody()
+	}
+
+	err = validate.Struct(dst)
+	if err != nil {
+		return validationFailed(err)
+	}
+
+	return nil
+}
+
+// newValidator создает валидатор, использующий в ошибках имена полей из json-тегов
+// и переводящий сообщения об ошибках на поддерживаемые языки
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(jsonFieldName)
+
+	err := i18n.RegisterValidator(v)
+	if err != nil {
+		panic(err)
+	}
+
+	return v
 }
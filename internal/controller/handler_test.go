@@ -1,11 +1,20 @@
 package controller
 
 import (
+	"os"
 	"testing"
 
+	"github.com/Ararat25/subscription-aggregation-service/internal/logger"
 	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
 )
 
+// TestMain - подменяет логгер для тестов контроллеров, так как внутренние ошибки логируются
+func TestMain(m *testing.M) {
+	logger.Log = zap.NewNop()
+	os.Exit(m.Run())
+}
+
 // TestNewHandler - тест для функции NewHandler
 func TestNewHandler(t *testing.T) {
 	mockService := new(MockAggregationService)
@@ -6,19 +6,23 @@ import (
 	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
 )
 
-// ListSubscriptions godoc
-// @Summary Получить список подписок
-// @Description Возвращает полный список всех подписок
-// @Tags subscriptions
-// @Produce json
-// @Success 200 {array} entity.SubscriptionRequest "Список подписок"
-// @Failure 500 {object} ErrorResponse "Ошибка получения данных"
-// @Router /subscriptions [get]
+// ListSubscriptions - получить список подписок (GET /api/v1/subscriptions)
 func (h *Handler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	version, done := h.subscriptionsVersion(w, r)
+	if done {
+		return
+	}
+
+	validators := versionValidators(version)
+	if notModified(w, r, validators) {
+		return
+	}
+
 	ctx := r.Context()
-	subs, err := h.aggregationService.ListSubscriptions(ctx)
+	filter := parseSubscriptionFilter(r)
+	subs, err := h.aggregationService.ListSubscriptions(ctx, &filter)
 	if err != nil {
-		sendError(w, err.Error(), http.StatusInternalServerError)
+		sendError(w, r, err)
 		return
 	}
 
@@ -27,5 +31,19 @@ func (h *Handler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
 		subsResp = append(subsResp, entity.ParseSubscriptionToRequest(sub))
 	}
 
+	setCacheHeaders(w, validators)
 	sendSuccess(w, subsResp, http.StatusOK)
 }
+
+// parseSubscriptionFilter получает фильтр по категории и тегам из параметров запроса
+func parseSubscriptionFilter(r *http.Request) entity.SubscriptionFilter {
+	var filter entity.SubscriptionFilter
+
+	if category := r.URL.Query().Get("category"); category != "" {
+		filter.Category = &category
+	}
+
+	filter.Tags = r.URL.Query()["tag"]
+
+	return filter
+}
@@ -18,6 +18,7 @@ func TestListSubscriptions(t *testing.T) {
 	mockService := new(MockAggregationService)
 
 	handler := NewHandler(mockService)
+	mockService.On("SubscriptionsVersion", mock.Anything).Return(&entity.TableVersion{Version: 1}, nil)
 
 	// Тестовый случай 1: Успешное получение списка подписок
 	{
@@ -39,7 +40,7 @@ func TestListSubscriptions(t *testing.T) {
 		req := httptest.NewRequest("GET", "/subscriptions", nil)
 		rw := httptest.NewRecorder()
 
-		mockService.On("ListSubscriptions", mock.Anything).Return(expectedSubs, nil).Once()
+		mockService.On("ListSubscriptions", mock.Anything, &entity.SubscriptionFilter{}).Return(expectedSubs, nil).Once()
 
 		handler.ListSubscriptions(rw, req)
 
@@ -62,14 +63,31 @@ func TestListSubscriptions(t *testing.T) {
 		req := httptest.NewRequest("GET", "/subscriptions", nil)
 		rw := httptest.NewRecorder()
 
-		mockService.On("ListSubscriptions", mock.Anything).Return([]*entity.Subscription{}, errors.New("internal service error")).Once()
+		mockService.On("ListSubscriptions", mock.Anything, mock.Anything).Return([]*entity.Subscription{}, errors.New("internal service error")).Once()
 
 		handler.ListSubscriptions(rw, req)
 
 		assert.Equal(t, http.StatusInternalServerError, rw.Code)
-		var errResp ErrorResponse
+		var errResp Problem
 		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
-		assert.Contains(t, errResp.Error, "internal service error")
+		assert.Equal(t, CodeInternal, errResp.Code)
+		assert.Equal(t, "internal server error", errResp.Detail)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 3: Фильтрация по категории и тегам
+	{
+		req := httptest.NewRequest("GET", "/subscriptions?category=streaming&tag=family&tag=work", nil)
+		rw := httptest.NewRecorder()
+
+		category := "streaming"
+		filter := &entity.SubscriptionFilter{Category: &category, Tags: []string{"family", "work"}}
+		mockService.On("ListSubscriptions", mock.Anything, filter).Return([]*entity.Subscription{}, nil).Once()
+
+		handler.ListSubscriptions(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.JSONEq(t, `[]`, rw.Body.String())
 		mockService.AssertExpectations(t)
 	}
 }
@@ -0,0 +1,223 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+)
+
+// SendOpenAPIError отправляет ответ с ошибкой проверки запроса или ответа по спецификации OpenAPI.
+// Используется как обработчик ошибок middleware.OpenAPIValidator
+func SendOpenAPIError(w http.ResponseWriter, r *http.Request, err error) {
+	sendError(w, r, openAPIError(err))
+}
+
+// openAPIError сопоставляет ошибку проверки по спецификации OpenAPI с типом ошибки ответа
+func openAPIError(err error) error {
+	var respErr *openapi3filter.ResponseError
+	if errors.As(err, &respErr) {
+		return &requestError{
+			kind:   responseInvalidKind,
+			detail: message{key: detailKey(CodeResponseInvalid)},
+			params: schemaParams("", respErr.Err),
+			cause:  err,
+		}
+	}
+
+	var reqErrs []*openapi3filter.RequestError
+	collectRequestErrors(err, &reqErrs)
+	if len(reqErrs) == 0 {
+		return err
+	}
+
+	var result *requestError
+	for _, re := range reqErrs {
+		e := requestErrorFromOpenAPI(re)
+		if e == nil {
+			return err
+		}
+		if e.kind == malformedBodyKind {
+			return e
+		}
+
+		if result == nil {
+			result = e
+			continue
+		}
+		result.params = append(result.params, e.params...)
+	}
+
+	return result
+}
+
+// collectRequestErrors собирает ошибки проверки запроса, в том числе вложенные в openapi3.MultiError
+func collectRequestErrors(err error, dst *[]*openapi3filter.RequestError) {
+	switch e := err.(type) {
+	case *openapi3filter.RequestError:
+		*dst = append(*dst, e)
+	case openapi3.MultiError:
+		for _, inner := range e {
+			collectRequestErrors(inner, dst)
+		}
+	}
+}
+
+// requestErrorFromOpenAPI преобразует ошибку проверки параметра или тела запроса, nil - ошибка неизвестного вида
+func requestErrorFromOpenAPI(re *openapi3filter.RequestError) *requestError {
+	switch {
+	case re.Parameter != nil:
+		name := re.Parameter.Name
+		if errors.Is(re.Err, openapi3filter.ErrInvalidRequired) {
+			return missingParam(name).(*requestError)
+		}
+
+		params := schemaParams(name, re.Err)
+		if len(params) == 0 {
+			params = []paramError{{name: name, reason: message{key: "schema.type", args: []any{name, parameterType(re.Parameter)}}}}
+		}
+
+		return &requestError{
+			kind:   invalidParameterKind,
+			detail: message{key: "param.invalid", args: []any{name}},
+			params: params,
+		}
+	case re.RequestBody != nil:
+		var parseErr *openapi3filter.ParseError
+		if errors.As(re.Err, &parseErr) || errors.Is(re.Err, openapi3filter.ErrInvalidRequired) {
+			return malformedBody().(*requestError)
+		}
+
+		params := schemaParams("", re.Err)
+		if len(params) == 0 {
+			return malformedBody().(*requestError)
+		}
+
+		return &requestError{
+			kind:   validationFailedKind,
+			detail: message{key: detailKey(CodeValidationFailed)},
+			params: params,
+		}
+	default:
+		return nil
+	}
+}
+
+// schemaParams возвращает некорректные поля из ошибок проверки по схеме, prefix - имя параметра запроса
+func schemaParams(prefix string, err error) []paramError {
+	switch e := err.(type) {
+	case *openapi3.SchemaError:
+		pointer := e.JSONPointer()
+		// для лишнего поля путь указывает на объект, а имя поля есть только в причине ошибки
+		var property string
+		if _, err := fmt.Sscanf(e.Reason, "property %q is unsupported", &property); err == nil {
+			pointer = append(pointer, property)
+		}
+
+		name := schemaPath(prefix, pointer)
+		return []paramError{{name: name, reason: schemaReason(name, e)}}
+	case openapi3.MultiError:
+		var params []paramError
+		for _, inner := range e {
+			params = append(params, schemaParams(prefix, inner)...)
+		}
+		return params
+	default:
+		return nil
+	}
+}
+
+// schemaPath возвращает путь к полю в виде "tags[0]" по пути из ошибки проверки по схеме
+func schemaPath(prefix string, pointer []string) string {
+	var b strings.Builder
+	b.WriteString(prefix)
+
+	for _, part := range pointer {
+		if _, err := strconv.Atoi(part); err == nil {
+			b.WriteString("[" + part + "]")
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString(".")
+		}
+		b.WriteString(part)
+	}
+
+	if b.Len() == 0 {
+		return "body"
+	}
+
+	return b.String()
+}
+
+// schemaReason возвращает причину ошибки проверки по схеме для поля name
+func schemaReason(name string, se *openapi3.SchemaError) message {
+	schema := se.Schema
+
+	switch se.SchemaField {
+	case "required":
+		return message{key: "schema.required", args: []any{name}}
+	case "minimum":
+		return message{key: "schema.minimum", args: []any{name, floatValue(schema.Min)}}
+	case "maximum":
+		return message{key: "schema.maximum", args: []any{name, floatValue(schema.Max)}}
+	case "minLength":
+		return message{key: "schema.min_length", args: []any{name, schema.MinLength}}
+	case "maxLength":
+		return message{key: "schema.max_length", args: []any{name, uintValue(schema.MaxLength)}}
+	case "minItems":
+		return message{key: "schema.min_items", args: []any{name, schema.MinItems}}
+	case "maxItems":
+		return message{key: "schema.max_items", args: []any{name, uintValue(schema.MaxItems)}}
+	case "enum":
+		values := make([]string, 0, len(schema.Enum))
+		for _, v := range schema.Enum {
+			values = append(values, fmt.Sprint(v))
+		}
+		return message{key: "schema.enum", args: []any{name, strings.Join(values, " ")}}
+	case "pattern":
+		return message{key: "schema.pattern", args: []any{name}}
+	case "type", "nullable":
+		return message{key: "schema.type", args: []any{name, schemaType(schema)}}
+	case "properties", "additionalProperties":
+		return message{key: "schema.unknown_field", args: []any{name}}
+	default:
+		return message{key: "schema.invalid", args: []any{name, se.Reason}}
+	}
+}
+
+// parameterType возвращает тип значения параметра запроса из спецификации
+func parameterType(p *openapi3.Parameter) string {
+	if p.Schema == nil || p.Schema.Value == nil {
+		return "string"
+	}
+	return schemaType(p.Schema.Value)
+}
+
+// schemaType возвращает тип значения из схемы
+func schemaType(schema *openapi3.Schema) string {
+	if schema.Type == nil || len(*schema.Type) == 0 {
+		return "object"
+	}
+	return strings.Join(schema.Type.Slice(), "|")
+}
+
+// floatValue возвращает значение ограничения схемы
+func floatValue(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+// uintValue возвращает значение ограничения схемы
+func uintValue(v *uint64) uint64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
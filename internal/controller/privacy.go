@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/Ararat25/subscription-aggregation-service/internal/i18n"
+	"github.com/Ararat25/subscription-aggregation-service/internal/model"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// UserDataExportResponse - структура для ответа от контроллера ExportUserData
+type UserDataExportResponse struct {
+	UserId                uuid.UUID                     `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"` // id пользователя
+	ExportedAt            time.Time                     `json:"exported_at" example:"2025-08-01T12:00:00Z"`             // время выгрузки
+	Subscriptions         []*entity.SubscriptionRequest `json:"subscriptions"`                                          // подписки пользователя
+	ArchivedSubscriptions []*entity.SubscriptionRequest `json:"archived_subscriptions"`                                 // архивные подписки пользователя
+	Summary               *UserSummaryResponse          `json:"summary"`                                                // сводка по подпискам на текущий месяц
+	MonthlyCosts          []*UserMonthlyCostResponse    `json:"monthly_costs"`                                          // помесячные итоги стоимости подписок
+	Events                []*entity.Event               `json:"events"`                                                 // события о подписках пользователя
+	WebhookDeliveries     []*entity.WebhookDelivery     `json:"webhook_deliveries"`                                     // доставки вебхуков с событиями о подписках
+	APIKeys               []*entity.APIKey              `json:"api_keys"`                                               // API-ключи, действующие от имени пользователя
+}
+
+// UserMonthlyCostResponse - итог стоимости подписок пользователя на сервис за месяц
+type UserMonthlyCostResponse struct {
+	Month         string `json:"month" example:"05-2025"`        // месяц итога
+	ServiceName   string `json:"service_name" example:"Netflix"` // название сервиса
+	Total         int    `json:"total" example:"499"`            // суммарная стоимость подписок
+	Subscriptions int    `json:"subscriptions" example:"1"`      // количество подписок
+}
+
+// ErasureReceiptResponse - структура для ответа с квитанцией об удалении данных пользователя
+type ErasureReceiptResponse struct {
+	Id                    uuid.UUID `json:"id" example:"7d9f0c2e-4b1a-4f5e-9c3d-2a6b8e1f0d4c"`                                       // id квитанции
+	TenantId              uuid.UUID `json:"tenant_id" example:"00000000-0000-0000-0000-000000000001"`                                // тенант, в котором удалены данные
+	SubjectHash           string    `json:"subject_hash" example:"a3a9e1ed9732cab28868127be00f1ce921acaefdd5c3b23a6e9e0072bd9c1a34"` // HMAC-SHA256 от id пользователя
+	ErasedAt              time.Time `json:"erased_at" example:"2025-08-01T12:00:00.123456Z"`                                         // время удаления
+	Subscriptions         int       `json:"subscriptions" example:"3"`                                                               // количество удаленных подписок
+	ArchivedSubscriptions int       `json:"archived_subscriptions" example:"1"`                                                      // количество удаленных архивных подписок
+	MonthlyCosts          int       `json:"monthly_costs" example:"24"`                                                              // количество удаленных строк помесячных итогов
+	APIKeys               int       `json:"api_keys" example:"1"`                                                                    // количество удаленных API-ключей
+	Events                int       `json:"events" example:"7"`                                                                      // количество обезличенных событий
+	WebhookDeliveries     int       `json:"webhook_deliveries" example:"7"`                                                          // количество обезличенных доставок вебхуков
+	Digest                string    `json:"digest" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`       // HMAC-SHA256 от полей квитанции
+	Verified              bool      `json:"verified" example:"true"`                                                                 // подпись digest совпала с полями квитанции
+}
+
+// PrivacyHandler структура для обработчиков запросов выгрузки и удаления данных пользователя
+type PrivacyHandler struct {
+	privacyService model.PrivacyService // объект для работы с сервисом данных пользователя
+}
+
+// NewPrivacyHandler создает новый объект PrivacyHandler
+func NewPrivacyHandler(privacyService model.PrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{
+		privacyService: privacyService,
+	}
+}
+
+// ExportUserData - выгрузить все данные пользователя файлом JSON (GET /api/v1/admin/users/{user_id}/export)
+func (h *PrivacyHandler) ExportUserData(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseUserIdParam(r)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	data, err := h.privacyService.ExportUserData(ctx, userID)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	resp := UserDataExportResponse{
+		UserId:                data.UserId,
+		ExportedAt:            data.ExportedAt,
+		Subscriptions:         parseSubscriptionsToRequests(data.Subscriptions),
+		ArchivedSubscriptions: parseSubscriptionsToRequests(data.ArchivedSubscriptions),
+		Summary:               newUserSummaryResponse(data.Summary),
+		MonthlyCosts:          make([]*UserMonthlyCostResponse, 0, len(data.MonthlyCosts)),
+		Events:                append([]*entity.Event{}, data.Events...),
+		WebhookDeliveries:     append([]*entity.WebhookDelivery{}, data.Deliveries...),
+		APIKeys:               append([]*entity.APIKey{}, data.APIKeys...),
+	}
+
+	for _, c := range data.MonthlyCosts {
+		resp.MonthlyCosts = append(resp.MonthlyCosts, &UserMonthlyCostResponse{
+			Month:         c.Month.Format(entity.DateLayout),
+			ServiceName:   c.ServiceName,
+			Total:         c.Total,
+			Subscriptions: c.Subscriptions,
+		})
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%s.json"`, userID))
+	sendSuccess(w, resp, http.StatusOK)
+}
+
+// EraseUserData - безвозвратно удалить данные пользователя и получить квитанцию (POST /api/v1/admin/users/{user_id}/erase)
+func (h *PrivacyHandler) EraseUserData(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseUserIdParam(r)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	receipt, err := h.privacyService.EraseUserData(ctx, userID)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	sendSuccess(w, newErasureReceiptResponse(receipt), http.StatusOK)
+}
+
+// ReadErasureReceipt - получить и проверить квитанцию об удалении данных (GET /api/v1/admin/erasure-receipts/{id})
+func (h *PrivacyHandler) ReadErasureReceipt(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	if idStr == "" {
+		sendError(w, r, missingParam("id"))
+		return
+	}
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		sendError(w, r, invalidParam("id", i18n.ReasonUUID))
+		return
+	}
+
+	ctx := r.Context()
+	receipt, err := h.privacyService.ReadErasureReceipt(ctx, id)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	sendSuccess(w, newErasureReceiptResponse(receipt), http.StatusOK)
+}
+
+// newErasureReceiptResponse преобразует квитанцию об удалении в ответ
+func newErasureReceiptResponse(receipt *entity.ErasureReceipt) *ErasureReceiptResponse {
+	return &ErasureReceiptResponse{
+		Id:                    receipt.Id,
+		TenantId:              receipt.TenantId,
+		SubjectHash:           receipt.SubjectHash,
+		ErasedAt:              receipt.ErasedAt.UTC(),
+		Subscriptions:         receipt.Subscriptions,
+		ArchivedSubscriptions: receipt.ArchivedSubscriptions,
+		MonthlyCosts:          receipt.MonthlyCosts,
+		APIKeys:               receipt.APIKeys,
+		Events:                receipt.Events,
+		WebhookDeliveries:     receipt.Deliveries,
+		Digest:                receipt.Digest,
+		Verified:              receipt.Verified,
+	}
+}
+
+// parseSubscriptionsToRequests преобразует подписки в формат ответа
+func parseSubscriptionsToRequests(subs []*entity.Subscription) []*entity.SubscriptionRequest {
+	res := make([]*entity.SubscriptionRequest, 0, len(subs))
+	for _, sub := range subs {
+		res = append(res, entity.ParseSubscriptionToRequest(sub))
+	}
+
+	return res
+}
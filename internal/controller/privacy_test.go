@@ -0,0 +1,268 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockPrivacyService - мок для интерфейса PrivacyService
+type MockPrivacyService struct {
+	mock.Mock
+}
+
+// ExportUserData - мок метод для выгрузки данных пользователя
+func (m *MockPrivacyService) ExportUserData(ctx context.Context, userID uuid.UUID) (*entity.UserData, error) {
+	args := m.Called(ctx, userID)
+	data, _ := args.Get(0).(*entity.UserData)
+	return data, args.Error(1)
+}
+
+// EraseUserData - мок метод для удаления данных пользователя
+func (m *MockPrivacyService) EraseUserData(ctx context.Context, userID uuid.UUID) (*entity.ErasureReceipt, error) {
+	args := m.Called(ctx, userID)
+	receipt, _ := args.Get(0).(*entity.ErasureReceipt)
+	return receipt, args.Error(1)
+}
+
+// ReadErasureReceipt - мок метод для чтения квитанции об удалении
+func (m *MockPrivacyService) ReadErasureReceipt(ctx context.Context, id uuid.UUID) (*entity.ErasureReceipt, error) {
+	args := m.Called(ctx, id)
+	receipt, _ := args.Get(0).(*entity.ErasureReceipt)
+	return receipt, args.Error(1)
+}
+
+// testReceiptSigner подписывает квитанции в тестах контроллера
+var testReceiptSigner = entity.NewReceiptSigner([]byte("test-receipt-key"))
+
+// newTestReceipt возвращает подписанную и проверенную сервисом квитанцию об удалении данных пользователя
+func newTestReceipt(userID uuid.UUID) *entity.ErasureReceipt {
+	receipt := &entity.ErasureReceipt{
+		Id:            uuid.New(),
+		TenantId:      uuid.New(),
+		SubjectHash:   testReceiptSigner.SubjectHash(userID),
+		ErasedAt:      time.Date(2025, time.August, 1, 12, 0, 0, 123456000, time.UTC),
+		Subscriptions: 3,
+		MonthlyCosts:  24,
+		Events:        7,
+	}
+	receipt.Digest = testReceiptSigner.Sign(receipt)
+	receipt.Verified = true
+
+	return receipt
+}
+
+// TestExportUserData - тест для ExportUserData контроллера
+func TestExportUserData(t *testing.T) {
+	mockService := new(MockPrivacyService)
+	handler := NewPrivacyHandler(mockService)
+	userID := uuid.New()
+
+	// Тестовый случай 1: Данные выгружаются файлом, месяц итогов возвращается в формате MM-YYYY
+	{
+		req := withURLParam(httptest.NewRequest("GET", "/admin/users/"+userID.String()+"/export", nil), "user_id", userID.String())
+		rw := httptest.NewRecorder()
+
+		data := &entity.UserData{
+			UserId:     userID,
+			ExportedAt: time.Date(2025, time.August, 1, 12, 0, 0, 0, time.UTC),
+			Subscriptions: []*entity.Subscription{{
+				Id: 1, ServiceName: "Netflix", Price: 499, UserId: userID, StartDate: time.Date(2025, time.May, 1, 0, 0, 0, 0, time.UTC)}},
+			Summary: &entity.UserSummary{UserId: userID, ActiveCount: 1, MonthlySpend: 499},
+			MonthlyCosts: []*entity.UserMonthlyCost{{
+				Month: time.Date(2025, time.May, 1, 0, 0, 0, 0, time.UTC), ServiceName: "Netflix", Total: 499, Subscriptions: 1}},
+		}
+		mockService.On("ExportUserData", mock.Anything, userID).Return(data, nil).Once()
+
+		handler.ExportUserData(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, `attachment; filename="user-`+userID.String()+`.json"`, rw.Header().Get("Content-Disposition"))
+
+		var resp UserDataExportResponse
+		_ = json.Unmarshal(rw.Body.Bytes(), &resp)
+		assert.Equal(t, userID, resp.UserId)
+		assert.Len(t, resp.Subscriptions, 1)
+		assert.Equal(t, "05-2025", resp.Subscriptions[0].StartDate)
+		assert.Empty(t, resp.ArchivedSubscriptions)
+		assert.Equal(t, []*UserMonthlyCostResponse{{Month: "05-2025", ServiceName: "Netflix", Total: 499, Subscriptions: 1}},
+			resp.MonthlyCosts)
+		assert.Equal(t, 499, resp.Summary.MonthlySpend)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 2: Пустые списки возвращаются массивами, а не null
+	{
+		req := withURLParam(httptest.NewRequest("GET", "/admin/users/"+userID.String()+"/export", nil), "user_id", userID.String())
+		rw := httptest.NewRecorder()
+
+		data := &entity.UserData{UserId: userID, Summary: &entity.UserSummary{UserId: userID}}
+		mockService.On("ExportUserData", mock.Anything, userID).Return(data, nil).Once()
+
+		handler.ExportUserData(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		var resp map[string]any
+		_ = json.Unmarshal(rw.Body.Bytes(), &resp)
+		for _, field := range []string{"subscriptions", "archived_subscriptions", "monthly_costs", "events", "webhook_deliveries", "api_keys"} {
+			assert.Equal(t, []any{}, resp[field], field)
+		}
+	}
+
+	// Тестовый случай 3: Некорректный user_id
+	{
+		req := withURLParam(httptest.NewRequest("GET", "/admin/users/abc/export", nil), "user_id", "abc")
+		rw := httptest.NewRecorder()
+
+		handler.ExportUserData(rw, req)
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		assert.Empty(t, rw.Header().Get("Content-Disposition"))
+	}
+
+	// Тестовый случай 4: Ошибка сервиса
+	{
+		req := withURLParam(httptest.NewRequest("GET", "/admin/users/"+userID.String()+"/export", nil), "user_id", userID.String())
+		rw := httptest.NewRecorder()
+
+		mockService.On("ExportUserData", mock.Anything, userID).Return(nil, errors.New("db error")).Once()
+
+		handler.ExportUserData(rw, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rw.Code)
+	}
+}
+
+// TestEraseUserData - тест для EraseUserData контроллера
+func TestEraseUserData(t *testing.T) {
+	mockService := new(MockPrivacyService)
+	handler := NewPrivacyHandler(mockService)
+	userID := uuid.New()
+
+	// Тестовый случай 1: Данные удалены, возвращается проверенная квитанция
+	{
+		req := withURLParam(httptest.NewRequest("POST", "/admin/users/"+userID.String()+"/erase", nil), "user_id", userID.String())
+		rw := httptest.NewRecorder()
+
+		receipt := newTestReceipt(userID)
+		mockService.On("EraseUserData", mock.Anything, userID).Return(receipt, nil).Once()
+
+		handler.EraseUserData(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		var resp ErasureReceiptResponse
+		_ = json.Unmarshal(rw.Body.Bytes(), &resp)
+		assert.Equal(t, receipt.Id, resp.Id)
+		assert.Equal(t, testReceiptSigner.SubjectHash(userID), resp.SubjectHash)
+		assert.Equal(t, 3, resp.Subscriptions)
+		assert.Equal(t, 24, resp.MonthlyCosts)
+		assert.Equal(t, 7, resp.Events)
+		assert.Equal(t, receipt.Digest, resp.Digest)
+		assert.True(t, resp.Verified)
+		assert.NotContains(t, rw.Body.String(), userID.String())
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 2: Некорректный user_id
+	{
+		req := withURLParam(httptest.NewRequest("POST", "/admin/users/abc/erase", nil), "user_id", "abc")
+		rw := httptest.NewRecorder()
+
+		handler.EraseUserData(rw, req)
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+	}
+
+	// Тестовый случай 3: Ошибка сервиса
+	{
+		req := withURLParam(httptest.NewRequest("POST", "/admin/users/"+userID.String()+"/erase", nil), "user_id", userID.String())
+		rw := httptest.NewRecorder()
+
+		mockService.On("EraseUserData", mock.Anything, userID).Return(nil, errors.New("db error")).Once()
+
+		handler.EraseUserData(rw, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rw.Code)
+	}
+}
+
+// TestReadErasureReceipt - тест для ReadErasureReceipt контроллера
+func TestReadErasureReceipt(t *testing.T) {
+	mockService := new(MockPrivacyService)
+	handler := NewPrivacyHandler(mockService)
+	receipt := newTestReceipt(uuid.New())
+
+	// Тестовый случай 1: Квитанция не изменялась после выдачи
+	{
+		req := withURLParam(httptest.NewRequest("GET", "/admin/erasure-receipts/"+receipt.Id.String(), nil), "id", receipt.Id.String())
+		rw := httptest.NewRecorder()
+
+		mockService.On("ReadErasureReceipt", mock.Anything, receipt.Id).Return(receipt, nil).Once()
+
+		handler.ReadErasureReceipt(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		var resp ErasureReceiptResponse
+		_ = json.Unmarshal(rw.Body.Bytes(), &resp)
+		assert.True(t, resp.Verified)
+		assert.True(t, receipt.ErasedAt.Equal(resp.ErasedAt))
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 2: Квитанция, не прошедшая проверку подписи в сервисе, возвращается с verified false
+	{
+		req := withURLParam(httptest.NewRequest("GET", "/admin/erasure-receipts/"+receipt.Id.String(), nil), "id", receipt.Id.String())
+		rw := httptest.NewRecorder()
+
+		tampered := *receipt
+		tampered.Subscriptions = 0
+		tampered.Verified = false
+		mockService.On("ReadErasureReceipt", mock.Anything, receipt.Id).Return(&tampered, nil).Once()
+
+		handler.ReadErasureReceipt(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		var resp ErasureReceiptResponse
+		_ = json.Unmarshal(rw.Body.Bytes(), &resp)
+		assert.False(t, resp.Verified)
+	}
+
+	// Тестовый случай 3: Квитанция не найдена
+	{
+		id := uuid.New()
+		req := withURLParam(httptest.NewRequest("GET", "/admin/erasure-receipts/"+id.String(), nil), "id", id.String())
+		rw := httptest.NewRecorder()
+
+		mockService.On("ReadErasureReceipt", mock.Anything, id).Return(nil, myError.ErrErasureReceiptNotFound).Once()
+
+		handler.ReadErasureReceipt(rw, req)
+
+		assert.Equal(t, http.StatusNotFound, rw.Code)
+		var errResp Problem
+		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
+		assert.Equal(t, CodeErasureReceiptNotFound, errResp.Code)
+	}
+
+	// Тестовый случай 4: Некорректный id
+	{
+		req := withURLParam(httptest.NewRequest("GET", "/admin/erasure-receipts/abc", nil), "id", "abc")
+		rw := httptest.NewRecorder()
+
+		handler.ReadErasureReceipt(rw, req)
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		var errResp Problem
+		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
+		assert.Equal(t, "invalid id parameter", errResp.Detail)
+	}
+}
@@ -0,0 +1,294 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/Ararat25/subscription-aggregation-service/internal/i18n"
+	"github.com/Ararat25/subscription-aggregation-service/internal/logger"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+const (
+	problemContentType = "application/problem+json"                                                                 // тип содержимого ответа с ошибкой (RFC 7807)
+	problemTypeBase    = "https://github.com/Ararat25/subscription-aggregation-service/blob/main/docs/problems.md#" // базовый адрес описания типов ошибок
+)
+
+// Коды ошибок, на которые могут опираться клиенты. Описание каждого кода - в docs/problems.md
+const (
+	CodeMalformedBody          = "malformed_body"
+	CodeValidationFailed       = "validation_failed"
+	CodeInvalidParameter       = "invalid_parameter"
+	CodeSubscriptionNotFound   = "subscription_not_found"
+	CodeDateRange              = "date_range"
+	CodePeriodRange            = "period_range"
+	CodeInvalidDate            = "invalid_date"
+	CodeWebhookNotFound        = "webhook_not_found"
+	CodeDeliveryNotFound       = "delivery_not_found"
+	CodeWebhookURLForbidden    = "webhook_url_forbidden"
+	CodeEmptySearchQuery       = "empty_search_query"
+	CodeServiceNotFound        = "service_not_found"
+	CodeServiceNameTaken       = "service_name_taken"
+	CodePriceRequired          = "price_required"
+	CodeAPIKeyNotFound         = "api_key_not_found"
+	CodeAPIKeyUserRequired     = "api_key_user_required"
+	CodeTenantRequired         = "tenant_required"
+	CodeTenantNameTaken        = "tenant_name_taken"
+	CodeErasureReceiptNotFound = "erasure_receipt_not_found"
+	CodeRateLimited            = "rate_limited"
+	CodeUnauthorized           = "unauthorized"
+	CodeForbidden              = "forbidden"
+	CodeInternal               = "internal_error"
+	CodeResponseInvalid        = "response_validation_failed"
+)
+
+// Problem описывает ответ с ошибкой в формате application/problem+json (RFC 7807)
+type Problem struct {
+	Type          string         `json:"type" example:"https://github.com/Ararat25/subscription-aggregation-service/blob/main/docs/problems.md#subscription_not_found"` // адрес описания типа ошибки
+	Title         string         `json:"title" example:"Subscription not found"`                                                                                        // краткое описание типа ошибки
+	Status        int            `json:"status" example:"404"`                                                                                                          // http-статус ответа
+	Detail        string         `json:"detail,omitempty" example:"subscription not found"`                                                                             // описание конкретной ошибки
+	Instance      string         `json:"instance,omitempty" example:"/api/v1/subscription/15"`                                                                          // путь запроса, вызвавшего ошибку
+	Code          string         `json:"code" example:"subscription_not_found"`                                                                                         // машиночитаемый код ошибки
+	InvalidParams []InvalidParam `json:"invalid_params,omitempty"`                                                                                                      // некорректные поля или параметры запроса
+}
+
+// InvalidParam описывает некорректное поле тела или параметр запроса
+type InvalidParam struct {
+	Name   string `json:"name" example:"price"`                        // имя поля или параметра
+	Reason string `json:"reason" example:"price must be 1 or greater"` // причина ошибки
+}
+
+// problemKind - тип ошибки: код и http-статус. Заголовок и описание берутся из каталога сообщений по коду
+type problemKind struct {
+	code   string
+	status int
+}
+
+// sentinelProblems сопоставляет ошибки сервисного слоя с типами ошибок ответа
+var sentinelProblems = []struct {
+	err  error
+	kind problemKind
+}{
+	{myError.ErrSubscriptionNotFound, problemKind{CodeSubscriptionNotFound, http.StatusNotFound}},
+	{myError.ErrDateRange, problemKind{CodeDateRange, http.StatusBadRequest}},
+	{myError.ErrPeriodRange, problemKind{CodePeriodRange, http.StatusBadRequest}},
+	{myError.ErrInvalidDate, problemKind{CodeInvalidDate, http.StatusBadRequest}},
+	{myError.ErrWebhookNotFound, problemKind{CodeWebhookNotFound, http.StatusNotFound}},
+	{myError.ErrDeliveryNotFound, problemKind{CodeDeliveryNotFound, http.StatusNotFound}},
+	{myError.ErrWebhookURLForbidden, problemKind{CodeWebhookURLForbidden, http.StatusBadRequest}},
+	{myError.ErrEmptySearchQuery, problemKind{CodeEmptySearchQuery, http.StatusBadRequest}},
+	{myError.ErrServiceNotFound, problemKind{CodeServiceNotFound, http.StatusNotFound}},
+	{myError.ErrServiceNameTaken, problemKind{CodeServiceNameTaken, http.StatusConflict}},
+	{myError.ErrPriceRequired, problemKind{CodePriceRequired, http.StatusBadRequest}},
+	{myError.ErrAPIKeyNotFound, problemKind{CodeAPIKeyNotFound, http.StatusNotFound}},
+	{myError.ErrAPIKeyUserRequired, problemKind{CodeAPIKeyUserRequired, http.StatusBadRequest}},
+	{myError.ErrTenantRequired, problemKind{CodeTenantRequired, http.StatusForbidden}},
+	{myError.ErrTenantNameTaken, problemKind{CodeTenantNameTaken, http.StatusConflict}},
+	{myError.ErrErasureReceiptNotFound, problemKind{CodeErasureReceiptNotFound, http.StatusNotFound}},
+	{myError.ErrRateLimited, problemKind{CodeRateLimited, http.StatusTooManyRequests}},
+	{myError.ErrUnauthorized, problemKind{CodeUnauthorized, http.StatusUnauthorized}},
+	{myError.ErrForbidden, problemKind{CodeForbidden, http.StatusForbidden}},
+}
+
+var (
+	malformedBodyKind    = problemKind{CodeMalformedBody, http.StatusBadRequest}
+	validationFailedKind = problemKind{CodeValidationFailed, http.StatusBadRequest}
+	invalidParameterKind = problemKind{CodeInvalidParameter, http.StatusBadRequest}
+	internalKind         = problemKind{CodeInternal, http.StatusInternalServerError}
+	responseInvalidKind  = problemKind{CodeResponseInvalid, http.StatusInternalServerError}
+)
+
+// message - ключ сообщения в каталоге i18n и аргументы для подстановки
+type message struct {
+	key  string
+	args []any
+}
+
+// translate возвращает сообщение на языке lang
+func (m message) translate(lang string) string {
+	return i18n.T(lang, m.key, m.args...)
+}
+
+// paramError - некорректный параметр запроса и причина ошибки
+type paramError struct {
+	name   string
+	reason message
+}
+
+// requestError - ошибка разбора запроса, уже сопоставленная с типом ошибки ответа.
+// Сообщения хранятся ключами каталога и переводятся на язык клиента при отправке ответа
+type requestError struct {
+	kind      problemKind
+	detail    message
+	params    []paramError
+	fieldErrs validator.ValidationErrors
+	cause     error // исходная ошибка, попадает только в лог
+}
+
+// Error возвращает описание ошибки на языке по умолчанию
+func (e *requestError) Error() string {
+	if e.cause != nil {
+		return e.detail.translate(i18n.DefaultLang) + ": " + e.cause.Error()
+	}
+	return e.detail.translate(i18n.DefaultLang)
+}
+
+// invalidParams возвращает список некорректных полей или параметров на языке lang
+func (e *requestError) invalidParams(lang string) []InvalidParam {
+	var params []InvalidParam
+	for _, p := range e.params {
+		params = append(params, InvalidParam{Name: p.name, Reason: p.reason.translate(lang)})
+	}
+	for _, fe := range e.fieldErrs {
+		params = append(params, InvalidParam{Name: fieldPath(fe), Reason: i18n.TranslateFieldError(lang, fe)})
+	}
+
+	return params
+}
+
+// malformedBody возвращает ошибку для тела запроса, которое не удалось разобрать как JSON
+func malformedBody() error {
+	return &requestError{kind: malformedBodyKind, detail: message{key: detailKey(CodeMalformedBody)}}
+}
+
+// missingParam возвращает ошибку для отсутствующего обязательного параметра
+func missingParam(name string) error {
+	return &requestError{
+		kind:   invalidParameterKind,
+		detail: message{key: "param.missing", args: []any{name}},
+		params: []paramError{{name: name, reason: message{key: i18n.ReasonRequired}}},
+	}
+}
+
+// invalidParam возвращает ошибку для параметра с некорректным значением,
+// reasonKey - ключ причины ошибки в каталоге i18n, args - аргументы для подстановки в причину
+func invalidParam(name string, reasonKey string, args ...any) error {
+	return &requestError{
+		kind:   invalidParameterKind,
+		detail: message{key: "param.invalid", args: []any{name}},
+		params: []paramError{{name: name, reason: message{key: reasonKey, args: args}}},
+	}
+}
+
+// validationFailed преобразует ошибку валидатора в ошибку со списком некорректных полей
+func validationFailed(err error) error {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+
+	return &requestError{
+		kind:      validationFailedKind,
+		detail:    message{key: detailKey(CodeValidationFailed)},
+		fieldErrs: validationErrs,
+	}
+}
+
+// fieldPath возвращает путь к полю без имени корневой структуры, например "tags[0]"
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.Index(ns, "."); i >= 0 {
+		return ns[i+1:]
+	}
+	return ns
+}
+
+// titleKey и detailKey возвращают ключи заголовка и описания ошибки с кодом code в каталоге i18n
+func titleKey(code string) string  { return "title." + code }
+func detailKey(code string) string { return "detail." + code }
+
+// jsonFieldName возвращает имя поля из json-тега, чтобы в ошибках валидации были имена полей из запроса
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// SendRateLimited отправляет ответ с ошибкой rate_limited. Используется как обработчик превышения
+// ограничения в middleware.RateLimit
+func SendRateLimited(w http.ResponseWriter, r *http.Request) {
+	sendError(w, r, myError.ErrRateLimited)
+}
+
+// SendUnauthorized отправляет ответ с ошибкой unauthorized и заголовком WWW-Authenticate со схемами Bearer и ApiKey.
+// Используется как обработчик отсутствующих или недействительных учетных данных в middleware.Authenticate
+func SendUnauthorized(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api", ApiKey realm="api"`)
+	sendError(w, r, myError.ErrUnauthorized)
+}
+
+// SendForbidden отправляет ответ с ошибкой forbidden. Используется как обработчик недостатка прав в middleware
+func SendForbidden(w http.ResponseWriter, r *http.Request) {
+	sendError(w, r, myError.ErrForbidden)
+}
+
+// sendError отправляет ответ с ошибкой в формате application/problem+json на языке из заголовка Accept-Language.
+// Ошибки сервисного слоя сопоставляются с кодами по sentinelProblems, неизвестные ошибки логируются
+// и возвращаются клиенту как internal_error без подробностей
+func sendError(w http.ResponseWriter, r *http.Request, err error) {
+	lang := i18n.Negotiate(r.Header.Get("Accept-Language"))
+	problem := newProblem(lang, r.URL.Path, err)
+
+	if problem.Status == http.StatusInternalServerError {
+		logger.Log.Error("request failed",
+			zap.String("method", r.Method),
+			zap.String("path", r.URL.Path),
+			zap.Error(err),
+		)
+	}
+
+	w.Header().Set("Content-Language", lang)
+	w.Header().Add("Vary", "Accept-Language")
+	writeProblem(w, problem)
+}
+
+// writeProblem записывает ошибку в ответ
+func writeProblem(w http.ResponseWriter, problem *Problem) {
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(problem.Status)
+
+	err := json.NewEncoder(w).Encode(problem)
+	if err != nil {
+		logger.Log.Error("error encoding response", zap.Error(err))
+	}
+}
+
+// newProblem сопоставляет ошибку с типом ошибки ответа, lang - язык сообщений, instance - путь запроса
+func newProblem(lang string, instance string, err error) *Problem {
+	kind := internalKind
+	detail := i18n.T(lang, detailKey(CodeInternal))
+	var params []InvalidParam
+
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		kind, detail, params = reqErr.kind, reqErr.detail.translate(lang), reqErr.invalidParams(lang)
+	} else {
+		for _, sp := range sentinelProblems {
+			if errors.Is(err, sp.err) {
+				kind, detail = sp.kind, i18n.T(lang, detailKey(sp.kind.code))
+				break
+			}
+		}
+	}
+
+	return &Problem{
+		Type:          problemTypeBase + kind.code,
+		Title:         i18n.T(lang, titleKey(kind.code)),
+		Status:        kind.status,
+		Detail:        detail,
+		Instance:      instance,
+		Code:          kind.code,
+		InvalidParams: params,
+	}
+}
@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/Ararat25/subscription-aggregation-service/internal/i18n"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSendError - тест для функции sendError
+func TestSendError(t *testing.T) {
+	// Тестовый случай 1: Известная ошибка сервисного слоя, обернутая в другую ошибку
+	{
+		req := httptest.NewRequest("GET", "/api/v1/subscription/15", nil)
+		rw := httptest.NewRecorder()
+
+		sendError(rw, req, fmt.Errorf("read: %w", myError.ErrSubscriptionNotFound))
+
+		assert.Equal(t, http.StatusNotFound, rw.Code)
+		assert.Equal(t, "application/problem+json", rw.Header().Get("Content-Type"))
+		var problem Problem
+		_ = json.Unmarshal(rw.Body.Bytes(), &problem)
+		assert.Equal(t, CodeSubscriptionNotFound, problem.Code)
+		assert.Equal(t, problemTypeBase+CodeSubscriptionNotFound, problem.Type)
+		assert.Equal(t, http.StatusNotFound, problem.Status)
+		assert.Equal(t, myError.ErrSubscriptionNotFound.Error(), problem.Detail)
+		assert.Equal(t, "/api/v1/subscription/15", problem.Instance)
+	}
+
+	// Тестовый случай 2: Неизвестная ошибка не раскрывается клиенту
+	{
+		req := httptest.NewRequest("GET", "/api/v1/subscriptions", nil)
+		rw := httptest.NewRecorder()
+
+		sendError(rw, req, errors.New("connection refused"))
+
+		assert.Equal(t, http.StatusInternalServerError, rw.Code)
+		var problem Problem
+		_ = json.Unmarshal(rw.Body.Bytes(), &problem)
+		assert.Equal(t, CodeInternal, problem.Code)
+		assert.Equal(t, "internal server error", problem.Detail)
+		assert.NotContains(t, rw.Body.String(), "connection refused")
+	}
+
+	// Тестовый случай 3: Некорректный параметр запроса
+	{
+		req := httptest.NewRequest("GET", "/api/v1/subscriptions/search", nil)
+		rw := httptest.NewRecorder()
+
+		sendError(rw, req, invalidParam("limit", i18n.ReasonPositiveInteger))
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		var problem Problem
+		_ = json.Unmarshal(rw.Body.Bytes(), &problem)
+		assert.Equal(t, CodeInvalidParameter, problem.Code)
+		assert.Equal(t, []InvalidParam{{Name: "limit", Reason: "must be a positive integer"}}, problem.InvalidParams)
+	}
+}
+
+// TestValidationFailed - тест для функции validationFailed
+func TestValidationFailed(t *testing.T) {
+	endDate := "2025-09"
+	subReq := &entity.SubscriptionRequest{
+		ServiceName: "Netflix",
+		Price:       -1,
+		UserId:      uuid.New(),
+		StartDate:   "08-2025",
+		EndDate:     &endDate,
+		Tags:        []string{""},
+	}
+
+	err := validationFailed(validate.Struct(subReq))
+
+	// Тестовый случай 1: Сообщения на английском
+	{
+		problem := newProblem(i18n.EN, "", err)
+
+		assert.Equal(t, CodeValidationFailed, problem.Code)
+		assert.Equal(t, http.StatusBadRequest, problem.Status)
+		assert.ElementsMatch(t, []InvalidParam{
+			{Name: "price", Reason: "price must be 1 or greater"},
+			{Name: "end_date", Reason: "end_date must be a month in MM-YYYY format"},
+			{Name: "tags[0]", Reason: "tags[0] is a required field"},
+		}, problem.InvalidParams)
+	}
+
+	// Тестовый случай 2: Сообщения на русском
+	{
+		problem := newProblem(i18n.RU, "", err)
+
+		assert.Equal(t, CodeValidationFailed, problem.Code)
+		assert.Equal(t, "Ошибка валидации запроса", problem.Title)
+		assert.Equal(t, "в теле запроса есть некорректные поля", problem.Detail)
+		assert.ElementsMatch(t, []InvalidParam{
+			{Name: "price", Reason: "price должен быть больше или равно 1"},
+			{Name: "end_date", Reason: "end_date должен быть месяцем в формате MM-YYYY"},
+			{Name: "tags[0]", Reason: "tags[0] обязательное поле"},
+		}, problem.InvalidParams)
+	}
+}
+
+// TestSendErrorLanguage - тест выбора языка ответа с ошибкой по заголовку Accept-Language
+func TestSendErrorLanguage(t *testing.T) {
+	// Тестовый случай 1: Русский язык в приоритете
+	{
+		req := httptest.NewRequest("GET", "/api/v1/subscription/15", nil)
+		req.Header.Set("Accept-Language", "ru-RU,ru;q=0.9,en;q=0.8")
+		rw := httptest.NewRecorder()
+
+		sendError(rw, req, myError.ErrSubscriptionNotFound)
+
+		assert.Equal(t, "ru", rw.Header().Get("Content-Language"))
+		var problem Problem
+		_ = json.Unmarshal(rw.Body.Bytes(), &problem)
+		assert.Equal(t, CodeSubscriptionNotFound, problem.Code)
+		assert.Equal(t, "Подписка не найдена", problem.Title)
+		assert.Equal(t, "подписка не найдена", problem.Detail)
+	}
+
+	// Тестовый случай 2: Неподдерживаемый язык - используется английский
+	{
+		req := httptest.NewRequest("GET", "/api/v1/subscriptions/search", nil)
+		req.Header.Set("Accept-Language", "de-DE")
+		rw := httptest.NewRecorder()
+
+		sendError(rw, req, missingParam("q"))
+
+		assert.Equal(t, "en", rw.Header().Get("Content-Language"))
+		var problem Problem
+		_ = json.Unmarshal(rw.Body.Bytes(), &problem)
+		assert.Equal(t, "q parameter not set", problem.Detail)
+		assert.Equal(t, []InvalidParam{{Name: "q", Reason: "is required"}}, problem.InvalidParams)
+	}
+}
@@ -1,50 +1,39 @@
 package controller
 
 import (
-	"errors"
 	"net/http"
-	"strconv"
 
 	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
-	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
-	"github.com/go-chi/chi/v5"
 )
 
-// ReadSubscription godoc
-// @Summary Получить подписку по ID
-// @Description Возвращает данные подписки по её идентификатору
-// @Tags subscriptions
-// @Produce json
-// @Param id path int true "ID подписки"
-// @Success 200 {object} entity.SubscriptionRequest "Информация о подписке"
-// @Failure 400 {object} ErrorResponse "Неверный параметр id или ошибка получения данных"
-// @Router /subscription/{id} [get]
+// ReadSubscription - получить подписку по ID (GET /api/v1/subscription/{id})
 func (h *Handler) ReadSubscription(w http.ResponseWriter, r *http.Request) {
-	idString := chi.URLParam(r, "id")
-
-	if idString == "" {
-		sendError(w, "id parameter not set", http.StatusBadRequest)
+	id, err := parseIdParam(r, "id")
+	if err != nil {
+		sendError(w, r, err)
 		return
 	}
 
-	id, err := strconv.ParseInt(idString, 10, 64)
-	if err != nil {
-		sendError(w, "invalid id parameter", http.StatusBadRequest)
+	version, done := h.subscriptionsVersion(w, r)
+	if done {
 		return
 	}
 
+	// подписка читается до сверки валидаторов: отсутствующая или чужая подписка - 404, а не 304
 	ctx := r.Context()
 	subscription, err := h.aggregationService.ReadSubscription(ctx, id)
-	if errors.Is(err, myError.ErrSubscriptionNotFound) {
-		sendError(w, err.Error(), http.StatusNotFound)
+	if err != nil {
+		sendError(w, r, err)
 		return
 	}
-	if err != nil {
-		sendError(w, err.Error(), http.StatusBadRequest)
+
+	validators := versionValidators(version)
+	if notModified(w, r, validators) {
 		return
 	}
 
 	subResp := entity.ParseSubscriptionToRequest(subscription)
 
+	setCacheHeaders(w, validators)
 	sendSuccess(w, subResp, http.StatusOK)
 }
@@ -22,6 +22,7 @@ func TestReadSubscription(t *testing.T) {
 	mockService := new(MockAggregationService)
 
 	handler := NewHandler(mockService)
+	mockService.On("SubscriptionsVersion", mock.Anything).Return(&entity.TableVersion{Version: 1}, nil)
 
 	// Тестовый случай 1: Успешное чтение подписки
 	{
@@ -67,9 +68,9 @@ func TestReadSubscription(t *testing.T) {
 		handler.ReadSubscription(rw, req)
 
 		assert.Equal(t, http.StatusBadRequest, rw.Code)
-		var errResp ErrorResponse
+		var errResp Problem
 		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
-		assert.Contains(t, errResp.Error, "id parameter not set")
+		assert.Contains(t, errResp.Detail, "id parameter not set")
 		mockService.AssertNotCalled(t, "ReadSubscription")
 	}
 
@@ -85,9 +86,9 @@ func TestReadSubscription(t *testing.T) {
 		handler.ReadSubscription(rw, req)
 
 		assert.Equal(t, http.StatusBadRequest, rw.Code)
-		var errResp ErrorResponse
+		var errResp Problem
 		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
-		assert.Contains(t, errResp.Error, "invalid id parameter")
+		assert.Contains(t, errResp.Detail, "invalid id parameter")
 		mockService.AssertNotCalled(t, "ReadSubscription")
 	}
 
@@ -105,9 +106,9 @@ func TestReadSubscription(t *testing.T) {
 		handler.ReadSubscription(rw, req)
 
 		assert.Equal(t, http.StatusNotFound, rw.Code)
-		var errResp ErrorResponse
+		var errResp Problem
 		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
-		assert.Contains(t, errResp.Error, myError.ErrSubscriptionNotFound.Error())
+		assert.Contains(t, errResp.Detail, myError.ErrSubscriptionNotFound.Error())
 		mockService.AssertExpectations(t)
 	}
 
@@ -124,10 +125,11 @@ func TestReadSubscription(t *testing.T) {
 
 		handler.ReadSubscription(rw, req)
 
-		assert.Equal(t, http.StatusBadRequest, rw.Code)
-		var errResp ErrorResponse
+		assert.Equal(t, http.StatusInternalServerError, rw.Code)
+		var errResp Problem
 		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
-		assert.Contains(t, errResp.Error, "some internal error")
+		assert.Equal(t, CodeInternal, errResp.Code)
+		assert.Equal(t, "internal server error", errResp.Detail)
 		mockService.AssertExpectations(t)
 	}
 }
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/Ararat25/subscription-aggregation-service/internal/model"
+	"github.com/google/uuid"
+)
+
+// MonthlyCostsRebuildResponse - структура для ответа от контроллера RebuildMonthlyCosts
+type MonthlyCostsRebuildResponse struct {
+	Rows         int    `json:"rows" example:"120"`              // количество строк итогов после пересборки
+	ThroughMonth string `json:"through_month" example:"07-2025"` // месяц, по который учтены подписки без даты окончания
+}
+
+// MonthlyCostsCheckResponse - структура для ответа от контроллера CheckMonthlyCosts
+type MonthlyCostsCheckResponse struct {
+	Rows         int                            `json:"rows" example:"120"`              // количество строк итогов
+	ThroughMonth string                         `json:"through_month" example:"07-2025"` // месяц, по который учтены подписки без даты окончания
+	Consistent   bool                           `json:"consistent" example:"true"`       // итоги совпадают с расчетом по подпискам
+	Mismatches   []*MonthlyCostMismatchResponse `json:"mismatches"`                      // расхождения итогов с расчетом по подпискам
+}
+
+// MonthlyCostMismatchResponse - расхождение итога стоимости за месяц с расчетом по подпискам
+type MonthlyCostMismatchResponse struct {
+	Month               string    `json:"month" example:"05-2025"`                                // месяц итога
+	UserId              uuid.UUID `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"` // id пользователя
+	ServiceName         string    `json:"service_name" example:"Netflix"`                         // название сервиса
+	RollupTotal         int       `json:"rollup_total" example:"499"`                             // стоимость в итогах
+	LiveTotal           int       `json:"live_total" example:"998"`                               // стоимость по подпискам
+	RollupSubscriptions int       `json:"rollup_subscriptions" example:"1"`                       // количество подписок в итогах
+	LiveSubscriptions   int       `json:"live_subscriptions" example:"2"`                         // количество подписок по подпискам
+}
+
+// CostRollupHandler структура для обработчиков запросов помесячных итогов стоимости
+type CostRollupHandler struct {
+	rollupService model.CostRollupService // объект для работы с сервисом помесячных итогов стоимости
+}
+
+// NewCostRollupHandler создает новый объект CostRollupHandler
+func NewCostRollupHandler(rollupService model.CostRollupService) *CostRollupHandler {
+	return &CostRollupHandler{
+		rollupService: rollupService,
+	}
+}
+
+// RebuildMonthlyCosts - пересобрать помесячные итоги стоимости тенанта (POST /api/v1/admin/monthly-costs/rebuild)
+func (h *CostRollupHandler) RebuildMonthlyCosts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rebuild, err := h.rollupService.RebuildMonthlyCosts(ctx)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	sendSuccess(w, MonthlyCostsRebuildResponse{
+		Rows:         rebuild.Rows,
+		ThroughMonth: rebuild.ThroughMonth.Format(entity.DateLayout),
+	}, http.StatusOK)
+}
+
+// CheckMonthlyCosts - сверить помесячные итоги стоимости тенанта с расчетом по подпискам
+// (GET /api/v1/admin/monthly-costs/check)
+func (h *CostRollupHandler) CheckMonthlyCosts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	check, err := h.rollupService.CheckMonthlyCosts(ctx)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	mismatches := make([]*MonthlyCostMismatchResponse, 0, len(check.Mismatches))
+	for _, m := range check.Mismatches {
+		mismatches = append(mismatches, &MonthlyCostMismatchResponse{
+			Month:               m.Month.Format(entity.DateLayout),
+			UserId:              m.UserId,
+			ServiceName:         m.ServiceName,
+			RollupTotal:         m.RollupTotal,
+			LiveTotal:           m.LiveTotal,
+			RollupSubscriptions: m.RollupSubscriptions,
+			LiveSubscriptions:   m.LiveSubscriptions,
+		})
+	}
+
+	sendSuccess(w, MonthlyCostsCheckResponse{
+		Rows:         check.Rows,
+		ThroughMonth: check.ThroughMonth.Format(entity.DateLayout),
+		Consistent:   len(mismatches) == 0,
+		Mismatches:   mismatches,
+	}, http.StatusOK)
+}
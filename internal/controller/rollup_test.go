@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockCostRollupService - мок для интерфейса CostRollupService
+type MockCostRollupService struct {
+	mock.Mock
+}
+
+// RebuildMonthlyCosts - мок метод для пересборки итогов
+func (m *MockCostRollupService) RebuildMonthlyCosts(ctx context.Context) (*entity.MonthlyCostsRebuild, error) {
+	args := m.Called(ctx)
+	rebuild, _ := args.Get(0).(*entity.MonthlyCostsRebuild)
+	return rebuild, args.Error(1)
+}
+
+// CheckMonthlyCosts - мок метод для сверки итогов
+func (m *MockCostRollupService) CheckMonthlyCosts(ctx context.Context) (*entity.MonthlyCostsCheck, error) {
+	args := m.Called(ctx)
+	check, _ := args.Get(0).(*entity.MonthlyCostsCheck)
+	return check, args.Error(1)
+}
+
+// TestRebuildMonthlyCosts - тест для RebuildMonthlyCosts контроллера
+func TestRebuildMonthlyCosts(t *testing.T) {
+	mockService := new(MockCostRollupService)
+	handler := NewCostRollupHandler(mockService)
+
+	// Тестовый случай 1: Итоги пересобраны, месяц возвращается в формате MM-YYYY
+	{
+		req := httptest.NewRequest("POST", "/admin/monthly-costs/rebuild", nil)
+		rw := httptest.NewRecorder()
+
+		rebuild := &entity.MonthlyCostsRebuild{Rows: 12, ThroughMonth: time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)}
+		mockService.On("RebuildMonthlyCosts", mock.Anything).Return(rebuild, nil).Once()
+
+		handler.RebuildMonthlyCosts(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		var resp MonthlyCostsRebuildResponse
+		_ = json.Unmarshal(rw.Body.Bytes(), &resp)
+		assert.Equal(t, MonthlyCostsRebuildResponse{Rows: 12, ThroughMonth: "07-2025"}, resp)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 2: Ошибка сервиса
+	{
+		req := httptest.NewRequest("POST", "/admin/monthly-costs/rebuild", nil)
+		rw := httptest.NewRecorder()
+
+		mockService.On("RebuildMonthlyCosts", mock.Anything).Return(nil, errors.New("db error")).Once()
+
+		handler.RebuildMonthlyCosts(rw, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rw.Code)
+	}
+}
+
+// TestCheckMonthlyCosts - тест для CheckMonthlyCosts контроллера
+func TestCheckMonthlyCosts(t *testing.T) {
+	mockService := new(MockCostRollupService)
+	handler := NewCostRollupHandler(mockService)
+	through := time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC)
+
+	// Тестовый случай 1: Итоги совпадают с подписками, расхождения - пустой список
+	{
+		req := httptest.NewRequest("GET", "/admin/monthly-costs/check", nil)
+		rw := httptest.NewRecorder()
+
+		check := &entity.MonthlyCostsCheck{Rows: 12, ThroughMonth: through, Mismatches: []*entity.MonthlyCostMismatch{}}
+		mockService.On("CheckMonthlyCosts", mock.Anything).Return(check, nil).Once()
+
+		handler.CheckMonthlyCosts(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.JSONEq(t, `{"rows":12,"through_month":"07-2025","consistent":true,"mismatches":[]}`, rw.Body.String())
+	}
+
+	// Тестовый случай 2: Расхождения возвращаются с месяцем в формате MM-YYYY
+	{
+		req := httptest.NewRequest("GET", "/admin/monthly-costs/check", nil)
+		rw := httptest.NewRecorder()
+
+		userID := uuid.New()
+		check := &entity.MonthlyCostsCheck{Rows: 12, ThroughMonth: through, Mismatches: []*entity.MonthlyCostMismatch{{
+			Month: time.Date(2025, time.May, 1, 0, 0, 0, 0, time.UTC), UserId: userID, ServiceName: "Netflix",
+			RollupTotal: 499, LiveTotal: 998, RollupSubscriptions: 1, LiveSubscriptions: 2,
+		}}}
+		mockService.On("CheckMonthlyCosts", mock.Anything).Return(check, nil).Once()
+
+		handler.CheckMonthlyCosts(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		var resp MonthlyCostsCheckResponse
+		_ = json.Unmarshal(rw.Body.Bytes(), &resp)
+		assert.False(t, resp.Consistent)
+		assert.Equal(t, []*MonthlyCostMismatchResponse{{
+			Month: "05-2025", UserId: userID, ServiceName: "Netflix",
+			RollupTotal: 499, LiveTotal: 998, RollupSubscriptions: 1, LiveSubscriptions: 2,
+		}}, resp.Mismatches)
+	}
+
+	// Тестовый случай 3: Ошибка сервиса
+	{
+		req := httptest.NewRequest("GET", "/admin/monthly-costs/check", nil)
+		rw := httptest.NewRecorder()
+
+		mockService.On("CheckMonthlyCosts", mock.Anything).Return(nil, errors.New("db error")).Once()
+
+		handler.CheckMonthlyCosts(rw, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rw.Code)
+		mockService.AssertExpectations(t)
+	}
+}
@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/Ararat25/subscription-aggregation-service/internal/i18n"
+)
+
+// SearchResultResponse - найденная подписка и ее релевантность
+type SearchResultResponse struct {
+	Subscription *entity.SubscriptionRequest `json:"subscription"`         // найденная подписка
+	Score        float64                     `json:"score" example:"0.75"` // сходство названия сервиса с запросом от 0 до 1
+}
+
+// SearchSubscriptions - поиск подписок по названию сервиса (GET /api/v1/subscriptions/search)
+func (h *Handler) SearchSubscriptions(w http.ResponseWriter, r *http.Request) {
+	query, limit, err := parseSearchParams(r)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	matches, err := h.aggregationService.SearchSubscriptions(r.Context(), query, limit)
+	if err != nil {
+		if errors.Is(err, myError.ErrEmptySearchQuery) {
+			sendError(w, r, err)
+			return
+		}
+		sendError(w, r, err)
+		return
+	}
+
+	resp := make([]SearchResultResponse, 0, len(matches))
+	for _, m := range matches {
+		resp = append(resp, SearchResultResponse{
+			Subscription: entity.ParseSubscriptionToRequest(m.Subscription),
+			Score:        m.Score,
+		})
+	}
+
+	sendSuccess(w, resp, http.StatusOK)
+}
+
+// SuggestServiceNames - автодополнение названия сервиса (GET /api/v1/services/suggest)
+func (h *Handler) SuggestServiceNames(w http.ResponseWriter, r *http.Request) {
+	query, limit, err := parseSearchParams(r)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	suggestions, err := h.aggregationService.SuggestServiceNames(r.Context(), query, limit)
+	if err != nil {
+		if errors.Is(err, myError.ErrEmptySearchQuery) {
+			sendError(w, r, err)
+			return
+		}
+		sendError(w, r, err)
+		return
+	}
+
+	if suggestions == nil {
+		suggestions = []*entity.ServiceSuggestion{}
+	}
+
+	sendSuccess(w, suggestions, http.StatusOK)
+}
+
+// parseSearchParams получает поисковый запрос и количество результатов из параметров запроса
+func parseSearchParams(r *http.Request) (string, int, error) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		return "", 0, missingParam("q")
+	}
+
+	var limit int
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed < 1 {
+			return "", 0, invalidParam("limit", i18n.ReasonPositiveInteger)
+		}
+		limit = parsed
+	}
+
+	return query, limit, nil
+}
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// TestSearchSubscriptions - тест для SearchSubscriptions контроллера
+func TestSearchSubscriptions(t *testing.T) {
+	mockService := new(MockAggregationService)
+
+	handler := NewHandler(mockService)
+
+	// Тестовый случай 1: Успешный поиск
+	{
+		req := httptest.NewRequest("GET", "/subscriptions/search?q=netflx&limit=5", nil)
+		rw := httptest.NewRecorder()
+
+		matches := []*entity.SubscriptionMatch{{
+			Subscription: &entity.Subscription{Id: 1, ServiceName: "Netflix", Price: 499, UserId: uuid.New()},
+			Score:        0.5,
+		}}
+		mockService.On("SearchSubscriptions", mock.Anything, "netflx", 5).Return(matches, nil).Once()
+
+		handler.SearchSubscriptions(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		var resp []SearchResultResponse
+		_ = json.Unmarshal(rw.Body.Bytes(), &resp)
+		assert.Len(t, resp, 1)
+		assert.Equal(t, "Netflix", resp[0].Subscription.ServiceName)
+		assert.Equal(t, 0.5, resp[0].Score)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 2: Ничего не найдено - пустой массив
+	{
+		req := httptest.NewRequest("GET", "/subscriptions/search?q=xyz", nil)
+		rw := httptest.NewRecorder()
+
+		mockService.On("SearchSubscriptions", mock.Anything, "xyz", 0).Return([]*entity.SubscriptionMatch(nil), nil).Once()
+
+		handler.SearchSubscriptions(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.JSONEq(t, `[]`, rw.Body.String())
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 3: Не передан запрос
+	{
+		req := httptest.NewRequest("GET", "/subscriptions/search", nil)
+		rw := httptest.NewRecorder()
+
+		handler.SearchSubscriptions(rw, req)
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		var errResp Problem
+		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
+		assert.Equal(t, "q parameter not set", errResp.Detail)
+	}
+
+	// Тестовый случай 4: Некорректный limit
+	{
+		req := httptest.NewRequest("GET", "/subscriptions/search?q=net&limit=-1", nil)
+		rw := httptest.NewRecorder()
+
+		handler.SearchSubscriptions(rw, req)
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		var errResp Problem
+		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
+		assert.Equal(t, "invalid limit parameter", errResp.Detail)
+		mockService.AssertNumberOfCalls(t, "SearchSubscriptions", 2)
+	}
+}
+
+// TestSuggestServiceNames - тест для SuggestServiceNames контроллера
+func TestSuggestServiceNames(t *testing.T) {
+	mockService := new(MockAggregationService)
+
+	handler := NewHandler(mockService)
+
+	// Тестовый случай 1: Успешное автодополнение
+	{
+		req := httptest.NewRequest("GET", "/services/suggest?q=net", nil)
+		rw := httptest.NewRecorder()
+
+		suggestions := []*entity.ServiceSuggestion{{ServiceName: "Netflix", Subscriptions: 3, Score: 0.3}}
+		mockService.On("SuggestServiceNames", mock.Anything, "net", 0).Return(suggestions, nil).Once()
+
+		handler.SuggestServiceNames(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		var resp []entity.ServiceSuggestion
+		_ = json.Unmarshal(rw.Body.Bytes(), &resp)
+		assert.Equal(t, []entity.ServiceSuggestion{*suggestions[0]}, resp)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 2: Ошибка сервиса
+	{
+		req := httptest.NewRequest("GET", "/services/suggest?q=net", nil)
+		rw := httptest.NewRecorder()
+
+		mockService.On("SuggestServiceNames", mock.Anything, "net", 0).Return([]*entity.ServiceSuggestion(nil), errors.New("db error")).Once()
+
+		handler.SuggestServiceNames(rw, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rw.Code)
+		mockService.AssertExpectations(t)
+	}
+}
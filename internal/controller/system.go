@@ -0,0 +1,31 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/model"
+)
+
+// SystemHandler структура для обработчиков запросов состояния развертывания
+type SystemHandler struct {
+	systemService model.SystemService // объект для работы с сервисом состояния развертывания
+}
+
+// NewSystemHandler создает новый объект SystemHandler
+func NewSystemHandler(systemService model.SystemService) *SystemHandler {
+	return &SystemHandler{
+		systemService: systemService,
+	}
+}
+
+// PoolStats - получить состояние пула соединений с бд (GET /api/v1/system/db-pool)
+func (h *SystemHandler) PoolStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	stats, err := h.systemService.PoolStats(ctx)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	sendSuccess(w, stats, http.StatusOK)
+}
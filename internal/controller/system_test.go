@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSystemService - мок для интерфейса SystemService
+type MockSystemService struct {
+	mock.Mock
+}
+
+// PoolStats - мок метод для получения состояния пула соединений
+func (m *MockSystemService) PoolStats(ctx context.Context) (*entity.PoolStats, error) {
+	args := m.Called(ctx)
+	stats, _ := args.Get(0).(*entity.PoolStats)
+	return stats, args.Error(1)
+}
+
+// TestPoolStats - тест для PoolStats контроллера
+func TestPoolStats(t *testing.T) {
+	mockService := new(MockSystemService)
+	handler := NewSystemHandler(mockService)
+
+	// Тестовый случай 1: Состояние пула возвращается как есть
+	{
+		req := httptest.NewRequest("GET", "/system/db-pool", nil)
+		rw := httptest.NewRecorder()
+
+		stats := &entity.PoolStats{MaxConns: 10, TotalConns: 3, AcquiredConns: 1, IdleConns: 2, AcquireCount: 42}
+		mockService.On("PoolStats", mock.Anything).Return(stats, nil).Once()
+
+		handler.PoolStats(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		var resp entity.PoolStats
+		_ = json.Unmarshal(rw.Body.Bytes(), &resp)
+		assert.Equal(t, *stats, resp)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 2: Ошибка сервиса
+	{
+		req := httptest.NewRequest("GET", "/system/db-pool", nil)
+		rw := httptest.NewRecorder()
+
+		mockService.On("PoolStats", mock.Anything).Return(nil, errors.New("pool closed")).Once()
+
+		handler.PoolStats(rw, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rw.Code)
+	}
+}
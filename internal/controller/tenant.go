@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/Ararat25/subscription-aggregation-service/internal/i18n"
+	"github.com/Ararat25/subscription-aggregation-service/internal/model"
+)
+
+// TenantHandler структура для обработчиков запросов управления тенантами
+type TenantHandler struct {
+	tenantService model.TenantService // объект для работы с сервисом тенантов
+}
+
+// NewTenantHandler создает новый объект TenantHandler
+func NewTenantHandler(tenantService model.TenantService) *TenantHandler {
+	return &TenantHandler{
+		tenantService: tenantService,
+	}
+}
+
+// CreateTenant - создать тенанта (POST /api/v1/tenants)
+func (h *TenantHandler) CreateTenant(w http.ResponseWriter, r *http.Request) {
+	req := &entity.TenantRequest{}
+	err := decodeBody(r, req)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	tenant, err := h.tenantService.CreateTenant(ctx, req)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	sendSuccess(w, tenant, http.StatusOK)
+}
+
+// ListTenants - получить список тенантов (GET /api/v1/tenants)
+func (h *TenantHandler) ListTenants(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	tenants, err := h.tenantService.ListTenants(ctx)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	if tenants == nil {
+		tenants = []*entity.Tenant{}
+	}
+
+	sendSuccess(w, tenants, http.StatusOK)
+}
+
+// TenantCosts - получить стоимость подписок каждого тенанта за период (GET /api/v1/tenants/cost)
+func (h *TenantHandler) TenantCosts(w http.ResponseWriter, r *http.Request) {
+	fromStr := r.URL.Query().Get("from")
+	toStr := r.URL.Query().Get("to")
+
+	if fromStr == "" {
+		sendError(w, r, missingParam("from"))
+		return
+	}
+
+	if toStr == "" {
+		sendError(w, r, missingParam("to"))
+		return
+	}
+
+	from, err := time.Parse(entity.DateLayout, fromStr)
+	if err != nil {
+		sendError(w, r, invalidParam("from", i18n.ReasonMonth))
+		return
+	}
+
+	to, err := time.Parse(entity.DateLayout, toStr)
+	if err != nil {
+		sendError(w, r, invalidParam("to", i18n.ReasonMonth))
+		return
+	}
+
+	ctx := r.Context()
+	costs, err := h.tenantService.TenantCosts(ctx, from, to)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	if costs == nil {
+		costs = []*entity.TenantCost{}
+	}
+
+	sendSuccess(w, costs, http.StatusOK)
+}
@@ -0,0 +1,161 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTenantService - мок для интерфейса TenantService
+type MockTenantService struct {
+	mock.Mock
+}
+
+// CreateTenant - мок метод для создания тенанта
+func (m *MockTenantService) CreateTenant(ctx context.Context, req *entity.TenantRequest) (*entity.Tenant, error) {
+	args := m.Called(ctx, req)
+	tenant, _ := args.Get(0).(*entity.Tenant)
+	return tenant, args.Error(1)
+}
+
+// ListTenants - мок метод для получения списка тенантов
+func (m *MockTenantService) ListTenants(ctx context.Context) ([]*entity.Tenant, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*entity.Tenant), args.Error(1)
+}
+
+// TenantCosts - мок метод для расчета стоимости подписок по тенантам
+func (m *MockTenantService) TenantCosts(ctx context.Context, from, to time.Time) ([]*entity.TenantCost, error) {
+	args := m.Called(ctx, from, to)
+	costs, _ := args.Get(0).([]*entity.TenantCost)
+	return costs, args.Error(1)
+}
+
+// TestCreateTenant - тест для CreateTenant контроллера
+func TestCreateTenant(t *testing.T) {
+	mockService := new(MockTenantService)
+	handler := NewTenantHandler(mockService)
+
+	// Тестовый случай 1: Успешное создание
+	{
+		id := uuid.New()
+		req := httptest.NewRequest("POST", "/tenants", bytes.NewBufferString(`{"name":"acme"}`))
+		rw := httptest.NewRecorder()
+
+		mockService.On("CreateTenant", mock.Anything, &entity.TenantRequest{Name: "acme"}).
+			Return(&entity.Tenant{Id: id, Name: "acme"}, nil).Once()
+
+		handler.CreateTenant(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		var resp entity.Tenant
+		_ = json.Unmarshal(rw.Body.Bytes(), &resp)
+		assert.Equal(t, id, resp.Id)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 2: Не указано название
+	{
+		req := httptest.NewRequest("POST", "/tenants", bytes.NewBufferString(`{}`))
+		rw := httptest.NewRecorder()
+
+		handler.CreateTenant(rw, req)
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		mockService.AssertNumberOfCalls(t, "CreateTenant", 1)
+	}
+
+	// Тестовый случай 3: Название занято
+	{
+		req := httptest.NewRequest("POST", "/tenants", bytes.NewBufferString(`{"name":"acme"}`))
+		rw := httptest.NewRecorder()
+
+		mockService.On("CreateTenant", mock.Anything, &entity.TenantRequest{Name: "acme"}).
+			Return(nil, myError.ErrTenantNameTaken).Once()
+
+		handler.CreateTenant(rw, req)
+
+		assert.Equal(t, http.StatusConflict, rw.Code)
+		var errResp Problem
+		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
+		assert.Equal(t, CodeTenantNameTaken, errResp.Code)
+	}
+}
+
+// TestListTenants - тест для ListTenants контроллера
+func TestListTenants(t *testing.T) {
+	mockService := new(MockTenantService)
+	handler := NewTenantHandler(mockService)
+
+	// Тестовый случай 1: Пустой список возвращается как массив
+	req := httptest.NewRequest("GET", "/tenants", nil)
+	rw := httptest.NewRecorder()
+
+	mockService.On("ListTenants", mock.Anything).Return([]*entity.Tenant(nil), nil).Once()
+
+	handler.ListTenants(rw, req)
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.JSONEq(t, `[]`, rw.Body.String())
+}
+
+// TestTenantCosts - тест для TenantCosts контроллера
+func TestTenantCosts(t *testing.T) {
+	mockService := new(MockTenantService)
+	handler := NewTenantHandler(mockService)
+
+	// Тестовый случай 1: Стоимость по тенантам за период
+	{
+		req := httptest.NewRequest("GET", "/tenants/cost?from=01-2025&to=03-2025", nil)
+		rw := httptest.NewRecorder()
+
+		from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+		costs := []*entity.TenantCost{{TenantId: uuid.New(), Name: "acme", TotalCost: 1200}}
+		mockService.On("TenantCosts", mock.Anything, from, to).Return(costs, nil).Once()
+
+		handler.TenantCosts(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		var resp []*entity.TenantCost
+		_ = json.Unmarshal(rw.Body.Bytes(), &resp)
+		assert.Equal(t, costs, resp)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 2: Не указан параметр to
+	{
+		req := httptest.NewRequest("GET", "/tenants/cost?from=01-2025", nil)
+		rw := httptest.NewRecorder()
+
+		handler.TenantCosts(rw, req)
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		var errResp Problem
+		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
+		assert.Equal(t, CodeInvalidParameter, errResp.Code)
+		mockService.AssertNumberOfCalls(t, "TenantCosts", 1)
+	}
+
+	// Тестовый случай 3: Конец периода раньше начала
+	{
+		req := httptest.NewRequest("GET", "/tenants/cost?from=03-2025&to=01-2025", nil)
+		rw := httptest.NewRecorder()
+
+		mockService.On("TenantCosts", mock.Anything, mock.Anything, mock.Anything).Return(nil, myError.ErrPeriodRange).Once()
+
+		handler.TenantCosts(rw, req)
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+	}
+}
@@ -6,74 +6,130 @@ import (
 	"time"
 
 	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/Ararat25/subscription-aggregation-service/internal/i18n"
 	"github.com/google/uuid"
 )
 
 // TotalCostControllerResponse - структура для ответа от контроллера TotalCost
 type TotalCostControllerResponse struct {
-	TotalCost int `json:"total_cost" example:"2000"` // суммарная стоимость подписок
+	TotalCost int                 `json:"total_cost" example:"2000"` // суммарная стоимость подписок
+	Groups    []*entity.CostGroup `json:"groups,omitempty"`          // стоимость в разрезе категорий или тегов, если передан group_by
 }
 
-// TotalCost godoc
-// @Summary Получить общую стоимость подписок
-// @Description Возвращает суммарную стоимость подписок за указанный период с возможной фильтрацией по id пользователя и названию сервиса
-// @Tags subscriptions
-// @Produce json
-// @Param from query string true "Дата начала периода (формат MM-YYYY)"
-// @Param to query string true "Дата конца периода (формат MM-YYYY)"
-// @Param id query string false "UUID пользователя"
-// @Param service_name query string false "Название сервиса"
-// @Success 200 {object} TotalCostControllerResponse "Общая стоимость"
-// @Failure 400 {object} ErrorResponse "Неверные параметры запроса"
-// @Failure 500 {object} ErrorResponse "Внутренняя ошибка сервера"
-// @Router /subscriptions/cost [get]
+// TotalCost - получить общую стоимость подписок (GET /api/v1/subscriptions/cost)
 func (h *Handler) TotalCost(w http.ResponseWriter, r *http.Request) {
 	fromStr := r.URL.Query().Get("from")
 	toStr := r.URL.Query().Get("to")
 
-	if fromStr == "" || toStr == "" {
-		sendError(w, "missing from or to parameter", http.StatusBadRequest)
+	if fromStr == "" {
+		sendError(w, r, missingParam("from"))
+		return
+	}
+
+	if toStr == "" {
+		sendError(w, r, missingParam("to"))
 		return
 	}
 
 	from, err := time.Parse(entity.DateLayout, fromStr)
 	if err != nil {
-		sendError(w, "invalid from parameter", http.StatusBadRequest)
+		sendError(w, r, invalidParam("from", i18n.ReasonMonth))
 		return
 	}
 
 	to, err := time.Parse(entity.DateLayout, toStr)
 	if err != nil {
-		sendError(w, "invalid to parameter", http.StatusBadRequest)
+		sendError(w, r, invalidParam("to", i18n.ReasonMonth))
 		return
 	}
 
 	idStr := r.URL.Query().Get("id")
 	serviceNameStr := r.URL.Query().Get("service_name")
 
-	var serviceName *string
+	match, err := entity.ParseMatchMode(r.URL.Query().Get("match"))
+	if err != nil {
+		sendError(w, r, invalidParam("match", i18n.ReasonOneOf, "exact icase fuzzy"))
+		return
+	}
+
+	filter := &entity.CostFilter{
+		SubscriptionFilter: parseSubscriptionFilter(r),
+	}
+
 	if strings.TrimSpace(serviceNameStr) != "" {
-		serviceName = &serviceNameStr
+		filter.ServiceName = &entity.ServiceNameFilter{
+			Name: serviceNameStr,
+			Mode: match,
+		}
 	}
 
-	var id *uuid.UUID
 	if idStr != "" {
 		parsedID, err := uuid.Parse(idStr)
 		if err != nil {
-			sendError(w, "invalid id", http.StatusBadRequest)
+			sendError(w, r, invalidParam("id", i18n.ReasonUUID))
 			return
 		}
-		id = &parsedID
+		filter.UserId = &parsedID
 	}
 
+	var groupBy entity.GroupBy
+	if groupByStr := r.URL.Query().Get("group_by"); groupByStr != "" {
+		groupBy, err = entity.ParseGroupBy(groupByStr)
+		if err != nil {
+			sendError(w, r, invalidParam("group_by", i18n.ReasonOneOf, "category tag"))
+			return
+		}
+	}
+
+	// стоимость подписок без даты окончания считается по ту же дату, по месяцу которой строятся валидаторы
+	now := h.now().UTC()
+	filter.Today = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	// доступ проверяется до валидаторов: иначе на запрос чужих подписок вместо 403 мог бы прийти 304
 	ctx := r.Context()
-	cost, err := h.aggregationService.TotalCost(ctx, from, to, id, serviceName)
+	filter, err = h.aggregationService.ScopeCostFilter(ctx, filter)
 	if err != nil {
-		sendError(w, err.Error(), http.StatusInternalServerError)
+		sendError(w, r, err)
+		return
+	}
+
+	version, done := h.subscriptionsVersion(w, r)
+	if done {
 		return
 	}
 
-	sendSuccess(w, TotalCostControllerResponse{
+	validators := monthValidators(version, filter.Today).forRequest(r.URL.Query().Encode(), scopedUser(filter))
+	if notModified(w, r, validators) {
+		return
+	}
+
+	cost, err := h.aggregationService.TotalCost(ctx, from, to, filter)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	resp := TotalCostControllerResponse{
 		TotalCost: cost,
-	}, http.StatusOK)
+	}
+
+	if groupBy != "" {
+		resp.Groups, err = h.aggregationService.CostBreakdown(ctx, from, to, filter, groupBy)
+		if err != nil {
+			sendError(w, r, err)
+			return
+		}
+	}
+
+	setCacheHeaders(w, validators)
+	sendSuccess(w, resp, http.StatusOK)
+}
+
+// scopedUser возвращает пользователя, которым сервис ограничил фильтр стоимости, или пустую строку
+func scopedUser(filter *entity.CostFilter) string {
+	if filter == nil || filter.UserId == nil {
+		return ""
+	}
+
+	return filter.UserId.String()
 }
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -20,6 +21,9 @@ func TestTotalCost(t *testing.T) {
 	mockService := new(MockAggregationService)
 
 	handler := NewHandler(mockService)
+	today := time.Date(2024, time.March, 20, 0, 0, 0, 0, time.UTC)
+	handler.now = func() time.Time { return today.Add(9 * time.Hour) }
+	mockService.On("SubscriptionsVersion", mock.Anything).Return(&entity.TableVersion{Version: 1}, nil)
 
 	// Тестовый случай 1: Успешное вычисление общей стоимости со всеми параметрами
 	{
@@ -40,7 +44,13 @@ func TestTotalCost(t *testing.T) {
 		parsedFrom, _ := time.Parse(entity.DateLayout, from)
 		parsedTo, _ := time.Parse(entity.DateLayout, to)
 
-		mockService.On("TotalCost", mock.Anything, parsedFrom, parsedTo, &userID, &serviceName).Return(1000, nil).Once()
+		filter := &entity.CostFilter{
+			SubscriptionFilter: entity.SubscriptionFilter{UserId: &userID},
+			ServiceName:        &entity.ServiceNameFilter{Name: serviceName, Mode: entity.MatchExact},
+			Today:              today,
+		}
+		mockService.On("ScopeCostFilter", mock.Anything, filter).Return(filter, nil).Once()
+		mockService.On("TotalCost", mock.Anything, parsedFrom, parsedTo, filter).Return(1000, nil).Once()
 
 		handler.TotalCost(rw, req)
 
@@ -66,7 +76,9 @@ func TestTotalCost(t *testing.T) {
 		parsedFrom, _ := time.Parse(entity.DateLayout, from)
 		parsedTo, _ := time.Parse(entity.DateLayout, to)
 
-		mockService.On("TotalCost", mock.Anything, parsedFrom, parsedTo, (*uuid.UUID)(nil), (*string)(nil)).Return(500, nil).Once()
+		filter := &entity.CostFilter{Today: today}
+		mockService.On("ScopeCostFilter", mock.Anything, filter).Return(filter, nil).Once()
+		mockService.On("TotalCost", mock.Anything, parsedFrom, parsedTo, filter).Return(500, nil).Once()
 
 		handler.TotalCost(rw, req)
 
@@ -88,9 +100,10 @@ func TestTotalCost(t *testing.T) {
 		handler.TotalCost(rw, req)
 
 		assert.Equal(t, http.StatusBadRequest, rw.Code)
-		var errResp ErrorResponse
+		var errResp Problem
 		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
-		assert.Contains(t, errResp.Error, "missing from or to parameter")
+		assert.Equal(t, CodeInvalidParameter, errResp.Code)
+		assert.Equal(t, "to parameter not set", errResp.Detail)
 		mockService.AssertNotCalled(t, "TotalCost")
 	}
 
@@ -106,9 +119,9 @@ func TestTotalCost(t *testing.T) {
 		handler.TotalCost(rw, req)
 
 		assert.Equal(t, http.StatusBadRequest, rw.Code)
-		var errResp ErrorResponse
+		var errResp Problem
 		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
-		assert.Contains(t, errResp.Error, "invalid from parameter")
+		assert.Contains(t, errResp.Detail, "invalid from parameter")
 		mockService.AssertNotCalled(t, "TotalCost")
 	}
 
@@ -124,9 +137,9 @@ func TestTotalCost(t *testing.T) {
 		handler.TotalCost(rw, req)
 
 		assert.Equal(t, http.StatusBadRequest, rw.Code)
-		var errResp ErrorResponse
+		var errResp Problem
 		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
-		assert.Contains(t, errResp.Error, "invalid to parameter")
+		assert.Contains(t, errResp.Detail, "invalid to parameter")
 		mockService.AssertNotCalled(t, "TotalCost")
 	}
 
@@ -143,9 +156,9 @@ func TestTotalCost(t *testing.T) {
 		handler.TotalCost(rw, req)
 
 		assert.Equal(t, http.StatusBadRequest, rw.Code)
-		var errResp ErrorResponse
+		var errResp Problem
 		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
-		assert.Contains(t, errResp.Error, "invalid id")
+		assert.Contains(t, errResp.Detail, "invalid id")
 		mockService.AssertNotCalled(t, "TotalCost")
 	}
 
@@ -164,14 +177,119 @@ func TestTotalCost(t *testing.T) {
 		parsedFrom, _ := time.Parse(entity.DateLayout, from)
 		parsedTo, _ := time.Parse(entity.DateLayout, to)
 
-		mockService.On("TotalCost", mock.Anything, parsedFrom, parsedTo, (*uuid.UUID)(nil), (*string)(nil)).Return(0, errors.New("internal service error")).Once()
+		filter := &entity.CostFilter{Today: today}
+		mockService.On("ScopeCostFilter", mock.Anything, filter).Return(filter, nil).Once()
+		mockService.On("TotalCost", mock.Anything, parsedFrom, parsedTo, filter).Return(0, errors.New("internal service error")).Once()
 
 		handler.TotalCost(rw, req)
 
 		assert.Equal(t, http.StatusInternalServerError, rw.Code)
-		var errResp ErrorResponse
+		var errResp Problem
 		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
-		assert.Contains(t, errResp.Error, "internal service error")
+		assert.Equal(t, CodeInternal, errResp.Code)
+		assert.Equal(t, "internal server error", errResp.Detail)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 8: Нечеткий поиск по названию сервиса
+	{
+		params := url.Values{}
+		params.Add("from", "01-2023")
+		params.Add("to", "12-2023")
+		params.Add("service_name", "netflx")
+		params.Add("match", "fuzzy")
+
+		req := httptest.NewRequest("GET", "/subscriptions/cost?"+params.Encode(), nil)
+		rw := httptest.NewRecorder()
+
+		filter := &entity.CostFilter{ServiceName: &entity.ServiceNameFilter{Name: "netflx", Mode: entity.MatchFuzzy}, Today: today}
+		mockService.On("ScopeCostFilter", mock.Anything, filter).Return(filter, nil).Once()
+		mockService.On("TotalCost", mock.Anything, mock.Anything, mock.Anything, filter).Return(1500, nil).Once()
+
+		handler.TotalCost(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		var resp TotalCostControllerResponse
+		_ = json.Unmarshal(rw.Body.Bytes(), &resp)
+		assert.Equal(t, 1500, resp.TotalCost)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 9: Неизвестный режим сравнения
+	{
+		params := url.Values{}
+		params.Add("from", "01-2023")
+		params.Add("to", "12-2023")
+		params.Add("service_name", "Netflix")
+		params.Add("match", "regex")
+
+		req := httptest.NewRequest("GET", "/subscriptions/cost?"+params.Encode(), nil)
+		rw := httptest.NewRecorder()
+
+		handler.TotalCost(rw, req)
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		var errResp Problem
+		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
+		assert.Equal(t, "invalid match parameter", errResp.Detail)
+	}
+
+	// Тестовый случай 10: Стоимость в разрезе тегов с фильтром по категории
+	{
+		params := url.Values{}
+		params.Add("from", "01-2023")
+		params.Add("to", "12-2023")
+		params.Add("category", "streaming")
+		params.Add("group_by", "tag")
+
+		req := httptest.NewRequest("GET", "/subscriptions/cost?"+params.Encode(), nil)
+		rw := httptest.NewRecorder()
+
+		category := "streaming"
+		filter := &entity.CostFilter{SubscriptionFilter: entity.SubscriptionFilter{Category: &category}, Today: today}
+		mockService.On("ScopeCostFilter", mock.Anything, filter).Return(filter, nil).Once()
+		family := "family"
+		groups := []*entity.CostGroup{{Key: &family, TotalCost: 700}, {Key: nil, TotalCost: 300}}
+		mockService.On("TotalCost", mock.Anything, mock.Anything, mock.Anything, filter).Return(1000, nil).Once()
+		mockService.On("CostBreakdown", mock.Anything, mock.Anything, mock.Anything, filter, entity.GroupByTag).Return(groups, nil).Once()
+
+		handler.TotalCost(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		var resp TotalCostControllerResponse
+		_ = json.Unmarshal(rw.Body.Bytes(), &resp)
+		assert.Equal(t, 1000, resp.TotalCost)
+		assert.Equal(t, groups, resp.Groups)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 11: Неизвестный разрез
+	{
+		req := httptest.NewRequest("GET", "/subscriptions/cost?from=01-2023&to=12-2023&group_by=user", nil)
+		rw := httptest.NewRecorder()
+
+		handler.TotalCost(rw, req)
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		var errResp Problem
+		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
+		assert.Equal(t, "invalid group_by parameter", errResp.Detail)
+	}
+
+	// Тестовый случай 12: Запрос чужих подписок отклоняется до проверки кэша, даже с If-None-Match: *
+	{
+		other := uuid.New()
+		req := httptest.NewRequest("GET", "/subscriptions/cost?from=01-2023&to=12-2023&id="+other.String(), nil)
+		req.Header.Set("If-None-Match", "*")
+		rw := httptest.NewRecorder()
+
+		filter := &entity.CostFilter{SubscriptionFilter: entity.SubscriptionFilter{UserId: &other}, Today: today}
+		mockService.On("ScopeCostFilter", mock.Anything, filter).Return(nil, myError.ErrForbidden).Once()
+
+		handler.TotalCost(rw, req)
+
+		assert.Equal(t, http.StatusForbidden, rw.Code)
+		assert.Empty(t, rw.Header().Get("ETag"))
 		mockService.AssertExpectations(t)
 	}
 }
@@ -10,7 +10,6 @@ import (
 
 	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
 	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
-	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -18,8 +17,6 @@ import (
 
 // TestUpdateSubscription - тест для функции UpdateSubscription контроллера
 func TestUpdateSubscription(t *testing.T) {
-	validate = validator.New()
-
 	mockService := new(MockAggregationService)
 
 	handler := NewHandler(mockService)
@@ -56,9 +53,9 @@ func TestUpdateSubscription(t *testing.T) {
 		handler.UpdateSubscription(rw, req)
 
 		assert.Equal(t, http.StatusBadRequest, rw.Code)
-		var errResp ErrorResponse
+		var errResp Problem
 		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
-		assert.Contains(t, errResp.Error, "invalid character")
+		assert.Equal(t, CodeMalformedBody, errResp.Code)
 		mockService.AssertNotCalled(t, "UpdateSubscription")
 	}
 
@@ -77,9 +74,10 @@ func TestUpdateSubscription(t *testing.T) {
 		handler.UpdateSubscription(rw, req)
 
 		assert.Equal(t, http.StatusBadRequest, rw.Code)
-		var errResp ErrorResponse
+		var errResp Problem
 		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
-		assert.Contains(t, errResp.Error, "ServiceName")
+		assert.Equal(t, CodeValidationFailed, errResp.Code)
+		assert.Equal(t, []InvalidParam{{Name: "service_name", Reason: "service_name is a required field"}}, errResp.InvalidParams)
 		mockService.AssertNotCalled(t, "UpdateSubscription")
 	}
 
@@ -101,9 +99,9 @@ func TestUpdateSubscription(t *testing.T) {
 		handler.UpdateSubscription(rw, req)
 
 		assert.Equal(t, http.StatusNotFound, rw.Code)
-		var errResp ErrorResponse
+		var errResp Problem
 		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
-		assert.Contains(t, errResp.Error, myError.ErrSubscriptionNotFound.Error())
+		assert.Contains(t, errResp.Detail, myError.ErrSubscriptionNotFound.Error())
 		mockService.AssertExpectations(t)
 	}
 
@@ -124,10 +122,11 @@ func TestUpdateSubscription(t *testing.T) {
 
 		handler.UpdateSubscription(rw, req)
 
-		assert.Equal(t, http.StatusBadRequest, rw.Code)
-		var errResp ErrorResponse
+		assert.Equal(t, http.StatusInternalServerError, rw.Code)
+		var errResp Problem
 		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
-		assert.Contains(t, errResp.Error, "some internal error")
+		assert.Equal(t, CodeInternal, errResp.Code)
+		assert.Equal(t, "internal server error", errResp.Detail)
 		mockService.AssertExpectations(t)
 	}
 }
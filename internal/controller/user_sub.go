@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/Ararat25/subscription-aggregation-service/internal/i18n"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// UserSummaryResponse - структура для ответа от контроллера UserSummary
+type UserSummaryResponse struct {
+	UserId        uuid.UUID                     `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000"` // id пользователя
+	ActiveCount   int                           `json:"active_count" example:"3"`                               // количество активных подписок
+	MonthlySpend  int                           `json:"monthly_spend" example:"1497"`                           // стоимость активных подписок за текущий месяц
+	LifetimeSpend int                           `json:"lifetime_spend" example:"17964"`                         // стоимость всех подписок с их начала по текущий месяц
+	MostExpensive *entity.SubscriptionRequest   `json:"most_expensive,omitempty"`                               // самая дорогая активная подписка
+	UpcomingEnds  []*entity.SubscriptionRequest `json:"upcoming_ends"`                                          // ближайшие окончания активных подписок
+}
+
+// ListUserSubscriptions - получить подписки пользователя (GET /api/v1/users/{user_id}/subscriptions)
+func (h *Handler) ListUserSubscriptions(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseUserIdParam(r)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	filter := parseSubscriptionFilter(r)
+	filter.UserId = &userID
+
+	ctx := r.Context()
+	subs, err := h.aggregationService.ListSubscriptions(ctx, &filter)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	subsResp := make([]*entity.SubscriptionRequest, 0, len(subs))
+	for _, sub := range subs {
+		subsResp = append(subsResp, entity.ParseSubscriptionToRequest(sub))
+	}
+
+	sendSuccess(w, subsResp, http.StatusOK)
+}
+
+// UserSummary - получить сводку по подпискам пользователя (GET /api/v1/users/{user_id}/summary)
+func (h *Handler) UserSummary(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseUserIdParam(r)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	summary, err := h.aggregationService.UserSummary(ctx, userID)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	sendSuccess(w, newUserSummaryResponse(summary), http.StatusOK)
+}
+
+// newUserSummaryResponse преобразует сводку по подпискам пользователя в ответ
+func newUserSummaryResponse(summary *entity.UserSummary) *UserSummaryResponse {
+	resp := &UserSummaryResponse{
+		UserId:        summary.UserId,
+		ActiveCount:   summary.ActiveCount,
+		MonthlySpend:  summary.MonthlySpend,
+		LifetimeSpend: summary.LifetimeSpend,
+		UpcomingEnds:  make([]*entity.SubscriptionRequest, 0, len(summary.UpcomingEnds)),
+	}
+
+	if summary.MostExpensive != nil {
+		resp.MostExpensive = entity.ParseSubscriptionToRequest(summary.MostExpensive)
+	}
+
+	for _, sub := range summary.UpcomingEnds {
+		resp.UpcomingEnds = append(resp.UpcomingEnds, entity.ParseSubscriptionToRequest(sub))
+	}
+
+	return resp
+}
+
+// parseUserIdParam получает UUID пользователя из параметра пути
+func parseUserIdParam(r *http.Request) (uuid.UUID, error) {
+	userIDStr := chi.URLParam(r, "user_id")
+	if userIDStr == "" {
+		return uuid.Nil, missingParam("user_id")
+	}
+
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return uuid.Nil, invalidParam("user_id", i18n.ReasonUUID)
+	}
+
+	return userID, nil
+}
@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// TestListUserSubscriptions - тест для ListUserSubscriptions контроллера
+func TestListUserSubscriptions(t *testing.T) {
+	mockService := new(MockAggregationService)
+
+	handler := NewHandler(mockService)
+
+	// Тестовый случай 1: Подписки пользователя с фильтром по тегу
+	{
+		userID := uuid.New()
+		req := withURLParam(httptest.NewRequest("GET", "/users/"+userID.String()+"/subscriptions?tag=family", nil), "user_id", userID.String())
+		rw := httptest.NewRecorder()
+
+		subs := []*entity.Subscription{{Id: 1, ServiceName: "Netflix", Price: 499, UserId: userID}}
+		filter := &entity.SubscriptionFilter{UserId: &userID, Tags: []string{"family"}}
+		mockService.On("ListSubscriptions", mock.Anything, filter).Return(subs, nil).Once()
+
+		handler.ListUserSubscriptions(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		var resp []*entity.SubscriptionRequest
+		_ = json.Unmarshal(rw.Body.Bytes(), &resp)
+		assert.Len(t, resp, 1)
+		assert.Equal(t, userID, resp[0].UserId)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 2: Некорректный UUID пользователя
+	{
+		req := withURLParam(httptest.NewRequest("GET", "/users/abc/subscriptions", nil), "user_id", "abc")
+		rw := httptest.NewRecorder()
+
+		handler.ListUserSubscriptions(rw, req)
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		var errResp Problem
+		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
+		assert.Equal(t, "invalid user_id parameter", errResp.Detail)
+		mockService.AssertNumberOfCalls(t, "ListSubscriptions", 1)
+	}
+}
+
+// TestUserSummary - тест для UserSummary контроллера
+func TestUserSummary(t *testing.T) {
+	mockService := new(MockAggregationService)
+
+	handler := NewHandler(mockService)
+
+	userID := uuid.New()
+	endDate := time.Date(2025, time.August, 1, 0, 0, 0, 0, time.UTC)
+	sub := &entity.Subscription{
+		Id:          3,
+		ServiceName: "Cloud",
+		Price:       500,
+		UserId:      userID,
+		StartDate:   time.Date(2025, time.May, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:     &endDate,
+	}
+
+	// Тестовый случай 1: Успешное получение сводки
+	{
+		req := withURLParam(httptest.NewRequest("GET", "/users/"+userID.String()+"/summary", nil), "user_id", userID.String())
+		rw := httptest.NewRecorder()
+
+		mockService.On("UserSummary", mock.Anything, userID).Return(&entity.UserSummary{
+			UserId:        userID,
+			ActiveCount:   1,
+			MonthlySpend:  500,
+			LifetimeSpend: 1000,
+			MostExpensive: sub,
+			UpcomingEnds:  []*entity.Subscription{sub},
+		}, nil).Once()
+
+		handler.UserSummary(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		var resp UserSummaryResponse
+		_ = json.Unmarshal(rw.Body.Bytes(), &resp)
+		assert.Equal(t, 1, resp.ActiveCount)
+		assert.Equal(t, 1000, resp.LifetimeSpend)
+		assert.Equal(t, "Cloud", resp.MostExpensive.ServiceName)
+		assert.Equal(t, "08-2025", *resp.UpcomingEnds[0].EndDate)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 2: Нет подписок - пустой список окончаний
+	{
+		otherID := uuid.New()
+		req := withURLParam(httptest.NewRequest("GET", "/users/"+otherID.String()+"/summary", nil), "user_id", otherID.String())
+		rw := httptest.NewRecorder()
+
+		mockService.On("UserSummary", mock.Anything, otherID).Return(&entity.UserSummary{UserId: otherID}, nil).Once()
+
+		handler.UserSummary(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.JSONEq(t, `{"user_id":"`+otherID.String()+`","active_count":0,"monthly_spend":0,"lifetime_spend":0,"upcoming_ends":[]}`, rw.Body.String())
+		mockService.AssertExpectations(t)
+	}
+}
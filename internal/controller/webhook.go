@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/Ararat25/subscription-aggregation-service/internal/model"
+)
+
+// WebhookHandler структура для обработчиков запросов управления вебхуками
+type WebhookHandler struct {
+	webhookService model.WebhookService // объект для работы с сервисом вебхуков
+}
+
+// NewWebhookHandler создает новый объект WebhookHandler
+func NewWebhookHandler(webhookService model.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// CreateWebhook - зарегистрировать вебхук (POST /api/v1/webhooks)
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	req := &entity.WebhookRequest{}
+	err := decodeBody(r, req)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	webhook, err := h.webhookService.CreateWebhook(ctx, req)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	sendSuccess(w, webhook, http.StatusOK)
+}
+
+// ListWebhooks - получить список вебхуков (GET /api/v1/webhooks)
+func (h *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	webhooks, err := h.webhookService.ListWebhooks(ctx)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	if webhooks == nil {
+		webhooks = []*entity.Webhook{}
+	}
+
+	sendSuccess(w, webhooks, http.StatusOK)
+}
+
+// DeleteWebhook - удалить вебхук (DELETE /api/v1/webhooks/{id})
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIdParam(r, "id")
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	err = h.webhookService.DeleteWebhook(ctx, id)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	sendSuccess(w, StatusResponse{Status: "success"}, http.StatusOK)
+}
+
+// ListDeliveries - получить журнал доставок вебхука (GET /api/v1/webhooks/{id}/deliveries)
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIdParam(r, "id")
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	deliveries, err := h.webhookService.ListDeliveries(ctx, id)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	if deliveries == nil {
+		deliveries = []*entity.WebhookDelivery{}
+	}
+
+	sendSuccess(w, deliveries, http.StatusOK)
+}
+
+// Redeliver - повторно отправить событие (POST /api/v1/webhooks/deliveries/{id}/redeliver)
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIdParam(r, "id")
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	err = h.webhookService.Redeliver(ctx, id)
+	if err != nil {
+		sendError(w, r, err)
+		return
+	}
+
+	sendSuccess(w, StatusResponse{Status: "success"}, http.StatusOK)
+}
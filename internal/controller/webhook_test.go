@@ -0,0 +1,257 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockWebhookService - мок для интерфейса WebhookService
+type MockWebhookService struct {
+	mock.Mock
+}
+
+// CreateWebhook - мок метод для регистрации вебхука
+func (m *MockWebhookService) CreateWebhook(ctx context.Context, w *entity.WebhookRequest) (*entity.Webhook, error) {
+	args := m.Called(ctx, w)
+	return args.Get(0).(*entity.Webhook), args.Error(1)
+}
+
+// ListWebhooks - мок метод для получения списка вебхуков
+func (m *MockWebhookService) ListWebhooks(ctx context.Context) ([]*entity.Webhook, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*entity.Webhook), args.Error(1)
+}
+
+// DeleteWebhook - мок метод для удаления вебхука
+func (m *MockWebhookService) DeleteWebhook(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// ListDeliveries - мок метод для получения журнала доставок
+func (m *MockWebhookService) ListDeliveries(ctx context.Context, webhookID int64) ([]*entity.WebhookDelivery, error) {
+	args := m.Called(ctx, webhookID)
+	return args.Get(0).([]*entity.WebhookDelivery), args.Error(1)
+}
+
+// Redeliver - мок метод для повторной отправки события
+func (m *MockWebhookService) Redeliver(ctx context.Context, deliveryID int64) error {
+	args := m.Called(ctx, deliveryID)
+	return args.Error(0)
+}
+
+// withURLParam добавляет параметр пути chi в запрос
+func withURLParam(req *http.Request, key, value string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add(key, value)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+// TestCreateWebhook - тест для CreateWebhook контроллера
+func TestCreateWebhook(t *testing.T) {
+	mockService := new(MockWebhookService)
+	handler := NewWebhookHandler(mockService)
+
+	// Тестовый случай 1: Успешная регистрация
+	{
+		body, _ := json.Marshal(entity.WebhookRequest{
+			URL:    "https://example.com/hook",
+			Events: []entity.EventType{entity.EventSubscriptionCreated},
+		})
+		req := httptest.NewRequest("POST", "/webhooks", bytes.NewBuffer(body))
+		rw := httptest.NewRecorder()
+
+		mockService.On("CreateWebhook", mock.Anything, mock.AnythingOfType("*entity.WebhookRequest")).
+			Return(&entity.Webhook{Id: 1, URL: "https://example.com/hook", Secret: "whsec_x"}, nil).Once()
+
+		handler.CreateWebhook(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		var resp entity.Webhook
+		_ = json.Unmarshal(rw.Body.Bytes(), &resp)
+		assert.Equal(t, int64(1), resp.Id)
+		assert.Equal(t, "whsec_x", resp.Secret)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 2: Неизвестный тип события
+	{
+		body := []byte(`{"url":"https://example.com/hook","events":["subscription.unknown"]}`)
+		req := httptest.NewRequest("POST", "/webhooks", bytes.NewBuffer(body))
+		rw := httptest.NewRecorder()
+
+		handler.CreateWebhook(rw, req)
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		mockService.AssertNumberOfCalls(t, "CreateWebhook", 1)
+	}
+
+	// Тестовый случай 3: Некорректный адрес
+	{
+		body := []byte(`{"url":"not a url","events":["subscription.created"]}`)
+		req := httptest.NewRequest("POST", "/webhooks", bytes.NewBuffer(body))
+		rw := httptest.NewRecorder()
+
+		handler.CreateWebhook(rw, req)
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		mockService.AssertNumberOfCalls(t, "CreateWebhook", 1)
+	}
+}
+
+// TestListWebhooks - тест для ListWebhooks контроллера
+func TestListWebhooks(t *testing.T) {
+	mockService := new(MockWebhookService)
+	handler := NewWebhookHandler(mockService)
+
+	// Тестовый случай 1: Пустой список возвращается как массив
+	{
+		req := httptest.NewRequest("GET", "/webhooks", nil)
+		rw := httptest.NewRecorder()
+
+		mockService.On("ListWebhooks", mock.Anything).Return([]*entity.Webhook(nil), nil).Once()
+
+		handler.ListWebhooks(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.JSONEq(t, `[]`, rw.Body.String())
+	}
+
+	// Тестовый случай 2: Ошибка сервиса
+	{
+		req := httptest.NewRequest("GET", "/webhooks", nil)
+		rw := httptest.NewRecorder()
+
+		mockService.On("ListWebhooks", mock.Anything).Return([]*entity.Webhook(nil), errors.New("db error")).Once()
+
+		handler.ListWebhooks(rw, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rw.Code)
+		mockService.AssertExpectations(t)
+	}
+}
+
+// TestDeleteWebhook - тест для DeleteWebhook контроллера
+func TestDeleteWebhook(t *testing.T) {
+	mockService := new(MockWebhookService)
+	handler := NewWebhookHandler(mockService)
+
+	// Тестовый случай 1: Успешное удаление
+	{
+		req := withURLParam(httptest.NewRequest("DELETE", "/webhooks/1", nil), "id", "1")
+		rw := httptest.NewRecorder()
+
+		mockService.On("DeleteWebhook", mock.Anything, int64(1)).Return(nil).Once()
+
+		handler.DeleteWebhook(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 2: Вебхук не найден
+	{
+		req := withURLParam(httptest.NewRequest("DELETE", "/webhooks/2", nil), "id", "2")
+		rw := httptest.NewRecorder()
+
+		mockService.On("DeleteWebhook", mock.Anything, int64(2)).Return(myError.ErrWebhookNotFound).Once()
+
+		handler.DeleteWebhook(rw, req)
+
+		assert.Equal(t, http.StatusNotFound, rw.Code)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 3: Некорректный id
+	{
+		req := withURLParam(httptest.NewRequest("DELETE", "/webhooks/abc", nil), "id", "abc")
+		rw := httptest.NewRecorder()
+
+		handler.DeleteWebhook(rw, req)
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		var errResp Problem
+		_ = json.Unmarshal(rw.Body.Bytes(), &errResp)
+		assert.Equal(t, "invalid id parameter", errResp.Detail)
+	}
+}
+
+// TestListDeliveries - тест для ListDeliveries контроллера
+func TestListDeliveries(t *testing.T) {
+	mockService := new(MockWebhookService)
+	handler := NewWebhookHandler(mockService)
+
+	// Тестовый случай 1: Успешное получение журнала
+	{
+		req := withURLParam(httptest.NewRequest("GET", "/webhooks/1/deliveries", nil), "id", "1")
+		rw := httptest.NewRecorder()
+
+		deliveries := []*entity.WebhookDelivery{{Id: 10, WebhookId: 1, Status: entity.DeliveryFailed, Payload: []byte(`{}`)}}
+		mockService.On("ListDeliveries", mock.Anything, int64(1)).Return(deliveries, nil).Once()
+
+		handler.ListDeliveries(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		var resp []entity.WebhookDelivery
+		_ = json.Unmarshal(rw.Body.Bytes(), &resp)
+		assert.Len(t, resp, 1)
+		assert.Equal(t, entity.DeliveryFailed, resp[0].Status)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 2: Вебхук не найден
+	{
+		req := withURLParam(httptest.NewRequest("GET", "/webhooks/2/deliveries", nil), "id", "2")
+		rw := httptest.NewRecorder()
+
+		mockService.On("ListDeliveries", mock.Anything, int64(2)).Return([]*entity.WebhookDelivery(nil), myError.ErrWebhookNotFound).Once()
+
+		handler.ListDeliveries(rw, req)
+
+		assert.Equal(t, http.StatusNotFound, rw.Code)
+		mockService.AssertExpectations(t)
+	}
+}
+
+// TestRedeliver - тест для Redeliver контроллера
+func TestRedeliver(t *testing.T) {
+	mockService := new(MockWebhookService)
+	handler := NewWebhookHandler(mockService)
+
+	// Тестовый случай 1: Успешная постановка в очередь
+	{
+		req := withURLParam(httptest.NewRequest("POST", "/webhooks/deliveries/10/redeliver", nil), "id", "10")
+		rw := httptest.NewRecorder()
+
+		mockService.On("Redeliver", mock.Anything, int64(10)).Return(nil).Once()
+
+		handler.Redeliver(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		mockService.AssertExpectations(t)
+	}
+
+	// Тестовый случай 2: Доставка не найдена
+	{
+		req := withURLParam(httptest.NewRequest("POST", "/webhooks/deliveries/11/redeliver", nil), "id", "11")
+		rw := httptest.NewRecorder()
+
+		mockService.On("Redeliver", mock.Anything, int64(11)).Return(myError.ErrDeliveryNotFound).Once()
+
+		handler.Redeliver(rw, req)
+
+		assert.Equal(t, http.StatusNotFound, rw.Code)
+		mockService.AssertExpectations(t)
+	}
+}
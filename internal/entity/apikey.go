@@ -0,0 +1,43 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey - структура для хранения API-ключа. Сам ключ не хранится, в бд записывается только его хэш
+type APIKey struct {
+	Id         int64      `json:"id" example:"1"`                                                   // id ключа в бд
+	Name       string     `json:"name" example:"nightly-export"`                                    // название ключа
+	Key        string     `json:"key,omitempty" example:"sas_J8lQ2..."`                             // ключ, возвращается только при создании
+	Prefix     string     `json:"prefix" example:"sas_J8lQ2x"`                                      // начало ключа для его опознания
+	Scopes     []Scope    `json:"scopes" example:"read"`                                            // права ключа
+	UserId     *uuid.UUID `json:"user_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"` // пользователь, от имени которого действует ключ
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" example:"2026-01-01T00:00:00Z"`              // срок действия
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" example:"2025-08-01T12:00:00Z"`            // время последнего использования
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`                                             // время отзыва
+	CreatedAt  time.Time  `json:"created_at" example:"2025-08-01T12:00:00Z"`                        // время создания
+
+	KeyHash  string    `json:"-"` // хэш ключа
+	TenantId uuid.UUID `json:"-"` // тенант, к данным которого ключ дает доступ
+}
+
+// APIKeyRequest - структура для парсинга данных API-ключа из запроса
+type APIKeyRequest struct {
+	Name      string     `json:"name" example:"nightly-export" validate:"required,max=100"`                   // название ключа
+	Scopes    []Scope    `json:"scopes" example:"read" validate:"required,min=1,dive,oneof=read write admin"` // права ключа
+	UserId    *uuid.UUID `json:"user_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`            // пользователь, обязателен без scope admin
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2026-01-01T00:00:00Z" validate:"omitempty,gt"` // срок действия, должен быть в будущем
+}
+
+// HasScope проверяет, выдано ли ключу право scope
+func (k *APIKey) HasScope(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
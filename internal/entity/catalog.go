@@ -0,0 +1,20 @@
+package entity
+
+// Service - запись каталога сервисов с каноническим названием
+type Service struct {
+	Id           int64    `json:"id" example:"1"`                                  // id сервиса в бд
+	Name         string   `json:"name" example:"Netflix"`                          // каноническое название сервиса
+	Aliases      []string `json:"aliases" example:"netflix,Нетфликс"`              // другие варианты написания названия
+	Category     *string  `json:"category,omitempty" example:"streaming"`          // категория сервиса
+	DefaultPrice *int     `json:"default_price,omitempty" example:"499"`           // стоимость месячной подписки по умолчанию в рублях
+	Website      *string  `json:"website,omitempty" example:"https://netflix.com"` // сайт сервиса
+}
+
+// ServiceRequest - структура для парсинга записи каталога из запроса
+type ServiceRequest struct {
+	Name         string   `json:"name" example:"Netflix" validate:"required,max=200"`                                      // каноническое название сервиса
+	Aliases      []string `json:"aliases,omitempty" example:"netflix,Нетфликс" validate:"omitempty,dive,required,max=200"` // другие варианты написания названия
+	Category     *string  `json:"category,omitempty" example:"streaming" validate:"omitempty,max=100"`                     // категория сервиса
+	DefaultPrice *int     `json:"default_price,omitempty" example:"499" validate:"omitempty,min=1"`                        // стоимость месячной подписки по умолчанию в рублях
+	Website      *string  `json:"website,omitempty" example:"https://netflix.com" validate:"omitempty,url"`                // сайт сервиса
+}
@@ -0,0 +1,36 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventType - тип события, связанного с подпиской
+type EventType string
+
+const (
+	EventSubscriptionCreated EventType = "subscription.created" // подписка создана
+	EventSubscriptionUpdated EventType = "subscription.updated" // подписка обновлена
+	EventSubscriptionDeleted EventType = "subscription.deleted" // подписка удалена
+	EventSubscriptionEnded   EventType = "subscription.ended"   // у подписки появилась дата окончания
+)
+
+// Event - структура события, отправляемого внешним системам
+type Event struct {
+	Id         uuid.UUID            `json:"id" example:"3f1c2a9e-8d1b-4c6a-9a51-0b2f5d6e7a8c"`        // id события
+	Type       EventType            `json:"type" example:"subscription.created"`                      // тип события
+	TenantId   uuid.UUID            `json:"tenant_id" example:"00000000-0000-0000-0000-000000000001"` // тенант подписки
+	OccurredAt time.Time            `json:"occurred_at" example:"2025-08-01T12:00:00Z"`               // время возникновения события
+	Data       *SubscriptionRequest `json:"data"`                                                     // данные подписки на момент события
+}
+
+// NewEvent создает событие указанного типа для подписки
+func NewEvent(eventType EventType, sub *Subscription) *Event {
+	return &Event{
+		Id:         uuid.New(),
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		Data:       ParseSubscriptionToRequest(sub),
+	}
+}
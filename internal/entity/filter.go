@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GroupBy - разрез, в котором раскладывается суммарная стоимость подписок
+type GroupBy string
+
+const (
+	GroupByCategory GroupBy = "category" // по категориям
+	GroupByTag      GroupBy = "tag"      // по тегам
+)
+
+// ParseGroupBy проверяет разрез суммарной стоимости
+func ParseGroupBy(s string) (GroupBy, error) {
+	switch GroupBy(s) {
+	case GroupByCategory, GroupByTag:
+		return GroupBy(s), nil
+	default:
+		return "", fmt.Errorf("unknown group by %q", s)
+	}
+}
+
+// SubscriptionFilter - фильтр подписок по пользователю, категории и тегам
+type SubscriptionFilter struct {
+	UserId   *uuid.UUID // id пользователя
+	Category *string    // категория подписки
+	Tags     []string   // теги, каждый из которых должен быть у подписки
+}
+
+// CostFilter - фильтр подписок при подсчете суммарной стоимости
+type CostFilter struct {
+	SubscriptionFilter
+
+	ServiceName *ServiceNameFilter // название сервиса
+	Today       time.Time          // дата, по которую действуют подписки без даты окончания, нулевая - текущая дата хранилища
+}
+
+// CostGroup - суммарная стоимость подписок одной категории или одного тега
+type CostGroup struct {
+	Key       *string `json:"key" example:"streaming"`   // категория или тег, null - подписки без категории или без тегов
+	TotalCost int     `json:"total_cost" example:"1500"` // суммарная стоимость подписок группы
+}
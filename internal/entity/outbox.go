@@ -0,0 +1,8 @@
+package entity
+
+// OutboxMessage - структура записи outbox, ожидающей публикации
+type OutboxMessage struct {
+	Id       int64  // id записи в бд
+	Event    *Event // событие для публикации
+	Attempts int    // количество неудачных попыток публикации
+}
@@ -0,0 +1,17 @@
+package entity
+
+// PoolStats - состояние пула соединений с бд
+type PoolStats struct {
+	MaxConns                int32   `json:"max_conns" example:"10"`                  // максимальное число соединений
+	TotalConns              int32   `json:"total_conns" example:"4"`                 // открытые соединения, включая создаваемые
+	AcquiredConns           int32   `json:"acquired_conns" example:"1"`              // соединения, занятые запросами
+	IdleConns               int32   `json:"idle_conns" example:"3"`                  // свободные соединения
+	ConstructingConns       int32   `json:"constructing_conns" example:"0"`          // соединения, которые сейчас открываются
+	AcquireCount            int64   `json:"acquire_count" example:"1520"`            // сколько раз соединение было взято из пула
+	AcquireDuration         float64 `json:"acquire_duration_seconds" example:"0.42"` // суммарное время ожидания соединения
+	EmptyAcquireCount       int64   `json:"empty_acquire_count" example:"12"`        // сколько раз пришлось ждать свободного соединения
+	CanceledAcquireCount    int64   `json:"canceled_acquire_count" example:"0"`      // сколько ожиданий соединения было отменено
+	NewConnsCount           int64   `json:"new_conns_count" example:"6"`             // сколько соединений открыто за время работы
+	MaxLifetimeDestroyCount int64   `json:"max_lifetime_destroy_count" example:"2"`  // закрыто по истечении времени жизни
+	MaxIdleDestroyCount     int64   `json:"max_idle_destroy_count" example:"0"`      // закрыто после долгого простоя
+}
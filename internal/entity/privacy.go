@@ -0,0 +1,96 @@
+package entity
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserData - все данные, хранящиеся о пользователе внутри тенанта
+type UserData struct {
+	UserId                uuid.UUID          // id пользователя
+	ExportedAt            time.Time          // время выгрузки
+	Subscriptions         []*Subscription    // подписки пользователя в порядке id
+	ArchivedSubscriptions []*Subscription    // архивные подписки пользователя в порядке id
+	Summary               *UserSummary       // сводка по подпискам на текущий месяц
+	MonthlyCosts          []*UserMonthlyCost // помесячные итоги стоимости по возрастанию месяца и сервиса
+	Events                []*Event           // события outbox о подписках пользователя в порядке записи
+	Deliveries            []*WebhookDelivery // доставки вебхуков с событиями о подписках пользователя в порядке id
+	APIKeys               []*APIKey          // API-ключи, действующие от имени пользователя, в порядке id
+}
+
+// UserMonthlyCost - итог стоимости подписок пользователя на сервис за месяц
+type UserMonthlyCost struct {
+	Month         time.Time // первое число месяца
+	ServiceName   string    // название сервиса
+	Total         int       // суммарная стоимость подписок
+	Subscriptions int       // количество подписок
+}
+
+// ErasureReceipt - квитанция об удалении данных пользователя. Сам id пользователя в квитанции не хранится,
+// только его ключевой хэш: по id сервис может найти квитанции о пользователе, а перебрать id по хэшу без ключа нельзя
+type ErasureReceipt struct {
+	Id                    uuid.UUID // id квитанции
+	TenantId              uuid.UUID // тенант, в котором удалены данные
+	SubjectHash           string    // HMAC-SHA256 от id пользователя, см. ReceiptSigner.SubjectHash
+	ErasedAt              time.Time // время удаления
+	Subscriptions         int       // количество удаленных подписок
+	ArchivedSubscriptions int       // количество удаленных архивных подписок
+	MonthlyCosts          int       // количество удаленных строк помесячных итогов
+	APIKeys               int       // количество удаленных API-ключей
+	Events                int       // количество обезличенных событий outbox
+	Deliveries            int       // количество обезличенных доставок вебхуков
+	Digest                string    // HMAC-SHA256 от полей квитанции, см. ReceiptSigner.Sign
+	Verified              bool      // подпись квитанции совпала с ее полями, выставляется сервисом при выдаче и чтении
+}
+
+// ReceiptSigner подписывает квитанции об удалении данных HMAC-SHA256 с секретным ключом сервиса: без ключа
+// нельзя ни подобрать подпись к измененной квитанции, ни найти id пользователя по хэшу перебором
+type ReceiptSigner struct {
+	key []byte // секретный ключ подписи
+}
+
+// NewReceiptSigner возвращает новый объект структуры ReceiptSigner с ключом key
+func NewReceiptSigner(key []byte) *ReceiptSigner {
+	return &ReceiptSigner{key: key}
+}
+
+// SubjectHash возвращает ключевой хэш id пользователя для квитанции об удалении его данных
+func (s *ReceiptSigner) SubjectHash(userID uuid.UUID) string {
+	return s.mac("subject", userID.String())
+}
+
+// Sign возвращает подпись полей квитанции, кроме Digest и Verified, записанных через перевод строки
+// в порядке объявления. Время удаления записывается в UTC в формате RFC 3339 с микросекундами
+func (s *ReceiptSigner) Sign(r *ErasureReceipt) string {
+	return s.mac("erasure-receipt",
+		r.Id.String(),
+		r.TenantId.String(),
+		r.SubjectHash,
+		r.ErasedAt.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		fmt.Sprint(r.Subscriptions),
+		fmt.Sprint(r.ArchivedSubscriptions),
+		fmt.Sprint(r.MonthlyCosts),
+		fmt.Sprint(r.APIKeys),
+		fmt.Sprint(r.Events),
+		fmt.Sprint(r.Deliveries),
+	)
+}
+
+// Verify проверяет, что поля квитанции не изменились после выдачи
+func (s *ReceiptSigner) Verify(r *ErasureReceipt) bool {
+	return hmac.Equal([]byte(r.Digest), []byte(s.Sign(r)))
+}
+
+// mac возвращает HMAC-SHA256 от полей fields, записанных через перевод строки после названия назначения
+// подписи: так хэш id пользователя нельзя выдать за подпись квитанции и наоборот
+func (s *ReceiptSigner) mac(purpose string, fields ...string) string {
+	h := hmac.New(sha256.New, s.key)
+	h.Write([]byte(purpose + "\n" + strings.Join(fields, "\n")))
+	return hex.EncodeToString(h.Sum(nil))
+}
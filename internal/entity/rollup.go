@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MonthlyCostsRebuild - результат пересборки помесячных итогов стоимости тенанта
+type MonthlyCostsRebuild struct {
+	Rows         int       // количество строк итогов после пересборки
+	ThroughMonth time.Time // месяц, по который в итогах учтены подписки без даты окончания
+}
+
+// MonthlyCostMismatch - расхождение итога стоимости за месяц с расчетом по подпискам
+type MonthlyCostMismatch struct {
+	Month               time.Time // месяц итога
+	UserId              uuid.UUID // id пользователя
+	ServiceName         string    // название сервиса
+	RollupTotal         int       // стоимость в итогах
+	LiveTotal           int       // стоимость по подпискам
+	RollupSubscriptions int       // количество подписок в итогах
+	LiveSubscriptions   int       // количество подписок по подпискам
+}
+
+// MonthlyCostsCheck - результат сверки помесячных итогов стоимости тенанта с расчетом по подпискам
+type MonthlyCostsCheck struct {
+	Rows         int                    // количество строк итогов
+	ThroughMonth time.Time              // месяц, по который в итогах учтены подписки без даты окончания
+	Mismatches   []*MonthlyCostMismatch // расхождения по возрастанию месяца, пользователя и сервиса
+}
@@ -0,0 +1,10 @@
+package entity
+
+// Scope - право клиента API
+type Scope string
+
+const (
+	ScopeRead  Scope = "read"  // чтение подписок и стоимости
+	ScopeWrite Scope = "write" // создание, изменение и удаление подписок
+	ScopeAdmin Scope = "admin" // доступ к подпискам всех пользователей, вебхукам, каталогу и ключам
+)
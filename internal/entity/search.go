@@ -0,0 +1,43 @@
+package entity
+
+import "fmt"
+
+// MatchMode - режим сравнения названия сервиса
+type MatchMode string
+
+const (
+	MatchExact  MatchMode = "exact" // точное совпадение с учетом регистра
+	MatchIgnore MatchMode = "icase" // совпадение без учета регистра и пробелов по краям
+	MatchFuzzy  MatchMode = "fuzzy" // нечеткое совпадение по триграммам (pg_trgm)
+)
+
+// ParseMatchMode проверяет режим сравнения, пустая строка означает точное совпадение
+func ParseMatchMode(s string) (MatchMode, error) {
+	switch MatchMode(s) {
+	case "", MatchExact:
+		return MatchExact, nil
+	case MatchIgnore, MatchFuzzy:
+		return MatchMode(s), nil
+	default:
+		return "", fmt.Errorf("unknown match mode %q", s)
+	}
+}
+
+// ServiceNameFilter - фильтр подписок по названию сервиса
+type ServiceNameFilter struct {
+	Name string    // название сервиса
+	Mode MatchMode // режим сравнения
+}
+
+// SubscriptionMatch - подписка, найденная поиском, и ее релевантность
+type SubscriptionMatch struct {
+	Subscription *Subscription // найденная подписка
+	Score        float64       // сходство названия сервиса с запросом от 0 до 1
+}
+
+// ServiceSuggestion - вариант автодополнения названия сервиса
+type ServiceSuggestion struct {
+	ServiceName   string  `json:"service_name" example:"Netflix"` // название сервиса
+	Subscriptions int     `json:"subscriptions" example:"12"`     // количество подписок на сервис
+	Score         float64 `json:"score" example:"0.8"`            // сходство названия с запросом от 0 до 1
+}
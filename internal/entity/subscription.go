@@ -16,16 +16,36 @@ type Subscription struct {
 	UserId      uuid.UUID  `json:"user_id"`            // id пользователя в формате UUID
 	StartDate   time.Time  `json:"start_date"`         // дата начала подписки (месяц и год)
 	EndDate     *time.Time `json:"end_date,omitempty"` // дата окончания подписки (месяц и год)
+
+	CancellationReason *string    `json:"cancellation_reason,omitempty"` // причина отмены подписки
+	CancelledAt        *time.Time `json:"cancelled_at,omitempty"`        // время отмены подписки
+	ServiceId          *int64     `json:"service_id,omitempty"`          // id сервиса в каталоге
+
+	Category *string  `json:"category,omitempty"` // категория подписки
+	Tags     []string `json:"tags"`               // произвольные теги подписки
 }
 
 // SubscriptionRequest - структура для парсинга данных подписки из запроса
 type SubscriptionRequest struct {
 	Id          int       `json:"id,omitempty" example:"1" validate:"omitempty"`                                    // id подписки в бд
 	ServiceName string    `json:"service_name"  example:"Netflix"  validate:"required"`                             // название сервиса, предоставляющего подписку
-	Price       int       `json:"price" example:"499" validate:"required,min=1"`                                    // стоимость месячной подписки в рублях
+	Price       int       `json:"price" example:"499" validate:"omitempty,min=1"`                                   // стоимость месячной подписки в рублях, по умолчанию - цена сервиса из каталога
 	UserId      uuid.UUID `json:"user_id" example:"550e8400-e29b-41d4-a716-446655440000" validate:"required,uuid4"` // id пользователя в формате UUID
 	StartDate   string    `json:"start_date" example:"08-2025" validate:"required,datetime=01-2006"`                // дата начала подписки (месяц и год)
 	EndDate     *string   `json:"end_date,omitempty" example:"09-2025" validate:"omitempty,datetime=01-2006"`       // дата окончания подписки (месяц и год)
+
+	CancellationReason *string    `json:"cancellation_reason,omitempty" example:"too expensive"` // причина отмены подписки (только для чтения)
+	CancelledAt        *time.Time `json:"cancelled_at,omitempty" example:"2025-09-15T10:00:00Z"` // время отмены подписки (только для чтения)
+	ServiceId          *int64     `json:"service_id,omitempty" example:"1"`                      // id сервиса в каталоге (только для чтения)
+
+	Category *string  `json:"category,omitempty" example:"streaming" validate:"omitempty,max=100"`                       // категория подписки, по умолчанию - категория сервиса из каталога
+	Tags     []string `json:"tags,omitempty" example:"family,personal" validate:"omitempty,max=20,dive,required,max=50"` // произвольные теги подписки
+}
+
+// CancelRequest - структура для парсинга запроса на отмену подписки
+type CancelRequest struct {
+	EndDate *string `json:"end_date,omitempty" example:"09-2025" validate:"omitempty,datetime=01-2006"` // месяц окончания подписки, по умолчанию текущий
+	Reason  *string `json:"reason,omitempty" example:"too expensive" validate:"omitempty,max=500"`      // причина отмены
 }
 
 // ParseSubscriptionToRequest парсит *entity.Subscription в *entity.SubscriptionRequest
@@ -42,5 +62,12 @@ func ParseSubscriptionToRequest(sub *Subscription) *SubscriptionRequest {
 		UserId:      sub.UserId,
 		StartDate:   sub.StartDate.Format(DateLayout),
 		EndDate:     endDateStr,
+
+		CancellationReason: sub.CancellationReason,
+		CancelledAt:        sub.CancelledAt,
+		ServiceId:          sub.ServiceId,
+
+		Category: sub.Category,
+		Tags:     sub.Tags,
 	}
 }
@@ -0,0 +1,13 @@
+package entity
+
+import "github.com/google/uuid"
+
+// UserSummary - сводка по подпискам пользователя на текущий месяц
+type UserSummary struct {
+	UserId        uuid.UUID       // id пользователя
+	ActiveCount   int             // количество активных подписок
+	MonthlySpend  int             // стоимость активных подписок за текущий месяц
+	LifetimeSpend int             // стоимость всех подписок с их начала по текущий месяц включительно
+	MostExpensive *Subscription   // самая дорогая активная подписка
+	UpcomingEnds  []*Subscription // активные подписки с датой окончания, по возрастанию даты окончания
+}
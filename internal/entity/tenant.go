@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tenant - структура для хранения тенанта - компании, данные которой изолированы от других
+type Tenant struct {
+	Id        uuid.UUID `json:"id" example:"7d9f1c2e-4b5a-4e8f-9c3d-2a1b0c9d8e7f"` // id тенанта
+	Name      string    `json:"name" example:"acme"`                               // название тенанта
+	CreatedAt time.Time `json:"created_at" example:"2025-08-01T12:00:00Z"`         // время создания
+}
+
+// TenantRequest - структура для парсинга данных тенанта из запроса
+type TenantRequest struct {
+	Name string `json:"name" example:"acme" validate:"required,max=100"` // название тенанта
+}
+
+// TenantCost - суммарная стоимость подписок тенанта за период
+type TenantCost struct {
+	TenantId  uuid.UUID `json:"tenant_id" example:"7d9f1c2e-4b5a-4e8f-9c3d-2a1b0c9d8e7f"` // id тенанта
+	Name      string    `json:"name" example:"acme"`                                      // название тенанта
+	TotalCost int       `json:"total_cost" example:"12000"`                               // стоимость подписок тенанта
+}
@@ -0,0 +1,9 @@
+package entity
+
+import "time"
+
+// TableVersion - счетчик изменений таблицы, увеличивается каждым изменяющим ее оператором
+type TableVersion struct {
+	Version   int64     // номер версии
+	UpdatedAt time.Time // время последнего изменения
+}
@@ -0,0 +1,53 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeliveryStatus - статус доставки вебхука
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"   // доставка ожидает отправки или повторной попытки
+	DeliverySucceeded DeliveryStatus = "succeeded" // получатель подтвердил доставку
+	DeliveryFailed    DeliveryStatus = "failed"    // попытки доставки исчерпаны
+)
+
+// Webhook - структура для хранения зарегистрированного вебхука
+type Webhook struct {
+	Id        int64       `json:"id" example:"1"`                               // id вебхука в бд
+	URL       string      `json:"url" example:"https://example.com/hooks/subs"` // адрес, на который отправляются события
+	Secret    string      `json:"secret,omitempty" example:"whsec_3c4f..."`     // секрет для подписи событий
+	Events    []EventType `json:"events" example:"subscription.created"`        // типы событий, на которые подписан вебхук
+	Active    bool        `json:"active" example:"true"`                        // включен ли вебхук
+	CreatedAt time.Time   `json:"created_at" example:"2025-08-01T12:00:00Z"`    // время регистрации
+}
+
+// WebhookRequest - структура для парсинга данных вебхука из запроса
+type WebhookRequest struct {
+	URL    string      `json:"url" example:"https://example.com/hooks/subs" validate:"required,url"`                                                                                         // адрес получателя
+	Secret string      `json:"secret,omitempty" example:"my-very-long-secret" validate:"omitempty,min=16"`                                                                                   // секрет для подписи, генерируется если не задан
+	Events []EventType `json:"events" example:"subscription.created" validate:"required,min=1,dive,oneof=subscription.created subscription.updated subscription.deleted subscription.ended"` // типы событий
+}
+
+// WebhookDelivery - структура записи журнала доставки вебхука
+type WebhookDelivery struct {
+	Id             int64           `json:"id" example:"10"`                                         // id доставки
+	WebhookId      int64           `json:"webhook_id" example:"1"`                                  // id вебхука
+	EventId        uuid.UUID       `json:"event_id" example:"3f1c2a9e-8d1b-4c6a-9a51-0b2f5d6e7a8c"` // id события
+	EventType      EventType       `json:"event_type" example:"subscription.created"`               // тип события
+	Payload        json.RawMessage `json:"payload"`                                                 // тело запроса, отправляемое получателю
+	Status         DeliveryStatus  `json:"status" example:"pending"`                                // статус доставки
+	Attempts       int             `json:"attempts" example:"1"`                                    // количество выполненных попыток
+	LastStatusCode *int            `json:"last_status_code,omitempty" example:"500"`                // http-код последнего ответа
+	LastError      *string         `json:"last_error,omitempty" example:"timeout"`                  // текст последней ошибки
+	NextAttemptAt  time.Time       `json:"next_attempt_at" example:"2025-08-01T12:00:10Z"`          // время следующей попытки
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`                                  // время успешной доставки
+	CreatedAt      time.Time       `json:"created_at" example:"2025-08-01T12:00:00Z"`               // время создания записи
+
+	URL    string `json:"-"` // адрес получателя (заполняется при выборке к отправке)
+	Secret string `json:"-"` // секрет вебхука (заполняется при выборке к отправке)
+}
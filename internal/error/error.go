@@ -5,6 +5,23 @@ import (
 )
 
 var (
-	ErrSubscriptionNotFound = errors.New("subscription not found")         // подписка не найдена
-	ErrDateRange            = errors.New("end_date must be >= start_date") // дата конца должна быть >= дате начала
+	ErrSubscriptionNotFound   = errors.New("subscription not found")                                   // подписка не найдена
+	ErrDateRange              = errors.New("end_date must be >= start_date")                           // дата конца должна быть >= дате начала
+	ErrPeriodRange            = errors.New("to must be >= from")                                       // конец периода должен быть >= начала периода
+	ErrInvalidDate            = errors.New("invalid date format")                                      // дата не в формате MM-YYYY
+	ErrWebhookNotFound        = errors.New("webhook not found")                                        // вебхук не найден
+	ErrDeliveryNotFound       = errors.New("webhook delivery not found")                               // доставка вебхука не найдена
+	ErrWebhookURLForbidden    = errors.New("webhook url must be a public http or https address")       // адрес вебхука не публичный http(s)-адрес
+	ErrEmptySearchQuery       = errors.New("search query must not be empty")                           // пустой поисковый запрос
+	ErrServiceNotFound        = errors.New("service not found")                                        // сервис не найден в каталоге
+	ErrServiceNameTaken       = errors.New("service name or alias is already used by another service") // написание названия занято другим сервисом
+	ErrPriceRequired          = errors.New("price is required: service has no default price")          // цена не указана, и у сервиса нет цены по умолчанию
+	ErrRateLimited            = errors.New("rate limit exceeded")                                      // превышено ограничение частоты запросов
+	ErrUnauthorized           = errors.New("authentication required")                                  // нет действительного токена
+	ErrForbidden              = errors.New("access denied")                                            // недостаточно прав для операции
+	ErrAPIKeyNotFound         = errors.New("api key not found")                                        // API-ключ не найден
+	ErrAPIKeyUserRequired     = errors.New("user_id is required for a key without admin scope")        // ключу без scope admin не указан пользователь
+	ErrTenantRequired         = errors.New("tenant is required")                                       // запрос к данным без тенанта
+	ErrTenantNameTaken        = errors.New("tenant name is already taken")                             // название тенанта занято
+	ErrErasureReceiptNotFound = errors.New("erasure receipt not found")                                // квитанция об удалении данных не найдена
 )
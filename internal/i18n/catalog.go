@@ -0,0 +1,146 @@
+package i18n
+
+// catalogs - каталоги сообщений по языкам.
+// Ключи title.<код> и detail.<код> - заголовок и описание ошибки с кодом из docs/problems.md
+var catalogs = map[string]map[string]string{
+	EN: {
+		"title.malformed_body":              "Malformed request body",
+		"title.validation_failed":           "Request validation failed",
+		"title.invalid_parameter":           "Invalid request parameter",
+		"title.subscription_not_found":      "Subscription not found",
+		"title.date_range":                  "End date is before start date",
+		"title.period_range":                "Period end is before period start",
+		"title.invalid_date":                "Invalid date",
+		"title.webhook_not_found":           "Webhook not found",
+		"title.delivery_not_found":          "Webhook delivery not found",
+		"title.webhook_url_forbidden":       "Webhook address is not allowed",
+		"title.empty_search_query":          "Empty search query",
+		"title.service_not_found":           "Service not found",
+		"title.service_name_taken":          "Service name is taken",
+		"title.price_required":              "Price is required",
+		"title.api_key_not_found":           "API key not found",
+		"title.api_key_user_required":       "User is required",
+		"title.tenant_required":             "Tenant is required",
+		"title.tenant_name_taken":           "Tenant name is taken",
+		"title.erasure_receipt_not_found":   "Erasure receipt not found",
+		"title.rate_limited":                "Too many requests",
+		"title.unauthorized":                "Authentication required",
+		"title.forbidden":                   "Access denied",
+		"title.internal_error":              "Internal server error",
+		"title.response_validation_failed":  "Response does not match the API specification",
+		"detail.malformed_body":             "request body is not a valid JSON document of the expected shape",
+		"detail.validation_failed":          "request body has invalid fields",
+		"detail.subscription_not_found":     "subscription not found",
+		"detail.date_range":                 "end_date must be >= start_date",
+		"detail.period_range":               "to must be >= from",
+		"detail.invalid_date":               "invalid date format",
+		"detail.webhook_not_found":          "webhook not found",
+		"detail.delivery_not_found":         "webhook delivery not found",
+		"detail.webhook_url_forbidden":      "webhook url must be a public http or https address",
+		"detail.empty_search_query":         "search query must not be empty",
+		"detail.service_not_found":          "service not found",
+		"detail.service_name_taken":         "service name or alias is already used by another service",
+		"detail.price_required":             "price is required: service has no default price",
+		"detail.api_key_not_found":          "api key not found",
+		"detail.api_key_user_required":      "user_id is required for a key without admin scope",
+		"detail.tenant_required":            "the credentials are not bound to a tenant",
+		"detail.tenant_name_taken":          "tenant name is already taken",
+		"detail.erasure_receipt_not_found":  "erasure receipt not found",
+		"detail.rate_limited":               "rate limit exceeded, retry after the time in the Retry-After header",
+		"detail.unauthorized":               "a valid bearer token or API key is required",
+		"detail.forbidden":                  "you do not have access to this resource",
+		"detail.internal_error":             "internal server error",
+		"detail.response_validation_failed": "response does not match the API specification",
+		"param.missing":                     "%s parameter not set",
+		"param.invalid":                     "invalid %s parameter",
+		ReasonRequired:                      "is required",
+		ReasonInteger:                       "must be an integer",
+		ReasonPositiveInteger:               "must be a positive integer",
+		ReasonMonth:                         "must be a month in MM-YYYY format",
+		ReasonUUID:                          "must be a UUID",
+		ReasonOneOf:                         "must be one of: %s",
+		"validation.datetime":               "{0} must be a month in MM-YYYY format",
+		"schema.required":                   "%s is a required field",
+		"schema.minimum":                    "%s must be %v or greater",
+		"schema.maximum":                    "%s must be %v or less",
+		"schema.min_length":                 "%s must be at least %d characters in length",
+		"schema.max_length":                 "%s must be a maximum of %d characters in length",
+		"schema.min_items":                  "%s must contain at least %d items",
+		"schema.max_items":                  "%s must contain at maximum %d items",
+		"schema.enum":                       "%s must be one of [%s]",
+		"schema.pattern":                    "%s has an invalid format",
+		"schema.type":                       "%s must be of type %s",
+		"schema.unknown_field":              "%s is not a known field",
+		"schema.invalid":                    "%s is invalid: %s",
+	},
+	RU: {
+		"title.malformed_body":              "Некорректное тело запроса",
+		"title.validation_failed":           "Ошибка валидации запроса",
+		"title.invalid_parameter":           "Некорректный параметр запроса",
+		"title.subscription_not_found":      "Подписка не найдена",
+		"title.date_range":                  "Дата окончания раньше даты начала",
+		"title.period_range":                "Конец периода раньше начала периода",
+		"title.invalid_date":                "Некорректная дата",
+		"title.webhook_not_found":           "Вебхук не найден",
+		"title.delivery_not_found":          "Доставка вебхука не найдена",
+		"title.webhook_url_forbidden":       "Недопустимый адрес вебхука",
+		"title.empty_search_query":          "Пустой поисковый запрос",
+		"title.service_not_found":           "Сервис не найден",
+		"title.service_name_taken":          "Название сервиса занято",
+		"title.price_required":              "Не указана цена",
+		"title.api_key_not_found":           "API-ключ не найден",
+		"title.api_key_user_required":       "Не указан пользователь",
+		"title.tenant_required":             "Не указан тенант",
+		"title.tenant_name_taken":           "Название тенанта занято",
+		"title.erasure_receipt_not_found":   "Квитанция об удалении не найдена",
+		"title.rate_limited":                "Слишком много запросов",
+		"title.unauthorized":                "Требуется аутентификация",
+		"title.forbidden":                   "Доступ запрещен",
+		"title.internal_error":              "Внутренняя ошибка сервера",
+		"title.response_validation_failed":  "Ответ не соответствует спецификации API",
+		"detail.malformed_body":             "тело запроса не является JSON-документом ожидаемой структуры",
+		"detail.validation_failed":          "в теле запроса есть некорректные поля",
+		"detail.subscription_not_found":     "подписка не найдена",
+		"detail.date_range":                 "end_date должна быть не раньше start_date",
+		"detail.period_range":               "to должен быть не раньше from",
+		"detail.invalid_date":               "некорректный формат даты",
+		"detail.webhook_not_found":          "вебхук не найден",
+		"detail.delivery_not_found":         "доставка вебхука не найдена",
+		"detail.webhook_url_forbidden":      "адрес вебхука должен быть публичным http- или https-адресом",
+		"detail.empty_search_query":         "поисковый запрос не должен быть пустым",
+		"detail.service_not_found":          "сервис не найден",
+		"detail.service_name_taken":         "название или псевдоним уже используется другим сервисом",
+		"detail.price_required":             "не указана цена, а у сервиса нет цены по умолчанию",
+		"detail.api_key_not_found":          "API-ключ не найден",
+		"detail.api_key_user_required":      "для ключа без права admin нужно указать user_id",
+		"detail.tenant_required":            "учетные данные не привязаны к тенанту",
+		"detail.tenant_name_taken":          "название тенанта уже занято",
+		"detail.erasure_receipt_not_found":  "квитанция об удалении данных не найдена",
+		"detail.rate_limited":               "превышено ограничение частоты запросов, повторите запрос через время из заголовка Retry-After",
+		"detail.unauthorized":               "требуется действительный bearer-токен или API-ключ",
+		"detail.forbidden":                  "нет доступа к этому ресурсу",
+		"detail.internal_error":             "внутренняя ошибка сервера",
+		"detail.response_validation_failed": "ответ не соответствует спецификации API",
+		"param.missing":                     "не указан параметр %s",
+		"param.invalid":                     "некорректный параметр %s",
+		ReasonRequired:                      "обязательный параметр",
+		ReasonInteger:                       "должен быть целым числом",
+		ReasonPositiveInteger:               "должен быть положительным целым числом",
+		ReasonMonth:                         "должен быть месяцем в формате MM-YYYY",
+		ReasonUUID:                          "должен быть UUID",
+		ReasonOneOf:                         "должен быть одним из: %s",
+		"validation.datetime":               "{0} должен быть месяцем в формате MM-YYYY",
+		"schema.required":                   "%s обязательное поле",
+		"schema.minimum":                    "%s должен быть больше или равно %v",
+		"schema.maximum":                    "%s должен быть меньше или равно %v",
+		"schema.min_length":                 "%s должен содержать минимум %d символов",
+		"schema.max_length":                 "%s должен содержать максимум %d символов",
+		"schema.min_items":                  "%s должен содержать минимум %d элементов",
+		"schema.max_items":                  "%s должен содержать максимум %d элементов",
+		"schema.enum":                       "%s должен быть одним из [%s]",
+		"schema.pattern":                    "%s имеет неверный формат",
+		"schema.type":                       "%s должен иметь тип %s",
+		"schema.unknown_field":              "%s - неизвестное поле",
+		"schema.invalid":                    "%s некорректен: %s",
+	},
+}
@@ -0,0 +1,71 @@
+package i18n
+
+import (
+	"fmt"
+
+	"golang.org/x/text/language"
+)
+
+// Поддерживаемые языки сообщений
+const (
+	EN = "en" // английский, язык по умолчанию
+	RU = "ru" // русский
+)
+
+// DefaultLang - язык сообщений, если клиент не указал поддерживаемый язык
+const DefaultLang = EN
+
+// Причины ошибок параметров запроса, передаются в T как ключи сообщений
+const (
+	ReasonRequired        = "reason.required"         // обязательный параметр не указан
+	ReasonInteger         = "reason.integer"          // параметр должен быть целым числом
+	ReasonPositiveInteger = "reason.positive_integer" // параметр должен быть положительным целым числом
+	ReasonMonth           = "reason.month"            // параметр должен быть месяцем в формате MM-YYYY
+	ReasonUUID            = "reason.uuid"             // параметр должен быть UUID
+	ReasonOneOf           = "reason.one_of"           // параметр должен быть одним из значений, аргумент - список значений
+)
+
+// matcher выбирает язык из поддерживаемых, первый - язык по умолчанию
+var matcher = language.NewMatcher([]language.Tag{language.English, language.Russian})
+
+// Negotiate выбирает язык сообщений по значению заголовка Accept-Language
+func Negotiate(acceptLanguage string) string {
+	if acceptLanguage == "" {
+		return DefaultLang
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return DefaultLang
+	}
+
+	tag, _, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return DefaultLang
+	}
+
+	base, _ := tag.Base()
+	if base.String() == RU {
+		return RU
+	}
+
+	return EN
+}
+
+// T возвращает сообщение по ключу на языке lang, args подставляются в сообщение через fmt.Sprintf.
+// Если сообщения нет в каталоге языка, используется каталог языка по умолчанию, а затем сам ключ
+func T(lang string, key string, args ...any) string {
+	msg, ok := catalogs[lang][key]
+	if !ok {
+		msg, ok = catalogs[DefaultLang][key]
+		if !ok {
+			return key
+		}
+	}
+
+	if len(args) == 0 {
+		return msg
+	}
+
+	return fmt.Sprintf(msg, args...)
+}
@@ -0,0 +1,46 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNegotiate - тест для функции Negotiate
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		header string
+		want   string
+	}{
+		{"", EN},
+		{"ru", RU},
+		{"ru-RU,ru;q=0.9,en-US;q=0.8,en;q=0.7", RU},
+		{"en-GB,ru;q=0.5", EN},
+		{"de-DE,ru;q=0.5", RU},
+		{"de-DE", EN},
+		{"*", EN},
+		{"not a language;;", EN},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.want, Negotiate(c.header), c.header)
+	}
+}
+
+// TestT - тест для функции T
+func TestT(t *testing.T) {
+	assert.Equal(t, "не указан параметр q", T(RU, "param.missing", "q"))
+	assert.Equal(t, "q parameter not set", T(EN, "param.missing", "q"))
+	assert.Equal(t, "q parameter not set", T("fr", "param.missing", "q"))
+	assert.Equal(t, "unknown.key", T(RU, "unknown.key"))
+}
+
+// TestCatalogsComplete - каталоги всех языков содержат одинаковый набор ключей
+func TestCatalogsComplete(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for key := range catalogs[DefaultLang] {
+			assert.Contains(t, catalog, key, "language %s", lang)
+		}
+		assert.Len(t, catalog, len(catalogs[DefaultLang]), "language %s", lang)
+	}
+}
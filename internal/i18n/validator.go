@@ -0,0 +1,55 @@
+package i18n
+
+import (
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/ru"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	ruTranslations "github.com/go-playground/validator/v10/translations/ru"
+)
+
+// universal - переводчики сообщений валидатора для поддерживаемых языков
+var universal = ut.New(en.New(), en.New(), ru.New())
+
+// RegisterValidator регистрирует в валидаторе переводы сообщений об ошибках для всех поддерживаемых языков
+func RegisterValidator(v *validator.Validate) error {
+	registrations := []struct {
+		lang     string
+		register func(*validator.Validate, ut.Translator) error
+	}{
+		{EN, enTranslations.RegisterDefaultTranslations},
+		{RU, ruTranslations.RegisterDefaultTranslations},
+	}
+
+	for _, reg := range registrations {
+		trans, _ := universal.GetTranslator(reg.lang)
+
+		err := reg.register(v, trans)
+		if err != nil {
+			return err
+		}
+
+		// формат datetime=01-2006 непонятен клиентам, поэтому описываем его словами
+		err = v.RegisterTranslation("datetime", trans,
+			func(trans ut.Translator) error {
+				return trans.Add("datetime", T(reg.lang, "validation.datetime"), true)
+			},
+			func(trans ut.Translator, fe validator.FieldError) string {
+				msg, _ := trans.T("datetime", fe.Field())
+				return msg
+			},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// TranslateFieldError возвращает сообщение об ошибке валидации поля на языке lang
+func TranslateFieldError(lang string, fe validator.FieldError) string {
+	trans, _ := universal.GetTranslator(lang)
+	return fe.Translate(trans)
+}
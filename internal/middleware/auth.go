@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/auth"
+	"github.com/Ararat25/subscription-aggregation-service/internal/logger"
+	"github.com/Ararat25/subscription-aggregation-service/internal/tenant"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Схемы заголовка Authorization
+const (
+	SchemeBearer = "Bearer" // JWT
+	SchemeAPIKey = "ApiKey" // API-ключ
+)
+
+// TokenVerifier проверяет учетные данные из заголовка Authorization и возвращает клиента
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (*auth.Identity, error)
+}
+
+// Authenticate - middleware, требующее заголовок Authorization: <схема> <учетные данные>. verifiers сопоставляет
+// схеме (без учета регистра) ее проверку. Клиент из проверенных учетных данных и его тенант кладутся в контекст
+// запроса, без них, с неизвестной схемой или с недействительными данными вызывается onUnauthorized
+func Authenticate(verifiers map[string]TokenVerifier, onUnauthorized func(http.ResponseWriter, *http.Request)) func(next http.Handler) http.Handler {
+	byScheme := make(map[string]TokenVerifier, len(verifiers))
+	for scheme, v := range verifiers {
+		byScheme[strings.ToLower(scheme)] = v
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+			token = strings.TrimSpace(token)
+
+			verifier, ok := byScheme[strings.ToLower(scheme)]
+			if !ok || token == "" {
+				onUnauthorized(w, r)
+				return
+			}
+
+			id, err := verifier.Verify(r.Context(), token)
+			if err != nil {
+				logger.Log.Debug("credentials rejected", zap.String("scheme", scheme), zap.String("path", r.URL.Path), zap.Error(err))
+				onUnauthorized(w, r)
+				return
+			}
+
+			setLogIdentity(r.Context(), id)
+
+			ctx := auth.WithIdentity(r.Context(), id)
+			if id.TenantID != uuid.Nil {
+				ctx = tenant.WithID(ctx, id.TenantID)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Authorize - middleware, пропускающее клиента только с правом perm по политике policy.
+// Если аутентификация выключена и клиента в контексте нет, запрос пропускается
+func Authorize(policy auth.Policy, perm auth.Permission, onForbidden func(http.ResponseWriter, *http.Request)) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			err := policy.Authorize(r.Context(), perm)
+			if err != nil {
+				logger.Log.Debug("access denied", zap.String("path", r.URL.Path), zap.Error(err))
+				onForbidden(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
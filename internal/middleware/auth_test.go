@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/auth"
+	"github.com/Ararat25/subscription-aggregation-service/internal/controller"
+	"github.com/Ararat25/subscription-aggregation-service/internal/tenant"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// stubVerifier - проверка токенов по заранее заданному списку
+type stubVerifier map[string]*auth.Identity
+
+// Verify возвращает клиента для известного токена
+func (v stubVerifier) Verify(_ context.Context, token string) (*auth.Identity, error) {
+	id, ok := v[token]
+	if !ok {
+		return nil, errors.New("unknown token")
+	}
+	return id, nil
+}
+
+// TestAuthenticate - тест аутентификации по bearer-токену и API-ключу и проверки прав по политике
+func TestAuthenticate(t *testing.T) {
+	verifiers := map[string]TokenVerifier{
+		SchemeBearer: stubVerifier{
+			"user":  {Subject: "user", Role: auth.RoleEditor},
+			"admin": {Subject: "admin", Role: auth.RoleAdmin, TenantID: tenant.DefaultID},
+		},
+		SchemeAPIKey: stubVerifier{
+			"sas_admin": {Subject: "api_key:1", Role: auth.RoleAdmin, Method: auth.MethodAPIKey, KeyID: 1},
+		},
+	}
+	policy := auth.Policy{auth.RoleAdmin: {auth.PermWebhooksManage}}
+
+	var (
+		got       *auth.Identity
+		gotTenant uuid.UUID
+	)
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = auth.FromContext(r.Context())
+		gotTenant, _ = tenant.FromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := JsonHeader(Authenticate(verifiers, controller.SendUnauthorized)(Authorize(policy, auth.PermWebhooksManage, controller.SendForbidden)(next)))
+
+	serve := func(authorization string) *httptest.ResponseRecorder {
+		got, gotTenant = nil, uuid.Nil
+		req := httptest.NewRequest("GET", "/api/v1/webhooks", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, req)
+		return rw
+	}
+
+	// Тестовый случай 1: Администратор проходит, клиент и его тенант доступны в контексте
+	rw := serve("Bearer admin")
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, "admin", got.Subject)
+	assert.Equal(t, tenant.DefaultID, gotTenant)
+
+	// Тестовый случай 2: Схема сравнивается без учета регистра, ключ администратора проходит
+	rw = serve("apikey sas_admin")
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, int64(1), got.KeyID)
+	assert.Equal(t, uuid.Nil, gotTenant)
+
+	// Тестовый случай 3: Без учетных данных, с неизвестной схемой или недействительными данными - 401 с WWW-Authenticate
+	for _, authorization := range []string{"", "Bearer unknown", "Basic admin", "Bearer ", "ApiKey admin", "Bearer sas_admin"} {
+		rw = serve(authorization)
+		assert.Equal(t, http.StatusUnauthorized, rw.Code, authorization)
+		assert.Equal(t, `Bearer realm="api", ApiKey realm="api"`, rw.Header().Get("WWW-Authenticate"))
+		assert.Equal(t, controller.CodeUnauthorized, decodeProblem(t, rw).Code)
+	}
+
+	// Тестовый случай 4: Пользователь без права на операцию получает 403
+	rw = serve("Bearer user")
+	assert.Equal(t, http.StatusForbidden, rw.Code)
+	assert.Equal(t, controller.CodeForbidden, decodeProblem(t, rw).Code)
+	assert.Nil(t, got)
+}
+
+// TestAuthorize - тест проверки прав ролей на операцию
+func TestAuthorize(t *testing.T) {
+	policy := auth.Policy{
+		auth.RoleViewer: {auth.PermSubscriptionsRead},
+		auth.RoleEditor: {auth.PermSubscriptionsRead, auth.PermSubscriptionsWrite},
+	}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	serve := func(perm auth.Permission, id *auth.Identity) int {
+		req := httptest.NewRequest("POST", "/api/v1/subscription", nil)
+		if id != nil {
+			req = req.WithContext(auth.WithIdentity(req.Context(), id))
+		}
+		rw := httptest.NewRecorder()
+		JsonHeader(Authorize(policy, perm, controller.SendForbidden)(next)).ServeHTTP(rw, req)
+		return rw.Code
+	}
+
+	viewer := &auth.Identity{Role: auth.RoleViewer}
+	editor := &auth.Identity{Role: auth.RoleEditor}
+
+	// Тестовый случай 1: Наблюдатель читает, но не изменяет подписки
+	assert.Equal(t, http.StatusOK, serve(auth.PermSubscriptionsRead, viewer))
+	assert.Equal(t, http.StatusForbidden, serve(auth.PermSubscriptionsWrite, viewer))
+
+	// Тестовый случай 2: Редактор изменяет подписки, без клиента запрос пропускается
+	assert.Equal(t, http.StatusOK, serve(auth.PermSubscriptionsWrite, editor))
+	assert.Equal(t, http.StatusOK, serve(auth.PermCatalogManage, nil))
+}
@@ -1,22 +1,41 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"time"
 
+	"github.com/Ararat25/subscription-aggregation-service/internal/auth"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
+// logEntry - данные запроса, которые заполняют middleware после ZapLogger
+type logEntry struct {
+	identity *auth.Identity // аутентифицированный клиент
+}
+
+// logEntryKey - ключ logEntry в контексте запроса
+type logEntryKey struct{}
+
+// setLogIdentity записывает клиента в журнал запроса, если запрос логируется
+func setLogIdentity(ctx context.Context, id *auth.Identity) {
+	if entry, ok := ctx.Value(logEntryKey{}).(*logEntry); ok {
+		entry.identity = id
+	}
+}
+
 // ZapLogger - middleware для логирования запросов
 func ZapLogger(log *zap.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			entry := &logEntry{}
 
 			t1 := time.Now()
 			defer func() {
-				log.Info("request",
+				fields := []zap.Field{
 					zap.String("method", r.Method),
 					zap.String("path", r.URL.Path),
 					zap.String("host", r.Host),
@@ -28,10 +47,21 @@ func ZapLogger(log *zap.Logger) func(next http.Handler) http.Handler {
 					zap.Int("status", ww.Status()),
 					zap.Int("bytes", ww.BytesWritten()),
 					zap.Duration("duration", time.Since(t1)),
-				)
+				}
+				if id := entry.identity; id != nil {
+					fields = append(fields, zap.String("auth_method", id.Method), zap.String("subject", id.Subject))
+					if id.KeyID != 0 {
+						fields = append(fields, zap.Int64("api_key_id", id.KeyID))
+					}
+					if id.TenantID != uuid.Nil {
+						fields = append(fields, zap.String("tenant_id", id.TenantID.String()))
+					}
+				}
+
+				log.Info("request", fields...)
 			}()
 
-			next.ServeHTTP(ww, r)
+			next.ServeHTTP(ww, r.WithContext(context.WithValue(r.Context(), logEntryKey{}, entry)))
 		})
 	}
 }
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/auth"
+	"github.com/Ararat25/subscription-aggregation-service/internal/controller"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestZapLoggerIdentity - тест записи аутентифицированного клиента в журнал запросов
+func TestZapLoggerIdentity(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	verifiers := map[string]TokenVerifier{
+		SchemeAPIKey: stubVerifier{"sas_key": {Subject: "api_key:5", Method: auth.MethodAPIKey, KeyID: 5}},
+	}
+	handler := ZapLogger(zap.New(core))(Authenticate(verifiers, controller.SendUnauthorized)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	req := httptest.NewRequest("GET", "/api/v1/subscriptions", nil)
+	req.Header.Set("Authorization", "ApiKey sas_key")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, auth.MethodAPIKey, fields["auth_method"])
+	assert.Equal(t, "api_key:5", fields["subject"])
+	assert.Equal(t, int64(5), fields["api_key_id"])
+}
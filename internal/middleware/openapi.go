@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+)
+
+// OpenAPIValidator - middleware для проверки запросов по спецификации OpenAPI 3.
+// Если validateResponses, проверяются и ответы: ответ буферизуется и отправляется клиенту только после проверки.
+// Запросы к путям, которых нет в спецификации (например, к документации), пропускаются без проверки.
+// Ошибки проверки передаются в onError, который формирует ответ клиенту
+func OpenAPIValidator(doc *openapi3.T, validateResponses bool, onError func(http.ResponseWriter, *http.Request, error)) (func(next http.Handler) http.Handler, error) {
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, pathParams, err := router.FindRoute(r)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			input := &openapi3filter.RequestValidationInput{
+				Request:    r,
+				PathParams: pathParams,
+				Route:      route,
+				Options: &openapi3filter.Options{
+					MultiError:         true,
+					AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+				},
+			}
+
+			err = openapi3filter.ValidateRequest(r.Context(), input)
+			if err != nil {
+				onError(w, r, err)
+				return
+			}
+
+			if !validateResponses {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			buf := &responseBuffer{header: w.Header().Clone(), status: http.StatusOK}
+			next.ServeHTTP(buf, r)
+
+			err = openapi3filter.ValidateResponse(r.Context(), &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: input,
+				Status:                 buf.status,
+				Header:                 buf.header,
+				Body:                   io.NopCloser(bytes.NewReader(buf.body.Bytes())),
+				Options: &openapi3filter.Options{
+					MultiError:            true,
+					IncludeResponseStatus: true,
+				},
+			})
+			if err != nil {
+				onError(w, r, err)
+				return
+			}
+
+			buf.flush(w)
+		})
+	}, nil
+}
+
+// responseBuffer - http.ResponseWriter, накапливающий ответ для проверки перед отправкой клиенту
+type responseBuffer struct {
+	header      http.Header  // заголовки ответа
+	status      int          // http-статус ответа
+	body        bytes.Buffer // тело ответа
+	wroteHeader bool         // был ли записан статус
+}
+
+// Header возвращает заголовки ответа
+func (b *responseBuffer) Header() http.Header {
+	return b.header
+}
+
+// WriteHeader запоминает статус ответа
+func (b *responseBuffer) WriteHeader(status int) {
+	if b.wroteHeader {
+		return
+	}
+	b.status = status
+	b.wroteHeader = true
+}
+
+// Write дописывает данные в тело ответа
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	b.WriteHeader(http.StatusOK)
+	return b.body.Write(p)
+}
+
+// flush отправляет накопленный ответ клиенту
+func (b *responseBuffer) flush(w http.ResponseWriter) {
+	for key, values := range b.header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(b.status)
+	_, _ = w.Write(b.body.Bytes())
+}
@@ -0,0 +1,213 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Ararat25/subscription-aggregation-service/api"
+	"github.com/Ararat25/subscription-aggregation-service/internal/controller"
+	"github.com/Ararat25/subscription-aggregation-service/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newValidatedHandler оборачивает next в OpenAPIValidator со спецификацией сервиса
+func newValidatedHandler(t *testing.T, validateResponses bool, next http.HandlerFunc) http.Handler {
+	doc, err := api.Load(context.Background())
+	require.NoError(t, err)
+
+	validator, err := OpenAPIValidator(doc, validateResponses, controller.SendOpenAPIError)
+	require.NoError(t, err)
+
+	return JsonHeader(validator(next))
+}
+
+// decodeProblem разбирает ответ с ошибкой
+func decodeProblem(t *testing.T, rw *httptest.ResponseRecorder) controller.Problem {
+	var problem controller.Problem
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &problem))
+	return problem
+}
+
+// TestOpenAPIValidatorRequest - тест проверки запросов по спецификации
+func TestOpenAPIValidatorRequest(t *testing.T) {
+	called := false
+	handler := newValidatedHandler(t, false, func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// Тестовый случай 1: Корректный запрос передается обработчику
+	{
+		called = false
+		body := `{"service_name":"Netflix","price":499,"user_id":"550e8400-e29b-41d4-a716-446655440000","start_date":"08-2025"}`
+		req := httptest.NewRequest("POST", "/api/v1/subscription", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rw := httptest.NewRecorder()
+
+		handler.ServeHTTP(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.True(t, called)
+	}
+
+	// Тестовый случай 2: Тело запроса не является JSON
+	{
+		called = false
+		req := httptest.NewRequest("POST", "/api/v1/subscription", strings.NewReader("invalid json"))
+		req.Header.Set("Content-Type", "application/json")
+		rw := httptest.NewRecorder()
+
+		handler.ServeHTTP(rw, req)
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		assert.Equal(t, controller.CodeMalformedBody, decodeProblem(t, rw).Code)
+		assert.False(t, called)
+	}
+
+	// Тестовый случай 3: Поля тела не соответствуют схеме
+	{
+		called = false
+		body := `{"price":0,"user_id":"550e8400-e29b-41d4-a716-446655440000","start_date":"2025-08","tags":["a"]}`
+		req := httptest.NewRequest("POST", "/api/v1/subscription", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rw := httptest.NewRecorder()
+
+		handler.ServeHTTP(rw, req)
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		assert.Equal(t, "application/problem+json", rw.Header().Get("Content-Type"))
+		problem := decodeProblem(t, rw)
+		assert.Equal(t, controller.CodeValidationFailed, problem.Code)
+		assert.ElementsMatch(t, []controller.InvalidParam{
+			{Name: "service_name", Reason: "service_name is a required field"},
+			{Name: "price", Reason: "price must be 1 or greater"},
+			{Name: "start_date", Reason: "start_date has an invalid format"},
+		}, problem.InvalidParams)
+		assert.False(t, called)
+	}
+
+	// Тестовый случай 4: Параметр пути не является числом, ответ на русском
+	{
+		called = false
+		req := httptest.NewRequest("GET", "/api/v1/subscription/abc", nil)
+		req.Header.Set("Accept-Language", "ru")
+		rw := httptest.NewRecorder()
+
+		handler.ServeHTTP(rw, req)
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		problem := decodeProblem(t, rw)
+		assert.Equal(t, controller.CodeInvalidParameter, problem.Code)
+		assert.Equal(t, []controller.InvalidParam{{Name: "id", Reason: "id должен иметь тип integer"}}, problem.InvalidParams)
+		assert.False(t, called)
+	}
+
+	// Тестовый случай 5: Не указан обязательный параметр запроса
+	{
+		called = false
+		req := httptest.NewRequest("GET", "/api/v1/subscriptions/cost?from=01-2025", nil)
+		rw := httptest.NewRecorder()
+
+		handler.ServeHTTP(rw, req)
+
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		problem := decodeProblem(t, rw)
+		assert.Equal(t, controller.CodeInvalidParameter, problem.Code)
+		assert.Equal(t, "to parameter not set", problem.Detail)
+		assert.False(t, called)
+	}
+
+	// Тестовый случай 6: Путь отсутствует в спецификации - запрос передается обработчику без проверки
+	{
+		called = false
+		req := httptest.NewRequest("GET", "/api/v1/doc/index.html", nil)
+		rw := httptest.NewRecorder()
+
+		handler.ServeHTTP(rw, req)
+
+		assert.True(t, called)
+	}
+}
+
+// TestOpenAPIValidatorResponse - тест проверки ответов по спецификации
+func TestOpenAPIValidatorResponse(t *testing.T) {
+	// ответ с полем Id вместо id не соответствует схеме CreateResponse
+	next := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"Id":15}`))
+	}
+	body := `{"service_name":"Netflix","price":499,"user_id":"550e8400-e29b-41d4-a716-446655440000","start_date":"08-2025"}`
+
+	// Тестовый случай 1: Проверка ответов включена
+	{
+		req := httptest.NewRequest("POST", "/api/v1/subscription", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rw := httptest.NewRecorder()
+
+		newValidatedHandler(t, true, next).ServeHTTP(rw, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rw.Code)
+		problem := decodeProblem(t, rw)
+		assert.Equal(t, controller.CodeResponseInvalid, problem.Code)
+		assert.ElementsMatch(t, []controller.InvalidParam{
+			{Name: "id", Reason: "id is a required field"},
+			{Name: "Id", Reason: "Id is not a known field"},
+		}, problem.InvalidParams)
+	}
+
+	// Тестовый случай 2: Проверка ответов выключена
+	{
+		req := httptest.NewRequest("POST", "/api/v1/subscription", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rw := httptest.NewRecorder()
+
+		newValidatedHandler(t, false, next).ServeHTTP(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, `{"Id":15}`, rw.Body.String())
+	}
+
+	// Тестовый случай 3: Корректный ответ отправляется клиенту без изменений
+	{
+		req := httptest.NewRequest("GET", "/api/v1/subscription/15", nil)
+		rw := httptest.NewRecorder()
+
+		newValidatedHandler(t, true, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"id":15,"service_name":"Netflix","price":499,"user_id":"550e8400-e29b-41d4-a716-446655440000","start_date":"08-2025"}`))
+		}).ServeHTTP(rw, req)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, "application/json", rw.Header().Get("Content-Type"))
+		assert.Contains(t, rw.Body.String(), `"service_name":"Netflix"`)
+	}
+
+	// Тестовый случай 4: Ответ 304 на условный запрос без тела проходит проверку
+	{
+		req := httptest.NewRequest("GET", "/api/v1/subscriptions/cost?from=01-2025&to=12-2025", nil)
+		req.Header.Set("If-None-Match", `W/"3"`)
+		rw := httptest.NewRecorder()
+
+		newValidatedHandler(t, true, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("ETag", `W/"3"`)
+			w.WriteHeader(http.StatusNotModified)
+		}).ServeHTTP(rw, req)
+
+		assert.Equal(t, http.StatusNotModified, rw.Code)
+		assert.Equal(t, `W/"3"`, rw.Header().Get("ETag"))
+		assert.Empty(t, rw.Body.String())
+	}
+}
+
+// TestMain - подменяет логгер для тестов middleware, так как внутренние ошибки логируются
+func TestMain(m *testing.M) {
+	logger.Log = zap.NewNop()
+	os.Exit(m.Run())
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/auth"
+	"github.com/Ararat25/subscription-aggregation-service/internal/logger"
+	"github.com/Ararat25/subscription-aggregation-service/internal/ratelimit"
+	"github.com/Ararat25/subscription-aggregation-service/internal/tenant"
+	"go.uber.org/zap"
+)
+
+// KeyFunc возвращает ключ клиента для ограничения частоты запросов, false - по запросу ключ не определить
+type KeyFunc func(r *http.Request) (string, bool)
+
+// ByAPIKey определяет клиента по id API-ключа, которым он аутентифицирован, поэтому должен работать после
+// Authenticate. Непроверенные заголовки не учитываются: иначе клиент получал бы новую корзину с каждым
+// выдуманным ключом. Без аутентификации по ключу клиент определяется следующими функциями
+func ByAPIKey(r *http.Request) (string, bool) {
+	id, ok := auth.FromContext(r.Context())
+	if !ok || id.KeyID == 0 {
+		return "", false
+	}
+
+	return "key:" + strconv.FormatInt(id.KeyID, 10), true
+}
+
+// ByUser определяет клиента по аутентифицированному пользователю, поэтому должен работать после Authenticate
+func ByUser(r *http.Request) (string, bool) {
+	id, ok := auth.FromContext(r.Context())
+	if !ok {
+		return "", false
+	}
+
+	return "user:" + id.Subject, true
+}
+
+// ByIP определяет клиента по IP-адресу. Если trustProxy, адрес берется из X-Forwarded-For или X-Real-IP,
+// которые выставляет балансировщик перед сервисом
+func ByIP(trustProxy bool) KeyFunc {
+	return func(r *http.Request) (string, bool) {
+		if trustProxy {
+			if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+				ip, _, _ := strings.Cut(forwarded, ",")
+				return "ip:" + strings.TrimSpace(ip), true
+			}
+			if ip := r.Header.Get("X-Real-IP"); ip != "" {
+				return "ip:" + ip, true
+			}
+		}
+
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+
+		return "ip:" + host, host != ""
+	}
+}
+
+// RateLimit - middleware, ограничивающее частоту запросов клиента корзиной токенов с параметрами limit.
+// Клиент определяется первой из функций keys, вернувшей ключ; name отделяет корзины разных ограничений друг от друга,
+// а тенант запроса - корзины разных тенантов, поэтому клиенты разных тенантов не расходуют лимит друг друга.
+// Ответ содержит заголовки RateLimit-Limit, RateLimit-Remaining и RateLimit-Reset, при превышении ограничения
+// выставляется Retry-After и вызывается onLimited. Если хранилище недоступно, запрос пропускается
+func RateLimit(store ratelimit.Store, name string, limit ratelimit.Limit, keys []KeyFunc, onLimited func(http.ResponseWriter, *http.Request)) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, ok := clientKey(r, keys)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			res, err := store.Take(r.Context(), bucketKey(r, name, key), limit)
+			if err != nil {
+				logger.Log.Error("rate limit store error", zap.String("limit", name), zap.Error(err))
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(seconds(res.ResetAfter)))
+
+			if !res.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(max(seconds(res.RetryAfter), 1)))
+				onLimited(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientKey возвращает ключ клиента от первой функции, которая смогла его определить
+func clientKey(r *http.Request, keys []KeyFunc) (string, bool) {
+	for _, key := range keys {
+		if k, ok := key(r); ok {
+			return k, true
+		}
+	}
+
+	return "", false
+}
+
+// bucketKey возвращает ключ корзины клиента key в ограничении name с учетом тенанта запроса
+func bucketKey(r *http.Request, name, key string) string {
+	if id, ok := tenant.FromContext(r.Context()); ok {
+		return name + ":" + id.String() + ":" + key
+	}
+
+	return name + ":" + key
+}
+
+// seconds округляет длительность вверх до целых секунд, как принято в заголовках Retry-After и RateLimit-Reset
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/auth"
+	"github.com/Ararat25/subscription-aggregation-service/internal/controller"
+	"github.com/Ararat25/subscription-aggregation-service/internal/ratelimit"
+	"github.com/Ararat25/subscription-aggregation-service/internal/tenant"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingStore - хранилище корзин, которое всегда возвращает ошибку
+type failingStore struct{}
+
+// Take возвращает ошибку хранилища
+func (failingStore) Take(context.Context, string, ratelimit.Limit) (*ratelimit.Result, error) {
+	return nil, errors.New("db error")
+}
+
+// Run ничего не делает
+func (failingStore) Run(context.Context) {}
+
+// TestRateLimit - тест ограничения частоты запросов
+func TestRateLimit(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	keys := []KeyFunc{ByAPIKey, ByIP(false)}
+	handler := JsonHeader(RateLimit(ratelimit.NewMemory(), "test", ratelimit.Limit{Rate: 1, Burst: 1}, keys, controller.SendRateLimited)(next))
+
+	newRequest := func(keyID int64) *http.Request {
+		req := httptest.NewRequest("GET", "/api/v1/subscriptions", nil)
+		req.RemoteAddr = "10.0.0.1:5000"
+		if keyID != 0 {
+			req = req.WithContext(auth.WithIdentity(req.Context(), &auth.Identity{Subject: "key", KeyID: keyID, Role: auth.RoleViewer}))
+		}
+		return req
+	}
+
+	// Тестовый случай 1: Первый запрос разрешен, ответ содержит заголовки ограничения
+	{
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, newRequest(0))
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, "1", rw.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "0", rw.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "1", rw.Header().Get("RateLimit-Reset"))
+		assert.Empty(t, rw.Header().Get("Retry-After"))
+	}
+
+	// Тестовый случай 2: Повторный запрос с того же IP отклонен
+	{
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, newRequest(0))
+
+		assert.Equal(t, http.StatusTooManyRequests, rw.Code)
+		assert.Equal(t, "1", rw.Header().Get("Retry-After"))
+		assert.Equal(t, "application/problem+json", rw.Header().Get("Content-Type"))
+		assert.Equal(t, controller.CodeRateLimited, decodeProblem(t, rw).Code)
+	}
+
+	// Тестовый случай 3: Клиент, аутентифицированный API-ключом, ограничивается по ключу, а не по IP
+	{
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, newRequest(42))
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+	}
+
+	// Тестовый случай 4: Непроверенный заголовок X-API-Key не дает новой корзины: запросы с разными
+	// выдуманными ключами с одного IP расходуют одну корзину
+	{
+		limited := JsonHeader(RateLimit(ratelimit.NewMemory(), "test", ratelimit.Limit{Rate: 1, Burst: 1}, keys, controller.SendRateLimited)(next))
+
+		req := newRequest(0)
+		req.Header.Set("X-API-Key", "bogus-1")
+		rw := httptest.NewRecorder()
+		limited.ServeHTTP(rw, req)
+		assert.Equal(t, http.StatusOK, rw.Code)
+
+		req = newRequest(0)
+		req.Header.Set("X-API-Key", "bogus-2")
+		rw = httptest.NewRecorder()
+		limited.ServeHTTP(rw, req)
+		assert.Equal(t, http.StatusTooManyRequests, rw.Code)
+	}
+
+	// Тестовый случай 5: Пользователи с одинаковым subject в разных тенантах не делят корзину
+	{
+		limited := JsonHeader(RateLimit(ratelimit.NewMemory(), "test", ratelimit.Limit{Rate: 1, Burst: 1}, []KeyFunc{ByUser}, controller.SendRateLimited)(next))
+		newTenantRequest := func(tenantID uuid.UUID) *http.Request {
+			req := httptest.NewRequest("GET", "/api/v1/subscriptions", nil)
+			ctx := auth.WithIdentity(req.Context(), &auth.Identity{Subject: "alice", Role: auth.RoleViewer})
+			return req.WithContext(tenant.WithID(ctx, tenantID))
+		}
+		first, second := uuid.New(), uuid.New()
+
+		rw := httptest.NewRecorder()
+		limited.ServeHTTP(rw, newTenantRequest(first))
+		assert.Equal(t, http.StatusOK, rw.Code)
+
+		rw = httptest.NewRecorder()
+		limited.ServeHTTP(rw, newTenantRequest(second))
+		assert.Equal(t, http.StatusOK, rw.Code)
+
+		rw = httptest.NewRecorder()
+		limited.ServeHTTP(rw, newTenantRequest(first))
+		assert.Equal(t, http.StatusTooManyRequests, rw.Code)
+	}
+
+	// Тестовый случай 6: Ошибка хранилища не блокирует запросы
+	{
+		rw := httptest.NewRecorder()
+		RateLimit(failingStore{}, "test", ratelimit.Limit{Rate: 1, Burst: 1}, keys, controller.SendRateLimited)(next).ServeHTTP(rw, newRequest(0))
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Empty(t, rw.Header().Get("RateLimit-Limit"))
+	}
+}
+
+// TestByIP - тест определения IP-адреса клиента
+func TestByIP(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.1:5000"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.2")
+
+	// Тестовый случай 1: Заголовки прокси игнорируются, если ему не доверяют
+	key, ok := ByIP(false)(req)
+	assert.True(t, ok)
+	assert.Equal(t, "ip:10.0.0.1", key)
+
+	// Тестовый случай 2: Адрес клиента берется из X-Forwarded-For
+	key, ok = ByIP(true)(req)
+	assert.True(t, ok)
+	assert.Equal(t, "ip:203.0.113.7", key)
+}
@@ -0,0 +1,309 @@
+// Package migrate применяет миграции схемы Postgres, встроенные в бинарник.
+// Версия схемы хранится в таблице schema_migrations в формате утилиты migrate, поэтому базу, размеченную ею,
+// можно дальше обновлять сервисом, и наоборот
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// NoVersion - версия схемы, к которой не применено ни одной миграции
+const NoVersion int64 = -1
+
+// lockKey - ключ advisory-блокировки, под которой применяются миграции. Экземпляры сервиса, запущенные одновременно,
+// применяют миграции по очереди: следующий дождется блокировки и увидит, что применять уже нечего
+const lockKey int64 = 7_245_102_318_660_401
+
+// fileNamePattern - имя файла миграции: <версия>_<название>.up.sql или <версия>_<название>.down.sql
+var fileNamePattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// ErrDirty возвращается, если предыдущая миграция завершилась ошибкой посередине и схема требует ручного исправления
+var ErrDirty = errors.New("database schema is dirty")
+
+// Migration - миграция схемы
+type Migration struct {
+	Version int64  // версия, которую получает схема после применения миграции
+	Name    string // название миграции
+	up      string // скрипт применения
+	down    string // скрипт отката, пустой, если откатить миграцию нельзя
+}
+
+// Status - состояние миграции в бд
+type Status struct {
+	Migration
+	Applied bool // миграция применена
+}
+
+// Migrator применяет миграции к бд через одно соединение
+type Migrator struct {
+	conn       *pgx.Conn   // соединение с бд, на котором держится advisory-блокировка
+	migrations []Migration // миграции по возрастанию версий
+}
+
+// New создает новый объект Migrator с миграциями из корня fsys
+func New(conn *pgx.Conn, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		conn:       conn,
+		migrations: migrations,
+	}, nil
+}
+
+// Load читает миграции из корня fsys и возвращает их по возрастанию версий. Файлы с другими именами пропускаются
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		parts := fileNamePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || parts == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+
+		script, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: parts[2]}
+			byVersion[version] = m
+		}
+
+		if m.Name != parts[2] {
+			return nil, fmt.Errorf("migrations %d_%s and %d_%s have the same version", version, m.Name, version, parts[2])
+		}
+
+		if parts[3] == "up" {
+			m.up = string(script)
+		} else {
+			m.down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Up применяет все миграции новее текущей версии схемы и возвращает примененные
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func() error {
+		version, err := m.cleanVersion(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				continue
+			}
+
+			err = m.apply(ctx, migration.up, migration.Version)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
+
+// Down откатывает steps последних примененных миграций и возвращает откаченные
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("steps must be positive")
+	}
+
+	var reverted []Migration
+	err := m.withLock(ctx, func() error {
+		version, err := m.cleanVersion(ctx)
+		if err != nil {
+			return err
+		}
+
+		for ; steps > 0 && version != NoVersion; steps-- {
+			i := sort.Search(len(m.migrations), func(i int) bool {
+				return m.migrations[i].Version >= version
+			})
+			if i == len(m.migrations) || m.migrations[i].Version != version {
+				return fmt.Errorf("database schema version %d is unknown", version)
+			}
+
+			migration := m.migrations[i]
+			if migration.down == "" {
+				return fmt.Errorf("migration %d_%s has no down script", migration.Version, migration.Name)
+			}
+
+			version = NoVersion
+			if i > 0 {
+				version = m.migrations[i-1].Version
+			}
+
+			err = m.apply(ctx, migration.down, version)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			reverted = append(reverted, migration)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reverted, nil
+}
+
+// Version возвращает текущую версию схемы и признак того, что последняя миграция не завершилась.
+// Для бд без примененных миграций возвращает NoVersion
+func (m *Migrator) Version(ctx context.Context) (int64, bool, error) {
+	var exists bool
+	err := m.conn.QueryRow(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if !exists {
+		return NoVersion, false, nil
+	}
+
+	var (
+		version int64
+		dirty   bool
+	)
+	err = m.conn.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return NoVersion, false, nil
+		}
+		return 0, false, err
+	}
+
+	return version, dirty, nil
+}
+
+// Status возвращает все известные миграции с отметкой, применены ли они, и признак незавершенной миграции
+func (m *Migrator) Status(ctx context.Context) ([]Status, bool, error) {
+	version, dirty, err := m.Version(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		statuses = append(statuses, Status{
+			Migration: migration,
+			Applied:   migration.Version <= version,
+		})
+	}
+
+	return statuses, dirty, nil
+}
+
+// withLock выполняет fn под advisory-блокировкой миграций, дожидаясь ее освобождения другими экземплярами сервиса
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	_, err := m.conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, lockKey)
+	if err != nil {
+		return fmt.Errorf("unable to acquire migration lock: %w", err)
+	}
+	defer func() {
+		// блокировка сессионная, поэтому снимается и при закрытии соединения, если снять ее здесь не удалось
+		_, _ = m.conn.Exec(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, lockKey)
+	}()
+
+	_, err = m.conn.Exec(ctx,
+		`CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`)
+	if err != nil {
+		return err
+	}
+
+	return fn()
+}
+
+// cleanVersion возвращает текущую версию схемы или ErrDirty, если последняя миграция не завершилась
+func (m *Migrator) cleanVersion(ctx context.Context) (int64, error) {
+	version, dirty, err := m.Version(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if dirty {
+		return 0, fmt.Errorf("%w at version %d", ErrDirty, version)
+	}
+
+	return version, nil
+}
+
+// apply выполняет скрипт миграции и записывает новую версию схемы в одной транзакции,
+// поэтому ошибка в скрипте оставляет схему и версию прежними
+func (m *Migrator) apply(ctx context.Context, script string, version int64) error {
+	tx, err := m.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// без параметров запрос идет по простому протоколу, который допускает несколько операторов в скрипте
+	_, err = tx.Exec(ctx, script)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `TRUNCATE schema_migrations`)
+	if err != nil {
+		return err
+	}
+
+	if version != NoVersion {
+		_, err = tx.Exec(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`, version)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
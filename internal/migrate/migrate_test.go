@@ -0,0 +1,59 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/Ararat25/subscription-aggregation-service/migrations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoad тестирует чтение миграций из файловой системы
+func TestLoad(t *testing.T) {
+	// Тестовый пример 1: Миграции упорядочены по числовой версии, посторонние файлы пропускаются
+	loaded, err := Load(fstest.MapFS{
+		"10_tenants.up.sql":   {Data: []byte("CREATE TABLE tenants ();")},
+		"10_tenants.down.sql": {Data: []byte("DROP TABLE tenants;")},
+		"2_outbox.up.sql":     {Data: []byte("CREATE TABLE outbox ();")},
+		"migrations.go":       {Data: []byte("package migrations")},
+		"sqlite/0_init.up.sql": {
+			Data: []byte("CREATE TABLE subscriptions ();"),
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, loaded, 2)
+	assert.Equal(t, int64(2), loaded[0].Version)
+	assert.Equal(t, "outbox", loaded[0].Name)
+	assert.Equal(t, "CREATE TABLE outbox ();", loaded[0].up)
+	assert.Empty(t, loaded[0].down)
+	assert.Equal(t, int64(10), loaded[1].Version)
+	assert.Equal(t, "tenants", loaded[1].Name)
+	assert.Equal(t, "DROP TABLE tenants;", loaded[1].down)
+
+	// Тестовый пример 2: Две миграции с одной версией
+	_, err = Load(fstest.MapFS{
+		"1_webhooks.up.sql": {Data: []byte("CREATE TABLE webhooks ();")},
+		"1_outbox.up.sql":   {Data: []byte("CREATE TABLE outbox ();")},
+	})
+	assert.ErrorContains(t, err, "have the same version")
+
+	// Тестовый пример 3: Скрипт отката без скрипта применения
+	_, err = Load(fstest.MapFS{
+		"1_webhooks.down.sql": {Data: []byte("DROP TABLE webhooks;")},
+	})
+	assert.ErrorContains(t, err, "has no up script")
+}
+
+// TestEmbeddedMigrations тестирует миграции Postgres, встроенные в бинарник
+func TestEmbeddedMigrations(t *testing.T) {
+	loaded, err := Load(migrations.Postgres)
+	require.NoError(t, err)
+	require.NotEmpty(t, loaded)
+
+	// Тестовый пример 1: Версии идут подряд с нуля, и каждую миграцию можно откатить
+	for i, m := range loaded {
+		assert.Equal(t, int64(i), m.Version)
+		assert.NotEmpty(t, m.down, "migration %d_%s has no down script", m.Version, m.Name)
+	}
+}
@@ -2,13 +2,15 @@ package model
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/Ararat25/subscription-aggregation-service/internal/auth"
 	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
 	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
 	"github.com/Ararat25/subscription-aggregation-service/internal/repository"
-	"github.com/google/uuid"
 )
 
 // AggregationService - структура для сервиса агрегации
@@ -23,6 +25,14 @@ func NewAggregationService(storage repository.Repo) *AggregationService {
 	}
 }
 
+// WithTx выполняет fn с сервисом, все обращения которого к хранилищу идут в одной транзакции. После конфликта
+// с другой транзакцией fn может выполниться заново, поэтому она не должна изменять данные вне транзакции
+func (ags *AggregationService) WithTx(ctx context.Context, fn func(tx *AggregationService) error) error {
+	return ags.Storage.WithTx(ctx, func(tx repository.Repo) error {
+		return fn(NewAggregationService(tx))
+	})
+}
+
 // CreateSubscription добавляет подписку в бд и возвращает id
 func (ags *AggregationService) CreateSubscription(ctx context.Context, s *entity.SubscriptionRequest) (int64, error) {
 	if s == nil {
@@ -38,7 +48,23 @@ func (ags *AggregationService) CreateSubscription(ctx context.Context, s *entity
 		return 0, myError.ErrDateRange
 	}
 
-	id, err := ags.Storage.CreateSubscription(ctx, subNew)
+	err = checkOwner(ctx, subNew.UserId)
+	if err != nil {
+		return 0, err
+	}
+
+	// сопоставление с каталогом и запись видят одно состояние каталога
+	var id int64
+	err = ags.WithTx(ctx, func(tx *AggregationService) error {
+		sub := *subNew
+		err := tx.resolveService(ctx, &sub)
+		if err != nil {
+			return err
+		}
+
+		id, err = tx.Storage.CreateSubscription(ctx, &sub)
+		return err
+	})
 	if err != nil {
 		return 0, err
 	}
@@ -48,7 +74,7 @@ func (ags *AggregationService) CreateSubscription(ctx context.Context, s *entity
 
 // ReadSubscription возвращает подписку из бд по id
 func (ags *AggregationService) ReadSubscription(ctx context.Context, id int64) (*entity.Subscription, error) {
-	sub, err := ags.Storage.ReadSubscription(ctx, id)
+	sub, err := ags.readOwned(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -71,7 +97,29 @@ func (ags *AggregationService) UpdateSubscription(ctx context.Context, s *entity
 		return myError.ErrDateRange
 	}
 
-	err = ags.Storage.UpdateSubscription(ctx, subNew)
+	// проверка владельца и запись в одной транзакции: подписку не передадут другому пользователю между ними
+	err = ags.WithTx(ctx, func(tx *AggregationService) error {
+		// обычный пользователь не может ни изменить чужую подписку, ни передать свою другому пользователю
+		if auth.UserScope(ctx) != nil {
+			_, err := tx.readOwned(ctx, int64(subNew.Id))
+			if err != nil {
+				return err
+			}
+
+			err = checkOwner(ctx, subNew.UserId)
+			if err != nil {
+				return err
+			}
+		}
+
+		sub := *subNew
+		err := tx.resolveService(ctx, &sub)
+		if err != nil {
+			return err
+		}
+
+		return tx.Storage.UpdateSubscription(ctx, &sub)
+	})
 	if err != nil {
 		return err
 	}
@@ -81,7 +129,16 @@ func (ags *AggregationService) UpdateSubscription(ctx context.Context, s *entity
 
 // DeleteSubscription удаляет подписку из бд
 func (ags *AggregationService) DeleteSubscription(ctx context.Context, id int64) error {
-	err := ags.Storage.DeleteSubscription(ctx, id)
+	err := ags.WithTx(ctx, func(tx *AggregationService) error {
+		if auth.UserScope(ctx) != nil {
+			_, err := tx.readOwned(ctx, id)
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Storage.DeleteSubscription(ctx, id)
+	})
 	if err != nil {
 		return err
 	}
@@ -89,9 +146,57 @@ func (ags *AggregationService) DeleteSubscription(ctx context.Context, id int64)
 	return nil
 }
 
-// ListSubscriptions возвращает список всех подписок из бд
-func (ags *AggregationService) ListSubscriptions(ctx context.Context) ([]*entity.Subscription, error) {
-	subs, err := ags.Storage.ListSubscriptions(ctx)
+// CancelSubscription завершает подписку с указанного месяца (по умолчанию - с текущего) и сохраняет причину отмены
+func (ags *AggregationService) CancelSubscription(ctx context.Context, id int64, req *entity.CancelRequest) (*entity.Subscription, error) {
+	if req == nil {
+		return nil, fmt.Errorf("invalid argument error")
+	}
+
+	endDate := resetDay(time.Now().UTC())
+	if req.EndDate != nil {
+		parsed, err := time.Parse(entity.DateLayout, *req.EndDate)
+		if err != nil {
+			return nil, myError.ErrInvalidDate
+		}
+		endDate = parsed
+	}
+
+	reason := req.Reason
+	if reason != nil && strings.TrimSpace(*reason) == "" {
+		reason = nil
+	}
+
+	// дата окончания проверяется по дате начала, которую не изменят до отмены
+	var cancelled *entity.Subscription
+	err := ags.WithTx(ctx, func(tx *AggregationService) error {
+		sub, err := tx.readOwned(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if !isEndDateValid(sub.StartDate, endDate) {
+			return myError.ErrDateRange
+		}
+
+		cancelled, err = tx.Storage.CancelSubscription(ctx, id, endDate, reason)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return cancelled, nil
+}
+
+// ListSubscriptions возвращает список подписок из бд с фильтрацией по категории и тегам.
+// Обычному пользователю возвращаются только его подписки
+func (ags *AggregationService) ListSubscriptions(ctx context.Context, filter *entity.SubscriptionFilter) ([]*entity.Subscription, error) {
+	filter, err := scopeFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	subs, err := ags.Storage.ListSubscriptions(ctx, normalizeFilter(filter))
 	if err != nil {
 		return nil, err
 	}
@@ -99,16 +204,21 @@ func (ags *AggregationService) ListSubscriptions(ctx context.Context) ([]*entity
 	return subs, nil
 }
 
-// TotalCost возвращает суммарную стоимость подписок за определенный период с фильтрацией по id пользователя и названию сервиса
-func (ags *AggregationService) TotalCost(ctx context.Context, from, to time.Time, userID *uuid.UUID, serviceName *string) (int, error) {
+// TotalCost возвращает суммарную стоимость подписок за определенный период с фильтрацией
+func (ags *AggregationService) TotalCost(ctx context.Context, from, to time.Time, filter *entity.CostFilter) (int, error) {
 	fromReset := resetDay(from)
 	toReset := resetDay(to)
 
 	if !isEndDateValid(fromReset, toReset) {
-		return 0, fmt.Errorf("to must be >= from")
+		return 0, myError.ErrPeriodRange
+	}
+
+	filter, err := scopeCostFilter(ctx, filter)
+	if err != nil {
+		return 0, err
 	}
 
-	cost, err := ags.Storage.TotalCost(ctx, fromReset, toReset, userID, serviceName)
+	cost, err := ags.Storage.TotalCost(ctx, fromReset, toReset, normalizeCostFilter(filter))
 	if err != nil {
 		return 0, err
 	}
@@ -116,6 +226,77 @@ func (ags *AggregationService) TotalCost(ctx context.Context, from, to time.Time
 	return cost, nil
 }
 
+// CostBreakdown возвращает суммарную стоимость подписок за определенный период в разрезе категорий или тегов
+func (ags *AggregationService) CostBreakdown(ctx context.Context, from, to time.Time, filter *entity.CostFilter, groupBy entity.GroupBy) ([]*entity.CostGroup, error) {
+	fromReset := resetDay(from)
+	toReset := resetDay(to)
+
+	if !isEndDateValid(fromReset, toReset) {
+		return nil, myError.ErrPeriodRange
+	}
+
+	filter, err := scopeCostFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := ags.Storage.CostBreakdown(ctx, fromReset, toReset, normalizeCostFilter(filter), groupBy)
+	if err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+// ScopeCostFilter возвращает фильтр стоимости, ограниченный пользователем клиента, как в TotalCost и CostBreakdown.
+// Контроллер ограничивает фильтр до проверки кэша ответа, чтобы запрос чужих подписок получал 403, а не 304
+func (ags *AggregationService) ScopeCostFilter(ctx context.Context, filter *entity.CostFilter) (*entity.CostFilter, error) {
+	filter, err := scopeCostFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+// SubscriptionsVersion возвращает версию данных о подписках, по которой строятся валидаторы HTTP-кэша
+func (ags *AggregationService) SubscriptionsVersion(ctx context.Context) (*entity.TableVersion, error) {
+	version, err := ags.Storage.SubscriptionsVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return version, nil
+}
+
+// resolveService сопоставляет название сервиса с каталогом: подписка получает ссылку на сервис и его каноническое
+// название, а если цена или категория не указаны - цену и категорию сервиса. Название, которого нет в каталоге, сохраняется как есть
+func (ags *AggregationService) resolveService(ctx context.Context, s *entity.Subscription) error {
+	service, err := ags.Storage.ResolveService(ctx, s.ServiceName)
+	if err != nil && !errors.Is(err, myError.ErrServiceNotFound) {
+		return err
+	}
+
+	if service != nil {
+		s.ServiceId = &service.Id
+		s.ServiceName = service.Name
+
+		if s.Category == nil {
+			s.Category = service.Category
+		}
+
+		if s.Price == 0 && service.DefaultPrice != nil {
+			s.Price = *service.DefaultPrice
+		}
+	}
+
+	if s.Price == 0 {
+		return myError.ErrPriceRequired
+	}
+
+	return nil
+}
+
 // resetDay обнуляет день
 func resetDay(t time.Time) time.Time {
 	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
@@ -127,6 +308,8 @@ func convertStringDateToTime(s *entity.SubscriptionRequest) (*entity.Subscriptio
 		ServiceName: s.ServiceName,
 		Price:       s.Price,
 		UserId:      s.UserId,
+		Category:    normalizeCategory(s.Category),
+		Tags:        normalizeTags(s.Tags),
 	}
 
 	if s.Id != 0 {
@@ -135,14 +318,14 @@ func convertStringDateToTime(s *entity.SubscriptionRequest) (*entity.Subscriptio
 
 	start, err := time.Parse(entity.DateLayout, s.StartDate)
 	if err != nil {
-		return nil, fmt.Errorf("invalid date format")
+		return nil, myError.ErrInvalidDate
 	}
 
 	var end *time.Time
 	if s.EndDate != nil {
 		endValue, err := time.Parse(entity.DateLayout, *s.EndDate)
 		if err != nil {
-			return nil, fmt.Errorf("invalid date format")
+			return nil, myError.ErrInvalidDate
 		}
 
 		end = &endValue
@@ -154,6 +337,71 @@ func convertStringDateToTime(s *entity.SubscriptionRequest) (*entity.Subscriptio
 	return subNew, nil
 }
 
+// normalizeFilter приводит категорию и теги фильтра к виду, в котором они хранятся в бд
+func normalizeFilter(filter *entity.SubscriptionFilter) *entity.SubscriptionFilter {
+	if filter == nil {
+		return nil
+	}
+
+	normalized := &entity.SubscriptionFilter{
+		UserId:   filter.UserId,
+		Category: normalizeCategory(filter.Category),
+	}
+
+	if len(filter.Tags) > 0 {
+		normalized.Tags = normalizeTags(filter.Tags)
+	}
+
+	return normalized
+}
+
+// normalizeCostFilter приводит категорию и теги фильтра стоимости к виду, в котором они хранятся в бд
+func normalizeCostFilter(filter *entity.CostFilter) *entity.CostFilter {
+	if filter == nil {
+		return nil
+	}
+
+	normalized := *filter
+	normalized.SubscriptionFilter = *normalizeFilter(&filter.SubscriptionFilter)
+
+	return &normalized
+}
+
+// normalizeCategory переводит категорию в нижний регистр без пробелов по краям, пустая категория считается неуказанной
+func normalizeCategory(category *string) *string {
+	if category == nil {
+		return nil
+	}
+
+	normalized := normalizeLabel(*category)
+	if normalized == "" {
+		return nil
+	}
+
+	return &normalized
+}
+
+// normalizeTags переводит теги в нижний регистр без пробелов по краям и убирает пустые и повторяющиеся
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = normalizeLabel(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	return normalized
+}
+
+// normalizeLabel приводит категорию или тег к нижнему регистру без пробелов по краям
+func normalizeLabel(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
 // isEndDateValid проверяет, что endDate >= startDate
 func isEndDateValid(startDate, endDate time.Time) bool {
 	return !endDate.Before(startDate)
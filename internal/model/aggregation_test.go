@@ -6,8 +6,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/Ararat25/subscription-aggregation-service/internal/config"
 	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
 	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/Ararat25/subscription-aggregation-service/internal/repository"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -19,8 +21,8 @@ type MockRepo struct {
 }
 
 // ConnectDB имитирует соединение с бд
-func (m *MockRepo) ConnectDB(ctx context.Context, dbHost string, dbUser string, dbPassword string, dbName string, dbPort int) error {
-	args := m.Called(ctx, dbHost, dbUser, dbPassword, dbName, dbPort)
+func (m *MockRepo) ConnectDB(ctx context.Context, conf config.DatabaseConfig) error {
+	args := m.Called(ctx, conf)
 	return args.Error(0)
 }
 
@@ -48,18 +50,65 @@ func (m *MockRepo) DeleteSubscription(ctx context.Context, id int64) error {
 	return args.Error(0)
 }
 
+// CancelSubscription имитирует отмену подписки
+func (m *MockRepo) CancelSubscription(ctx context.Context, id int64, endDate time.Time, reason *string) (*entity.Subscription, error) {
+	args := m.Called(ctx, id, endDate, reason)
+	return args.Get(0).(*entity.Subscription), args.Error(1)
+}
+
 // ListSubscriptions имитирует вывод списка подписок
-func (m *MockRepo) ListSubscriptions(ctx context.Context) ([]*entity.Subscription, error) {
-	args := m.Called(ctx)
+func (m *MockRepo) ListSubscriptions(ctx context.Context, filter *entity.SubscriptionFilter) ([]*entity.Subscription, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]*entity.Subscription), args.Error(1)
+}
+
+// ListSubscriptionHistory имитирует вывод списка подписок вместе с архивными
+func (m *MockRepo) ListSubscriptionHistory(ctx context.Context, filter *entity.SubscriptionFilter) ([]*entity.Subscription, error) {
+	args := m.Called(ctx, filter)
 	return args.Get(0).([]*entity.Subscription), args.Error(1)
 }
 
 // TotalCost имитирует вывод суммарной стоимости подписок
-func (m *MockRepo) TotalCost(ctx context.Context, from, to time.Time, userID *uuid.UUID, serviceName *string) (int, error) {
-	args := m.Called(ctx, from, to, userID, serviceName)
+func (m *MockRepo) TotalCost(ctx context.Context, from, to time.Time, filter *entity.CostFilter) (int, error) {
+	args := m.Called(ctx, from, to, filter)
 	return args.Int(0), args.Error(1)
 }
 
+// CostBreakdown имитирует вывод стоимости подписок в разрезе категорий или тегов
+func (m *MockRepo) CostBreakdown(ctx context.Context, from, to time.Time, filter *entity.CostFilter, groupBy entity.GroupBy) ([]*entity.CostGroup, error) {
+	args := m.Called(ctx, from, to, filter, groupBy)
+	return args.Get(0).([]*entity.CostGroup), args.Error(1)
+}
+
+// SearchSubscriptions имитирует поиск подписок по названию сервиса
+func (m *MockRepo) SearchSubscriptions(ctx context.Context, query string, userID *uuid.UUID, limit int) ([]*entity.SubscriptionMatch, error) {
+	args := m.Called(ctx, query, userID, limit)
+	return args.Get(0).([]*entity.SubscriptionMatch), args.Error(1)
+}
+
+// SuggestServiceNames имитирует автодополнение названия сервиса
+func (m *MockRepo) SuggestServiceNames(ctx context.Context, query string, userID *uuid.UUID, limit int) ([]*entity.ServiceSuggestion, error) {
+	args := m.Called(ctx, query, userID, limit)
+	return args.Get(0).([]*entity.ServiceSuggestion), args.Error(1)
+}
+
+// ResolveService имитирует поиск сервиса в каталоге по названию
+func (m *MockRepo) ResolveService(ctx context.Context, name string) (*entity.Service, error) {
+	args := m.Called(ctx, name)
+	return args.Get(0).(*entity.Service), args.Error(1)
+}
+
+// SubscriptionsVersion имитирует получение счетчика изменений таблицы подписок
+func (m *MockRepo) SubscriptionsVersion(ctx context.Context) (*entity.TableVersion, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(*entity.TableVersion), args.Error(1)
+}
+
+// WithTx выполняет fn с тем же mock вместо транзакции
+func (m *MockRepo) WithTx(ctx context.Context, fn func(tx repository.Repo) error) error {
+	return fn(m)
+}
+
 // Close имитирует закрытие соединенеия с бд
 func (m *MockRepo) Close(ctx context.Context) error {
 	args := m.Called(ctx)
@@ -74,6 +123,29 @@ func TestNewAggregationService(t *testing.T) {
 	assert.Equal(t, mockRepo, service.Storage)
 }
 
+// TestWithTx тестирует выполнение операций сервиса в транзакции хранилища
+func TestWithTx(t *testing.T) {
+	ctx := context.Background()
+
+	// Тестовый пример 1: Операции идут в хранилище транзакции, а ошибка fn возвращается из WithTx
+	storage := repository.NewMemRepo()
+	service := NewAggregationService(storage)
+	errAbort := errors.New("abort")
+
+	err := service.WithTx(ctx, func(tx *AggregationService) error {
+		assert.NotSame(t, storage, tx.Storage)
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+
+	// Тестовый пример 2: Ошибка хранилища внутри транзакции возвращается вызывающему
+	err = service.WithTx(ctx, func(tx *AggregationService) error {
+		_, err := tx.ReadSubscription(ctx, 1)
+		return err
+	})
+	assert.ErrorIs(t, err, myError.ErrTenantRequired)
+}
+
 // TestCreateSubscription тестирует создание подписки
 func TestCreateSubscription(t *testing.T) {
 	mockRepo := new(MockRepo)
@@ -93,7 +165,9 @@ func TestCreateSubscription(t *testing.T) {
 		UserId:      subReq.UserId,
 		StartDate:   time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC),
 		EndDate:     nil,
+		Tags:        []string{},
 	}
+	mockRepo.On("ResolveService", ctx, "Test Service").Return((*entity.Service)(nil), myError.ErrServiceNotFound).Once()
 	mockRepo.On("CreateSubscription", ctx, expectedSub).Return(int64(1), nil).Once()
 	id, err := service.CreateSubscription(ctx, subReq)
 	assert.NoError(t, err)
@@ -144,13 +218,52 @@ func TestCreateSubscription(t *testing.T) {
 		UserId:      subReqRepoError.UserId,
 		StartDate:   time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC),
 		EndDate:     nil,
+		Tags:        []string{},
 	}
+	mockRepo.On("ResolveService", ctx, "Test Service").Return((*entity.Service)(nil), myError.ErrServiceNotFound).Once()
 	mockRepo.On("CreateSubscription", ctx, expectedSubRepoError).Return(int64(0), errors.New("db error")).Once()
 	id, err = service.CreateSubscription(ctx, subReqRepoError)
 	assert.Error(t, err)
 	assert.Equal(t, int64(0), id)
 	assert.Contains(t, err.Error(), "db error")
 	mockRepo.AssertExpectations(t)
+
+	// Тестовый пример 6: Название из каталога заменяется каноническим, цена и категория берутся из каталога
+	defaultPrice := 499
+	category := "streaming"
+	catalogService := &entity.Service{Id: 7, Name: "Netflix", DefaultPrice: &defaultPrice, Category: &category}
+	subReqCatalog := &entity.SubscriptionRequest{
+		ServiceName: " netflix",
+		UserId:      uuid.New(),
+		StartDate:   "01-2023",
+	}
+	expectedSubCatalog := &entity.Subscription{
+		ServiceName: "Netflix",
+		Price:       499,
+		UserId:      subReqCatalog.UserId,
+		StartDate:   time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC),
+		ServiceId:   &catalogService.Id,
+		Category:    &category,
+		Tags:        []string{},
+	}
+	mockRepo.On("ResolveService", ctx, " netflix").Return(catalogService, nil).Once()
+	mockRepo.On("CreateSubscription", ctx, expectedSubCatalog).Return(int64(3), nil).Once()
+	id, err = service.CreateSubscription(ctx, subReqCatalog)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), id)
+	mockRepo.AssertExpectations(t)
+
+	// Тестовый пример 7: Цена не указана, и сервиса нет в каталоге
+	subReqNoPrice := &entity.SubscriptionRequest{
+		ServiceName: "Unknown",
+		UserId:      uuid.New(),
+		StartDate:   "01-2023",
+	}
+	mockRepo.On("ResolveService", ctx, "Unknown").Return((*entity.Service)(nil), myError.ErrServiceNotFound).Once()
+	id, err = service.CreateSubscription(ctx, subReqNoPrice)
+	assert.ErrorIs(t, err, myError.ErrPriceRequired)
+	assert.Equal(t, int64(0), id)
+	mockRepo.AssertExpectations(t)
 }
 
 // TestReadSubscription тестирует чтение подписки
@@ -203,7 +316,9 @@ func TestUpdateSubscription(t *testing.T) {
 		UserId:      subReq.UserId,
 		StartDate:   time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC),
 		EndDate:     nil,
+		Tags:        []string{},
 	}
+	mockRepo.On("ResolveService", ctx, "Updated Service").Return((*entity.Service)(nil), myError.ErrServiceNotFound).Once()
 	mockRepo.On("UpdateSubscription", ctx, expectedSub).Return(nil).Once()
 	err := service.UpdateSubscription(ctx, subReq)
 	assert.NoError(t, err)
@@ -254,7 +369,9 @@ func TestUpdateSubscription(t *testing.T) {
 		UserId:      subReqRepoError.UserId,
 		StartDate:   time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC),
 		EndDate:     nil,
+		Tags:        []string{},
 	}
+	mockRepo.On("ResolveService", ctx, "Updated Service").Return((*entity.Service)(nil), myError.ErrServiceNotFound).Once()
 	mockRepo.On("UpdateSubscription", ctx, expectedSubRepoError).Return(errors.New("db error")).Once()
 	err = service.UpdateSubscription(ctx, subReqRepoError)
 	assert.Error(t, err)
@@ -282,6 +399,69 @@ func TestDeleteSubscription(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+// TestCancelSubscription тестирует отмену подписки
+func TestCancelSubscription(t *testing.T) {
+	mockRepo := new(MockRepo)
+	service := NewAggregationService(mockRepo)
+	ctx := context.Background()
+
+	sub := &entity.Subscription{
+		Id:          1,
+		ServiceName: "Test Service",
+		Price:       100,
+		UserId:      uuid.New(),
+		StartDate:   time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+	reason := "too expensive"
+
+	// Тестовый пример 1: Отмена с указанным месяцем и причиной
+	endDate := time.Date(2023, time.June, 1, 0, 0, 0, 0, time.UTC)
+	cancelled := *sub
+	cancelled.EndDate = &endDate
+	cancelled.CancellationReason = &reason
+	mockRepo.On("ReadSubscription", ctx, int64(1)).Return(sub, nil).Once()
+	mockRepo.On("CancelSubscription", ctx, int64(1), endDate, &reason).Return(&cancelled, nil).Once()
+	res, err := service.CancelSubscription(ctx, 1, &entity.CancelRequest{
+		EndDate: func() *string { s := "06-2023"; return &s }(),
+		Reason:  &reason,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &cancelled, res)
+	mockRepo.AssertExpectations(t)
+
+	// Тестовый пример 2: Без месяца подписка завершается текущим месяцем, пустая причина не сохраняется
+	currentMonth := mock.MatchedBy(func(d time.Time) bool {
+		now := time.Now().UTC()
+		return d.Year() == now.Year() && d.Month() == now.Month() && d.Day() == 1
+	})
+	mockRepo.On("ReadSubscription", ctx, int64(1)).Return(sub, nil).Once()
+	mockRepo.On("CancelSubscription", ctx, int64(1), currentMonth, (*string)(nil)).Return(&cancelled, nil).Once()
+	_, err = service.CancelSubscription(ctx, 1, &entity.CancelRequest{Reason: func() *string { s := " "; return &s }()})
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+
+	// Тестовый пример 3: Месяц окончания раньше начала подписки
+	mockRepo.On("ReadSubscription", ctx, int64(1)).Return(sub, nil).Once()
+	res, err = service.CancelSubscription(ctx, 1, &entity.CancelRequest{
+		EndDate: func() *string { s := "12-2022"; return &s }(),
+	})
+	assert.ErrorIs(t, err, myError.ErrDateRange)
+	assert.Nil(t, res)
+	mockRepo.AssertExpectations(t)
+
+	// Тестовый пример 4: Подписка не найдена
+	mockRepo.On("ReadSubscription", ctx, int64(2)).Return((*entity.Subscription)(nil), myError.ErrSubscriptionNotFound).Once()
+	res, err = service.CancelSubscription(ctx, 2, &entity.CancelRequest{})
+	assert.ErrorIs(t, err, myError.ErrSubscriptionNotFound)
+	assert.Nil(t, res)
+	mockRepo.AssertExpectations(t)
+
+	// Тестовый пример 5: Недопустимый аргумент
+	res, err = service.CancelSubscription(ctx, 1, nil)
+	assert.Error(t, err)
+	assert.Nil(t, res)
+}
+
 // TestListSubscriptions тестирует вывод всех подписок
 func TestListSubscriptions(t *testing.T) {
 	mockRepo := new(MockRepo)
@@ -305,19 +485,31 @@ func TestListSubscriptions(t *testing.T) {
 			StartDate:   time.Date(2023, time.February, 1, 0, 0, 0, 0, time.UTC),
 		},
 	}
-	mockRepo.On("ListSubscriptions", ctx).Return(expectedSubs, nil).Once()
-	subs, err := service.ListSubscriptions(ctx)
+	mockRepo.On("ListSubscriptions", ctx, (*entity.SubscriptionFilter)(nil)).Return(expectedSubs, nil).Once()
+	subs, err := service.ListSubscriptions(ctx, nil)
 	assert.NoError(t, err)
 	assert.Equal(t, expectedSubs, subs)
 	mockRepo.AssertExpectations(t)
 
 	// Тестовый пример 2: Ошибка репозитория
-	mockRepo.On("ListSubscriptions", ctx).Return([]*entity.Subscription{}, errors.New("db error")).Once()
-	subs, err = service.ListSubscriptions(ctx)
+	mockRepo.On("ListSubscriptions", ctx, (*entity.SubscriptionFilter)(nil)).Return([]*entity.Subscription{}, errors.New("db error")).Once()
+	subs, err = service.ListSubscriptions(ctx, nil)
 	assert.Error(t, err)
 	assert.Nil(t, subs)
 	assert.Contains(t, err.Error(), "db error")
 	mockRepo.AssertExpectations(t)
+
+	// Тестовый пример 3: Категория и теги фильтра нормализуются
+	category := " Streaming "
+	normalized := &entity.SubscriptionFilter{
+		Category: func() *string { s := "streaming"; return &s }(),
+		Tags:     []string{"family", "work"},
+	}
+	mockRepo.On("ListSubscriptions", ctx, normalized).Return(expectedSubs, nil).Once()
+	subs, err = service.ListSubscriptions(ctx, &entity.SubscriptionFilter{Category: &category, Tags: []string{"Family", "work", " family"}})
+	assert.NoError(t, err)
+	assert.Equal(t, expectedSubs, subs)
+	mockRepo.AssertExpectations(t)
 }
 
 // TestTotalCost тестирует вывод суммарной стоимости подписок
@@ -329,26 +521,75 @@ func TestTotalCost(t *testing.T) {
 	from := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
 	to := time.Date(2023, time.December, 31, 0, 0, 0, 0, time.UTC)
 	userID := uuid.New()
-	serviceName := "Test Service"
+	filter := &entity.CostFilter{
+		SubscriptionFilter: entity.SubscriptionFilter{UserId: &userID},
+		ServiceName:        &entity.ServiceNameFilter{Name: "Test Service", Mode: entity.MatchFuzzy},
+	}
 
 	// Тестовый пример 1: Успешный расчет общей стоимости
-	mockRepo.On("TotalCost", ctx, resetDay(from), resetDay(to), &userID, &serviceName).Return(1000, nil).Once()
-	cost, err := service.TotalCost(ctx, from, to, &userID, &serviceName)
+	mockRepo.On("TotalCost", ctx, resetDay(from), resetDay(to), filter).Return(1000, nil).Once()
+	cost, err := service.TotalCost(ctx, from, to, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 1000, cost)
 	mockRepo.AssertExpectations(t)
 
 	// Тестовый пример 2: Недопустимый диапазон дат (до < от)
-	cost, err = service.TotalCost(ctx, to, from, &userID, &serviceName)
+	cost, err = service.TotalCost(ctx, to, from, filter)
 	assert.Error(t, err)
 	assert.Equal(t, 0, cost)
 	assert.Contains(t, err.Error(), "to must be >= from")
 
 	// Тестовый пример 3: Ошибка репозитория
-	mockRepo.On("TotalCost", ctx, resetDay(from), resetDay(to), (*uuid.UUID)(nil), (*string)(nil)).Return(0, errors.New("db error")).Once()
-	cost, err = service.TotalCost(ctx, from, to, nil, nil)
+	mockRepo.On("TotalCost", ctx, resetDay(from), resetDay(to), (*entity.CostFilter)(nil)).Return(0, errors.New("db error")).Once()
+	cost, err = service.TotalCost(ctx, from, to, nil)
 	assert.Error(t, err)
 	assert.Equal(t, 0, cost)
 	assert.Contains(t, err.Error(), "db error")
 	mockRepo.AssertExpectations(t)
 }
+
+// TestCostBreakdown тестирует вывод стоимости подписок в разрезе категорий и тегов
+func TestCostBreakdown(t *testing.T) {
+	mockRepo := new(MockRepo)
+	service := NewAggregationService(mockRepo)
+	ctx := context.Background()
+
+	from := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2023, time.December, 1, 0, 0, 0, 0, time.UTC)
+	streaming := "streaming"
+	groups := []*entity.CostGroup{{Key: &streaming, TotalCost: 900}, {Key: nil, TotalCost: 100}}
+
+	// Тестовый пример 1: Успешный расчет, теги фильтра нормализуются
+	normalized := &entity.CostFilter{SubscriptionFilter: entity.SubscriptionFilter{Tags: []string{"work"}}}
+	mockRepo.On("CostBreakdown", ctx, from, to, normalized, entity.GroupByCategory).Return(groups, nil).Once()
+	res, err := service.CostBreakdown(ctx, from, to, &entity.CostFilter{SubscriptionFilter: entity.SubscriptionFilter{Tags: []string{" Work"}}}, entity.GroupByCategory)
+	assert.NoError(t, err)
+	assert.Equal(t, groups, res)
+	mockRepo.AssertExpectations(t)
+
+	// Тестовый пример 2: Недопустимый диапазон дат
+	res, err = service.CostBreakdown(ctx, to, from, nil, entity.GroupByTag)
+	assert.Error(t, err)
+	assert.Nil(t, res)
+}
+
+// TestSubscriptionsVersion тестирует получение версии данных о подписках
+func TestSubscriptionsVersion(t *testing.T) {
+	mockRepo := new(MockRepo)
+	service := NewAggregationService(mockRepo)
+	ctx := context.Background()
+
+	// Тестовый пример 1: Успешное получение версии
+	version := &entity.TableVersion{Version: 42, UpdatedAt: time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)}
+	mockRepo.On("SubscriptionsVersion", ctx).Return(version, nil).Once()
+	res, err := service.SubscriptionsVersion(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, version, res)
+
+	// Тестовый пример 2: Ошибка репозитория
+	mockRepo.On("SubscriptionsVersion", ctx).Return((*entity.TableVersion)(nil), errors.New("db error")).Once()
+	res, err = service.SubscriptionsVersion(ctx)
+	assert.Error(t, err)
+	assert.Nil(t, res)
+	mockRepo.AssertExpectations(t)
+}
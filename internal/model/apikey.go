@@ -0,0 +1,163 @@
+package model
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/auth"
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/Ararat25/subscription-aggregation-service/internal/logger"
+	"github.com/Ararat25/subscription-aggregation-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	apiKeyPrefix       = "sas_"      // префикс API-ключей сервиса
+	apiKeyPrefixLength = 12          // длина начала ключа, сохраняемого для опознания
+	apiKeyTouchPeriod  = time.Minute // как часто обновляется время последнего использования ключа
+)
+
+// APIKeyManager - структура для сервиса API-ключей и проверки ключей при аутентификации
+type APIKeyManager struct {
+	Storage repository.APIKeyRepo // объект для работы с бд
+	now     func() time.Time      // текущее время
+}
+
+// NewAPIKeyManager возвращает новый объект структуры APIKeyManager
+func NewAPIKeyManager(storage repository.APIKeyRepo) *APIKeyManager {
+	return &APIKeyManager{
+		Storage: storage,
+		now:     time.Now,
+	}
+}
+
+// CreateAPIKey создает API-ключ. Сам ключ возвращается только здесь, в бд сохраняется его хэш.
+// Ключ без права admin действует от имени пользователя и должен иметь user_id
+func (km *APIKeyManager) CreateAPIKey(ctx context.Context, req *entity.APIKeyRequest) (*entity.APIKey, error) {
+	if req == nil {
+		return nil, fmt.Errorf("invalid argument error")
+	}
+
+	key := &entity.APIKey{
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		UserId:    req.UserId,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if !key.HasScope(entity.ScopeAdmin) && key.UserId == nil {
+		return nil, myError.ErrAPIKeyUserRequired
+	}
+
+	secret, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	key.Key = secret
+	key.Prefix = secret[:apiKeyPrefixLength]
+	key.KeyHash = hashAPIKey(secret)
+
+	id, err := km.Storage.CreateAPIKey(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	key.Id = id
+
+	return key, nil
+}
+
+// ListAPIKeys возвращает список API-ключей без самих ключей
+func (km *APIKeyManager) ListAPIKeys(ctx context.Context) ([]*entity.APIKey, error) {
+	keys, err := km.Storage.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает API-ключ, после этого ключ не проходит аутентификацию
+func (km *APIKeyManager) RevokeAPIKey(ctx context.Context, id int64) error {
+	err := km.Storage.RevokeAPIKey(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Verify проверяет API-ключ и возвращает клиента с ролью по правам ключа и тенантом ключа. Отозванный, истекший
+// или неизвестный ключ не проходит проверку. Время последнего использования обновляется не чаще раза в минуту
+func (km *APIKeyManager) Verify(ctx context.Context, token string) (*auth.Identity, error) {
+	key, err := km.Storage.FindAPIKey(ctx, hashAPIKey(token))
+	if err != nil {
+		if errors.Is(err, myError.ErrAPIKeyNotFound) {
+			return nil, fmt.Errorf("%w: unknown api key", auth.ErrInvalidToken)
+		}
+		return nil, err
+	}
+
+	now := km.now()
+	switch {
+	case key.RevokedAt != nil:
+		return nil, fmt.Errorf("%w: api key %d is revoked", auth.ErrInvalidToken, key.Id)
+	case key.ExpiresAt != nil && !now.Before(*key.ExpiresAt):
+		return nil, fmt.Errorf("%w: api key %d is expired", auth.ErrInvalidToken, key.Id)
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchPeriod {
+		err = km.Storage.TouchAPIKey(ctx, key.Id, now)
+		if err != nil {
+			logger.Log.Warn("error updating api key last use", zap.Int64("api_key_id", key.Id), zap.Error(err))
+		}
+	}
+
+	id := &auth.Identity{
+		Subject:  "api_key:" + strconv.FormatInt(key.Id, 10),
+		Role:     apiKeyRole(key),
+		Method:   auth.MethodAPIKey,
+		KeyID:    key.Id,
+		TenantID: key.TenantId,
+	}
+	if key.UserId != nil {
+		id.UserID = *key.UserId
+	}
+
+	return id, nil
+}
+
+// apiKeyRole возвращает роль клиента с API-ключом: admin - администратор, write - редактор, read - наблюдатель
+func apiKeyRole(key *entity.APIKey) auth.Role {
+	switch {
+	case key.HasScope(entity.ScopeAdmin):
+		return auth.RoleAdmin
+	case key.HasScope(entity.ScopeWrite):
+		return auth.RoleEditor
+	default:
+		return auth.RoleViewer
+	}
+}
+
+// generateAPIKey генерирует новый случайный API-ключ
+func generateAPIKey() (string, error) {
+	buf := make([]byte, 32)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashAPIKey возвращает хэш API-ключа, под которым ключ хранится в бд
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
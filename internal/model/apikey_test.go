@@ -0,0 +1,148 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/auth"
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/Ararat25/subscription-aggregation-service/internal/logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+// MockAPIKeyRepo это mock реализация хранилища API-ключей
+type MockAPIKeyRepo struct {
+	mock.Mock
+}
+
+// CreateAPIKey имитирует создание API-ключа
+func (m *MockAPIKeyRepo) CreateAPIKey(ctx context.Context, k *entity.APIKey) (int64, error) {
+	args := m.Called(ctx, k)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// ListAPIKeys имитирует вывод списка API-ключей
+func (m *MockAPIKeyRepo) ListAPIKeys(ctx context.Context) ([]*entity.APIKey, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*entity.APIKey), args.Error(1)
+}
+
+// RevokeAPIKey имитирует отзыв API-ключа
+func (m *MockAPIKeyRepo) RevokeAPIKey(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// FindAPIKey имитирует поиск API-ключа по хэшу
+func (m *MockAPIKeyRepo) FindAPIKey(ctx context.Context, keyHash string) (*entity.APIKey, error) {
+	args := m.Called(ctx, keyHash)
+	key, _ := args.Get(0).(*entity.APIKey)
+	return key, args.Error(1)
+}
+
+// TouchAPIKey имитирует обновление времени последнего использования ключа
+func (m *MockAPIKeyRepo) TouchAPIKey(ctx context.Context, id int64, usedAt time.Time) error {
+	args := m.Called(ctx, id, usedAt)
+	return args.Error(0)
+}
+
+// TestCreateAPIKey тестирует создание API-ключа
+func TestCreateAPIKey(t *testing.T) {
+	mockRepo := new(MockAPIKeyRepo)
+	manager := NewAPIKeyManager(mockRepo)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	// Тестовый пример 1: Ключ генерируется, в бд сохраняются только его начало и хэш
+	var saved *entity.APIKey
+	mockRepo.On("CreateAPIKey", ctx, mock.AnythingOfType("*entity.APIKey")).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(*entity.APIKey) }).
+		Return(int64(3), nil).Once()
+	key, err := manager.CreateAPIKey(ctx, &entity.APIKeyRequest{Name: "export", Scopes: []entity.Scope{entity.ScopeRead}, UserId: &userID})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), key.Id)
+	assert.True(t, strings.HasPrefix(key.Key, apiKeyPrefix))
+	assert.Equal(t, key.Key[:apiKeyPrefixLength], saved.Prefix)
+	assert.Equal(t, hashAPIKey(key.Key), saved.KeyHash)
+	assert.NotEqual(t, key.Key, saved.KeyHash)
+	mockRepo.AssertExpectations(t)
+
+	// Тестовый пример 2: Ключу без права admin нужен пользователь
+	_, err = manager.CreateAPIKey(ctx, &entity.APIKeyRequest{Name: "export", Scopes: []entity.Scope{entity.ScopeRead}})
+	assert.ErrorIs(t, err, myError.ErrAPIKeyUserRequired)
+
+	// Тестовый пример 3: Ключ администратора создается без пользователя
+	mockRepo.On("CreateAPIKey", ctx, mock.AnythingOfType("*entity.APIKey")).Return(int64(4), nil).Once()
+	_, err = manager.CreateAPIKey(ctx, &entity.APIKeyRequest{Name: "ops", Scopes: []entity.Scope{entity.ScopeAdmin}})
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestVerifyAPIKey тестирует аутентификацию по API-ключу
+func TestVerifyAPIKey(t *testing.T) {
+	logger.Log = zap.NewNop()
+
+	mockRepo := new(MockAPIKeyRepo)
+	manager := NewAPIKeyManager(mockRepo)
+	now := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	manager.now = func() time.Time { return now }
+	ctx := context.Background()
+	userID := uuid.New()
+	hash := hashAPIKey("sas_key")
+
+	// Тестовый пример 1: Действующий ключ пользователя, время использования обновляется, тенант берется из ключа
+	tenantID := uuid.New()
+	mockRepo.On("FindAPIKey", ctx, hash).
+		Return(&entity.APIKey{Id: 7, Scopes: []entity.Scope{entity.ScopeRead}, UserId: &userID, TenantId: tenantID}, nil).Once()
+	mockRepo.On("TouchAPIKey", ctx, int64(7), now).Return(nil).Once()
+	id, err := manager.Verify(ctx, "sas_key")
+	assert.NoError(t, err)
+	assert.Equal(t, &auth.Identity{
+		Subject:  "api_key:7",
+		UserID:   userID,
+		Role:     auth.RoleViewer,
+		Method:   auth.MethodAPIKey,
+		KeyID:    7,
+		TenantID: tenantID,
+	}, id)
+	mockRepo.AssertExpectations(t)
+
+	// Тестовый пример 2: Недавно использованный ключ не обновляется, ошибка обновления не мешает аутентификации
+	recent := now.Add(-10 * time.Second)
+	mockRepo.On("FindAPIKey", ctx, hash).
+		Return(&entity.APIKey{Id: 7, Scopes: []entity.Scope{entity.ScopeAdmin}, LastUsedAt: &recent}, nil).Once()
+	id, err = manager.Verify(ctx, "sas_key")
+	assert.NoError(t, err)
+	assert.True(t, id.IsAdmin())
+
+	stale := now.Add(-time.Hour)
+	mockRepo.On("FindAPIKey", ctx, hash).
+		Return(&entity.APIKey{Id: 7, Scopes: []entity.Scope{entity.ScopeAdmin}, LastUsedAt: &stale}, nil).Once()
+	mockRepo.On("TouchAPIKey", ctx, int64(7), now).Return(errors.New("db error")).Once()
+	_, err = manager.Verify(ctx, "sas_key")
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+
+	// Тестовый пример 3: Отозванный, истекший и неизвестный ключи не проходят проверку
+	revoked := now.Add(-time.Minute)
+	mockRepo.On("FindAPIKey", ctx, hash).Return(&entity.APIKey{Id: 7, RevokedAt: &revoked}, nil).Once()
+	_, err = manager.Verify(ctx, "sas_key")
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+
+	expired := now
+	mockRepo.On("FindAPIKey", ctx, hash).Return(&entity.APIKey{Id: 7, ExpiresAt: &expired}, nil).Once()
+	_, err = manager.Verify(ctx, "sas_key")
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+
+	mockRepo.On("FindAPIKey", ctx, hashAPIKey("sas_unknown")).Return(nil, myError.ErrAPIKeyNotFound).Once()
+	_, err = manager.Verify(ctx, "sas_unknown")
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/config"
+	"github.com/Ararat25/subscription-aggregation-service/internal/logger"
+	"github.com/Ararat25/subscription-aggregation-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+// SubscriptionArchiver - фоновая задача, переносящая давно закончившиеся подписки в архив.
+// Стоимость за период и сводка пользователя учитывают архивные подписки, списки и изменение подписок - нет
+type SubscriptionArchiver struct {
+	Storage repository.ArchiveRepo // объект для работы с бд
+	conf    config.ArchiveConfig   // настройки архивации
+}
+
+// NewSubscriptionArchiver возвращает новый объект структуры SubscriptionArchiver
+func NewSubscriptionArchiver(storage repository.ArchiveRepo, conf config.ArchiveConfig) *SubscriptionArchiver {
+	return &SubscriptionArchiver{
+		Storage: storage,
+		conf:    conf,
+	}
+}
+
+// Run архивирует подписки при запуске и затем периодически, пока не будет отменен контекст.
+// При AfterMonths = 0 архивация выключена
+func (sa *SubscriptionArchiver) Run(ctx context.Context) {
+	if sa.conf.AfterMonths <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(sa.conf.Interval)
+	defer ticker.Stop()
+
+	for {
+		sa.archive(ctx, archiveCutoff(time.Now(), sa.conf.AfterMonths))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// archive переносит в архив пачками все подписки, закончившиеся раньше месяца endedBefore,
+// и возвращает количество перенесенных
+func (sa *SubscriptionArchiver) archive(ctx context.Context, endedBefore time.Time) int {
+	total := 0
+	for ctx.Err() == nil {
+		archived, err := sa.Storage.ArchiveSubscriptions(ctx, endedBefore, sa.conf.BatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Log.Error("failed to archive subscriptions", zap.Error(err))
+			}
+			break
+		}
+
+		total += archived
+		if archived < sa.conf.BatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		logger.Log.Info("subscriptions archived", zap.Int("count", total), zap.Time("ended_before", endedBefore))
+	}
+
+	return total
+}
+
+// archiveCutoff возвращает первое число месяца, на months месяцев более раннего, чем месяц now:
+// подписки, закончившиеся раньше него, закончились больше months месяцев назад
+func archiveCutoff(now time.Time, months int) time.Time {
+	return time.Date(now.Year(), now.Month()-time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+}
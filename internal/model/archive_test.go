@@ -0,0 +1,61 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/config"
+	"github.com/Ararat25/subscription-aggregation-service/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+// MockArchiveRepo это mock реализация хранилища архива подписок
+type MockArchiveRepo struct {
+	mock.Mock
+}
+
+// ArchiveSubscriptions имитирует перенос подписок в архив
+func (m *MockArchiveRepo) ArchiveSubscriptions(ctx context.Context, endedBefore time.Time, limit int) (int, error) {
+	args := m.Called(ctx, endedBefore, limit)
+	return args.Int(0), args.Error(1)
+}
+
+// TestArchiveCutoff тестирует расчет месяца, раньше которого подписки уходят в архив
+func TestArchiveCutoff(t *testing.T) {
+	// Тестовый пример 1: Месяц отсчитывается от первого числа текущего месяца
+	assert.Equal(t, time.Date(2024, time.October, 1, 0, 0, 0, 0, time.UTC),
+		archiveCutoff(time.Date(2026, time.October, 19, 15, 30, 0, 0, time.UTC), 24))
+
+	// Тестовый пример 2: Переход через начало года
+	assert.Equal(t, time.Date(2025, time.November, 1, 0, 0, 0, 0, time.UTC),
+		archiveCutoff(time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC), 3))
+}
+
+// TestArchive тестирует перенос подписок в архив пачками
+func TestArchive(t *testing.T) {
+	logger.Log = zap.NewNop()
+	ctx := context.Background()
+	cutoff := time.Date(2024, time.October, 1, 0, 0, 0, 0, time.UTC)
+
+	// Тестовый пример 1: Пачки переносятся, пока не останется неполная
+	repo := new(MockArchiveRepo)
+	archiver := NewSubscriptionArchiver(repo, config.ArchiveConfig{AfterMonths: 24, BatchSize: 2})
+	repo.On("ArchiveSubscriptions", ctx, cutoff, 2).Return(2, nil).Twice()
+	repo.On("ArchiveSubscriptions", ctx, cutoff, 2).Return(1, nil).Once()
+
+	assert.Equal(t, 5, archiver.archive(ctx, cutoff))
+	repo.AssertExpectations(t)
+
+	// Тестовый пример 2: Ошибка останавливает архивацию до следующего запуска
+	repo = new(MockArchiveRepo)
+	archiver = NewSubscriptionArchiver(repo, config.ArchiveConfig{AfterMonths: 24, BatchSize: 2})
+	repo.On("ArchiveSubscriptions", ctx, cutoff, 2).Return(2, nil).Once()
+	repo.On("ArchiveSubscriptions", ctx, cutoff, 2).Return(0, errors.New("db error")).Once()
+
+	assert.Equal(t, 2, archiver.archive(ctx, cutoff))
+	repo.AssertExpectations(t)
+}
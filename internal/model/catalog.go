@@ -0,0 +1,112 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/Ararat25/subscription-aggregation-service/internal/repository"
+)
+
+// ServiceCatalog - структура для сервиса каталога сервисов
+type ServiceCatalog struct {
+	Storage repository.CatalogRepo // объект для работы с бд
+}
+
+// NewServiceCatalog возвращает новый объект структуры ServiceCatalog
+func NewServiceCatalog(storage repository.CatalogRepo) *ServiceCatalog {
+	return &ServiceCatalog{
+		Storage: storage,
+	}
+}
+
+// CreateService добавляет сервис в каталог
+func (sc *ServiceCatalog) CreateService(ctx context.Context, req *entity.ServiceRequest) (*entity.Service, error) {
+	if req == nil {
+		return nil, fmt.Errorf("invalid argument error")
+	}
+
+	service := parseServiceRequest(req)
+
+	id, err := sc.Storage.CreateService(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+	service.Id = id
+
+	return service, nil
+}
+
+// ReadService возвращает сервис из каталога по id
+func (sc *ServiceCatalog) ReadService(ctx context.Context, id int64) (*entity.Service, error) {
+	service, err := sc.Storage.ReadService(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return service, nil
+}
+
+// UpdateService заменяет данные сервиса в каталоге
+func (sc *ServiceCatalog) UpdateService(ctx context.Context, id int64, req *entity.ServiceRequest) (*entity.Service, error) {
+	if req == nil {
+		return nil, fmt.Errorf("invalid argument error")
+	}
+
+	service := parseServiceRequest(req)
+	service.Id = id
+
+	err := sc.Storage.UpdateService(ctx, service)
+	if err != nil {
+		return nil, err
+	}
+
+	return service, nil
+}
+
+// DeleteService удаляет сервис из каталога
+func (sc *ServiceCatalog) DeleteService(ctx context.Context, id int64) error {
+	err := sc.Storage.DeleteService(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListServices возвращает каталог сервисов
+func (sc *ServiceCatalog) ListServices(ctx context.Context) ([]*entity.Service, error) {
+	services, err := sc.Storage.ListServices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return services, nil
+}
+
+// parseServiceRequest преобразует запрос в запись каталога: убирает пробелы по краям названий,
+// пустые псевдонимы и псевдонимы, повторяющие название или друг друга без учета регистра, и приводит категорию к нижнему регистру
+func parseServiceRequest(req *entity.ServiceRequest) *entity.Service {
+	name := strings.TrimSpace(req.Name)
+
+	seen := map[string]bool{strings.ToLower(name): true}
+	aliases := make([]string, 0, len(req.Aliases))
+	for _, alias := range req.Aliases {
+		alias = strings.TrimSpace(alias)
+		key := strings.ToLower(alias)
+		if alias == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		aliases = append(aliases, alias)
+	}
+
+	return &entity.Service{
+		Name:         name,
+		Aliases:      aliases,
+		Category:     normalizeCategory(req.Category),
+		DefaultPrice: req.DefaultPrice,
+		Website:      req.Website,
+	}
+}
@@ -0,0 +1,100 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockCatalogRepo это mock реализация хранилища каталога сервисов
+type MockCatalogRepo struct {
+	mock.Mock
+}
+
+// CreateService имитирует добавление сервиса в каталог
+func (m *MockCatalogRepo) CreateService(ctx context.Context, s *entity.Service) (int64, error) {
+	args := m.Called(ctx, s)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// ReadService имитирует чтение сервиса из каталога
+func (m *MockCatalogRepo) ReadService(ctx context.Context, id int64) (*entity.Service, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*entity.Service), args.Error(1)
+}
+
+// UpdateService имитирует обновление сервиса в каталоге
+func (m *MockCatalogRepo) UpdateService(ctx context.Context, s *entity.Service) error {
+	args := m.Called(ctx, s)
+	return args.Error(0)
+}
+
+// DeleteService имитирует удаление сервиса из каталога
+func (m *MockCatalogRepo) DeleteService(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// ListServices имитирует вывод каталога сервисов
+func (m *MockCatalogRepo) ListServices(ctx context.Context) ([]*entity.Service, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*entity.Service), args.Error(1)
+}
+
+// TestCreateService тестирует добавление сервиса в каталог
+func TestCreateService(t *testing.T) {
+	mockRepo := new(MockCatalogRepo)
+	catalog := NewServiceCatalog(mockRepo)
+	ctx := context.Background()
+
+	// Тестовый пример 1: Названия очищаются, повторы псевдонимов отбрасываются
+	req := &entity.ServiceRequest{
+		Name:    " Netflix ",
+		Aliases: []string{"netflix", "Нетфликс", " нетфликс", ""},
+	}
+	expected := &entity.Service{Name: "Netflix", Aliases: []string{"Нетфликс"}}
+	mockRepo.On("CreateService", ctx, expected).Return(int64(3), nil).Once()
+	service, err := catalog.CreateService(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), service.Id)
+	assert.Equal(t, []string{"Нетфликс"}, service.Aliases)
+	mockRepo.AssertExpectations(t)
+
+	// Тестовый пример 2: Написание занято другим сервисом
+	mockRepo.On("CreateService", ctx, mock.Anything).Return(int64(0), myError.ErrServiceNameTaken).Once()
+	service, err = catalog.CreateService(ctx, &entity.ServiceRequest{Name: "Netflix"})
+	assert.ErrorIs(t, err, myError.ErrServiceNameTaken)
+	assert.Nil(t, service)
+	mockRepo.AssertExpectations(t)
+
+	// Тестовый пример 3: Недопустимый аргумент
+	service, err = catalog.CreateService(ctx, nil)
+	assert.Error(t, err)
+	assert.Nil(t, service)
+}
+
+// TestUpdateService тестирует обновление сервиса в каталоге
+func TestUpdateService(t *testing.T) {
+	mockRepo := new(MockCatalogRepo)
+	catalog := NewServiceCatalog(mockRepo)
+	ctx := context.Background()
+
+	// Тестовый пример 1: Успешное обновление
+	expected := &entity.Service{Id: 3, Name: "Netflix", Aliases: []string{}}
+	mockRepo.On("UpdateService", ctx, expected).Return(nil).Once()
+	service, err := catalog.UpdateService(ctx, 3, &entity.ServiceRequest{Name: "Netflix"})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, service)
+	mockRepo.AssertExpectations(t)
+
+	// Тестовый пример 2: Сервис не найден
+	mockRepo.On("UpdateService", ctx, mock.Anything).Return(myError.ErrServiceNotFound).Once()
+	service, err = catalog.UpdateService(ctx, 4, &entity.ServiceRequest{Name: "Spotify"})
+	assert.ErrorIs(t, err, myError.ErrServiceNotFound)
+	assert.Nil(t, service)
+	mockRepo.AssertExpectations(t)
+}
@@ -14,6 +14,63 @@ type Service interface {
 	ReadSubscription(ctx context.Context, id int64) (*entity.Subscription, error)
 	UpdateSubscription(ctx context.Context, s *entity.SubscriptionRequest) error
 	DeleteSubscription(ctx context.Context, id int64) error
-	ListSubscriptions(ctx context.Context) ([]*entity.Subscription, error)
-	TotalCost(ctx context.Context, from, to time.Time, userID *uuid.UUID, serviceName *string) (int, error)
+	CancelSubscription(ctx context.Context, id int64, req *entity.CancelRequest) (*entity.Subscription, error)
+	ListSubscriptions(ctx context.Context, filter *entity.SubscriptionFilter) ([]*entity.Subscription, error)
+	TotalCost(ctx context.Context, from, to time.Time, filter *entity.CostFilter) (int, error)
+	CostBreakdown(ctx context.Context, from, to time.Time, filter *entity.CostFilter, groupBy entity.GroupBy) ([]*entity.CostGroup, error)
+	ScopeCostFilter(ctx context.Context, filter *entity.CostFilter) (*entity.CostFilter, error)
+	SearchSubscriptions(ctx context.Context, query string, limit int) ([]*entity.SubscriptionMatch, error)
+	SuggestServiceNames(ctx context.Context, query string, limit int) ([]*entity.ServiceSuggestion, error)
+	UserSummary(ctx context.Context, userID uuid.UUID) (*entity.UserSummary, error)
+	SubscriptionsVersion(ctx context.Context) (*entity.TableVersion, error)
+}
+
+// CatalogService интерфейс для сервиса каталога сервисов
+type CatalogService interface {
+	CreateService(ctx context.Context, s *entity.ServiceRequest) (*entity.Service, error)
+	ReadService(ctx context.Context, id int64) (*entity.Service, error)
+	UpdateService(ctx context.Context, id int64, s *entity.ServiceRequest) (*entity.Service, error)
+	DeleteService(ctx context.Context, id int64) error
+	ListServices(ctx context.Context) ([]*entity.Service, error)
+}
+
+// WebhookService интерфейс для сервиса вебхуков
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, w *entity.WebhookRequest) (*entity.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]*entity.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int64) error
+	ListDeliveries(ctx context.Context, webhookID int64) ([]*entity.WebhookDelivery, error)
+	Redeliver(ctx context.Context, deliveryID int64) error
+}
+
+// APIKeyService интерфейс для сервиса API-ключей
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, req *entity.APIKeyRequest) (*entity.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]*entity.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int64) error
+}
+
+// TenantService интерфейс для сервиса тенантов
+type TenantService interface {
+	CreateTenant(ctx context.Context, req *entity.TenantRequest) (*entity.Tenant, error)
+	ListTenants(ctx context.Context) ([]*entity.Tenant, error)
+	TenantCosts(ctx context.Context, from, to time.Time) ([]*entity.TenantCost, error)
+}
+
+// SystemService интерфейс для сервиса состояния развертывания
+type SystemService interface {
+	PoolStats(ctx context.Context) (*entity.PoolStats, error)
+}
+
+// CostRollupService интерфейс для сервиса помесячных итогов стоимости
+type CostRollupService interface {
+	RebuildMonthlyCosts(ctx context.Context) (*entity.MonthlyCostsRebuild, error)
+	CheckMonthlyCosts(ctx context.Context) (*entity.MonthlyCostsCheck, error)
+}
+
+// PrivacyService интерфейс для сервиса выгрузки и удаления данных пользователя
+type PrivacyService interface {
+	ExportUserData(ctx context.Context, userID uuid.UUID) (*entity.UserData, error)
+	EraseUserData(ctx context.Context, userID uuid.UUID) (*entity.ErasureReceipt, error)
+	ReadErasureReceipt(ctx context.Context, id uuid.UUID) (*entity.ErasureReceipt, error)
 }
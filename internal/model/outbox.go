@@ -0,0 +1,136 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/config"
+	"github.com/Ararat25/subscription-aggregation-service/internal/logger"
+	"github.com/Ararat25/subscription-aggregation-service/internal/publisher"
+	"github.com/Ararat25/subscription-aggregation-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+// purgeBatchSize - количество устаревших событий или доставок, удаляемых одной транзакцией
+const purgeBatchSize = 1000
+
+// OutboxRelay - ретранслятор, публикующий события из outbox.
+// Событие отмечается опубликованным только после успешной публикации, поэтому доставка выполняется не менее одного раза
+type OutboxRelay struct {
+	Storage   repository.OutboxRepo // объект для работы с бд
+	Publisher publisher.Publisher   // публикатор событий
+	conf      config.OutboxConfig   // настройки ретранслятора
+}
+
+// NewOutboxRelay возвращает новый объект структуры OutboxRelay
+func NewOutboxRelay(storage repository.OutboxRepo, pub publisher.Publisher, conf config.OutboxConfig) *OutboxRelay {
+	return &OutboxRelay{
+		Storage:   storage,
+		Publisher: pub,
+		conf:      conf,
+	}
+}
+
+// Run периодически публикует накопившиеся события и раз в PurgeInterval удаляет опубликованные события старше
+// Retention, пока не будет отменен контекст
+func (or *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(or.conf.PollInterval)
+	defer ticker.Stop()
+
+	var nextPurge time.Time
+	for {
+		if or.conf.Retention > 0 && !time.Now().Before(nextPurge) {
+			or.purge(ctx, time.Now())
+			nextPurge = time.Now().Add(or.conf.PurgeInterval)
+		}
+
+		published := or.relayBatch(ctx)
+
+		// пачка заполнена целиком, значит в outbox могут оставаться события - публикуем их без ожидания
+		if published == or.conf.BatchSize && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// relayBatch публикует одну пачку событий и возвращает количество опубликованных.
+// После первой ошибки оставшиеся события пачки откладываются, чтобы сохранить порядок публикации
+func (or *OutboxRelay) relayBatch(ctx context.Context) int {
+	messages, err := or.Storage.ClaimOutbox(ctx, time.Now(), or.conf.Lease, or.conf.BatchSize)
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.Log.Error("failed to claim outbox messages", zap.Error(err))
+		}
+		return 0
+	}
+
+	published := 0
+	for i, msg := range messages {
+		err = or.Publisher.Publish(ctx, msg.Event)
+		if err != nil {
+			nextAttemptAt := time.Now().Add(backoff(msg.Attempts+1, or.conf.PollInterval, or.conf.MaxBackoff))
+			for _, rest := range messages[i:] {
+				markErr := or.Storage.MarkOutboxFailed(ctx, rest.Id, err.Error(), nextAttemptAt)
+				if markErr != nil {
+					logger.Log.Error("failed to save outbox error", zap.Int64("outbox_id", rest.Id), zap.Error(markErr))
+				}
+			}
+
+			logger.Log.Warn("failed to publish outbox message",
+				zap.Int64("outbox_id", msg.Id),
+				zap.String("event_type", string(msg.Event.Type)),
+				zap.Error(err),
+			)
+			return published
+		}
+
+		err = or.Storage.MarkOutboxPublished(ctx, msg.Id)
+		if err != nil {
+			// событие будет опубликовано повторно после истечения lease
+			logger.Log.Error("failed to mark outbox message published", zap.Int64("outbox_id", msg.Id), zap.Error(err))
+			return published
+		}
+		published++
+	}
+
+	return published
+}
+
+// purge удаляет события, опубликованные раньше срока хранения, и возвращает количество удаленных
+func (or *OutboxRelay) purge(ctx context.Context, now time.Time) int {
+	purged, err := purgeExpired(ctx, now.Add(-or.conf.Retention), or.Storage.PurgeOutbox)
+	if err != nil && ctx.Err() == nil {
+		logger.Log.Error("failed to purge outbox messages", zap.Error(err))
+	}
+
+	if purged > 0 {
+		logger.Log.Info("published outbox messages purged", zap.Int("count", purged))
+	}
+
+	return purged
+}
+
+// purgeExpired удаляет функцией purge пачками по purgeBatchSize записи старше before, пока пачка заполняется
+// целиком, и возвращает количество удаленных
+func purgeExpired(ctx context.Context, before time.Time, purge func(context.Context, time.Time, int) (int, error)) (int, error) {
+	total := 0
+	for ctx.Err() == nil {
+		purged, err := purge(ctx, before, purgeBatchSize)
+		if err != nil {
+			return total, err
+		}
+
+		total += purged
+		if purged < purgeBatchSize {
+			break
+		}
+	}
+
+	return total, nil
+}
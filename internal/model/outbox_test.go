@@ -0,0 +1,136 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/config"
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/Ararat25/subscription-aggregation-service/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+// MockOutboxRepo это mock реализация хранилища outbox
+type MockOutboxRepo struct {
+	mock.Mock
+}
+
+// ClaimOutbox имитирует выборку событий к публикации
+func (m *MockOutboxRepo) ClaimOutbox(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*entity.OutboxMessage, error) {
+	args := m.Called(ctx, now, lease, limit)
+	return args.Get(0).([]*entity.OutboxMessage), args.Error(1)
+}
+
+// MarkOutboxPublished имитирует отметку о публикации
+func (m *MockOutboxRepo) MarkOutboxPublished(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// MarkOutboxFailed имитирует сохранение ошибки публикации
+func (m *MockOutboxRepo) MarkOutboxFailed(ctx context.Context, id int64, errMsg string, nextAttemptAt time.Time) error {
+	args := m.Called(ctx, id, errMsg, nextAttemptAt)
+	return args.Error(0)
+}
+
+// PurgeOutbox имитирует удаление опубликованных событий
+func (m *MockOutboxRepo) PurgeOutbox(ctx context.Context, publishedBefore time.Time, limit int) (int, error) {
+	args := m.Called(ctx, publishedBefore, limit)
+	return args.Int(0), args.Error(1)
+}
+
+// MockPublisher это mock реализация публикатора событий
+type MockPublisher struct {
+	mock.Mock
+}
+
+// Publish имитирует публикацию события
+func (m *MockPublisher) Publish(ctx context.Context, event *entity.Event) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+// TestRelayBatch тестирует публикацию пачки событий из outbox
+func TestRelayBatch(t *testing.T) {
+	logger.Log = zap.NewNop()
+	ctx := context.Background()
+	conf := config.OutboxConfig{PollInterval: time.Second, BatchSize: 10, Lease: time.Minute, MaxBackoff: time.Minute}
+
+	first := &entity.OutboxMessage{Id: 1, Event: entity.NewEvent(entity.EventSubscriptionCreated, &entity.Subscription{Id: 1})}
+	second := &entity.OutboxMessage{Id: 2, Event: entity.NewEvent(entity.EventSubscriptionUpdated, &entity.Subscription{Id: 1})}
+	third := &entity.OutboxMessage{Id: 3, Event: entity.NewEvent(entity.EventSubscriptionDeleted, &entity.Subscription{Id: 1})}
+
+	// Тестовый пример 1: Все события опубликованы и отмечены
+	mockRepo := new(MockOutboxRepo)
+	mockPublisher := new(MockPublisher)
+	relay := NewOutboxRelay(mockRepo, mockPublisher, conf)
+
+	mockRepo.On("ClaimOutbox", ctx, mock.Anything, time.Minute, 10).Return([]*entity.OutboxMessage{first, second}, nil).Once()
+	mockPublisher.On("Publish", ctx, first.Event).Return(nil).Once()
+	mockPublisher.On("Publish", ctx, second.Event).Return(nil).Once()
+	mockRepo.On("MarkOutboxPublished", ctx, int64(1)).Return(nil).Once()
+	mockRepo.On("MarkOutboxPublished", ctx, int64(2)).Return(nil).Once()
+
+	assert.Equal(t, 2, relay.relayBatch(ctx))
+	mockRepo.AssertExpectations(t)
+	mockPublisher.AssertExpectations(t)
+
+	// Тестовый пример 2: Ошибка публикации откладывает текущее и последующие события
+	mockRepo = new(MockOutboxRepo)
+	mockPublisher = new(MockPublisher)
+	relay = NewOutboxRelay(mockRepo, mockPublisher, conf)
+
+	mockRepo.On("ClaimOutbox", ctx, mock.Anything, time.Minute, 10).Return([]*entity.OutboxMessage{first, second, third}, nil).Once()
+	mockPublisher.On("Publish", ctx, first.Event).Return(nil).Once()
+	mockPublisher.On("Publish", ctx, second.Event).Return(errors.New("broker unavailable")).Once()
+	mockRepo.On("MarkOutboxPublished", ctx, int64(1)).Return(nil).Once()
+	mockRepo.On("MarkOutboxFailed", ctx, int64(2), "broker unavailable", mock.Anything).Return(nil).Once()
+	mockRepo.On("MarkOutboxFailed", ctx, int64(3), "broker unavailable", mock.Anything).Return(nil).Once()
+
+	assert.Equal(t, 1, relay.relayBatch(ctx))
+	mockRepo.AssertExpectations(t)
+	mockPublisher.AssertExpectations(t)
+	mockPublisher.AssertNotCalled(t, "Publish", ctx, third.Event)
+
+	// Тестовый пример 3: Ошибка чтения outbox
+	mockRepo = new(MockOutboxRepo)
+	relay = NewOutboxRelay(mockRepo, new(MockPublisher), conf)
+
+	mockRepo.On("ClaimOutbox", ctx, mock.Anything, time.Minute, 10).Return([]*entity.OutboxMessage(nil), errors.New("db error")).Once()
+
+	assert.Equal(t, 0, relay.relayBatch(ctx))
+	mockRepo.AssertExpectations(t)
+}
+
+// TestPurgeOutbox тестирует удаление опубликованных событий старше срока хранения
+func TestPurgeOutbox(t *testing.T) {
+	logger.Log = zap.NewNop()
+	ctx := context.Background()
+	now := time.Date(2025, time.August, 15, 12, 0, 0, 0, time.UTC)
+	conf := config.OutboxConfig{Retention: 7 * 24 * time.Hour}
+	before := now.Add(-7 * 24 * time.Hour)
+
+	// Тестовый пример 1: События удаляются пачками, пока пачка заполняется целиком
+	mockRepo := new(MockOutboxRepo)
+	relay := NewOutboxRelay(mockRepo, new(MockPublisher), conf)
+
+	mockRepo.On("PurgeOutbox", ctx, before, purgeBatchSize).Return(purgeBatchSize, nil).Twice()
+	mockRepo.On("PurgeOutbox", ctx, before, purgeBatchSize).Return(3, nil).Once()
+
+	assert.Equal(t, 2*purgeBatchSize+3, relay.purge(ctx, now))
+	mockRepo.AssertExpectations(t)
+
+	// Тестовый пример 2: Ошибка хранилища прекращает удаление, удаленное раньше учитывается
+	mockRepo = new(MockOutboxRepo)
+	relay = NewOutboxRelay(mockRepo, new(MockPublisher), conf)
+
+	mockRepo.On("PurgeOutbox", ctx, before, purgeBatchSize).Return(purgeBatchSize, nil).Once()
+	mockRepo.On("PurgeOutbox", ctx, before, purgeBatchSize).Return(0, errors.New("db error")).Once()
+
+	assert.Equal(t, purgeBatchSize, relay.purge(ctx, now))
+	mockRepo.AssertExpectations(t)
+}
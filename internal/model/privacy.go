@@ -0,0 +1,84 @@
+package model
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/Ararat25/subscription-aggregation-service/internal/logger"
+	"github.com/Ararat25/subscription-aggregation-service/internal/repository"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// UserPrivacy - структура для сервиса выгрузки и удаления данных пользователя по его запросу
+type UserPrivacy struct {
+	Storage repository.PrivacyRepo // объект для работы с бд
+	signer  *entity.ReceiptSigner  // подпись квитанций об удалении секретным ключом сервиса
+	now     func() time.Time       // текущее время
+}
+
+// NewUserPrivacy возвращает новый объект структуры UserPrivacy, подписывающий квитанции ключом signer
+func NewUserPrivacy(storage repository.PrivacyRepo, signer *entity.ReceiptSigner) *UserPrivacy {
+	return &UserPrivacy{
+		Storage: storage,
+		signer:  signer,
+		now:     time.Now,
+	}
+}
+
+// ExportUserData возвращает все данные тенанта о пользователе вместе со сводкой по его подпискам на текущий месяц
+func (up *UserPrivacy) ExportUserData(ctx context.Context, userID uuid.UUID) (*entity.UserData, error) {
+	data, err := up.Storage.ExportUserData(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := up.now().UTC()
+	data.ExportedAt = now
+
+	// сводка считается, как в UserSummary, по подпискам вместе с архивными в порядке id
+	history := slices.Concat(data.Subscriptions, data.ArchivedSubscriptions)
+	slices.SortFunc(history, func(a, b *entity.Subscription) int {
+		return a.Id - b.Id
+	})
+	data.Summary = buildUserSummary(userID, history, resetDay(now))
+
+	return data, nil
+}
+
+// EraseUserData безвозвратно удаляет данные пользователя в тенанте и возвращает квитанцию об удалении.
+// Повторное удаление выдает новую квитанцию с нулевыми количествами
+func (up *UserPrivacy) EraseUserData(ctx context.Context, userID uuid.UUID) (*entity.ErasureReceipt, error) {
+	receipt := &entity.ErasureReceipt{
+		Id:          uuid.New(),
+		SubjectHash: up.signer.SubjectHash(userID),
+		// время хранится в бд с точностью до микросекунд, а входит в подпись квитанции
+		ErasedAt: up.now().UTC().Truncate(time.Microsecond),
+	}
+
+	err := up.Storage.EraseUserData(ctx, userID, receipt, up.signer.Sign)
+	if err != nil {
+		return nil, err
+	}
+	receipt.Verified = up.signer.Verify(receipt)
+
+	logger.Log.Info("user data erased",
+		zap.String("receipt_id", receipt.Id.String()),
+		zap.String("tenant_id", receipt.TenantId.String()),
+		zap.String("subject_hash", receipt.SubjectHash))
+
+	return receipt, nil
+}
+
+// ReadErasureReceipt возвращает квитанцию об удалении данных по id с результатом проверки ее подписи
+func (up *UserPrivacy) ReadErasureReceipt(ctx context.Context, id uuid.UUID) (*entity.ErasureReceipt, error) {
+	receipt, err := up.Storage.ReadErasureReceipt(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	receipt.Verified = up.signer.Verify(receipt)
+
+	return receipt, nil
+}
@@ -0,0 +1,197 @@
+package model
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/Ararat25/subscription-aggregation-service/internal/logger"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+// MockPrivacyRepo это mock реализация хранилища данных пользователя
+type MockPrivacyRepo struct {
+	mock.Mock
+}
+
+// ExportUserData имитирует выгрузку данных пользователя
+func (m *MockPrivacyRepo) ExportUserData(ctx context.Context, userID uuid.UUID) (*entity.UserData, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(*entity.UserData), args.Error(1)
+}
+
+// EraseUserData имитирует удаление данных пользователя
+func (m *MockPrivacyRepo) EraseUserData(ctx context.Context, userID uuid.UUID, receipt *entity.ErasureReceipt, sign func(*entity.ErasureReceipt) string) error {
+	args := m.Called(ctx, userID, receipt)
+	if args.Error(0) == nil {
+		receipt.Digest = sign(receipt)
+	}
+	return args.Error(0)
+}
+
+// ReadErasureReceipt имитирует чтение квитанции об удалении
+func (m *MockPrivacyRepo) ReadErasureReceipt(ctx context.Context, id uuid.UUID) (*entity.ErasureReceipt, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*entity.ErasureReceipt), args.Error(1)
+}
+
+// testReceiptSigner подписывает квитанции в тестах сервиса
+var testReceiptSigner = entity.NewReceiptSigner([]byte("test-receipt-key"))
+
+// plainSHA256 возвращает SHA-256 от полей без ключа: так мог бы пересчитать digest тот, кто изменил квитанцию
+func plainSHA256(fields ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// TestExportUserData тестирует выгрузку данных пользователя со сводкой по подпискам
+func TestExportUserData(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	now := time.Date(2025, time.August, 15, 12, 0, 0, 0, time.UTC)
+
+	// Тестовый пример 1: Сводка учитывает архивные подписки, время выгрузки заполняется
+	repo := new(MockPrivacyRepo)
+	ended := month(2020, time.March)
+	active := &entity.Subscription{Id: 3, ServiceName: "Netflix", Price: 500, UserId: userID, StartDate: month(2025, time.July)}
+	archived := &entity.Subscription{Id: 1, ServiceName: "Okko", Price: 100, UserId: userID, StartDate: month(2020, time.January), EndDate: &ended}
+	repo.On("ExportUserData", ctx, userID).Return(&entity.UserData{
+		UserId:                userID,
+		Subscriptions:         []*entity.Subscription{active},
+		ArchivedSubscriptions: []*entity.Subscription{archived},
+	}, nil).Once()
+
+	service := NewUserPrivacy(repo, testReceiptSigner)
+	service.now = func() time.Time { return now }
+
+	data, err := service.ExportUserData(ctx, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, now, data.ExportedAt)
+	assert.Equal(t, &entity.UserSummary{
+		UserId:        userID,
+		ActiveCount:   1,
+		MonthlySpend:  500,
+		LifetimeSpend: 100*3 + 500*2,
+		MostExpensive: active,
+		UpcomingEnds:  []*entity.Subscription{},
+	}, data.Summary)
+
+	// Тестовый пример 2: Ошибка хранилища
+	repo.On("ExportUserData", ctx, userID).Return((*entity.UserData)(nil), errors.New("db error")).Once()
+
+	data, err = service.ExportUserData(ctx, userID)
+	assert.Error(t, err)
+	assert.Nil(t, data)
+	repo.AssertExpectations(t)
+}
+
+// TestEraseUserData тестирует выдачу квитанции об удалении данных пользователя
+func TestEraseUserData(t *testing.T) {
+	logger.Log = zap.NewNop()
+	ctx := context.Background()
+	userID := uuid.New()
+	tenantID := uuid.New()
+	now := time.Date(2025, time.August, 15, 12, 0, 0, 123456789, time.UTC)
+
+	// Тестовый пример 1: Квитанция содержит хэш пользователя, время удаления и заполненные хранилищем количества
+	repo := new(MockPrivacyRepo)
+	repo.On("EraseUserData", ctx, userID, mock.AnythingOfType("*entity.ErasureReceipt")).Return(nil).Once().
+		Run(func(args mock.Arguments) {
+			receipt := args.Get(2).(*entity.ErasureReceipt)
+			receipt.TenantId = tenantID
+			receipt.Subscriptions = 2
+		})
+
+	service := NewUserPrivacy(repo, testReceiptSigner)
+	service.now = func() time.Time { return now }
+
+	receipt, err := service.EraseUserData(ctx, userID)
+	assert.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, receipt.Id)
+	assert.Equal(t, testReceiptSigner.SubjectHash(userID), receipt.SubjectHash)
+	assert.NotEqual(t, plainSHA256(userID.String()), receipt.SubjectHash)
+	assert.Equal(t, now.Truncate(time.Microsecond), receipt.ErasedAt)
+	assert.Equal(t, tenantID, receipt.TenantId)
+	assert.Equal(t, 2, receipt.Subscriptions)
+	assert.True(t, receipt.Verified)
+	assert.True(t, testReceiptSigner.Verify(receipt))
+
+	// Тестовый пример 2: Измененная квитанция не проходит проверку
+	tampered := *receipt
+	tampered.Subscriptions = 0
+	assert.False(t, testReceiptSigner.Verify(&tampered))
+
+	// Тестовый пример 3: Ошибка хранилища
+	repo.On("EraseUserData", ctx, userID, mock.AnythingOfType("*entity.ErasureReceipt")).Return(errors.New("db error")).Once()
+
+	receipt, err = service.EraseUserData(ctx, userID)
+	assert.Error(t, err)
+	assert.Nil(t, receipt)
+	repo.AssertExpectations(t)
+}
+
+// TestReadErasureReceipt тестирует проверку подписи квитанции при чтении
+func TestReadErasureReceipt(t *testing.T) {
+	ctx := context.Background()
+	repo := new(MockPrivacyRepo)
+	service := NewUserPrivacy(repo, testReceiptSigner)
+
+	newReceipt := func() *entity.ErasureReceipt {
+		receipt := &entity.ErasureReceipt{
+			Id:            uuid.New(),
+			TenantId:      uuid.New(),
+			SubjectHash:   testReceiptSigner.SubjectHash(uuid.New()),
+			ErasedAt:      time.Date(2025, time.August, 1, 12, 0, 0, 123456000, time.UTC),
+			Subscriptions: 3,
+			Events:        7,
+		}
+		receipt.Digest = testReceiptSigner.Sign(receipt)
+		return receipt
+	}
+
+	// Тестовый пример 1: Квитанция, не изменявшаяся после выдачи, проходит проверку
+	receipt := newReceipt()
+	repo.On("ReadErasureReceipt", ctx, receipt.Id).Return(receipt, nil).Once()
+
+	read, err := service.ReadErasureReceipt(ctx, receipt.Id)
+	assert.NoError(t, err)
+	assert.True(t, read.Verified)
+
+	// Тестовый пример 2: Измененное количество с пересчитанным без ключа digest не проходит проверку
+	receipt = newReceipt()
+	receipt.Subscriptions = 0
+	receipt.Digest = plainSHA256(receipt.Id.String(), receipt.TenantId.String(), receipt.SubjectHash,
+		receipt.ErasedAt.Format("2006-01-02T15:04:05.000000Z07:00"), "0", "0", "0", "0", "7", "0")
+	repo.On("ReadErasureReceipt", ctx, receipt.Id).Return(receipt, nil).Once()
+
+	read, err = service.ReadErasureReceipt(ctx, receipt.Id)
+	assert.NoError(t, err)
+	assert.False(t, read.Verified)
+
+	// Тестовый пример 3: Квитанция, подписанная другим ключом, не проходит проверку
+	receipt = newReceipt()
+	receipt.Digest = entity.NewReceiptSigner([]byte("other-key")).Sign(receipt)
+	repo.On("ReadErasureReceipt", ctx, receipt.Id).Return(receipt, nil).Once()
+
+	read, err = service.ReadErasureReceipt(ctx, receipt.Id)
+	assert.NoError(t, err)
+	assert.False(t, read.Verified)
+
+	// Тестовый пример 4: Ошибка хранилища
+	id := uuid.New()
+	repo.On("ReadErasureReceipt", ctx, id).Return((*entity.ErasureReceipt)(nil), myError.ErrErasureReceiptNotFound).Once()
+
+	read, err = service.ReadErasureReceipt(ctx, id)
+	assert.ErrorIs(t, err, myError.ErrErasureReceiptNotFound)
+	assert.Nil(t, read)
+	repo.AssertExpectations(t)
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/config"
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/Ararat25/subscription-aggregation-service/internal/logger"
+	"github.com/Ararat25/subscription-aggregation-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+// CostRollup - структура для сервиса помесячных итогов стоимости подписок
+type CostRollup struct {
+	Storage repository.CostRollupRepo // объект для работы с бд
+	conf    config.RollupConfig       // настройки продления итогов
+}
+
+// NewCostRollup возвращает новый объект структуры CostRollup
+func NewCostRollup(storage repository.CostRollupRepo, conf config.RollupConfig) *CostRollup {
+	return &CostRollup{
+		Storage: storage,
+		conf:    conf,
+	}
+}
+
+// Run продлевает итоги на наступивший месяц при запуске и затем периодически, пока не будет отменен контекст
+func (cr *CostRollup) Run(ctx context.Context) {
+	ticker := time.NewTicker(cr.conf.AdvanceInterval)
+	defer ticker.Stop()
+
+	for {
+		_, err := cr.Storage.AdvanceMonthlyCosts(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Log.Error("failed to advance monthly costs", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RebuildMonthlyCosts заново рассчитывает итоги тенанта по его подпискам
+func (cr *CostRollup) RebuildMonthlyCosts(ctx context.Context) (*entity.MonthlyCostsRebuild, error) {
+	rebuild, err := cr.Storage.RebuildMonthlyCosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return rebuild, nil
+}
+
+// CheckMonthlyCosts сверяет итоги тенанта с расчетом по подпискам. Расхождения записываются в журнал:
+// они означают, что итоги изменялись в обход хранилища, и исправляются пересборкой
+func (cr *CostRollup) CheckMonthlyCosts(ctx context.Context) (*entity.MonthlyCostsCheck, error) {
+	check, err := cr.Storage.CheckMonthlyCosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(check.Mismatches) > 0 {
+		logger.Log.Warn("monthly costs differ from subscriptions", zap.Int("mismatches", len(check.Mismatches)))
+	}
+
+	return check, nil
+}
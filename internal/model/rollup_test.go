@@ -0,0 +1,86 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/config"
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/Ararat25/subscription-aggregation-service/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+// MockCostRollupRepo это mock реализация хранилища помесячных итогов стоимости
+type MockCostRollupRepo struct {
+	mock.Mock
+}
+
+// RebuildMonthlyCosts имитирует пересборку итогов
+func (m *MockCostRollupRepo) RebuildMonthlyCosts(ctx context.Context) (*entity.MonthlyCostsRebuild, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(*entity.MonthlyCostsRebuild), args.Error(1)
+}
+
+// CheckMonthlyCosts имитирует сверку итогов
+func (m *MockCostRollupRepo) CheckMonthlyCosts(ctx context.Context) (*entity.MonthlyCostsCheck, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(*entity.MonthlyCostsCheck), args.Error(1)
+}
+
+// AdvanceMonthlyCosts имитирует продление итогов
+func (m *MockCostRollupRepo) AdvanceMonthlyCosts(ctx context.Context) (time.Time, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+// TestCostRollupRun тестирует продление итогов при запуске и по таймеру
+func TestCostRollupRun(t *testing.T) {
+	logger.Log = zap.NewNop()
+
+	// Тестовый пример 1: Итоги продлеваются при запуске, ошибка не останавливает задачу
+	ctx, cancel := context.WithCancel(context.Background())
+	repo := new(MockCostRollupRepo)
+	repo.On("AdvanceMonthlyCosts", ctx).Return(time.Time{}, errors.New("db error")).Once()
+	repo.On("AdvanceMonthlyCosts", ctx).Return(time.Date(2025, time.July, 1, 0, 0, 0, 0, time.UTC), nil).Once().
+		Run(func(mock.Arguments) { cancel() })
+
+	done := make(chan struct{})
+	go func() {
+		NewCostRollup(repo, config.RollupConfig{AdvanceInterval: time.Millisecond}).Run(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not stop after context cancellation")
+	}
+	repo.AssertExpectations(t)
+}
+
+// TestCheckMonthlyCosts тестирует сверку итогов
+func TestCheckMonthlyCosts(t *testing.T) {
+	logger.Log = zap.NewNop()
+	ctx := context.Background()
+
+	// Тестовый пример 1: Расхождения возвращаются вызывающему
+	repo := new(MockCostRollupRepo)
+	check := &entity.MonthlyCostsCheck{Rows: 2, Mismatches: []*entity.MonthlyCostMismatch{{ServiceName: "Netflix", LiveTotal: 100}}}
+	repo.On("CheckMonthlyCosts", ctx).Return(check, nil).Once()
+
+	res, err := NewCostRollup(repo, config.RollupConfig{}).CheckMonthlyCosts(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, check, res)
+
+	// Тестовый пример 2: Ошибка хранилища
+	repo.On("CheckMonthlyCosts", ctx).Return((*entity.MonthlyCostsCheck)(nil), errors.New("db error")).Once()
+
+	res, err = NewCostRollup(repo, config.RollupConfig{}).CheckMonthlyCosts(ctx)
+	assert.Error(t, err)
+	assert.Nil(t, res)
+	repo.AssertExpectations(t)
+}
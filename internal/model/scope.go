@@ -0,0 +1,93 @@
+package model
+
+import (
+	"context"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/auth"
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/google/uuid"
+)
+
+// checkOwner проверяет, что клиент может работать с подписками пользователя userID:
+// обычному пользователю доступны только его собственные подписки
+func checkOwner(ctx context.Context, userID uuid.UUID) error {
+	scope := auth.UserScope(ctx)
+	if scope != nil && *scope != userID {
+		return myError.ErrForbidden
+	}
+
+	return nil
+}
+
+// scopeUser возвращает пользователя, подписками которого ограничен запрос: для обычного пользователя - он сам,
+// даже если requested не указан. Запрос чужих подписок возвращает ErrForbidden
+func scopeUser(ctx context.Context, requested *uuid.UUID) (*uuid.UUID, error) {
+	scope := auth.UserScope(ctx)
+	if scope == nil {
+		return requested, nil
+	}
+
+	if requested != nil && *requested != *scope {
+		return nil, myError.ErrForbidden
+	}
+
+	return scope, nil
+}
+
+// readOwned возвращает подписку, если она доступна клиенту. Чужая подписка для обычного пользователя
+// не отличается от несуществующей, чтобы не раскрывать ее наличие
+func (ags *AggregationService) readOwned(ctx context.Context, id int64) (*entity.Subscription, error) {
+	sub, err := ags.Storage.ReadSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if checkOwner(ctx, sub.UserId) != nil {
+		return nil, myError.ErrSubscriptionNotFound
+	}
+
+	return sub, nil
+}
+
+// scopeFilter возвращает фильтр подписок, ограниченный пользователем клиента
+func scopeFilter(ctx context.Context, filter *entity.SubscriptionFilter) (*entity.SubscriptionFilter, error) {
+	var requested *uuid.UUID
+	if filter != nil {
+		requested = filter.UserId
+	}
+
+	userID, err := scopeUser(ctx, requested)
+	if err != nil || userID == requested {
+		return filter, err
+	}
+
+	scoped := entity.SubscriptionFilter{}
+	if filter != nil {
+		scoped = *filter
+	}
+	scoped.UserId = userID
+
+	return &scoped, nil
+}
+
+// scopeCostFilter возвращает фильтр стоимости, ограниченный пользователем клиента
+func scopeCostFilter(ctx context.Context, filter *entity.CostFilter) (*entity.CostFilter, error) {
+	var requested *uuid.UUID
+	if filter != nil {
+		requested = filter.UserId
+	}
+
+	userID, err := scopeUser(ctx, requested)
+	if err != nil || userID == requested {
+		return filter, err
+	}
+
+	scoped := entity.CostFilter{}
+	if filter != nil {
+		scoped = *filter
+	}
+	scoped.UserId = userID
+
+	return &scoped, nil
+}
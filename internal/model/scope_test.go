@@ -0,0 +1,90 @@
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/auth"
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// TestUserScope тестирует ограничение обычного пользователя его собственными подписками
+func TestUserScope(t *testing.T) {
+	mockRepo := new(MockRepo)
+	service := NewAggregationService(mockRepo)
+
+	owner := uuid.New()
+	other := uuid.New()
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{Subject: owner.String(), UserID: owner, Role: auth.RoleEditor})
+	adminCtx := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "ops", Role: auth.RoleAdmin})
+
+	own := &entity.Subscription{Id: 1, ServiceName: "Netflix", Price: 500, UserId: owner, StartDate: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)}
+	foreign := &entity.Subscription{Id: 2, ServiceName: "Spotify", Price: 300, UserId: other, StartDate: time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)}
+	mockRepo.On("ReadSubscription", mock.Anything, int64(1)).Return(own, nil)
+	mockRepo.On("ReadSubscription", mock.Anything, int64(2)).Return(foreign, nil)
+
+	// Тестовый пример 1: Своя подписка доступна, чужая не отличается от несуществующей
+	sub, err := service.ReadSubscription(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, own, sub)
+
+	sub, err = service.ReadSubscription(ctx, 2)
+	assert.ErrorIs(t, err, myError.ErrSubscriptionNotFound)
+	assert.Nil(t, sub)
+
+	// Тестовый пример 2: Администратор видит подписки всех пользователей
+	sub, err = service.ReadSubscription(adminCtx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, foreign, sub)
+
+	// Тестовый пример 3: Чужую подписку нельзя удалить
+	err = service.DeleteSubscription(ctx, 2)
+	assert.ErrorIs(t, err, myError.ErrSubscriptionNotFound)
+	mockRepo.AssertNotCalled(t, "DeleteSubscription", mock.Anything, int64(2))
+
+	// Тестовый пример 4: Нельзя создать подписку другому пользователю
+	_, err = service.CreateSubscription(ctx, &entity.SubscriptionRequest{ServiceName: "Netflix", Price: 500, UserId: other, StartDate: "01-2024"})
+	assert.ErrorIs(t, err, myError.ErrForbidden)
+
+	// Тестовый пример 5: Нельзя передать свою подписку другому пользователю
+	err = service.UpdateSubscription(ctx, &entity.SubscriptionRequest{Id: 1, ServiceName: "Netflix", Price: 500, UserId: other, StartDate: "01-2024"})
+	assert.ErrorIs(t, err, myError.ErrForbidden)
+
+	// Тестовый пример 6: Список и стоимость без фильтра ограничиваются пользователем
+	mockRepo.On("ListSubscriptions", ctx, &entity.SubscriptionFilter{UserId: &owner}).Return([]*entity.Subscription{own}, nil).Once()
+	subs, err := service.ListSubscriptions(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []*entity.Subscription{own}, subs)
+
+	from := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	mockRepo.On("TotalCost", ctx, from, from, &entity.CostFilter{SubscriptionFilter: entity.SubscriptionFilter{UserId: &owner}}).Return(500, nil).Once()
+	cost, err := service.TotalCost(ctx, from, from, &entity.CostFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, 500, cost)
+
+	today := time.Date(2024, time.March, 20, 0, 0, 0, 0, time.UTC)
+	scoped, err := service.ScopeCostFilter(ctx, &entity.CostFilter{Today: today})
+	assert.NoError(t, err)
+	assert.Equal(t, &entity.CostFilter{SubscriptionFilter: entity.SubscriptionFilter{UserId: &owner}, Today: today}, scoped)
+
+	// Тестовый пример 7: Запрос чужих подписок или сводки запрещен
+	_, err = service.TotalCost(ctx, from, from, &entity.CostFilter{SubscriptionFilter: entity.SubscriptionFilter{UserId: &other}})
+	assert.ErrorIs(t, err, myError.ErrForbidden)
+
+	_, err = service.ScopeCostFilter(ctx, &entity.CostFilter{SubscriptionFilter: entity.SubscriptionFilter{UserId: &other}})
+	assert.ErrorIs(t, err, myError.ErrForbidden)
+
+	_, err = service.UserSummary(ctx, other)
+	assert.ErrorIs(t, err, myError.ErrForbidden)
+
+	// Тестовый пример 8: Поиск выполняется только среди подписок пользователя
+	mockRepo.On("SearchSubscriptions", ctx, "net", &owner, DefaultSearchLimit).Return([]*entity.SubscriptionMatch{}, nil).Once()
+	_, err = service.SearchSubscriptions(ctx, "net", 0)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
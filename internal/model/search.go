@@ -0,0 +1,56 @@
+package model
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/auth"
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+)
+
+const (
+	DefaultSearchLimit = 10  // количество результатов поиска по умолчанию
+	MaxSearchLimit     = 100 // максимальное количество результатов поиска
+)
+
+// SearchSubscriptions ищет подписки по названию сервиса и возвращает их в порядке убывания релевантности.
+// Обычный пользователь ищет только среди своих подписок
+func (ags *AggregationService) SearchSubscriptions(ctx context.Context, query string, limit int) ([]*entity.SubscriptionMatch, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, myError.ErrEmptySearchQuery
+	}
+
+	matches, err := ags.Storage.SearchSubscriptions(ctx, query, auth.UserScope(ctx), searchLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+// SuggestServiceNames возвращает варианты автодополнения названия сервиса.
+// Обычному пользователю предлагаются названия только из его подписок
+func (ags *AggregationService) SuggestServiceNames(ctx context.Context, query string, limit int) ([]*entity.ServiceSuggestion, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, myError.ErrEmptySearchQuery
+	}
+
+	suggestions, err := ags.Storage.SuggestServiceNames(ctx, query, auth.UserScope(ctx), searchLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	return suggestions, nil
+}
+
+// searchLimit приводит количество результатов к допустимому диапазону
+func searchLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultSearchLimit
+	}
+
+	return min(limit, MaxSearchLimit)
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSearchSubscriptions тестирует поиск подписок по названию сервиса
+func TestSearchSubscriptions(t *testing.T) {
+	mockRepo := new(MockRepo)
+	service := NewAggregationService(mockRepo)
+	ctx := context.Background()
+
+	matches := []*entity.SubscriptionMatch{{Subscription: &entity.Subscription{Id: 1, ServiceName: "Netflix"}, Score: 0.6}}
+
+	// Тестовый пример 1: Запрос очищается от пробелов, используется limit по умолчанию
+	mockRepo.On("SearchSubscriptions", ctx, "netflx", (*uuid.UUID)(nil), DefaultSearchLimit).Return(matches, nil).Once()
+	res, err := service.SearchSubscriptions(ctx, "  netflx ", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, matches, res)
+	mockRepo.AssertExpectations(t)
+
+	// Тестовый пример 2: Слишком большой limit ограничивается
+	mockRepo.On("SearchSubscriptions", ctx, "netflix", (*uuid.UUID)(nil), MaxSearchLimit).Return(matches, nil).Once()
+	_, err = service.SearchSubscriptions(ctx, "netflix", 1000)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+
+	// Тестовый пример 3: Пустой запрос
+	res, err = service.SearchSubscriptions(ctx, "   ", 5)
+	assert.ErrorIs(t, err, myError.ErrEmptySearchQuery)
+	assert.Nil(t, res)
+}
+
+// TestSuggestServiceNames тестирует автодополнение названия сервиса
+func TestSuggestServiceNames(t *testing.T) {
+	mockRepo := new(MockRepo)
+	service := NewAggregationService(mockRepo)
+	ctx := context.Background()
+
+	suggestions := []*entity.ServiceSuggestion{{ServiceName: "Netflix", Subscriptions: 2, Score: 0.4}}
+
+	// Тестовый пример 1: Успешное автодополнение
+	mockRepo.On("SuggestServiceNames", ctx, "net", (*uuid.UUID)(nil), 5).Return(suggestions, nil).Once()
+	res, err := service.SuggestServiceNames(ctx, "net", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, suggestions, res)
+	mockRepo.AssertExpectations(t)
+
+	// Тестовый пример 2: Пустой запрос
+	res, err = service.SuggestServiceNames(ctx, "", 5)
+	assert.ErrorIs(t, err, myError.ErrEmptySearchQuery)
+	assert.Nil(t, res)
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"context"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/Ararat25/subscription-aggregation-service/internal/repository"
+)
+
+// SystemMonitor - структура для сервиса состояния развертывания
+type SystemMonitor struct {
+	Storage repository.PoolStatsRepo // объект для работы с бд
+}
+
+// NewSystemMonitor возвращает новый объект структуры SystemMonitor
+func NewSystemMonitor(storage repository.PoolStatsRepo) *SystemMonitor {
+	return &SystemMonitor{
+		Storage: storage,
+	}
+}
+
+// PoolStats возвращает состояние пула соединений с бд
+func (sm *SystemMonitor) PoolStats(ctx context.Context) (*entity.PoolStats, error) {
+	stats, err := sm.Storage.PoolStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
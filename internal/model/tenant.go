@@ -0,0 +1,67 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/Ararat25/subscription-aggregation-service/internal/repository"
+)
+
+// TenantManager - структура для сервиса тенантов
+type TenantManager struct {
+	Storage repository.TenantRepo // объект для работы с бд
+}
+
+// NewTenantManager возвращает новый объект структуры TenantManager
+func NewTenantManager(storage repository.TenantRepo) *TenantManager {
+	return &TenantManager{
+		Storage: storage,
+	}
+}
+
+// CreateTenant создает тенанта. Название сравнивается с уже занятыми с точностью до пробелов по краям
+func (tm *TenantManager) CreateTenant(ctx context.Context, req *entity.TenantRequest) (*entity.Tenant, error) {
+	if req == nil {
+		return nil, fmt.Errorf("invalid argument error")
+	}
+
+	t := &entity.Tenant{Name: strings.TrimSpace(req.Name)}
+
+	err := tm.Storage.CreateTenant(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// ListTenants возвращает список тенантов
+func (tm *TenantManager) ListTenants(ctx context.Context) ([]*entity.Tenant, error) {
+	tenants, err := tm.Storage.ListTenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return tenants, nil
+}
+
+// TenantCosts возвращает суммарную стоимость подписок каждого тенанта за период
+func (tm *TenantManager) TenantCosts(ctx context.Context, from, to time.Time) ([]*entity.TenantCost, error) {
+	fromReset := resetDay(from)
+	toReset := resetDay(to)
+
+	if !isEndDateValid(fromReset, toReset) {
+		return nil, myError.ErrPeriodRange
+	}
+
+	costs, err := tm.Storage.TenantCosts(ctx, fromReset, toReset)
+	if err != nil {
+		return nil, err
+	}
+
+	return costs, nil
+}
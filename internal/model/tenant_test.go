@@ -0,0 +1,84 @@
+package model
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTenantRepo это mock реализация хранилища тенантов
+type MockTenantRepo struct {
+	mock.Mock
+}
+
+// CreateTenant имитирует создание тенанта
+func (m *MockTenantRepo) CreateTenant(ctx context.Context, t *entity.Tenant) error {
+	args := m.Called(ctx, t)
+	return args.Error(0)
+}
+
+// ListTenants имитирует вывод списка тенантов
+func (m *MockTenantRepo) ListTenants(ctx context.Context) ([]*entity.Tenant, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*entity.Tenant), args.Error(1)
+}
+
+// TenantCosts имитирует расчет стоимости подписок по тенантам
+func (m *MockTenantRepo) TenantCosts(ctx context.Context, from, to time.Time) ([]*entity.TenantCost, error) {
+	args := m.Called(ctx, from, to)
+	costs, _ := args.Get(0).([]*entity.TenantCost)
+	return costs, args.Error(1)
+}
+
+// TestCreateTenant тестирует создание тенанта
+func TestCreateTenant(t *testing.T) {
+	mockRepo := new(MockTenantRepo)
+	manager := NewTenantManager(mockRepo)
+	ctx := context.Background()
+
+	// Тестовый пример 1: Название сохраняется без пробелов по краям
+	id := uuid.New()
+	mockRepo.On("CreateTenant", ctx, &entity.Tenant{Name: "acme"}).
+		Run(func(args mock.Arguments) { args.Get(1).(*entity.Tenant).Id = id }).
+		Return(nil).Once()
+	tenant, err := manager.CreateTenant(ctx, &entity.TenantRequest{Name: "  acme "})
+	assert.NoError(t, err)
+	assert.Equal(t, id, tenant.Id)
+	assert.Equal(t, "acme", tenant.Name)
+	mockRepo.AssertExpectations(t)
+
+	// Тестовый пример 2: Название занято
+	mockRepo.On("CreateTenant", ctx, &entity.Tenant{Name: "acme"}).Return(myError.ErrTenantNameTaken).Once()
+	_, err = manager.CreateTenant(ctx, &entity.TenantRequest{Name: "acme"})
+	assert.ErrorIs(t, err, myError.ErrTenantNameTaken)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestTenantCosts тестирует расчет стоимости подписок по тенантам
+func TestTenantCosts(t *testing.T) {
+	mockRepo := new(MockTenantRepo)
+	manager := NewTenantManager(mockRepo)
+	ctx := context.Background()
+
+	// Тестовый пример 1: Границы периода приводятся к началу месяца
+	from := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC)
+	costs := []*entity.TenantCost{{TenantId: uuid.New(), Name: "acme", TotalCost: 1200}}
+	mockRepo.On("TenantCosts", ctx, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)).
+		Return(costs, nil).Once()
+	res, err := manager.TenantCosts(ctx, from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, costs, res)
+	mockRepo.AssertExpectations(t)
+
+	// Тестовый пример 2: Конец периода раньше начала
+	_, err = manager.TenantCosts(ctx, to, from)
+	assert.ErrorIs(t, err, myError.ErrPeriodRange)
+	mockRepo.AssertNotCalled(t, "TenantCosts", ctx, to, from)
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/google/uuid"
+)
+
+// MaxUpcomingEnds - максимальное количество ближайших окончаний подписок в сводке пользователя
+const MaxUpcomingEnds = 5
+
+// UserSummary возвращает сводку по подпискам пользователя на текущий месяц. Обычному пользователю доступна только своя сводка
+func (ags *AggregationService) UserSummary(ctx context.Context, userID uuid.UUID) (*entity.UserSummary, error) {
+	err := checkOwner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// архивные подписки давно закончились, но входят в стоимость за все время
+	subs, err := ags.Storage.ListSubscriptionHistory(ctx, &entity.SubscriptionFilter{UserId: &userID})
+	if err != nil {
+		return nil, err
+	}
+
+	return buildUserSummary(userID, subs, resetDay(time.Now().UTC())), nil
+}
+
+// buildUserSummary считает сводку по подпискам пользователя на месяц month.
+// Подписка активна, если началась не позже month и не закончилась раньше него; месяц окончания оплачивается
+func buildUserSummary(userID uuid.UUID, subs []*entity.Subscription, month time.Time) *entity.UserSummary {
+	summary := &entity.UserSummary{
+		UserId:       userID,
+		UpcomingEnds: []*entity.Subscription{},
+	}
+
+	for _, sub := range subs {
+		if sub.StartDate.After(month) {
+			continue
+		}
+
+		last := month
+		if sub.EndDate != nil && sub.EndDate.Before(month) {
+			last = *sub.EndDate
+		}
+		summary.LifetimeSpend += sub.Price * monthsBetween(sub.StartDate, last)
+
+		if sub.EndDate != nil && sub.EndDate.Before(month) {
+			continue
+		}
+
+		summary.ActiveCount++
+		summary.MonthlySpend += sub.Price
+
+		if summary.MostExpensive == nil || sub.Price > summary.MostExpensive.Price {
+			summary.MostExpensive = sub
+		}
+
+		if sub.EndDate != nil {
+			summary.UpcomingEnds = append(summary.UpcomingEnds, sub)
+		}
+	}
+
+	sort.SliceStable(summary.UpcomingEnds, func(i, j int) bool {
+		return summary.UpcomingEnds[i].EndDate.Before(*summary.UpcomingEnds[j].EndDate)
+	})
+
+	if len(summary.UpcomingEnds) > MaxUpcomingEnds {
+		summary.UpcomingEnds = summary.UpcomingEnds[:MaxUpcomingEnds]
+	}
+
+	return summary
+}
+
+// monthsBetween возвращает количество месяцев с from по to включительно
+func monthsBetween(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()-from.Month()) + 1
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// month возвращает первое число месяца для тестов
+func month(year int, m time.Month) time.Time {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// TestBuildUserSummary тестирует подсчет сводки по подпискам пользователя
+func TestBuildUserSummary(t *testing.T) {
+	userID := uuid.New()
+	now := month(2025, time.June)
+	ended := month(2025, time.March)
+	endsSoon := month(2025, time.August)
+	endsThisMonth := month(2025, time.June)
+
+	subs := []*entity.Subscription{
+		// активна с января: 6 месяцев по 100
+		{Id: 1, ServiceName: "Spotify", Price: 100, StartDate: month(2025, time.January)},
+		// закончилась в марте: 3 месяца по 200, не активна
+		{Id: 2, ServiceName: "Netflix", Price: 200, StartDate: month(2025, time.January), EndDate: &ended},
+		// активна до августа: 2 месяца по 500
+		{Id: 3, ServiceName: "Cloud", Price: 500, StartDate: month(2025, time.May), EndDate: &endsSoon},
+		// заканчивается в текущем месяце: 1 месяц по 50
+		{Id: 4, ServiceName: "News", Price: 50, StartDate: month(2025, time.June), EndDate: &endsThisMonth},
+		// начнется в будущем, не учитывается
+		{Id: 5, ServiceName: "Future", Price: 1000, StartDate: month(2025, time.September)},
+	}
+
+	summary := buildUserSummary(userID, subs, now)
+
+	assert.Equal(t, userID, summary.UserId)
+	assert.Equal(t, 3, summary.ActiveCount)
+	assert.Equal(t, 650, summary.MonthlySpend)
+	assert.Equal(t, 600+600+1000+50, summary.LifetimeSpend)
+	assert.Equal(t, 3, summary.MostExpensive.Id)
+	assert.Len(t, summary.UpcomingEnds, 2)
+	assert.Equal(t, 4, summary.UpcomingEnds[0].Id)
+	assert.Equal(t, 3, summary.UpcomingEnds[1].Id)
+
+	// Пользователь без подписок
+	summary = buildUserSummary(userID, nil, now)
+	assert.Equal(t, 0, summary.ActiveCount)
+	assert.Nil(t, summary.MostExpensive)
+	assert.Empty(t, summary.UpcomingEnds)
+}
+
+// TestUserSummary тестирует получение сводки по подпискам пользователя
+func TestUserSummary(t *testing.T) {
+	mockRepo := new(MockRepo)
+	service := NewAggregationService(mockRepo)
+	ctx := context.Background()
+	userID := uuid.New()
+
+	// Тестовый пример 1: Подписки выбираются по пользователю
+	subs := []*entity.Subscription{{Id: 1, Price: 100, UserId: userID, StartDate: month(2020, time.January)}}
+	mockRepo.On("ListSubscriptionHistory", ctx, &entity.SubscriptionFilter{UserId: &userID}).Return(subs, nil).Once()
+	summary, err := service.UserSummary(ctx, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, summary.ActiveCount)
+	assert.Equal(t, 100, summary.MonthlySpend)
+	mockRepo.AssertExpectations(t)
+
+	// Тестовый пример 2: Ошибка репозитория
+	mockRepo.On("ListSubscriptionHistory", ctx, &entity.SubscriptionFilter{UserId: &userID}).Return([]*entity.Subscription(nil), errors.New("db error")).Once()
+	summary, err = service.UserSummary(ctx, userID)
+	assert.Error(t, err)
+	assert.Nil(t, summary)
+	mockRepo.AssertExpectations(t)
+}
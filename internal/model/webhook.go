@@ -0,0 +1,402 @@
+package model
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/config"
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/Ararat25/subscription-aggregation-service/internal/logger"
+	"github.com/Ararat25/subscription-aggregation-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature" // заголовок с HMAC-подписью тела запроса
+	TimestampHeader = "X-Webhook-Timestamp" // заголовок со временем отправки, входящим в подпись
+	EventHeader     = "X-Webhook-Event"     // заголовок с типом события
+	DeliveryHeader  = "X-Webhook-Delivery"  // заголовок с id доставки
+
+	maxErrorLength = 512 // максимальная длина сохраняемого текста ошибки
+	maxRedirects   = 10  // максимальное количество перенаправлений при доставке
+)
+
+// reservedPrefixes - диапазоны, которые netip не считает частными, но которые не ведут в интернет:
+// сеть 0.0.0.0/8 («этот хост»), адреса провайдерского NAT 100.64.0.0/10 и сеть для тестов производительности 198.18.0.0/15
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+}
+
+// WebhookDispatcher - структура для сервиса вебхуков и доставки событий
+type WebhookDispatcher struct {
+	Storage repository.WebhookRepo // объект для работы с бд
+	client  *http.Client           // http-клиент для отправки событий
+	conf    config.WebhookConfig   // настройки доставки
+}
+
+// NewWebhookDispatcher возвращает новый объект структуры WebhookDispatcher
+func NewWebhookDispatcher(storage repository.WebhookRepo, conf config.WebhookConfig) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		Storage: storage,
+		client:  newWebhookClient(conf),
+		conf:    conf,
+	}
+}
+
+// CreateWebhook регистрирует вебхук, при отсутствии секрета генерирует его
+func (wd *WebhookDispatcher) CreateWebhook(ctx context.Context, w *entity.WebhookRequest) (*entity.Webhook, error) {
+	if w == nil {
+		return nil, fmt.Errorf("invalid argument error")
+	}
+
+	err := wd.validateURL(w.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := w.Secret
+	if secret == "" {
+		generated, err := generateSecret()
+		if err != nil {
+			return nil, err
+		}
+		secret = generated
+	}
+
+	webhook := &entity.Webhook{
+		URL:    w.URL,
+		Secret: secret,
+		Events: w.Events,
+		Active: true,
+	}
+
+	id, err := wd.Storage.CreateWebhook(ctx, webhook)
+	if err != nil {
+		return nil, err
+	}
+	webhook.Id = id
+
+	return webhook, nil
+}
+
+// ListWebhooks возвращает список вебхуков без секретов
+func (wd *WebhookDispatcher) ListWebhooks(ctx context.Context) ([]*entity.Webhook, error) {
+	webhooks, err := wd.Storage.ListWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, w := range webhooks {
+		w.Secret = ""
+	}
+
+	return webhooks, nil
+}
+
+// DeleteWebhook удаляет вебхук
+func (wd *WebhookDispatcher) DeleteWebhook(ctx context.Context, id int64) error {
+	return wd.Storage.DeleteWebhook(ctx, id)
+}
+
+// ListDeliveries возвращает журнал доставок вебхука
+func (wd *WebhookDispatcher) ListDeliveries(ctx context.Context, webhookID int64) ([]*entity.WebhookDelivery, error) {
+	deliveries, err := wd.Storage.ListDeliveries(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver ставит доставку в очередь на повторную отправку
+func (wd *WebhookDispatcher) Redeliver(ctx context.Context, deliveryID int64) error {
+	return wd.Storage.RedeliverDelivery(ctx, deliveryID)
+}
+
+// Publish сохраняет событие в журнал доставок всех подписанных на него вебхуков.
+// Повторная публикация того же события не создает дублей доставок
+func (wd *WebhookDispatcher) Publish(ctx context.Context, event *entity.Event) error {
+	if event == nil {
+		return fmt.Errorf("invalid argument error")
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = wd.Storage.CreateDeliveries(ctx, event, payload)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Run периодически отправляет доставки, время которых наступило, и раз в PurgeInterval удаляет завершенные
+// доставки старше DeliveryRetention, пока не будет отменен контекст
+func (wd *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(wd.conf.PollInterval)
+	defer ticker.Stop()
+
+	var nextPurge time.Time
+	for {
+		if wd.conf.DeliveryRetention > 0 && !time.Now().Before(nextPurge) {
+			wd.purge(ctx, time.Now())
+			nextPurge = time.Now().Add(wd.conf.PurgeInterval)
+		}
+
+		wd.processDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processDue отправляет одну пачку доставок из очереди
+func (wd *WebhookDispatcher) processDue(ctx context.Context) {
+	deliveries, err := wd.Storage.ClaimDueDeliveries(ctx, time.Now(), 2*wd.conf.Timeout, wd.conf.BatchSize)
+	if err != nil {
+		if ctx.Err() == nil {
+			logger.Log.Error("failed to claim webhook deliveries", zap.Error(err))
+		}
+		return
+	}
+
+	for _, d := range deliveries {
+		wd.deliver(ctx, d)
+
+		err = wd.Storage.UpdateDelivery(ctx, d)
+		if err != nil {
+			logger.Log.Error("failed to save webhook delivery",
+				zap.Int64("delivery_id", d.Id),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// purge удаляет завершенные доставки, последняя попытка которых была раньше срока хранения,
+// и возвращает количество удаленных
+func (wd *WebhookDispatcher) purge(ctx context.Context, now time.Time) int {
+	purged, err := purgeExpired(ctx, now.Add(-wd.conf.DeliveryRetention), wd.Storage.PurgeDeliveries)
+	if err != nil && ctx.Err() == nil {
+		logger.Log.Error("failed to purge webhook deliveries", zap.Error(err))
+	}
+
+	if purged > 0 {
+		logger.Log.Info("webhook deliveries purged", zap.Int("count", purged))
+	}
+
+	return purged
+}
+
+// deliver выполняет одну попытку доставки и записывает ее результат в d
+func (wd *WebhookDispatcher) deliver(ctx context.Context, d *entity.WebhookDelivery) {
+	now := time.Now()
+	d.Attempts++
+
+	statusCode, err := wd.send(ctx, d, now)
+	if statusCode != 0 {
+		d.LastStatusCode = &statusCode
+	}
+
+	if err == nil {
+		d.Status = entity.DeliverySucceeded
+		d.DeliveredAt = &now
+		d.LastError = nil
+		return
+	}
+
+	errMsg := err.Error()
+	if len(errMsg) > maxErrorLength {
+		errMsg = errMsg[:maxErrorLength]
+	}
+	d.LastError = &errMsg
+
+	if d.Attempts >= wd.conf.MaxAttempts {
+		d.Status = entity.DeliveryFailed
+		return
+	}
+
+	d.NextAttemptAt = now.Add(backoff(d.Attempts, wd.conf.BaseBackoff, wd.conf.MaxBackoff))
+}
+
+// send отправляет подписанное событие получателю и возвращает http-код ответа
+func (wd *WebhookDispatcher) send(ctx context.Context, d *entity.WebhookDelivery, now time.Time) (int, error) {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(d.EventType))
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(d.Id, 10))
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, "sha256="+SignPayload(d.Secret, timestamp, d.Payload))
+
+	resp, err := wd.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status: %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// validateURL проверяет, что вебхук отправляет события по http или https и что адрес хоста не указывает явно на
+// loopback, link-local или частную сеть. Адрес, в который разрешается имя хоста, проверяется при каждом подключении
+// (см. newWebhookClient): он может измениться после регистрации
+func (wd *WebhookDispatcher) validateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return myError.ErrWebhookURLForbidden
+	}
+
+	if wd.conf.AllowPrivateNetworks {
+		return nil
+	}
+
+	host := u.Hostname()
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return myError.ErrWebhookURLForbidden
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err == nil && !isPublicAddr(addr) {
+		return myError.ErrWebhookURLForbidden
+	}
+
+	return nil
+}
+
+// newWebhookClient возвращает http-клиент для доставки событий. Если адреса частных сетей не разрешены,
+// клиент отказывается подключаться к ним после разрешения имени хоста, в том числе при перенаправлениях.
+// Прокси из окружения не используется, чтобы проверялся адрес получателя, а не прокси
+func newWebhookClient(conf config.WebhookConfig) *http.Client {
+	dialer := &net.Dialer{Timeout: conf.Timeout}
+	if !conf.AllowPrivateNetworks {
+		dialer.Control = guardDial
+	}
+
+	return &http.Client{
+		Timeout: conf.Timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return myError.ErrWebhookURLForbidden
+			}
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return nil
+		},
+	}
+}
+
+// guardDial запрещает подключение к адресам loopback, link-local и частных сетей.
+// Вызывается для каждого адреса, в который разрешилось имя хоста, непосредственно перед подключением
+func guardDial(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+
+	if !isPublicAddr(addr) {
+		return fmt.Errorf("%w: %s", myError.ErrWebhookURLForbidden, addr)
+	}
+
+	return nil
+}
+
+// isPublicAddr сообщает, что адрес - публичный unicast-адрес: не loopback, не link-local (в том числе не адрес
+// метаданных облака 169.254.169.254), не multicast, не из частных и зарезервированных диапазонов
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SignPayload вычисляет HMAC-SHA256 подпись события в виде hex-строки.
+// Подписывается строка "<timestamp>.<payload>", чтобы получатель мог отбрасывать старые запросы
+func SignPayload(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// backoff возвращает экспоненциальную задержку перед следующей попыткой
+func backoff(attempt int, base, maxDelay time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > maxDelay {
+		return maxDelay
+	}
+
+	return delay
+}
+
+// generateSecret генерирует случайный секрет для подписи событий
+func generateSecret() (string, error) {
+	buf := make([]byte, 32)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
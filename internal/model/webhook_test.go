@@ -0,0 +1,338 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/config"
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/Ararat25/subscription-aggregation-service/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// MockWebhookRepo это mock реализация хранилища вебхуков
+type MockWebhookRepo struct {
+	mock.Mock
+}
+
+// CreateWebhook имитирует создание вебхука
+func (m *MockWebhookRepo) CreateWebhook(ctx context.Context, w *entity.Webhook) (int64, error) {
+	args := m.Called(ctx, w)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// ListWebhooks имитирует вывод списка вебхуков
+func (m *MockWebhookRepo) ListWebhooks(ctx context.Context) ([]*entity.Webhook, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*entity.Webhook), args.Error(1)
+}
+
+// DeleteWebhook имитирует удаление вебхука
+func (m *MockWebhookRepo) DeleteWebhook(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// CreateDeliveries имитирует создание записей о доставке события
+func (m *MockWebhookRepo) CreateDeliveries(ctx context.Context, event *entity.Event, payload []byte) (int, error) {
+	args := m.Called(ctx, event, payload)
+	return args.Int(0), args.Error(1)
+}
+
+// ClaimDueDeliveries имитирует выборку доставок к отправке
+func (m *MockWebhookRepo) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*entity.WebhookDelivery, error) {
+	args := m.Called(ctx, now, lease, limit)
+	return args.Get(0).([]*entity.WebhookDelivery), args.Error(1)
+}
+
+// UpdateDelivery имитирует сохранение результата доставки
+func (m *MockWebhookRepo) UpdateDelivery(ctx context.Context, d *entity.WebhookDelivery) error {
+	args := m.Called(ctx, d)
+	return args.Error(0)
+}
+
+// ListDeliveries имитирует вывод журнала доставок
+func (m *MockWebhookRepo) ListDeliveries(ctx context.Context, webhookID int64) ([]*entity.WebhookDelivery, error) {
+	args := m.Called(ctx, webhookID)
+	return args.Get(0).([]*entity.WebhookDelivery), args.Error(1)
+}
+
+// RedeliverDelivery имитирует постановку доставки в очередь
+func (m *MockWebhookRepo) RedeliverDelivery(ctx context.Context, id int64) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// PurgeDeliveries имитирует удаление завершенных доставок
+func (m *MockWebhookRepo) PurgeDeliveries(ctx context.Context, finishedBefore time.Time, limit int) (int, error) {
+	args := m.Called(ctx, finishedBefore, limit)
+	return args.Int(0), args.Error(1)
+}
+
+// testWebhookConfig возвращает настройки доставки для тестов. Тестовые получатели слушают loopback,
+// поэтому адреса частных сетей разрешены
+func testWebhookConfig() config.WebhookConfig {
+	return config.WebhookConfig{
+		MaxAttempts:          3,
+		BaseBackoff:          time.Second,
+		MaxBackoff:           time.Minute,
+		Timeout:              time.Second,
+		PollInterval:         time.Second,
+		BatchSize:            10,
+		AllowPrivateNetworks: true,
+	}
+}
+
+// TestCreateWebhook тестирует регистрацию вебхука
+func TestCreateWebhook(t *testing.T) {
+	mockRepo := new(MockWebhookRepo)
+	dispatcher := NewWebhookDispatcher(mockRepo, testWebhookConfig())
+	ctx := context.Background()
+
+	// Тестовый пример 1: Секрет генерируется, если не передан
+	req := &entity.WebhookRequest{
+		URL:    "https://example.com/hook",
+		Events: []entity.EventType{entity.EventSubscriptionCreated},
+	}
+	mockRepo.On("CreateWebhook", ctx, mock.AnythingOfType("*entity.Webhook")).Return(int64(5), nil).Once()
+	webhook, err := dispatcher.CreateWebhook(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), webhook.Id)
+	assert.True(t, strings.HasPrefix(webhook.Secret, "whsec_"))
+	assert.True(t, webhook.Active)
+	mockRepo.AssertExpectations(t)
+
+	// Тестовый пример 2: Переданный секрет сохраняется как есть
+	req.Secret = "0123456789abcdef"
+	mockRepo.On("CreateWebhook", ctx, mock.AnythingOfType("*entity.Webhook")).Return(int64(6), nil).Once()
+	webhook, err = dispatcher.CreateWebhook(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, "0123456789abcdef", webhook.Secret)
+	mockRepo.AssertExpectations(t)
+
+	// Тестовый пример 3: Недопустимый аргумент
+	webhook, err = dispatcher.CreateWebhook(ctx, nil)
+	assert.Error(t, err)
+	assert.Nil(t, webhook)
+
+	// Тестовый пример 4: Адреса не по http(s), loopback, link-local и частных сетей не регистрируются
+	conf := testWebhookConfig()
+	conf.AllowPrivateNetworks = false
+	strict := NewWebhookDispatcher(mockRepo, conf)
+	for _, u := range []string{
+		"ftp://example.com/hook",
+		"file:///etc/passwd",
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://[::1]/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://10.0.0.5/hook",
+		"http://192.168.1.1/hook",
+		"http://[::ffff:172.16.0.1]/hook",
+		"http://100.64.0.1/hook",
+		"http://0.0.0.0/hook",
+	} {
+		webhook, err = strict.CreateWebhook(ctx, &entity.WebhookRequest{URL: u, Events: req.Events})
+		assert.ErrorIs(t, err, myError.ErrWebhookURLForbidden, u)
+		assert.Nil(t, webhook)
+	}
+
+	// Тестовый пример 5: Публичные адреса и имена хостов регистрируются
+	mockRepo.On("CreateWebhook", ctx, mock.AnythingOfType("*entity.Webhook")).Return(int64(7), nil).Twice()
+	for _, u := range []string{"https://example.com/hook", "http://93.184.216.34/hook"} {
+		_, err = strict.CreateWebhook(ctx, &entity.WebhookRequest{URL: u, Events: req.Events})
+		assert.NoError(t, err, u)
+	}
+	mockRepo.AssertExpectations(t)
+
+	// Тестовый пример 6: При разрешенных частных сетях проверяется только схема
+	mockRepo.On("CreateWebhook", ctx, mock.AnythingOfType("*entity.Webhook")).Return(int64(8), nil).Once()
+	_, err = dispatcher.CreateWebhook(ctx, &entity.WebhookRequest{URL: "http://127.0.0.1:8080/hook", Events: req.Events})
+	assert.NoError(t, err)
+	_, err = dispatcher.CreateWebhook(ctx, &entity.WebhookRequest{URL: "gopher://127.0.0.1/hook", Events: req.Events})
+	assert.ErrorIs(t, err, myError.ErrWebhookURLForbidden)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestListWebhooks тестирует скрытие секретов в списке вебхуков
+func TestListWebhooks(t *testing.T) {
+	mockRepo := new(MockWebhookRepo)
+	dispatcher := NewWebhookDispatcher(mockRepo, testWebhookConfig())
+	ctx := context.Background()
+
+	mockRepo.On("ListWebhooks", ctx).Return([]*entity.Webhook{{Id: 1, Secret: "secret"}}, nil).Once()
+	webhooks, err := dispatcher.ListWebhooks(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, webhooks, 1)
+	assert.Empty(t, webhooks[0].Secret)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestPublish тестирует сохранение события в журнал доставок
+func TestPublish(t *testing.T) {
+	mockRepo := new(MockWebhookRepo)
+	dispatcher := NewWebhookDispatcher(mockRepo, testWebhookConfig())
+	ctx := context.Background()
+
+	event := entity.NewEvent(entity.EventSubscriptionCreated, &entity.Subscription{Id: 1, ServiceName: "Netflix"})
+
+	// Тестовый пример 1: Успешное сохранение
+	mockRepo.On("CreateDeliveries", ctx, event, mock.Anything).Return(2, nil).Once()
+	err := dispatcher.Publish(ctx, event)
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+
+	// Тестовый пример 2: Ошибка репозитория
+	mockRepo.On("CreateDeliveries", ctx, event, mock.Anything).Return(0, errors.New("db error")).Once()
+	err = dispatcher.Publish(ctx, event)
+	assert.Error(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestDeliver тестирует отправку события получателю
+func TestDeliver(t *testing.T) {
+	ctx := context.Background()
+	payload := []byte(`{"type":"subscription.created"}`)
+
+	// Тестовый пример 1: Получатель принял событие, подпись корректна
+	var gotSignature, gotTimestamp string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSignature = r.Header.Get(SignatureHeader)
+		gotTimestamp = r.Header.Get(TimestampHeader)
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	dispatcher := NewWebhookDispatcher(new(MockWebhookRepo), testWebhookConfig())
+	d := &entity.WebhookDelivery{Id: 1, URL: server.URL, Secret: "secret", Payload: payload, Status: entity.DeliveryPending}
+	dispatcher.deliver(ctx, d)
+
+	assert.Equal(t, entity.DeliverySucceeded, d.Status)
+	assert.Equal(t, 1, d.Attempts)
+	assert.NotNil(t, d.DeliveredAt)
+	assert.Equal(t, payload, gotBody)
+	assert.Equal(t, "sha256="+SignPayload("secret", gotTimestamp, payload), gotSignature)
+
+	// Тестовый пример 2: Ошибка получателя откладывает доставку
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	d = &entity.WebhookDelivery{Id: 2, URL: failing.URL, Secret: "secret", Payload: payload, Status: entity.DeliveryPending}
+	before := time.Now()
+	dispatcher.deliver(ctx, d)
+
+	assert.Equal(t, entity.DeliveryPending, d.Status)
+	assert.Equal(t, http.StatusInternalServerError, *d.LastStatusCode)
+	assert.NotNil(t, d.LastError)
+	assert.True(t, d.NextAttemptAt.After(before))
+
+	// Тестовый пример 3: Исчерпание попыток переводит доставку в failed
+	d.Attempts = 2
+	dispatcher.deliver(ctx, d)
+	assert.Equal(t, entity.DeliveryFailed, d.Status)
+	assert.Equal(t, 3, d.Attempts)
+
+	// Тестовый пример 4: Без разрешения частных сетей клиент не подключается к loopback, даже если адрес
+	// был сохранен раньше или получен разрешением имени хоста
+	conf := testWebhookConfig()
+	conf.AllowPrivateNetworks = false
+	strict := NewWebhookDispatcher(new(MockWebhookRepo), conf)
+
+	called := false
+	guarded := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer guarded.Close()
+
+	for i, u := range []string{guarded.URL, strings.Replace(guarded.URL, "127.0.0.1", "localhost", 1)} {
+		d = &entity.WebhookDelivery{Id: int64(4 + i), URL: u, Secret: "secret", Payload: payload, Status: entity.DeliveryPending}
+		strict.deliver(ctx, d)
+
+		assert.Equal(t, entity.DeliveryPending, d.Status, u)
+		assert.Nil(t, d.DeliveredAt, u)
+		require.NotNil(t, d.LastError, u)
+		assert.Contains(t, *d.LastError, myError.ErrWebhookURLForbidden.Error(), u)
+	}
+	assert.False(t, called)
+
+	// Тестовый пример 5: Подключение разрешается только к публичным адресам
+	assert.True(t, isPublicAddr(netip.MustParseAddr("93.184.216.34")))
+	assert.False(t, isPublicAddr(netip.MustParseAddr("169.254.169.254")))
+	assert.False(t, isPublicAddr(netip.MustParseAddr("fd00::1")))
+	assert.False(t, isPublicAddr(netip.MustParseAddr("fe80::1")))
+	assert.ErrorIs(t, guardDial("tcp4", "127.0.0.1:80", nil), myError.ErrWebhookURLForbidden)
+	assert.NoError(t, guardDial("tcp4", "93.184.216.34:443", nil))
+}
+
+// TestProcessDue тестирует обработку очереди доставок
+func TestProcessDue(t *testing.T) {
+	logger.Log = zap.NewNop()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	mockRepo := new(MockWebhookRepo)
+	dispatcher := NewWebhookDispatcher(mockRepo, testWebhookConfig())
+	ctx := context.Background()
+
+	d := &entity.WebhookDelivery{Id: 1, URL: server.URL, Secret: "secret", Payload: []byte(`{}`), Status: entity.DeliveryPending}
+	mockRepo.On("ClaimDueDeliveries", ctx, mock.Anything, 2*time.Second, 10).Return([]*entity.WebhookDelivery{d}, nil).Once()
+	mockRepo.On("UpdateDelivery", ctx, mock.MatchedBy(func(d *entity.WebhookDelivery) bool {
+		return d.Id == 1 && d.Status == entity.DeliverySucceeded
+	})).Return(nil).Once()
+
+	dispatcher.processDue(ctx)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestPurgeDeliveries тестирует удаление завершенных доставок старше срока хранения
+func TestPurgeDeliveries(t *testing.T) {
+	logger.Log = zap.NewNop()
+	ctx := context.Background()
+	now := time.Date(2025, time.August, 15, 12, 0, 0, 0, time.UTC)
+	conf := testWebhookConfig()
+	conf.DeliveryRetention = 30 * 24 * time.Hour
+
+	// Тестовый пример 1: Доставки удаляются до срока хранения, пока пачка заполняется целиком
+	mockRepo := new(MockWebhookRepo)
+	dispatcher := NewWebhookDispatcher(mockRepo, conf)
+
+	before := now.Add(-30 * 24 * time.Hour)
+	mockRepo.On("PurgeDeliveries", ctx, before, purgeBatchSize).Return(purgeBatchSize, nil).Once()
+	mockRepo.On("PurgeDeliveries", ctx, before, purgeBatchSize).Return(0, nil).Once()
+
+	assert.Equal(t, purgeBatchSize, dispatcher.purge(ctx, now))
+	mockRepo.AssertExpectations(t)
+
+	// Тестовый пример 2: Ошибка хранилища
+	mockRepo.On("PurgeDeliveries", ctx, before, purgeBatchSize).Return(0, errors.New("db error")).Once()
+
+	assert.Zero(t, dispatcher.purge(ctx, now))
+	mockRepo.AssertExpectations(t)
+}
+
+// TestBackoff тестирует расчет экспоненциальной задержки
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 10*time.Second, backoff(1, 10*time.Second, time.Hour))
+	assert.Equal(t, 20*time.Second, backoff(2, 10*time.Second, time.Hour))
+	assert.Equal(t, 80*time.Second, backoff(4, 10*time.Second, time.Hour))
+	assert.Equal(t, time.Hour, backoff(20, 10*time.Second, time.Hour))
+}
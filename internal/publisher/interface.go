@@ -0,0 +1,13 @@
+package publisher
+
+import (
+	"context"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+)
+
+// Publisher интерфейс для публикации событий о подписках во внешние системы.
+// Реализация должна возвращать ошибку, если не может гарантировать, что событие принято
+type Publisher interface {
+	Publish(ctx context.Context, event *entity.Event) error
+}
@@ -0,0 +1,73 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+)
+
+// Handler - функция-обработчик события, подписанная на Memory
+type Handler func(ctx context.Context, event *entity.Event) error
+
+// Memory - публикатор, передающий события обработчикам внутри процесса и хранящий последние события
+type Memory struct {
+	mu       sync.RWMutex    // защищает поля ниже
+	handlers []Handler       // подписанные обработчики
+	events   []*entity.Event // последние опубликованные события
+	capacity int             // максимальное количество хранимых событий
+}
+
+// NewMemory создает новый объект Memory, хранящий не более capacity последних событий
+func NewMemory(capacity int) *Memory {
+	return &Memory{
+		capacity: capacity,
+	}
+}
+
+// Subscribe подписывает обработчик на все последующие события
+func (m *Memory) Subscribe(handler Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.handlers = append(m.handlers, handler)
+}
+
+// Publish сохраняет событие и синхронно передает его всем обработчикам
+func (m *Memory) Publish(ctx context.Context, event *entity.Event) error {
+	if event == nil {
+		return fmt.Errorf("invalid argument error")
+	}
+
+	m.mu.Lock()
+	if m.capacity > 0 {
+		if len(m.events) >= m.capacity {
+			m.events = m.events[1:]
+		}
+		m.events = append(m.events, event)
+	}
+	handlers := make([]Handler, len(m.handlers))
+	copy(handlers, m.handlers)
+	m.mu.Unlock()
+
+	for _, handler := range handlers {
+		err := handler(ctx, event)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Events возвращает копию списка последних опубликованных событий
+func (m *Memory) Events() []*entity.Event {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	events := make([]*entity.Event, len(m.events))
+	copy(events, m.events)
+
+	return events
+}
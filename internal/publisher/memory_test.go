@@ -0,0 +1,59 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMemoryPublish тестирует публикацию событий в памяти
+func TestMemoryPublish(t *testing.T) {
+	ctx := context.Background()
+	memory := NewMemory(2)
+
+	var received []entity.EventType
+	memory.Subscribe(func(ctx context.Context, event *entity.Event) error {
+		received = append(received, event.Type)
+		return nil
+	})
+
+	// Тестовый пример 1: События передаются обработчикам, хранятся только последние
+	for _, eventType := range []entity.EventType{entity.EventSubscriptionCreated, entity.EventSubscriptionUpdated, entity.EventSubscriptionDeleted} {
+		err := memory.Publish(ctx, entity.NewEvent(eventType, &entity.Subscription{Id: 1}))
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, []entity.EventType{entity.EventSubscriptionCreated, entity.EventSubscriptionUpdated, entity.EventSubscriptionDeleted}, received)
+	events := memory.Events()
+	assert.Len(t, events, 2)
+	assert.Equal(t, entity.EventSubscriptionUpdated, events[0].Type)
+
+	// Тестовый пример 2: Ошибка обработчика возвращается публикующему
+	memory.Subscribe(func(ctx context.Context, event *entity.Event) error {
+		return errors.New("handler error")
+	})
+	err := memory.Publish(ctx, entity.NewEvent(entity.EventSubscriptionEnded, &entity.Subscription{Id: 1}))
+	assert.Error(t, err)
+
+	// Тестовый пример 3: Недопустимый аргумент
+	err = memory.Publish(ctx, nil)
+	assert.Error(t, err)
+}
+
+// TestMultiPublish тестирует публикацию в несколько публикаторов
+func TestMultiPublish(t *testing.T) {
+	ctx := context.Background()
+	first := NewMemory(10)
+	second := NewMemory(10)
+
+	multi := NewMulti(first, nil, second)
+	assert.Len(t, multi, 2)
+
+	err := multi.Publish(ctx, entity.NewEvent(entity.EventSubscriptionCreated, &entity.Subscription{Id: 1}))
+	assert.NoError(t, err)
+	assert.Len(t, first.Events(), 1)
+	assert.Len(t, second.Events(), 1)
+}
@@ -0,0 +1,35 @@
+package publisher
+
+import (
+	"context"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+)
+
+// Multi - публикатор, передающий событие нескольким публикаторам по очереди
+type Multi []Publisher
+
+// NewMulti создает публикатор из списка, пропуская nil
+func NewMulti(publishers ...Publisher) Multi {
+	multi := make(Multi, 0, len(publishers))
+	for _, p := range publishers {
+		if p != nil {
+			multi = append(multi, p)
+		}
+	}
+
+	return multi
+}
+
+// Publish публикует событие во все публикаторы. При ошибке публикация прерывается,
+// и событие будет отправлено повторно, поэтому получатели должны быть идемпотентными
+func (m Multi) Publish(ctx context.Context, event *entity.Event) error {
+	for _, p := range m {
+		err := p.Publish(ctx, event)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
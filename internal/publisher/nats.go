@@ -0,0 +1,75 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/nats-io/nats.go"
+)
+
+// NATS - публикатор событий в NATS. Тема сообщения строится как "<prefix>.<тип события>"
+type NATS struct {
+	conn   *nats.Conn            // соединение с NATS
+	js     nats.JetStreamContext // контекст JetStream (nil, если JetStream не используется)
+	prefix string                // префикс темы
+}
+
+// NewNATS подключается к NATS. Если jetStream = true, публикация ждет подтверждения от потока JetStream,
+// иначе событие считается принятым после сброса буфера соединения на сервер
+func NewNATS(url string, prefix string, jetStream bool) (*NATS, error) {
+	conn, err := nats.Connect(url, nats.Name("subscription-aggregation-service"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to nats: %w", err)
+	}
+
+	p := &NATS{
+		conn:   conn,
+		prefix: prefix,
+	}
+
+	if jetStream {
+		js, err := conn.JetStream()
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("unable to init jetstream: %w", err)
+		}
+		p.js = js
+	}
+
+	return p, nil
+}
+
+// Publish публикует событие. Id события передается в заголовке Nats-Msg-Id для дедупликации в JetStream
+func (p *NATS) Publish(ctx context.Context, event *entity.Event) error {
+	if event == nil {
+		return fmt.Errorf("invalid argument error")
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(p.prefix + "." + string(event.Type))
+	msg.Header.Set(nats.MsgIdHdr, event.Id.String())
+	msg.Data = data
+
+	if p.js != nil {
+		_, err = p.js.PublishMsg(msg, nats.Context(ctx))
+		return err
+	}
+
+	err = p.conn.PublishMsg(msg)
+	if err != nil {
+		return err
+	}
+
+	return p.conn.FlushWithContext(ctx)
+}
+
+// Close закрывает соединение с NATS, дожидаясь отправки буфера
+func (p *NATS) Close() error {
+	return p.conn.Drain()
+}
@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// bucket - состояние корзины токенов
+type bucket struct {
+	tokens    float64       // количество токенов на момент updatedAt
+	updatedAt time.Time     // время последнего обращения к корзине
+	fillTime  time.Duration // время заполнения пустой корзины
+}
+
+// Memory - хранилище корзин в памяти процесса. Подходит для одного экземпляра сервиса
+type Memory struct {
+	mu      sync.Mutex         // защищает buckets
+	buckets map[string]*bucket // корзины по ключам
+	now     func() time.Time   // источник текущего времени
+}
+
+// NewMemory создает новый объект Memory
+func NewMemory() *Memory {
+	return &Memory{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Take пытается взять токен из корзины key
+func (m *Memory) Take(_ context.Context, key string, limit Limit) (*Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updatedAt: now}
+		m.buckets[key] = b
+	}
+
+	b.tokens = refill(b.tokens, now.Sub(b.updatedAt), limit)
+	b.updatedAt = now
+	b.fillTime = limit.FillTime()
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	return newResult(allowed, b.tokens, limit), nil
+}
+
+// Run периодически удаляет заполнившиеся корзины до отмены ctx
+func (m *Memory) Run(ctx context.Context) {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.cleanup()
+		}
+	}
+}
+
+// cleanup удаляет корзины, которые заполнились с момента последнего обращения:
+// новая корзина для этого ключа будет в том же состоянии
+func (m *Memory) cleanup() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for key, b := range m.buckets {
+		if now.Sub(b.updatedAt) >= b.fillTime {
+			delete(m.buckets, key)
+		}
+	}
+}
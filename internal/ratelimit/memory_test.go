@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMemoryTake тестирует корзину токенов в памяти
+func TestMemoryTake(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	memory := NewMemory()
+	memory.now = func() time.Time { return now }
+	limit := Limit{Rate: 0.5, Burst: 2}
+
+	// Тестовый пример 1: Запросы в пределах емкости корзины разрешены
+	for remaining := 1; remaining >= 0; remaining-- {
+		res, err := memory.Take(ctx, "client", limit)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 2, res.Limit)
+		assert.Equal(t, remaining, res.Remaining)
+	}
+
+	// Тестовый пример 2: Пустая корзина - запрос отклонен, токен появится через 2 секунды
+	res, err := memory.Take(ctx, "client", limit)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 2*time.Second, res.RetryAfter)
+	assert.Equal(t, 4*time.Second, res.ResetAfter)
+
+	// Тестовый пример 3: Корзины разных клиентов независимы
+	res, err = memory.Take(ctx, "other", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+
+	// Тестовый пример 4: Корзина пополняется со временем
+	now = now.Add(2 * time.Second)
+	res, err = memory.Take(ctx, "client", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+
+	// Тестовый пример 5: Заполнившиеся корзины удаляются
+	now = now.Add(3 * time.Second)
+	memory.cleanup()
+	assert.Contains(t, memory.buckets, "client")
+	assert.NotContains(t, memory.buckets, "other")
+}
@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/logger"
+	"github.com/Ararat25/subscription-aggregation-service/internal/repository"
+	"go.uber.org/zap"
+)
+
+// Postgres - хранилище корзин в таблице rate_limits. Несколько экземпляров сервиса делят одни и те же корзины
+type Postgres struct {
+	repo    repository.RateLimitRepo // хранилище состояния корзин
+	idleTTL time.Duration            // через сколько после последнего обращения корзина удаляется
+}
+
+// NewPostgres создает новый объект Postgres. idleTTL должен быть не меньше времени заполнения самой медленной корзины,
+// иначе удаленная корзина начнет заново с полного набора токенов раньше, чем заполнилась бы
+func NewPostgres(repo repository.RateLimitRepo, idleTTL time.Duration) *Postgres {
+	return &Postgres{
+		repo:    repo,
+		idleTTL: idleTTL,
+	}
+}
+
+// Take пытается взять токен из корзины key. Пополнение и списание выполняются одним запросом к бд
+func (p *Postgres) Take(ctx context.Context, key string, limit Limit) (*Result, error) {
+	tokens, allowed, err := p.repo.TakeRateLimitToken(ctx, key, limit.Rate, limit.Burst)
+	if err != nil {
+		return nil, err
+	}
+
+	return newResult(allowed, tokens, limit), nil
+}
+
+// Run периодически удаляет неиспользуемые корзины до отмены ctx
+func (p *Postgres) Run(ctx context.Context) {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := p.repo.DeleteIdleRateLimits(ctx, time.Now().Add(-p.idleTTL))
+			if err != nil {
+				logger.Log.Error("failed to delete idle rate limits", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit - параметры корзины токенов
+type Limit struct {
+	Rate  float64 // скорость пополнения корзины, токенов в секунду
+	Burst int     // емкость корзины - сколько запросов можно сделать подряд
+}
+
+// Result - результат попытки взять токен из корзины
+type Result struct {
+	Allowed    bool          // запрос разрешен
+	Limit      int           // емкость корзины
+	Remaining  int           // оставшееся количество токенов
+	RetryAfter time.Duration // через сколько появится токен, если запрос не разрешен
+	ResetAfter time.Duration // через сколько корзина заполнится полностью
+}
+
+// Store - хранилище состояния корзин токенов
+type Store interface {
+	// Take пытается взять токен из корзины key
+	Take(ctx context.Context, key string, limit Limit) (*Result, error)
+	// Run периодически удаляет корзины, которые успели заполниться и больше не нужны
+	Run(ctx context.Context)
+}
+
+// cleanupInterval - период удаления неиспользуемых корзин
+const cleanupInterval = time.Minute
+
+// refill возвращает количество токенов в корзине, в которой было tokens токенов elapsed назад
+func refill(tokens float64, elapsed time.Duration, limit Limit) float64 {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return math.Min(float64(limit.Burst), tokens+elapsed.Seconds()*limit.Rate)
+}
+
+// newResult возвращает результат по количеству токенов, оставшихся после попытки взять токен
+func newResult(allowed bool, tokens float64, limit Limit) *Result {
+	res := &Result{
+		Allowed:    allowed,
+		Limit:      limit.Burst,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: limit.wait(float64(limit.Burst) - tokens),
+	}
+
+	if !allowed {
+		res.RetryAfter = limit.wait(1 - tokens)
+	}
+
+	return res
+}
+
+// wait возвращает время, за которое в корзину поступит tokens токенов
+func (l Limit) wait(tokens float64) time.Duration {
+	if tokens <= 0 {
+		return 0
+	}
+	if l.Rate <= 0 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(tokens / l.Rate * float64(time.Second))
+}
+
+// FillTime возвращает время, за которое пустая корзина заполняется полностью
+func (l Limit) FillTime() time.Duration {
+	return l.wait(float64(l.Burst))
+}
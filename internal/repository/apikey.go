@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/jackc/pgx/v5"
+)
+
+// apiKeyColumns - колонки API-ключа в порядке, ожидаемом scanAPIKey
+const apiKeyColumns = `id, name, prefix, key_hash, scopes, user_id, expires_at, last_used_at, revoked_at, created_at, tenant_id`
+
+// CreateAPIKey добавляет API-ключ в бд и возвращает id
+func (repo *PGRepo) CreateAPIKey(ctx context.Context, k *entity.APIKey) (int64, error) {
+	if k == nil {
+		return 0, fmt.Errorf("invalid argument error")
+	}
+
+	var id int64
+	err := repo.inTx(ctx, func(tx pgx.Tx) error {
+		return tx.QueryRow(ctx,
+			`INSERT INTO api_keys (tenant_id, name, prefix, key_hash, scopes, user_id, expires_at)
+             VALUES (current_tenant_id(), $1, $2, $3, $4, $5, $6)
+             RETURNING id, created_at, tenant_id`,
+			k.Name, k.Prefix, k.KeyHash, scopesToStrings(k.Scopes), k.UserId, k.ExpiresAt).Scan(&id, &k.CreatedAt, &k.TenantId)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// ListAPIKeys возвращает список всех API-ключей тенанта, включая отозванные
+func (repo *PGRepo) ListAPIKeys(ctx context.Context) ([]*entity.APIKey, error) {
+	keys := []*entity.APIKey{}
+	err := repo.inTx(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE `+tenantCondition+` ORDER BY id`)
+		if err != nil {
+			return err
+		}
+
+		keys, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (*entity.APIKey, error) {
+			return scanAPIKey(row)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает API-ключ. Повторный отзыв не меняет время отзыва
+func (repo *PGRepo) RevokeAPIKey(ctx context.Context, id int64) error {
+	return repo.inTx(ctx, func(tx pgx.Tx) error {
+		cmdTag, err := tx.Exec(ctx,
+			`UPDATE api_keys SET revoked_at = COALESCE(revoked_at, now()) WHERE id = $1 AND `+tenantCondition, id)
+		if err != nil {
+			return err
+		}
+
+		if cmdTag.RowsAffected() == 0 {
+			return myError.ErrAPIKeyNotFound
+		}
+
+		return nil
+	})
+}
+
+// FindAPIKey возвращает API-ключ по хэшу, в том числе отозванный или истекший. Ключ ищется среди всех тенантов:
+// тенант запроса определяется по найденному ключу
+func (repo *PGRepo) FindAPIKey(ctx context.Context, keyHash string) (*entity.APIKey, error) {
+	var k *entity.APIKey
+	err := repo.inSystemTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		k, err = scanAPIKey(tx.QueryRow(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = $1`, keyHash))
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, myError.ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// TouchAPIKey записывает время последнего использования API-ключа
+func (repo *PGRepo) TouchAPIKey(ctx context.Context, id int64, usedAt time.Time) error {
+	err := repo.inSystemTx(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`, id, usedAt)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// scanAPIKey читает API-ключ из строки результата
+func scanAPIKey(row pgx.Row) (*entity.APIKey, error) {
+	var (
+		k      entity.APIKey
+		scopes []string
+	)
+
+	err := row.Scan(&k.Id, &k.Name, &k.Prefix, &k.KeyHash, &scopes, &k.UserId, &k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt, &k.CreatedAt, &k.TenantId)
+	if err != nil {
+		return nil, err
+	}
+	k.Scopes = stringsToScopes(scopes)
+
+	return &k, nil
+}
+
+// scopesToStrings преобразует права ключа в строки для записи в бд
+func scopesToStrings(scopes []entity.Scope) []string {
+	res := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		res = append(res, string(s))
+	}
+	return res
+}
+
+// stringsToScopes преобразует строки из бд в права ключа
+func stringsToScopes(scopes []string) []entity.Scope {
+	res := make([]entity.Scope, 0, len(scopes))
+	for _, s := range scopes {
+		res = append(res, entity.Scope(s))
+	}
+	return res
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/jackc/pgx/v5"
+)
+
+// ArchiveSubscriptions переносит в архив не больше limit подписок всех тенантов, закончившихся раньше
+// месяца endedBefore, и возвращает количество перенесенных. Помесячные итоги не меняются: архивные подписки
+// учитываются в стоимости так же, как до переноса. Строки, заблокированные изменением, пропускаются до следующего
+// запуска
+func (repo *PGRepo) ArchiveSubscriptions(ctx context.Context, endedBefore time.Time, limit int) (int, error) {
+	var archived int
+	err := repo.inSystemTx(ctx, func(tx pgx.Tx) error {
+		cmdTag, err := tx.Exec(ctx,
+			`WITH moved AS (
+                 DELETE FROM subscriptions
+                 WHERE id IN (SELECT id FROM subscriptions
+                              WHERE end_date < $1
+                              ORDER BY id
+                              LIMIT $2 FOR UPDATE SKIP LOCKED)
+                 RETURNING tenant_id, `+subscriptionColumns+`)
+             INSERT INTO subscriptions_archive (tenant_id, `+subscriptionColumns+`)
+             SELECT tenant_id, `+subscriptionColumns+` FROM moved`,
+			endedBefore, limit)
+		if err != nil {
+			return err
+		}
+
+		archived = int(cmdTag.RowsAffected())
+
+		return nil
+	})
+	return archived, err
+}
+
+// ListSubscriptionHistory возвращает подписки вместе с архивными с фильтрацией по пользователю, категории и тегам
+func (repo *PGRepo) ListSubscriptionHistory(ctx context.Context, filter *entity.SubscriptionFilter) ([]*entity.Subscription, error) {
+	query, args := appendSubscriptionFilter(`SELECT `+subscriptionColumns+` FROM subscription_history WHERE `+tenantCondition, nil, filter)
+	query += ` ORDER BY id`
+
+	var subs []*entity.Subscription
+	err := repo.inReadTx(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+
+		subs, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (*entity.Subscription, error) {
+			return scanSubscription(row)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// serviceColumns - колонки сервиса в порядке, ожидаемом scanService
+const serviceColumns = `id, name, aliases, category, default_price, website`
+
+// uniqueViolation - код ошибки postgres при нарушении уникальности
+const uniqueViolation = "23505"
+
+// CreateService добавляет сервис в каталог и возвращает id
+func (repo *PGRepo) CreateService(ctx context.Context, s *entity.Service) (int64, error) {
+	if s == nil {
+		return 0, fmt.Errorf("invalid argument error")
+	}
+
+	var id int64
+	err := repo.inTx(ctx, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx,
+			`INSERT INTO services (tenant_id, name, aliases, category, default_price, website)
+             VALUES (current_tenant_id(), $1, $2, $3, $4, $5)
+             RETURNING id`,
+			s.Name, s.Aliases, s.Category, s.DefaultPrice, s.Website).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		return insertServiceNames(ctx, tx, id, s)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// ReadService возвращает сервис из каталога по id
+func (repo *PGRepo) ReadService(ctx context.Context, id int64) (*entity.Service, error) {
+	var s *entity.Service
+	err := repo.inTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		s, err = scanService(tx.QueryRow(ctx,
+			`SELECT `+serviceColumns+` FROM services WHERE id = $1 AND `+tenantCondition, id))
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, myError.ErrServiceNotFound
+		}
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// UpdateService обновляет сервис в каталоге. При смене канонического названия оно переносится в подписки сервиса,
+// для каждой измененной подписки записывается событие subscription.updated
+func (repo *PGRepo) UpdateService(ctx context.Context, s *entity.Service) error {
+	if s == nil {
+		return fmt.Errorf("invalid argument error")
+	}
+
+	return repo.inWriteTx(ctx, func(tx pgx.Tx) error {
+		cmdTag, err := tx.Exec(ctx,
+			`UPDATE services SET name = $1, aliases = $2, category = $3, default_price = $4, website = $5
+             WHERE id = $6 AND `+tenantCondition,
+			s.Name, s.Aliases, s.Category, s.DefaultPrice, s.Website, s.Id)
+		if err != nil {
+			return err
+		}
+
+		if cmdTag.RowsAffected() == 0 {
+			return myError.ErrServiceNotFound
+		}
+
+		_, err = tx.Exec(ctx, `DELETE FROM service_names WHERE service_id = $1 AND `+tenantCondition, s.Id)
+		if err != nil {
+			return err
+		}
+
+		err = insertServiceNames(ctx, tx, s.Id, s)
+		if err != nil {
+			return err
+		}
+
+		// прежние названия нужны, чтобы перенести стоимость подписок в итогах на новое название
+		rows, err := tx.Query(ctx,
+			`SELECT `+subscriptionColumns+` FROM subscriptions
+             WHERE service_id = $2 AND service_name <> $1 AND `+tenantCondition+`
+             FOR UPDATE`,
+			s.Name, s.Id)
+		if err != nil {
+			return err
+		}
+
+		before, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*entity.Subscription, error) {
+			return scanSubscription(row)
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, sub := range before {
+			after := *sub
+			after.ServiceName = s.Name
+
+			err = replaceMonthlyCosts(ctx, tx, sub, &after)
+			if err != nil {
+				return err
+			}
+		}
+
+		rows, err = tx.Query(ctx,
+			`UPDATE subscriptions SET service_name = $1
+             WHERE service_id = $2 AND service_name <> $1 AND `+tenantCondition+`
+             RETURNING `+subscriptionColumns,
+			s.Name, s.Id)
+		if err != nil {
+			return err
+		}
+
+		renamed, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*entity.Subscription, error) {
+			return scanSubscription(row)
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, sub := range renamed {
+			err = insertOutbox(ctx, tx, entity.EventSubscriptionUpdated, sub)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// DeleteService удаляет сервис из каталога. Подписки сервиса сохраняют название, но теряют ссылку на каталог
+func (repo *PGRepo) DeleteService(ctx context.Context, id int64) error {
+	return repo.inWriteTx(ctx, func(tx pgx.Tx) error {
+		cmdTag, err := tx.Exec(ctx, `DELETE FROM services WHERE id = $1 AND `+tenantCondition, id)
+		if err != nil {
+			return err
+		}
+
+		if cmdTag.RowsAffected() == 0 {
+			return myError.ErrServiceNotFound
+		}
+
+		return nil
+	})
+}
+
+// ListServices возвращает каталог сервисов тенанта, упорядоченный по названию
+func (repo *PGRepo) ListServices(ctx context.Context) ([]*entity.Service, error) {
+	services := []*entity.Service{}
+	err := repo.inTx(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx,
+			`SELECT `+serviceColumns+` FROM services WHERE `+tenantCondition+` ORDER BY lower(name), id`)
+		if err != nil {
+			return err
+		}
+
+		services, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (*entity.Service, error) {
+			return scanService(row)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return services, nil
+}
+
+// ResolveService ищет сервис, каноническое название или псевдоним которого совпадает с name
+// без учета регистра и пробелов по краям
+func (repo *PGRepo) ResolveService(ctx context.Context, name string) (*entity.Service, error) {
+	var s *entity.Service
+	err := repo.inTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		s, err = scanService(tx.QueryRow(ctx,
+			`SELECT `+serviceColumns+` FROM services
+             WHERE id = (SELECT service_id FROM service_names WHERE name_key = lower(btrim($1)) AND `+tenantCondition+`)
+               AND `+tenantCondition, name))
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, myError.ErrServiceNotFound
+		}
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// insertServiceNames сохраняет нормализованные написания названия сервиса. Если написание уже занято другим
+// сервисом, возвращает myError.ErrServiceNameTaken
+func insertServiceNames(ctx context.Context, tx pgx.Tx, id int64, s *entity.Service) error {
+	names := append([]string{s.Name}, s.Aliases...)
+
+	_, err := tx.Exec(ctx,
+		`INSERT INTO service_names (tenant_id, name_key, service_id)
+         SELECT DISTINCT current_tenant_id(), lower(btrim(name)), $2 FROM unnest($1::text[]) AS name`,
+		names, id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return myError.ErrServiceNameTaken
+		}
+		return err
+	}
+
+	return nil
+}
+
+// scanService читает сервис из строки результата с колонками serviceColumns
+func scanService(row pgx.Row) (*entity.Service, error) {
+	var s entity.Service
+	err := row.Scan(&s.Id, &s.Name, &s.Aliases, &s.Category, &s.DefaultPrice, &s.Website)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
@@ -4,18 +4,118 @@ import (
 	"context"
 	"time"
 
+	"github.com/Ararat25/subscription-aggregation-service/internal/config"
 	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
 	"github.com/google/uuid"
 )
 
 // Repo интерфейс хранилища для подписок
 type Repo interface {
-	ConnectDB(ctx context.Context, dbHost string, dbUser string, dbPassword string, dbName string, dbPort int) error
+	ConnectDB(ctx context.Context, conf config.DatabaseConfig) error
 	CreateSubscription(ctx context.Context, s *entity.Subscription) (int64, error)
 	ReadSubscription(ctx context.Context, id int64) (*entity.Subscription, error)
 	UpdateSubscription(ctx context.Context, s *entity.Subscription) error
 	DeleteSubscription(ctx context.Context, id int64) error
-	ListSubscriptions(ctx context.Context) ([]*entity.Subscription, error)
-	TotalCost(ctx context.Context, from, to time.Time, userID *uuid.UUID, serviceName *string) (int, error)
+	CancelSubscription(ctx context.Context, id int64, endDate time.Time, reason *string) (*entity.Subscription, error)
+	ListSubscriptions(ctx context.Context, filter *entity.SubscriptionFilter) ([]*entity.Subscription, error)
+	ListSubscriptionHistory(ctx context.Context, filter *entity.SubscriptionFilter) ([]*entity.Subscription, error)
+	TotalCost(ctx context.Context, from, to time.Time, filter *entity.CostFilter) (int, error)
+	CostBreakdown(ctx context.Context, from, to time.Time, filter *entity.CostFilter, groupBy entity.GroupBy) ([]*entity.CostGroup, error)
+	SearchSubscriptions(ctx context.Context, query string, userID *uuid.UUID, limit int) ([]*entity.SubscriptionMatch, error)
+	SuggestServiceNames(ctx context.Context, query string, userID *uuid.UUID, limit int) ([]*entity.ServiceSuggestion, error)
+	ResolveService(ctx context.Context, name string) (*entity.Service, error)
+	SubscriptionsVersion(ctx context.Context) (*entity.TableVersion, error)
+	WithTx(ctx context.Context, fn func(tx Repo) error) error
 	Close(ctx context.Context) error
 }
+
+// CatalogRepo интерфейс хранилища каталога сервисов
+type CatalogRepo interface {
+	CreateService(ctx context.Context, s *entity.Service) (int64, error)
+	ReadService(ctx context.Context, id int64) (*entity.Service, error)
+	UpdateService(ctx context.Context, s *entity.Service) error
+	DeleteService(ctx context.Context, id int64) error
+	ListServices(ctx context.Context) ([]*entity.Service, error)
+}
+
+// WebhookRepo интерфейс хранилища для вебхуков и журнала их доставок
+type WebhookRepo interface {
+	CreateWebhook(ctx context.Context, w *entity.Webhook) (int64, error)
+	ListWebhooks(ctx context.Context) ([]*entity.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int64) error
+	CreateDeliveries(ctx context.Context, event *entity.Event, payload []byte) (int, error)
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*entity.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, d *entity.WebhookDelivery) error
+	ListDeliveries(ctx context.Context, webhookID int64) ([]*entity.WebhookDelivery, error)
+	RedeliverDelivery(ctx context.Context, id int64) error
+	PurgeDeliveries(ctx context.Context, finishedBefore time.Time, limit int) (int, error)
+}
+
+// OutboxRepo интерфейс хранилища outbox с событиями, записанными вместе с изменением подписок
+type OutboxRepo interface {
+	ClaimOutbox(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*entity.OutboxMessage, error)
+	MarkOutboxPublished(ctx context.Context, id int64) error
+	MarkOutboxFailed(ctx context.Context, id int64, errMsg string, nextAttemptAt time.Time) error
+	PurgeOutbox(ctx context.Context, publishedBefore time.Time, limit int) (int, error)
+}
+
+// RateLimitRepo интерфейс хранилища корзин токенов для ограничения частоты запросов
+type RateLimitRepo interface {
+	TakeRateLimitToken(ctx context.Context, key string, rate float64, burst int) (float64, bool, error)
+	DeleteIdleRateLimits(ctx context.Context, before time.Time) error
+}
+
+// APIKeyRepo интерфейс хранилища API-ключей
+type APIKeyRepo interface {
+	CreateAPIKey(ctx context.Context, k *entity.APIKey) (int64, error)
+	ListAPIKeys(ctx context.Context) ([]*entity.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int64) error
+	FindAPIKey(ctx context.Context, keyHash string) (*entity.APIKey, error)
+	TouchAPIKey(ctx context.Context, id int64, usedAt time.Time) error
+}
+
+// TenantRepo интерфейс хранилища тенантов
+type TenantRepo interface {
+	CreateTenant(ctx context.Context, t *entity.Tenant) error
+	ListTenants(ctx context.Context) ([]*entity.Tenant, error)
+	TenantCosts(ctx context.Context, from, to time.Time) ([]*entity.TenantCost, error)
+}
+
+// PoolStatsRepo интерфейс хранилища, отдающего состояние пула соединений с бд
+type PoolStatsRepo interface {
+	PoolStats(ctx context.Context) (*entity.PoolStats, error)
+}
+
+// CostRollupRepo интерфейс хранилища помесячных итогов стоимости подписок
+type CostRollupRepo interface {
+	RebuildMonthlyCosts(ctx context.Context) (*entity.MonthlyCostsRebuild, error)
+	CheckMonthlyCosts(ctx context.Context) (*entity.MonthlyCostsCheck, error)
+	AdvanceMonthlyCosts(ctx context.Context) (time.Time, error)
+}
+
+// ArchiveRepo интерфейс хранилища, переносящего давно закончившиеся подписки в архив
+type ArchiveRepo interface {
+	ArchiveSubscriptions(ctx context.Context, endedBefore time.Time, limit int) (int, error)
+}
+
+// PrivacyRepo интерфейс хранилища, выгружающего и удаляющего данные пользователя по его запросу
+type PrivacyRepo interface {
+	ExportUserData(ctx context.Context, userID uuid.UUID) (*entity.UserData, error)
+	EraseUserData(ctx context.Context, userID uuid.UUID, receipt *entity.ErasureReceipt, sign func(*entity.ErasureReceipt) string) error
+	ReadErasureReceipt(ctx context.Context, id uuid.UUID) (*entity.ErasureReceipt, error)
+}
+
+// Storage интерфейс хранилища всех данных сервиса, реализуется PGRepo, SQLiteRepo и MemRepo
+type Storage interface {
+	Repo
+	CatalogRepo
+	WebhookRepo
+	OutboxRepo
+	RateLimitRepo
+	APIKeyRepo
+	TenantRepo
+	PoolStatsRepo
+	CostRollupRepo
+	ArchiveRepo
+	PrivacyRepo
+}
@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/config"
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/Ararat25/subscription-aggregation-service/internal/tenant"
+	"github.com/google/uuid"
+)
+
+// MemRepo - хранилище в памяти процесса с той же семантикой, что и PGRepo: те же ошибки, порядок выдачи,
+// правила подсчета стоимости и изоляция тенантов. Данные теряются при перезапуске, поэтому хранилище подходит
+// для локальных демонстраций и быстрых тестов без Postgres. Каждый метод выполняется под общей блокировкой
+// и, как транзакция PGRepo, либо применяет все изменения, либо не применяет ни одного
+type MemRepo struct {
+	mu  sync.Mutex
+	now func() time.Time // источник текущего времени
+
+	seq           map[string]int64                      // последние выданные id по таблицам
+	tenants       []*entity.Tenant                      // тенанты в порядке создания
+	versions      map[uuid.UUID]*entity.TableVersion    // счетчики изменений подписок по тенантам
+	subscriptions []*memRow[entity.Subscription]        // подписки в порядке id
+	archived      []*memRow[entity.Subscription]        // архивные подписки в порядке переноса
+	services      []*memRow[entity.Service]             // каталог сервисов в порядке id
+	serviceNames  map[memNameKey]int64                  // нормализованные написания названий сервисов
+	webhooks      []*memRow[entity.Webhook]             // вебхуки в порядке id
+	deliveries    []*memRow[entity.WebhookDelivery]     // журнал доставок в порядке id
+	outbox        []*memOutbox                          // outbox в порядке id
+	apiKeys       []*entity.APIKey                      // API-ключи в порядке id
+	rateLimits    map[string]*memBucket                 // корзины токенов по ключам
+	monthlyCosts  map[monthlyCostKey]*monthlyCostTotals // помесячные итоги стоимости подписок
+	costsThrough  time.Time                             // месяц, по который в итогах учтены подписки без даты окончания
+	receipts      []*entity.ErasureReceipt              // квитанции об удалении данных пользователей в порядке выдачи
+}
+
+// memRow - строка таблицы тенанта
+type memRow[T any] struct {
+	tenantID uuid.UUID // тенант, которому принадлежит строка
+	value    T         // данные строки
+}
+
+// memNameKey - ключ написания названия сервиса, уникальный внутри тенанта
+type memNameKey struct {
+	tenantID uuid.UUID // тенант сервиса
+	name     string    // название в нижнем регистре без пробелов по краям
+}
+
+// memOutbox - запись outbox
+type memOutbox struct {
+	id            int64      // id записи
+	tenantID      uuid.UUID  // тенант события
+	payload       []byte     // событие в формате JSON
+	attempts      int        // количество неудачных попыток публикации
+	lastError     *string    // текст последней ошибки
+	nextAttemptAt time.Time  // время следующей попытки
+	publishedAt   *time.Time // время публикации
+}
+
+// memBucket - корзина токенов ограничения частоты запросов
+type memBucket struct {
+	tokens    float64   // количество токенов на момент updatedAt
+	updatedAt time.Time // время последнего обращения
+}
+
+// NewMemRepo возвращает пустое хранилище в памяти с тенантом по умолчанию
+func NewMemRepo() *MemRepo {
+	repo := &MemRepo{
+		now:          time.Now,
+		seq:          make(map[string]int64),
+		versions:     make(map[uuid.UUID]*entity.TableVersion),
+		serviceNames: make(map[memNameKey]int64),
+		rateLimits:   make(map[string]*memBucket),
+		monthlyCosts: make(map[monthlyCostKey]*monthlyCostTotals),
+	}
+
+	now := repo.now()
+	repo.costsThrough = monthOf(now)
+	repo.tenants = append(repo.tenants, &entity.Tenant{Id: tenant.DefaultID, Name: "default", CreatedAt: now})
+	repo.versions[tenant.DefaultID] = &entity.TableVersion{UpdatedAt: now}
+
+	return repo
+}
+
+// ConnectDB ничего не делает: хранилищу в памяти не к чему подключаться
+func (repo *MemRepo) ConnectDB(_ context.Context, _ config.DatabaseConfig) error {
+	return nil
+}
+
+// Close ничего не делает: данные хранилища освобождаются вместе с ним
+func (repo *MemRepo) Close(_ context.Context) error {
+	return nil
+}
+
+// PoolStats возвращает нулевое состояние пула: у хранилища в памяти нет соединений
+func (repo *MemRepo) PoolStats(_ context.Context) (*entity.PoolStats, error) {
+	return &entity.PoolStats{}, nil
+}
+
+// nextID возвращает следующий id таблицы table, как последовательность BIGSERIAL
+func (repo *MemRepo) nextID(table string) int64 {
+	repo.seq[table]++
+	return repo.seq[table]
+}
+
+// bumpVersion увеличивает счетчик изменений подписок тенанта, как триггер subscriptions_bump_version
+func (repo *MemRepo) bumpVersion(tenantID uuid.UUID) {
+	v, ok := repo.versions[tenantID]
+	if !ok {
+		v = &entity.TableVersion{}
+		repo.versions[tenantID] = v
+	}
+
+	v.Version++
+	v.UpdatedAt = repo.now()
+}
+
+// newOutbox готовит запись outbox с событием о подписке. Запись добавляется вызывающим вместе с изменением,
+// чтобы ошибка подготовки не оставила изменение без события
+func (repo *MemRepo) newOutbox(tenantID uuid.UUID, eventType entity.EventType, sub *entity.Subscription) (*memOutbox, error) {
+	event := entity.NewEvent(eventType, sub)
+	event.TenantId = tenantID
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	return &memOutbox{tenantID: tenantID, payload: payload, nextAttemptAt: repo.now()}, nil
+}
+
+// newChangeEvents готовит событие subscription.updated, а если у подписки появилась дата окончания -
+// еще и subscription.ended
+func (repo *MemRepo) newChangeEvents(tenantID uuid.UUID, before, after *entity.Subscription) ([]*memOutbox, error) {
+	updated, err := repo.newOutbox(tenantID, entity.EventSubscriptionUpdated, after)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isEnded(before, after) {
+		return []*memOutbox{updated}, nil
+	}
+
+	ended, err := repo.newOutbox(tenantID, entity.EventSubscriptionEnded, after)
+	if err != nil {
+		return nil, err
+	}
+
+	return []*memOutbox{updated, ended}, nil
+}
+
+// appendOutbox добавляет подготовленные записи outbox, выдавая им id
+func (repo *MemRepo) appendOutbox(messages ...*memOutbox) {
+	for _, msg := range messages {
+		msg.id = repo.nextID("outbox")
+		repo.outbox = append(repo.outbox, msg)
+	}
+}
+
+// memTenant возвращает тенанта из контекста. Без тенанта возвращает myError.ErrTenantRequired, как PGRepo
+func memTenant(ctx context.Context) (uuid.UUID, error) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return uuid.Nil, myError.ErrTenantRequired
+	}
+
+	return tenantID, nil
+}
+
+// toDate отбрасывает время, оставляя дату, как при записи в колонку типа date
+func toDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// costToday возвращает дату, по которую действуют подписки без даты окончания: дату фильтра или дату now,
+// если в фильтре она не указана
+func costToday(filter *entity.CostFilter, now time.Time) time.Time {
+	if filter == nil || filter.Today.IsZero() {
+		return toDate(now)
+	}
+
+	return toDate(filter.Today)
+}
+
+// monthOf возвращает первое число месяца даты t, как date_trunc('month', ...)
+func monthOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// toDatePtr отбрасывает время у необязательной даты
+func toDatePtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	d := toDate(*t)
+	return &d
+}
+
+// cloneStrings копирует срез строк, заменяя nil пустым срезом, как массив со значением по умолчанию '{}'
+func cloneStrings(s []string) []string {
+	return append([]string{}, s...)
+}
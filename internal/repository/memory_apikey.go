@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+)
+
+// CreateAPIKey добавляет API-ключ и возвращает id
+func (repo *MemRepo) CreateAPIKey(ctx context.Context, k *entity.APIKey) (int64, error) {
+	if k == nil {
+		return 0, fmt.Errorf("invalid argument error")
+	}
+
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if repo.findAPIKey(func(stored *entity.APIKey) bool { return stored.KeyHash == k.KeyHash }) != nil {
+		return 0, fmt.Errorf("api key hash already exists")
+	}
+
+	k.CreatedAt = repo.now()
+	k.TenantId = tenantID
+
+	stored := cloneAPIKey(k)
+	stored.Id = repo.nextID("api_keys")
+	stored.Key = ""
+	repo.apiKeys = append(repo.apiKeys, stored)
+
+	return stored.Id, nil
+}
+
+// ListAPIKeys возвращает список всех API-ключей тенанта, включая отозванные
+func (repo *MemRepo) ListAPIKeys(ctx context.Context) ([]*entity.APIKey, error) {
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	keys := []*entity.APIKey{}
+	for _, k := range repo.apiKeys {
+		if k.TenantId == tenantID {
+			keys = append(keys, cloneAPIKey(k))
+		}
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает API-ключ. Повторный отзыв не меняет время отзыва
+func (repo *MemRepo) RevokeAPIKey(ctx context.Context, id int64) error {
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	k := repo.findAPIKey(func(stored *entity.APIKey) bool { return stored.TenantId == tenantID && stored.Id == id })
+	if k == nil {
+		return myError.ErrAPIKeyNotFound
+	}
+
+	if k.RevokedAt == nil {
+		now := repo.now()
+		k.RevokedAt = &now
+	}
+
+	return nil
+}
+
+// FindAPIKey возвращает API-ключ по хэшу, в том числе отозванный или истекший. Ключ ищется среди всех тенантов:
+// тенант запроса определяется по найденному ключу
+func (repo *MemRepo) FindAPIKey(_ context.Context, keyHash string) (*entity.APIKey, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	k := repo.findAPIKey(func(stored *entity.APIKey) bool { return stored.KeyHash == keyHash })
+	if k == nil {
+		return nil, myError.ErrAPIKeyNotFound
+	}
+
+	return cloneAPIKey(k), nil
+}
+
+// TouchAPIKey записывает время последнего использования API-ключа
+func (repo *MemRepo) TouchAPIKey(_ context.Context, id int64, usedAt time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if k := repo.findAPIKey(func(stored *entity.APIKey) bool { return stored.Id == id }); k != nil {
+		k.LastUsedAt = &usedAt
+	}
+
+	return nil
+}
+
+// findAPIKey возвращает первый API-ключ, подходящий под условие match, или nil
+func (repo *MemRepo) findAPIKey(match func(k *entity.APIKey) bool) *entity.APIKey {
+	for _, k := range repo.apiKeys {
+		if match(k) {
+			return k
+		}
+	}
+
+	return nil
+}
+
+// cloneAPIKey возвращает копию API-ключа, не разделяющую с ним память
+func cloneAPIKey(k *entity.APIKey) *entity.APIKey {
+	c := *k
+	c.Scopes = append([]entity.Scope{}, k.Scopes...)
+	c.UserId = clonePtr(k.UserId)
+	c.ExpiresAt = clonePtr(k.ExpiresAt)
+	c.LastUsedAt = clonePtr(k.LastUsedAt)
+	c.RevokedAt = clonePtr(k.RevokedAt)
+
+	return &c
+}
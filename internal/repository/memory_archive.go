@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/google/uuid"
+)
+
+// ArchiveSubscriptions переносит в архив не больше limit подписок всех тенантов, закончившихся раньше
+// месяца endedBefore, и возвращает количество перенесенных. Помесячные итоги не меняются
+func (repo *MemRepo) ArchiveSubscriptions(_ context.Context, endedBefore time.Time, limit int) (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	endedBefore = toDate(endedBefore)
+	bumped := make(map[uuid.UUID]bool)
+	archived := 0
+	repo.subscriptions = slices.DeleteFunc(repo.subscriptions, func(row *memRow[entity.Subscription]) bool {
+		if archived == limit || row.value.EndDate == nil || !row.value.EndDate.Before(endedBefore) {
+			return false
+		}
+
+		repo.archived = append(repo.archived, row)
+		archived++
+
+		if !bumped[row.tenantID] {
+			bumped[row.tenantID] = true
+			repo.bumpVersion(row.tenantID)
+		}
+
+		return true
+	})
+
+	return archived, nil
+}
+
+// ListSubscriptionHistory возвращает подписки вместе с архивными с фильтрацией по пользователю, категории и тегам
+func (repo *MemRepo) ListSubscriptionHistory(ctx context.Context, filter *entity.SubscriptionFilter) ([]*entity.Subscription, error) {
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var subs []*entity.Subscription
+	for _, row := range repo.history() {
+		if row.tenantID == tenantID && matchSubscriptionFilter(&row.value, filter) {
+			subs = append(subs, cloneSubscription(&row.value))
+		}
+	}
+
+	return subs, nil
+}
+
+// history возвращает подписки вместе с архивными в порядке id, как представление subscription_history
+func (repo *MemRepo) history() []*memRow[entity.Subscription] {
+	rows := slices.Concat(repo.subscriptions, repo.archived)
+	slices.SortFunc(rows, func(a, b *memRow[entity.Subscription]) int {
+		return a.value.Id - b.value.Id
+	})
+
+	return rows
+}
@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/google/uuid"
+)
+
+// CreateService добавляет сервис в каталог и возвращает id
+func (repo *MemRepo) CreateService(ctx context.Context, s *entity.Service) (int64, error) {
+	if s == nil {
+		return 0, fmt.Errorf("invalid argument error")
+	}
+
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	id := repo.nextID("services")
+
+	err = repo.insertServiceNames(tenantID, id, s)
+	if err != nil {
+		return 0, err
+	}
+
+	stored := cloneService(s)
+	stored.Id = id
+	repo.services = append(repo.services, &memRow[entity.Service]{tenantID: tenantID, value: *stored})
+
+	return id, nil
+}
+
+// ReadService возвращает сервис из каталога по id
+func (repo *MemRepo) ReadService(ctx context.Context, id int64) (*entity.Service, error) {
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	row := repo.findService(tenantID, id)
+	if row == nil {
+		return nil, myError.ErrServiceNotFound
+	}
+
+	return cloneService(&row.value), nil
+}
+
+// UpdateService обновляет сервис в каталоге. При смене канонического названия оно переносится в подписки сервиса,
+// для каждой измененной подписки записывается событие subscription.updated
+func (repo *MemRepo) UpdateService(ctx context.Context, s *entity.Service) error {
+	if s == nil {
+		return fmt.Errorf("invalid argument error")
+	}
+
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	row := repo.findService(tenantID, s.Id)
+	if row == nil {
+		return myError.ErrServiceNotFound
+	}
+
+	var (
+		renamed  []*memRow[entity.Subscription]
+		messages []*memOutbox
+	)
+	for _, sub := range repo.subscriptions {
+		if sub.tenantID != tenantID || sub.value.ServiceId == nil || *sub.value.ServiceId != s.Id || sub.value.ServiceName == s.Name {
+			continue
+		}
+
+		after := cloneSubscription(&sub.value)
+		after.ServiceName = s.Name
+
+		msg, err := repo.newOutbox(tenantID, entity.EventSubscriptionUpdated, after)
+		if err != nil {
+			return err
+		}
+
+		renamed = append(renamed, sub)
+		messages = append(messages, msg)
+	}
+
+	old := repo.deleteServiceNames(tenantID, s.Id)
+	err = repo.insertServiceNames(tenantID, s.Id, s)
+	if err != nil {
+		for _, key := range old {
+			repo.serviceNames[key] = s.Id
+		}
+		return err
+	}
+
+	stored := cloneService(s)
+	row.value = *stored
+
+	for _, sub := range renamed {
+		after := sub.value
+		after.ServiceName = s.Name
+
+		repo.replaceMonthlyCosts(tenantID, &sub.value, &after)
+		sub.value.ServiceName = s.Name
+	}
+	repo.appendOutbox(messages...)
+	repo.bumpVersion(tenantID)
+
+	return nil
+}
+
+// DeleteService удаляет сервис из каталога. Подписки сервиса сохраняют название, но теряют ссылку на каталог
+func (repo *MemRepo) DeleteService(ctx context.Context, id int64) error {
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	row := repo.findService(tenantID, id)
+	if row == nil {
+		return myError.ErrServiceNotFound
+	}
+
+	repo.services = slices.DeleteFunc(repo.services, func(r *memRow[entity.Service]) bool {
+		return r == row
+	})
+	repo.deleteServiceNames(tenantID, id)
+
+	for _, sub := range repo.subscriptions {
+		if sub.tenantID == tenantID && sub.value.ServiceId != nil && *sub.value.ServiceId == id {
+			sub.value.ServiceId = nil
+		}
+	}
+	repo.bumpVersion(tenantID)
+
+	return nil
+}
+
+// ListServices возвращает каталог сервисов тенанта, упорядоченный по названию
+func (repo *MemRepo) ListServices(ctx context.Context) ([]*entity.Service, error) {
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	services := []*entity.Service{}
+	for _, row := range repo.services {
+		if row.tenantID == tenantID {
+			services = append(services, cloneService(&row.value))
+		}
+	}
+
+	sort.SliceStable(services, func(i, j int) bool {
+		a, b := strings.ToLower(services[i].Name), strings.ToLower(services[j].Name)
+		if a != b {
+			return a < b
+		}
+		return services[i].Id < services[j].Id
+	})
+
+	return services, nil
+}
+
+// ResolveService ищет сервис, каноническое название или псевдоним которого совпадает с name
+// без учета регистра и пробелов по краям
+func (repo *MemRepo) ResolveService(ctx context.Context, name string) (*entity.Service, error) {
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	id, ok := repo.serviceNames[memNameKey{tenantID: tenantID, name: normalizeName(name)}]
+	if !ok {
+		return nil, myError.ErrServiceNotFound
+	}
+
+	row := repo.findService(tenantID, id)
+	if row == nil {
+		return nil, myError.ErrServiceNotFound
+	}
+
+	return cloneService(&row.value), nil
+}
+
+// findService возвращает строку сервиса тенанта по id или nil
+func (repo *MemRepo) findService(tenantID uuid.UUID, id int64) *memRow[entity.Service] {
+	for _, row := range repo.services {
+		if row.tenantID == tenantID && row.value.Id == id {
+			return row
+		}
+	}
+
+	return nil
+}
+
+// insertServiceNames сохраняет нормализованные написания названия сервиса. Если написание уже занято другим
+// сервисом, ничего не сохраняет и возвращает myError.ErrServiceNameTaken
+func (repo *MemRepo) insertServiceNames(tenantID uuid.UUID, id int64, s *entity.Service) error {
+	var keys []memNameKey
+	for _, name := range append([]string{s.Name}, s.Aliases...) {
+		key := memNameKey{tenantID: tenantID, name: normalizeName(name)}
+		if _, taken := repo.serviceNames[key]; taken {
+			return myError.ErrServiceNameTaken
+		}
+		keys = append(keys, key)
+	}
+
+	for _, key := range keys {
+		repo.serviceNames[key] = id
+	}
+
+	return nil
+}
+
+// deleteServiceNames удаляет написания названия сервиса и возвращает удаленные ключи
+func (repo *MemRepo) deleteServiceNames(tenantID uuid.UUID, id int64) []memNameKey {
+	var deleted []memNameKey
+	for key, serviceID := range repo.serviceNames {
+		if key.tenantID == tenantID && serviceID == id {
+			delete(repo.serviceNames, key)
+			deleted = append(deleted, key)
+		}
+	}
+
+	return deleted
+}
+
+// cloneService возвращает копию сервиса, не разделяющую с ним память
+func cloneService(s *entity.Service) *entity.Service {
+	c := *s
+	c.Aliases = cloneStrings(s.Aliases)
+	c.Category = clonePtr(s.Category)
+	c.DefaultPrice = clonePtr(s.DefaultPrice)
+	c.Website = clonePtr(s.Website)
+
+	return &c
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+)
+
+// ClaimOutbox выбирает неопубликованные события в порядке записи и откладывает их на время lease,
+// чтобы параллельные ретрансляторы не публиковали одно событие одновременно
+func (repo *MemRepo) ClaimOutbox(_ context.Context, now time.Time, lease time.Duration, limit int) ([]*entity.OutboxMessage, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var claimed []*memOutbox
+	for _, row := range repo.outbox {
+		if len(claimed) == limit {
+			break
+		}
+
+		if row.publishedAt == nil && !row.nextAttemptAt.After(now) {
+			claimed = append(claimed, row)
+		}
+	}
+
+	messages := make([]*entity.OutboxMessage, 0, len(claimed))
+	for _, row := range claimed {
+		msg := &entity.OutboxMessage{Id: row.id, Attempts: row.attempts}
+		err := json.Unmarshal(row.payload, &msg.Event)
+		if err != nil {
+			return nil, err
+		}
+		msg.Event.TenantId = row.tenantID
+
+		messages = append(messages, msg)
+	}
+
+	for _, row := range claimed {
+		row.nextAttemptAt = now.Add(lease)
+	}
+
+	return messages, nil
+}
+
+// MarkOutboxPublished отмечает событие как опубликованное
+func (repo *MemRepo) MarkOutboxPublished(_ context.Context, id int64) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if row := repo.findOutbox(id); row != nil {
+		now := repo.now()
+		row.publishedAt = &now
+		row.lastError = nil
+	}
+
+	return nil
+}
+
+// MarkOutboxFailed сохраняет ошибку публикации и время следующей попытки
+func (repo *MemRepo) MarkOutboxFailed(_ context.Context, id int64, errMsg string, nextAttemptAt time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if row := repo.findOutbox(id); row != nil {
+		row.attempts++
+		row.lastError = &errMsg
+		row.nextAttemptAt = nextAttemptAt
+	}
+
+	return nil
+}
+
+// PurgeOutbox удаляет до limit событий, опубликованных раньше publishedBefore, и возвращает количество удаленных
+func (repo *MemRepo) PurgeOutbox(_ context.Context, publishedBefore time.Time, limit int) (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	purged := 0
+	repo.outbox = slices.DeleteFunc(repo.outbox, func(row *memOutbox) bool {
+		if purged == limit || row.publishedAt == nil || !row.publishedAt.Before(publishedBefore) {
+			return false
+		}
+
+		purged++
+		return true
+	})
+
+	return purged, nil
+}
+
+// findOutbox возвращает запись outbox по id или nil
+func (repo *MemRepo) findOutbox(id int64) *memOutbox {
+	for _, row := range repo.outbox {
+		if row.id == id {
+			return row
+		}
+	}
+
+	return nil
+}
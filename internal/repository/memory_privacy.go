@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/google/uuid"
+)
+
+// ExportUserData возвращает все данные тенанта о пользователе: подписки, в том числе архивные, помесячные итоги,
+// события outbox и доставки вебхуков о его подписках и API-ключи, действующие от его имени
+func (repo *MemRepo) ExportUserData(ctx context.Context, userID uuid.UUID) (*entity.UserData, error) {
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	data := &entity.UserData{UserId: userID}
+	for _, row := range repo.subscriptions {
+		if row.tenantID == tenantID && row.value.UserId == userID {
+			data.Subscriptions = append(data.Subscriptions, cloneSubscription(&row.value))
+		}
+	}
+
+	for _, row := range repo.archived {
+		if row.tenantID == tenantID && row.value.UserId == userID {
+			data.ArchivedSubscriptions = append(data.ArchivedSubscriptions, cloneSubscription(&row.value))
+		}
+	}
+	slices.SortFunc(data.ArchivedSubscriptions, func(a, b *entity.Subscription) int {
+		return a.Id - b.Id
+	})
+
+	for key, t := range repo.monthlyCosts {
+		if key.tenantID == tenantID && key.userID == userID {
+			data.MonthlyCosts = append(data.MonthlyCosts, &entity.UserMonthlyCost{
+				Month: key.month, ServiceName: key.serviceName, Total: t.total, Subscriptions: t.subscriptions})
+		}
+	}
+	slices.SortFunc(data.MonthlyCosts, func(a, b *entity.UserMonthlyCost) int {
+		if c := a.Month.Compare(b.Month); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ServiceName, b.ServiceName)
+	})
+
+	for _, msg := range repo.outbox {
+		if msg.tenantID != tenantID {
+			continue
+		}
+
+		event, err := userEvent(msg.payload, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		if event != nil {
+			event.TenantId = tenantID
+			data.Events = append(data.Events, event)
+		}
+	}
+
+	for _, row := range repo.deliveries {
+		if row.tenantID != tenantID {
+			continue
+		}
+
+		event, err := userEvent(row.value.Payload, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		if event != nil {
+			data.Deliveries = append(data.Deliveries, cloneDelivery(&row.value))
+		}
+	}
+
+	for _, k := range repo.apiKeys {
+		if k.TenantId == tenantID && k.UserId != nil && *k.UserId == userID {
+			data.APIKeys = append(data.APIKeys, cloneAPIKey(k))
+		}
+	}
+
+	return data, nil
+}
+
+// EraseUserData удаляет подписки пользователя, в том числе архивные, его помесячные итоги и API-ключи,
+// а в событиях outbox и доставках вебхуков о его подписках заменяет id пользователя нулевым UUID и удаляет
+// причину отмены, как EraseUserData у PGRepo
+func (repo *MemRepo) EraseUserData(ctx context.Context, userID uuid.UUID, receipt *entity.ErasureReceipt, sign func(*entity.ErasureReceipt) string) error {
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	// обезличенные события готовятся до удаления, чтобы ошибка разбора не оставила удаление без квитанции
+	outbox := make(map[*memOutbox][]byte)
+	for _, msg := range repo.outbox {
+		if msg.tenantID != tenantID {
+			continue
+		}
+
+		payload, err := anonymizeEvent(msg.payload, userID)
+		if err != nil {
+			return err
+		}
+
+		if payload != nil {
+			outbox[msg] = payload
+		}
+	}
+
+	deliveries := make(map[*memRow[entity.WebhookDelivery]][]byte)
+	for _, row := range repo.deliveries {
+		if row.tenantID != tenantID {
+			continue
+		}
+
+		payload, err := anonymizeEvent(row.value.Payload, userID)
+		if err != nil {
+			return err
+		}
+
+		if payload != nil {
+			deliveries[row] = payload
+		}
+	}
+
+	isUserRow := func(row *memRow[entity.Subscription]) bool {
+		return row.tenantID == tenantID && row.value.UserId == userID
+	}
+
+	before := len(repo.subscriptions)
+	repo.subscriptions = slices.DeleteFunc(repo.subscriptions, isUserRow)
+	receipt.Subscriptions = before - len(repo.subscriptions)
+
+	before = len(repo.archived)
+	repo.archived = slices.DeleteFunc(repo.archived, isUserRow)
+	receipt.ArchivedSubscriptions = before - len(repo.archived)
+
+	receipt.MonthlyCosts = 0
+	for key := range repo.monthlyCosts {
+		if key.tenantID == tenantID && key.userID == userID {
+			delete(repo.monthlyCosts, key)
+			receipt.MonthlyCosts++
+		}
+	}
+
+	before = len(repo.apiKeys)
+	repo.apiKeys = slices.DeleteFunc(repo.apiKeys, func(k *entity.APIKey) bool {
+		return k.TenantId == tenantID && k.UserId != nil && *k.UserId == userID
+	})
+	receipt.APIKeys = before - len(repo.apiKeys)
+
+	for msg, payload := range outbox {
+		msg.payload = payload
+	}
+	receipt.Events = len(outbox)
+
+	for row, payload := range deliveries {
+		row.value.Payload = payload
+	}
+	receipt.Deliveries = len(deliveries)
+
+	if receipt.Subscriptions > 0 || receipt.ArchivedSubscriptions > 0 {
+		repo.bumpVersion(tenantID)
+	}
+
+	receipt.TenantId = tenantID
+	receipt.Digest = sign(receipt)
+
+	stored := *receipt
+	repo.receipts = append(repo.receipts, &stored)
+
+	return nil
+}
+
+// ReadErasureReceipt возвращает квитанцию об удалении данных по id
+func (repo *MemRepo) ReadErasureReceipt(ctx context.Context, id uuid.UUID) (*entity.ErasureReceipt, error) {
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, r := range repo.receipts {
+		if r.TenantId == tenantID && r.Id == id {
+			receipt := *r
+			return &receipt, nil
+		}
+	}
+
+	return nil, myError.ErrErasureReceiptNotFound
+}
+
+// userEvent разбирает событие и возвращает его, если оно о подписке пользователя userID, иначе nil
+func userEvent(payload []byte, userID uuid.UUID) (*entity.Event, error) {
+	var event entity.Event
+	err := json.Unmarshal(payload, &event)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.Data == nil || event.Data.UserId != userID {
+		return nil, nil
+	}
+
+	return &event, nil
+}
+
+// anonymizeEvent возвращает событие о подписке пользователя userID, в котором id пользователя заменен нулевым
+// UUID, а причина отмены удалена. Для событий о подписках других пользователей возвращает nil
+func anonymizeEvent(payload []byte, userID uuid.UUID) ([]byte, error) {
+	event, err := userEvent(payload, userID)
+	if err != nil || event == nil {
+		return nil, err
+	}
+
+	event.Data.UserId = uuid.Nil
+	event.Data.CancellationReason = nil
+
+	return json.Marshal(event)
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// TakeRateLimitToken пополняет корзину key со скоростью rate токенов в секунду (не больше burst) и берет из нее токен,
+// если он есть. Возвращает количество оставшихся токенов и признак того, что токен взят
+func (repo *MemRepo) TakeRateLimitToken(_ context.Context, key string, rate float64, burst int) (float64, bool, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	now := repo.now()
+
+	b, ok := repo.rateLimits[key]
+	if !ok {
+		b = &memBucket{tokens: float64(burst)}
+	} else {
+		b.tokens = min(float64(burst), b.tokens+now.Sub(b.updatedAt).Seconds()*rate)
+	}
+	repo.rateLimits[key] = b
+
+	b.updatedAt = now
+	if b.tokens < 1 {
+		return b.tokens, false, nil
+	}
+
+	b.tokens--
+	return b.tokens, true, nil
+}
+
+// DeleteIdleRateLimits удаляет корзины, к которым не обращались с момента before
+func (repo *MemRepo) DeleteIdleRateLimits(_ context.Context, before time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for key, b := range repo.rateLimits {
+		if b.updatedAt.Before(before) {
+			delete(repo.rateLimits, key)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/google/uuid"
+)
+
+// RebuildMonthlyCosts заново рассчитывает помесячные итоги стоимости тенанта по его подпискам
+func (repo *MemRepo) RebuildMonthlyCosts(ctx context.Context) (*entity.MonthlyCostsRebuild, error) {
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	maps.DeleteFunc(repo.monthlyCosts, func(key monthlyCostKey, _ *monthlyCostTotals) bool {
+		return key.tenantID == tenantID
+	})
+
+	live := repo.liveMonthlyCosts(tenantID)
+	maps.Copy(repo.monthlyCosts, live)
+
+	return &entity.MonthlyCostsRebuild{Rows: len(live), ThroughMonth: repo.costsThrough}, nil
+}
+
+// CheckMonthlyCosts сверяет помесячные итоги стоимости тенанта с расчетом по его подпискам
+func (repo *MemRepo) CheckMonthlyCosts(ctx context.Context) (*entity.MonthlyCostsCheck, error) {
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	rollup := make(map[monthlyCostKey]*monthlyCostTotals)
+	for key, t := range repo.monthlyCosts {
+		if key.tenantID == tenantID {
+			rollup[key] = t
+		}
+	}
+
+	return &entity.MonthlyCostsCheck{
+		Rows:         len(rollup),
+		ThroughMonth: repo.costsThrough,
+		Mismatches:   diffMonthlyCosts(rollup, repo.liveMonthlyCosts(tenantID)),
+	}, nil
+}
+
+// AdvanceMonthlyCosts продлевает итоги всех тенантов по текущий месяц: подписки без даты окончания
+// добавляются в месяцы после прежнего месяца продления. Возвращает месяц, по который продлены итоги
+func (repo *MemRepo) AdvanceMonthlyCosts(_ context.Context) (time.Time, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	current := monthOf(repo.now())
+	if !current.After(repo.costsThrough) {
+		return repo.costsThrough, nil
+	}
+
+	for _, row := range repo.subscriptions {
+		s := &row.value
+		if s.EndDate != nil {
+			continue
+		}
+
+		for _, m := range subscriptionMonths(s, current) {
+			if !m.After(repo.costsThrough) {
+				continue
+			}
+
+			key := monthlyCostKey{tenantID: row.tenantID, month: m, userID: s.UserId, serviceName: s.ServiceName}
+			t, ok := repo.monthlyCosts[key]
+			if !ok {
+				t = &monthlyCostTotals{}
+				repo.monthlyCosts[key] = t
+			}
+			t.total += s.Price
+			t.subscriptions++
+		}
+	}
+
+	repo.costsThrough = current
+
+	return current, nil
+}
+
+// replaceMonthlyCosts переносит подписку тенанта в итогах из состояния before в состояние after
+func (repo *MemRepo) replaceMonthlyCosts(tenantID uuid.UUID, before, after *entity.Subscription) {
+	addMonthlyCostTotals(repo.monthlyCosts, tenantID, before, repo.costsThrough, -1)
+	addMonthlyCostTotals(repo.monthlyCosts, tenantID, after, repo.costsThrough, 1)
+}
+
+// monthlyCostsTotal возвращает стоимость подписок тенанта за месяц из итогов. Фильтр ограничивает только
+// пользователя и точное название сервиса, как проверяет rollupCostMonth
+func (repo *MemRepo) monthlyCostsTotal(tenantID uuid.UUID, month time.Time, filter *entity.CostFilter) int {
+	total := 0
+	for key, t := range repo.monthlyCosts {
+		if key.tenantID != tenantID || !key.month.Equal(month) {
+			continue
+		}
+
+		if filter != nil && filter.UserId != nil && key.userID != *filter.UserId {
+			continue
+		}
+
+		if filter != nil && filter.ServiceName != nil && key.serviceName != filter.ServiceName.Name {
+			continue
+		}
+
+		total += t.total
+	}
+
+	return total
+}
+
+// liveMonthlyCosts рассчитывает помесячные итоги стоимости тенанта по его подпискам
+func (repo *MemRepo) liveMonthlyCosts(tenantID uuid.UUID) map[monthlyCostKey]*monthlyCostTotals {
+	live := make(map[monthlyCostKey]*monthlyCostTotals)
+	for _, row := range repo.history() {
+		if row.tenantID == tenantID {
+			addMonthlyCostTotals(live, tenantID, &row.value, repo.costsThrough, 1)
+		}
+	}
+
+	return live
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/google/uuid"
+)
+
+// SearchSubscriptions ищет подписки по названию сервиса без учета регистра: подходят названия, начинающиеся с запроса,
+// и названия, похожие на запрос по триграммам. Сначала идут совпадения по началу названия, затем - по убыванию сходства.
+// Если userID не nil, ищутся только подписки этого пользователя
+func (repo *MemRepo) SearchSubscriptions(ctx context.Context, query string, userID *uuid.UUID, limit int) ([]*entity.SubscriptionMatch, error) {
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	q := normalizeName(query)
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	type match struct {
+		*entity.SubscriptionMatch
+		prefix bool // название начинается с запроса
+	}
+
+	var found []match
+	for _, row := range repo.subscriptions {
+		s := &row.value
+		if row.tenantID != tenantID || (userID != nil && s.UserId != *userID) {
+			continue
+		}
+
+		score := trigramSimilarity(strings.ToLower(s.ServiceName), q)
+		prefix := strings.HasPrefix(normalizeName(s.ServiceName), q)
+		if score < similarityThreshold && !prefix {
+			continue
+		}
+
+		found = append(found, match{
+			SubscriptionMatch: &entity.SubscriptionMatch{Subscription: cloneSubscription(s), Score: float64(score)},
+			prefix:            prefix,
+		})
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		a, b := found[i], found[j]
+		if a.prefix != b.prefix {
+			return a.prefix
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.Subscription.Id < b.Subscription.Id
+	})
+
+	var matches []*entity.SubscriptionMatch
+	for _, m := range found {
+		if len(matches) == limit {
+			break
+		}
+		matches = append(matches, m.SubscriptionMatch)
+	}
+
+	return matches, nil
+}
+
+// SuggestServiceNames возвращает варианты автодополнения названия сервиса. Названия, отличающиеся только регистром
+// и пробелами по краям, объединяются, в ответ попадает самое частое написание.
+// Если userID не nil, учитываются только подписки этого пользователя
+func (repo *MemRepo) SuggestServiceNames(ctx context.Context, query string, userID *uuid.UUID, limit int) ([]*entity.ServiceSuggestion, error) {
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	q := normalizeName(query)
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	type group struct {
+		spellings map[string]int // количество подписок с каждым написанием без пробелов по краям
+		count     int            // количество подписок группы
+		score     float32        // наибольшее сходство названия с запросом
+		prefix    bool           // хотя бы одно название начинается с запроса
+	}
+
+	groups := make(map[string]*group)
+	for _, row := range repo.subscriptions {
+		s := &row.value
+		if row.tenantID != tenantID || (userID != nil && s.UserId != *userID) {
+			continue
+		}
+
+		score := trigramSimilarity(strings.ToLower(s.ServiceName), q)
+		key := normalizeName(s.ServiceName)
+		prefix := strings.HasPrefix(key, q)
+		if score < similarityThreshold && !prefix {
+			continue
+		}
+
+		g, ok := groups[key]
+		if !ok {
+			g = &group{spellings: make(map[string]int)}
+			groups[key] = g
+		}
+		g.spellings[strings.TrimSpace(s.ServiceName)]++
+		g.count++
+		g.score = max(g.score, score)
+		g.prefix = g.prefix || prefix
+	}
+
+	type suggestion struct {
+		*entity.ServiceSuggestion
+		prefix bool
+	}
+
+	found := make([]suggestion, 0, len(groups))
+	for _, g := range groups {
+		found = append(found, suggestion{
+			ServiceSuggestion: &entity.ServiceSuggestion{
+				ServiceName:   modeSpelling(g.spellings),
+				Subscriptions: g.count,
+				Score:         float64(g.score),
+			},
+			prefix: g.prefix,
+		})
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		a, b := found[i], found[j]
+		if a.prefix != b.prefix {
+			return a.prefix
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Subscriptions != b.Subscriptions {
+			return a.Subscriptions > b.Subscriptions
+		}
+		return a.ServiceName < b.ServiceName
+	})
+
+	suggestions := []*entity.ServiceSuggestion{}
+	for _, s := range found {
+		if len(suggestions) == limit {
+			break
+		}
+		suggestions = append(suggestions, s.ServiceSuggestion)
+	}
+
+	return suggestions, nil
+}
+
+// modeSpelling возвращает самое частое написание, а из одинаково частых - первое по порядку сортировки,
+// как агрегат mode() WITHIN GROUP (ORDER BY ...)
+func modeSpelling(spellings map[string]int) string {
+	best, bestCount := "", 0
+	for spelling, count := range spellings {
+		if count > bestCount || (count == bestCount && spelling < best) {
+			best, bestCount = spelling, count
+		}
+	}
+
+	return best
+}
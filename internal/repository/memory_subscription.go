@@ -0,0 +1,432 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/google/uuid"
+)
+
+// CreateSubscription добавляет подписку вместе с событием subscription.created и возвращает id
+func (repo *MemRepo) CreateSubscription(ctx context.Context, s *entity.Subscription) (int64, error) {
+	if s == nil {
+		return 0, fmt.Errorf("invalid argument error")
+	}
+
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	// как и последовательность в бд, id не возвращается при ошибке
+	id := repo.nextID("subscriptions")
+
+	created := *s
+	created.Id = int(id)
+
+	msg, err := repo.newOutbox(tenantID, entity.EventSubscriptionCreated, &created)
+	if err != nil {
+		return 0, err
+	}
+
+	stored := storedSubscription(&created)
+	stored.CancellationReason = nil
+	stored.CancelledAt = nil
+	repo.subscriptions = append(repo.subscriptions, &memRow[entity.Subscription]{tenantID: tenantID, value: *stored})
+	addMonthlyCostTotals(repo.monthlyCosts, tenantID, stored, repo.costsThrough, 1)
+	repo.appendOutbox(msg)
+	repo.bumpVersion(tenantID)
+
+	return id, nil
+}
+
+// ReadSubscription возвращает подписку по id
+func (repo *MemRepo) ReadSubscription(ctx context.Context, id int64) (*entity.Subscription, error) {
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	row := repo.findSubscription(tenantID, id)
+	if row == nil {
+		return nil, myError.ErrSubscriptionNotFound
+	}
+
+	return cloneSubscription(&row.value), nil
+}
+
+// UpdateSubscription обновляет данные подписки и записывает событие subscription.updated,
+// а если у подписки появилась дата окончания - еще и subscription.ended
+func (repo *MemRepo) UpdateSubscription(ctx context.Context, s *entity.Subscription) error {
+	if s == nil {
+		return fmt.Errorf("invalid argument error: subscription is nil")
+	}
+
+	if s.Id == 0 {
+		return fmt.Errorf("invalid argument error: missing subscription ID")
+	}
+
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	row := repo.findSubscription(tenantID, int64(s.Id))
+	if row == nil {
+		return myError.ErrSubscriptionNotFound
+	}
+
+	after := storedSubscription(s)
+	// при снятии даты окончания подписка снова активна, и причина отмены больше не актуальна
+	after.CancellationReason = nil
+	after.CancelledAt = nil
+	if after.EndDate != nil {
+		after.CancellationReason = clonePtr(row.value.CancellationReason)
+		after.CancelledAt = clonePtr(row.value.CancelledAt)
+	}
+
+	messages, err := repo.newChangeEvents(tenantID, &row.value, after)
+	if err != nil {
+		return err
+	}
+
+	repo.replaceMonthlyCosts(tenantID, &row.value, after)
+	row.value = *after
+	repo.appendOutbox(messages...)
+	repo.bumpVersion(tenantID)
+
+	return nil
+}
+
+// DeleteSubscription удаляет подписку и записывает событие subscription.deleted
+func (repo *MemRepo) DeleteSubscription(ctx context.Context, id int64) error {
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	row := repo.findSubscription(tenantID, id)
+	if row == nil {
+		return myError.ErrSubscriptionNotFound
+	}
+
+	msg, err := repo.newOutbox(tenantID, entity.EventSubscriptionDeleted, &row.value)
+	if err != nil {
+		return err
+	}
+
+	repo.subscriptions = slices.DeleteFunc(repo.subscriptions, func(r *memRow[entity.Subscription]) bool {
+		return r == row
+	})
+	addMonthlyCostTotals(repo.monthlyCosts, tenantID, &row.value, repo.costsThrough, -1)
+	repo.appendOutbox(msg)
+	repo.bumpVersion(tenantID)
+
+	return nil
+}
+
+// CancelSubscription устанавливает дату окончания подписки и сохраняет причину отмены
+func (repo *MemRepo) CancelSubscription(ctx context.Context, id int64, endDate time.Time, reason *string) (*entity.Subscription, error) {
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	row := repo.findSubscription(tenantID, id)
+	if row == nil {
+		return nil, myError.ErrSubscriptionNotFound
+	}
+
+	now := repo.now()
+	after := cloneSubscription(&row.value)
+	after.EndDate = toDatePtr(&endDate)
+	after.CancellationReason = clonePtr(reason)
+	after.CancelledAt = &now
+
+	messages, err := repo.newChangeEvents(tenantID, &row.value, after)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.replaceMonthlyCosts(tenantID, &row.value, after)
+	row.value = *after
+	repo.appendOutbox(messages...)
+	repo.bumpVersion(tenantID)
+
+	return cloneSubscription(after), nil
+}
+
+// ListSubscriptions возвращает список подписок с фильтрацией по пользователю, категории и тегам
+func (repo *MemRepo) ListSubscriptions(ctx context.Context, filter *entity.SubscriptionFilter) ([]*entity.Subscription, error) {
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var subs []*entity.Subscription
+	for _, row := range repo.subscriptions {
+		if row.tenantID == tenantID && matchSubscriptionFilter(&row.value, filter) {
+			subs = append(subs, cloneSubscription(&row.value))
+		}
+	}
+
+	return subs, nil
+}
+
+// TotalCost возвращает суммарную стоимость подписок за определенный период с фильтрацией.
+// Стоимость за один месяц по пользователю и точному названию сервиса берется из помесячных итогов
+func (repo *MemRepo) TotalCost(ctx context.Context, from, to time.Time, filter *entity.CostFilter) (int, error) {
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if month, ok := rollupCostMonth(from, to, filter); ok && !month.After(repo.costsThrough) {
+		return repo.monthlyCostsTotal(tenantID, month, filter), nil
+	}
+
+	total := 0
+	for _, s := range repo.costSubscriptions(tenantID, from, to, filter) {
+		total += s.Price
+	}
+
+	return total, nil
+}
+
+// CostBreakdown возвращает суммарную стоимость подписок за определенный период в разрезе категорий или тегов.
+// Подписка с несколькими тегами учитывается в группе каждого тега
+func (repo *MemRepo) CostBreakdown(ctx context.Context, from, to time.Time, filter *entity.CostFilter, groupBy entity.GroupBy) ([]*entity.CostGroup, error) {
+	if groupBy != entity.GroupByCategory && groupBy != entity.GroupByTag {
+		return nil, fmt.Errorf("invalid argument error: unknown group by %q", groupBy)
+	}
+
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	// подписки без категории или без тегов попадают в группу с ключом nil
+	totals := make(map[string]int)
+	noKey, hasNoKey := 0, false
+	add := func(key *string, price int) {
+		if key == nil {
+			noKey += price
+			hasNoKey = true
+			return
+		}
+		totals[*key] += price
+	}
+
+	for _, s := range repo.costSubscriptions(tenantID, from, to, filter) {
+		if groupBy == entity.GroupByCategory {
+			add(s.Category, s.Price)
+			continue
+		}
+
+		if len(s.Tags) == 0 {
+			add(nil, s.Price)
+		}
+		for _, tag := range s.Tags {
+			add(&tag, s.Price)
+		}
+	}
+
+	var groups []*entity.CostGroup
+	for key, total := range totals {
+		groups = append(groups, &entity.CostGroup{Key: &key, TotalCost: total})
+	}
+	if hasNoKey {
+		groups = append(groups, &entity.CostGroup{TotalCost: noKey})
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		if a.TotalCost != b.TotalCost {
+			return a.TotalCost > b.TotalCost
+		}
+		if a.Key == nil || b.Key == nil {
+			return b.Key == nil && a.Key != nil
+		}
+		return *a.Key < *b.Key
+	})
+
+	return groups, nil
+}
+
+// SubscriptionsVersion возвращает счетчик изменений подписок тенанта
+func (repo *MemRepo) SubscriptionsVersion(ctx context.Context) (*entity.TableVersion, error) {
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	v, ok := repo.versions[tenantID]
+	if !ok {
+		return &entity.TableVersion{}, nil
+	}
+
+	res := *v
+	return &res, nil
+}
+
+// findSubscription возвращает строку подписки тенанта по id или nil
+func (repo *MemRepo) findSubscription(tenantID uuid.UUID, id int64) *memRow[entity.Subscription] {
+	for _, row := range repo.subscriptions {
+		if row.tenantID == tenantID && int64(row.value.Id) == id {
+			return row
+		}
+	}
+
+	return nil
+}
+
+// costSubscriptions возвращает подписки тенанта вместе с архивными, пересекающиеся с периодом [from, to] и подходящие под фильтр
+func (repo *MemRepo) costSubscriptions(tenantID uuid.UUID, from, to time.Time, filter *entity.CostFilter) []*entity.Subscription {
+	from, to = toDate(from), toDate(to)
+	today := costToday(filter, repo.now())
+
+	var subs []*entity.Subscription
+	for _, row := range repo.history() {
+		s := &row.value
+		if row.tenantID == tenantID && inCostPeriod(s, from, to, today) && matchCostFilter(s, filter) {
+			subs = append(subs, s)
+		}
+	}
+
+	return subs
+}
+
+// inCostPeriod проверяет пересечение подписки с периодом [from, to] так же, как costPeriodCondition:
+// подписка без даты окончания считается действующей по today
+func inCostPeriod(s *entity.Subscription, from, to, today time.Time) bool {
+	end := today
+	if s.EndDate != nil {
+		end = *s.EndDate
+	}
+
+	between := func(d time.Time) bool {
+		return !d.Before(s.StartDate) && !d.After(end)
+	}
+
+	return between(from) || between(to) || from.Equal(s.StartDate) || to.Equal(s.StartDate)
+}
+
+// matchCostFilter проверяет подписку на соответствие фильтру стоимости, как appendCostFilter
+func matchCostFilter(s *entity.Subscription, filter *entity.CostFilter) bool {
+	if filter == nil {
+		return true
+	}
+
+	if filter.ServiceName != nil && !matchServiceName(s.ServiceName, filter.ServiceName) {
+		return false
+	}
+
+	return matchSubscriptionFilter(s, &filter.SubscriptionFilter)
+}
+
+// matchSubscriptionFilter проверяет подписку на соответствие фильтру по пользователю, категории и тегам,
+// как appendSubscriptionFilter
+func matchSubscriptionFilter(s *entity.Subscription, filter *entity.SubscriptionFilter) bool {
+	if filter == nil {
+		return true
+	}
+
+	if filter.UserId != nil && s.UserId != *filter.UserId {
+		return false
+	}
+
+	if filter.Category != nil && (s.Category == nil || *s.Category != *filter.Category) {
+		return false
+	}
+
+	for _, tag := range filter.Tags {
+		if !slices.Contains(s.Tags, tag) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchServiceName сравнивает название сервиса подписки с фильтром, как serviceNameCondition
+func matchServiceName(name string, filter *entity.ServiceNameFilter) bool {
+	switch filter.Mode {
+	case entity.MatchIgnore:
+		return normalizeName(name) == normalizeName(filter.Name)
+	case entity.MatchFuzzy:
+		return trigramSimilar(strings.ToLower(name), normalizeName(filter.Name))
+	default:
+		return name == filter.Name
+	}
+}
+
+// normalizeName приводит название к нижнему регистру без пробелов по краям, как lower(btrim(...))
+func normalizeName(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// storedSubscription возвращает копию подписки в том виде, в котором ее хранит бд: даты без времени,
+// пустой массив тегов вместо nil
+func storedSubscription(s *entity.Subscription) *entity.Subscription {
+	c := cloneSubscription(s)
+	c.StartDate = toDate(s.StartDate)
+	c.EndDate = toDatePtr(s.EndDate)
+
+	return c
+}
+
+// cloneSubscription возвращает копию подписки, не разделяющую с ней память
+func cloneSubscription(s *entity.Subscription) *entity.Subscription {
+	c := *s
+	c.EndDate = clonePtr(s.EndDate)
+	c.CancellationReason = clonePtr(s.CancellationReason)
+	c.CancelledAt = clonePtr(s.CancelledAt)
+	c.ServiceId = clonePtr(s.ServiceId)
+	c.Category = clonePtr(s.Category)
+	c.Tags = cloneStrings(s.Tags)
+
+	return &c
+}
+
+// clonePtr возвращает указатель на копию значения или nil
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+
+	v := *p
+	return &v
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/google/uuid"
+)
+
+// CreateTenant добавляет тенанта и заполняет его id и время создания
+func (repo *MemRepo) CreateTenant(_ context.Context, t *entity.Tenant) error {
+	if t == nil {
+		return fmt.Errorf("invalid argument error")
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, existing := range repo.tenants {
+		if existing.Name == t.Name {
+			return myError.ErrTenantNameTaken
+		}
+	}
+
+	t.Id = uuid.New()
+	t.CreatedAt = repo.now()
+
+	stored := *t
+	repo.tenants = append(repo.tenants, &stored)
+	// счетчик изменений нужен кэшу ответов до первого изменения подписок тенанта
+	repo.versions[t.Id] = &entity.TableVersion{UpdatedAt: t.CreatedAt}
+
+	return nil
+}
+
+// ListTenants возвращает список тенантов в порядке создания
+func (repo *MemRepo) ListTenants(_ context.Context) ([]*entity.Tenant, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	tenants := make([]*entity.Tenant, 0, len(repo.tenants))
+	for _, t := range repo.tenants {
+		c := *t
+		tenants = append(tenants, &c)
+	}
+
+	sort.SliceStable(tenants, func(i, j int) bool {
+		if !tenants[i].CreatedAt.Equal(tenants[j].CreatedAt) {
+			return tenants[i].CreatedAt.Before(tenants[j].CreatedAt)
+		}
+		return tenants[i].Name < tenants[j].Name
+	})
+
+	return tenants, nil
+}
+
+// TenantCosts возвращает суммарную стоимость подписок каждого тенанта за период, включая тенантов без подписок
+func (repo *MemRepo) TenantCosts(_ context.Context, from, to time.Time) ([]*entity.TenantCost, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	costs := make([]*entity.TenantCost, 0, len(repo.tenants))
+	for _, t := range repo.tenants {
+		total := 0
+		for _, s := range repo.costSubscriptions(t.Id, from, to, nil) {
+			total += s.Price
+		}
+
+		costs = append(costs, &entity.TenantCost{TenantId: t.Id, Name: t.Name, TotalCost: total})
+	}
+
+	sort.SliceStable(costs, func(i, j int) bool {
+		if costs[i].TotalCost != costs[j].TotalCost {
+			return costs[i].TotalCost > costs[j].TotalCost
+		}
+		return costs[i].Name < costs[j].Name
+	})
+
+	return costs, nil
+}
@@ -0,0 +1,13 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/repository"
+	"github.com/Ararat25/subscription-aggregation-service/internal/repository/repotest"
+)
+
+// TestMemRepo проверяет хранилище в памяти общим набором тестов поведения хранилища
+func TestMemRepo(t *testing.T) {
+	repotest.Run(t, repository.NewMemRepo())
+}
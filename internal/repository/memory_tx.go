@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"maps"
+)
+
+// WithTx выполняет fn в одной транзакции: вызовы хранилища tx внутри fn либо применяются все, либо, если fn
+// вернула ошибку, не применяется ни один. fn работает с копией данных и держит общую блокировку, поэтому
+// транзакции сериализуемы и не требуют повторов; вызывать внутри fn само хранилище, а не tx, нельзя.
+// Вызов WithTx у хранилища tx работает как точка сохранения
+func (repo *MemRepo) WithTx(_ context.Context, fn func(tx Repo) error) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	tx := repo.snapshot()
+	err := fn(tx)
+	if err != nil {
+		return err
+	}
+
+	repo.seq = tx.seq
+	repo.tenants = tx.tenants
+	repo.versions = tx.versions
+	repo.subscriptions = tx.subscriptions
+	repo.archived = tx.archived
+	repo.services = tx.services
+	repo.serviceNames = tx.serviceNames
+	repo.webhooks = tx.webhooks
+	repo.deliveries = tx.deliveries
+	repo.outbox = tx.outbox
+	repo.apiKeys = tx.apiKeys
+	repo.rateLimits = tx.rateLimits
+	repo.monthlyCosts = tx.monthlyCosts
+	repo.costsThrough = tx.costsThrough
+	repo.receipts = tx.receipts
+
+	return nil
+}
+
+// snapshot возвращает хранилище с копией данных. Методы хранилища изменяют строки на месте, поэтому
+// копируются и сами строки. Новое поле данных MemRepo нужно копировать здесь и переносить обратно в WithTx
+func (repo *MemRepo) snapshot() *MemRepo {
+	return &MemRepo{
+		now:           repo.now,
+		seq:           maps.Clone(repo.seq),
+		tenants:       cloneRows(repo.tenants),
+		versions:      cloneValues(repo.versions),
+		subscriptions: cloneRows(repo.subscriptions),
+		archived:      cloneRows(repo.archived),
+		services:      cloneRows(repo.services),
+		serviceNames:  maps.Clone(repo.serviceNames),
+		webhooks:      cloneRows(repo.webhooks),
+		deliveries:    cloneRows(repo.deliveries),
+		outbox:        cloneRows(repo.outbox),
+		apiKeys:       cloneRows(repo.apiKeys),
+		rateLimits:    cloneValues(repo.rateLimits),
+		monthlyCosts:  cloneValues(repo.monthlyCosts),
+		costsThrough:  repo.costsThrough,
+		receipts:      cloneRows(repo.receipts),
+	}
+}
+
+// cloneRows копирует срез указателей вместе со значениями, на которые они указывают
+func cloneRows[T any](rows []*T) []*T {
+	cloned := make([]*T, 0, len(rows))
+	for _, row := range rows {
+		c := *row
+		cloned = append(cloned, &c)
+	}
+
+	return cloned
+}
+
+// cloneValues копирует отображение с указателями вместе со значениями, на которые они указывают
+func cloneValues[K comparable, V any](m map[K]*V) map[K]*V {
+	cloned := make(map[K]*V, len(m))
+	for k, v := range m {
+		c := *v
+		cloned[k] = &c
+	}
+
+	return cloned
+}
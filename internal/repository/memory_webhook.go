@@ -0,0 +1,297 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/google/uuid"
+)
+
+// CreateWebhook добавляет вебхук и возвращает id
+func (repo *MemRepo) CreateWebhook(ctx context.Context, w *entity.Webhook) (int64, error) {
+	if w == nil {
+		return 0, fmt.Errorf("invalid argument error")
+	}
+
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	w.CreatedAt = repo.now()
+
+	stored := cloneWebhook(w)
+	stored.Id = repo.nextID("webhooks")
+	repo.webhooks = append(repo.webhooks, &memRow[entity.Webhook]{tenantID: tenantID, value: *stored})
+
+	return stored.Id, nil
+}
+
+// ListWebhooks возвращает список всех вебхуков тенанта
+func (repo *MemRepo) ListWebhooks(ctx context.Context) ([]*entity.Webhook, error) {
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	webhooks := []*entity.Webhook{}
+	for _, row := range repo.webhooks {
+		if row.tenantID == tenantID {
+			webhooks = append(webhooks, cloneWebhook(&row.value))
+		}
+	}
+
+	return webhooks, nil
+}
+
+// DeleteWebhook удаляет вебхук вместе с журналом его доставок
+func (repo *MemRepo) DeleteWebhook(ctx context.Context, id int64) error {
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if repo.findWebhook(tenantID, id) == nil {
+		return myError.ErrWebhookNotFound
+	}
+
+	repo.webhooks = slices.DeleteFunc(repo.webhooks, func(r *memRow[entity.Webhook]) bool {
+		return r.value.Id == id
+	})
+	repo.deliveries = slices.DeleteFunc(repo.deliveries, func(r *memRow[entity.WebhookDelivery]) bool {
+		return r.value.WebhookId == id
+	})
+
+	return nil
+}
+
+// CreateDeliveries создает записи о доставке события для всех активных вебхуков тенанта события,
+// подписанных на его тип, и возвращает количество созданных записей
+func (repo *MemRepo) CreateDeliveries(_ context.Context, event *entity.Event, payload []byte) (int, error) {
+	if event == nil {
+		return 0, fmt.Errorf("invalid argument error")
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	now := repo.now()
+	created := 0
+	for _, w := range repo.webhooks {
+		if w.tenantID != event.TenantId || !w.value.Active || !slices.Contains(w.value.Events, event.Type) {
+			continue
+		}
+
+		exists := slices.ContainsFunc(repo.deliveries, func(d *memRow[entity.WebhookDelivery]) bool {
+			return d.value.WebhookId == w.value.Id && d.value.EventId == event.Id
+		})
+		if exists {
+			continue
+		}
+
+		repo.deliveries = append(repo.deliveries, &memRow[entity.WebhookDelivery]{
+			tenantID: w.tenantID,
+			value: entity.WebhookDelivery{
+				Id:            repo.nextID("webhook_deliveries"),
+				WebhookId:     w.value.Id,
+				EventId:       event.Id,
+				EventType:     event.Type,
+				Payload:       append([]byte{}, payload...),
+				Status:        entity.DeliveryPending,
+				NextAttemptAt: now,
+				CreatedAt:     now,
+			},
+		})
+		created++
+	}
+
+	return created, nil
+}
+
+// ClaimDueDeliveries выбирает доставки, время отправки которых наступило, и откладывает их на время lease,
+// чтобы параллельные обработчики не отправили одно событие дважды. Доставки выключенных вебхуков не выбираются
+func (repo *MemRepo) ClaimDueDeliveries(_ context.Context, now time.Time, lease time.Duration, limit int) ([]*entity.WebhookDelivery, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var due []*memRow[entity.WebhookDelivery]
+	for _, d := range repo.deliveries {
+		if d.value.Status != entity.DeliveryPending || d.value.NextAttemptAt.After(now) {
+			continue
+		}
+		if w := repo.findWebhook(d.tenantID, d.value.WebhookId); w != nil && w.value.Active {
+			due = append(due, d)
+		}
+	}
+
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].value.NextAttemptAt.Before(due[j].value.NextAttemptAt)
+	})
+
+	var deliveries []*entity.WebhookDelivery
+	for _, d := range due {
+		if len(deliveries) == limit {
+			break
+		}
+
+		d.value.NextAttemptAt = now.Add(lease)
+
+		claimed := cloneDelivery(&d.value)
+		w := repo.findWebhook(d.tenantID, d.value.WebhookId)
+		claimed.URL = w.value.URL
+		claimed.Secret = w.value.Secret
+		deliveries = append(deliveries, claimed)
+	}
+
+	return deliveries, nil
+}
+
+// UpdateDelivery сохраняет результат попытки доставки
+func (repo *MemRepo) UpdateDelivery(_ context.Context, d *entity.WebhookDelivery) error {
+	if d == nil {
+		return fmt.Errorf("invalid argument error")
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, row := range repo.deliveries {
+		if row.value.Id != d.Id {
+			continue
+		}
+
+		row.value.Status = d.Status
+		row.value.Attempts = d.Attempts
+		row.value.LastStatusCode = clonePtr(d.LastStatusCode)
+		row.value.LastError = clonePtr(d.LastError)
+		row.value.NextAttemptAt = d.NextAttemptAt
+		row.value.DeliveredAt = clonePtr(d.DeliveredAt)
+
+		return nil
+	}
+
+	return myError.ErrDeliveryNotFound
+}
+
+// ListDeliveries возвращает журнал доставок вебхука, начиная с последних
+func (repo *MemRepo) ListDeliveries(ctx context.Context, webhookID int64) ([]*entity.WebhookDelivery, error) {
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if repo.findWebhook(tenantID, webhookID) == nil {
+		return nil, myError.ErrWebhookNotFound
+	}
+
+	deliveries := []*entity.WebhookDelivery{}
+	for i := len(repo.deliveries) - 1; i >= 0; i-- {
+		row := repo.deliveries[i]
+		if row.tenantID == tenantID && row.value.WebhookId == webhookID {
+			deliveries = append(deliveries, cloneDelivery(&row.value))
+		}
+	}
+
+	return deliveries, nil
+}
+
+// RedeliverDelivery ставит доставку в очередь на немедленную повторную отправку
+func (repo *MemRepo) RedeliverDelivery(ctx context.Context, id int64) error {
+	tenantID, err := memTenant(ctx)
+	if err != nil {
+		return err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, row := range repo.deliveries {
+		if row.tenantID != tenantID || row.value.Id != id {
+			continue
+		}
+
+		row.value.Status = entity.DeliveryPending
+		row.value.Attempts = 0
+		row.value.NextAttemptAt = repo.now()
+		row.value.DeliveredAt = nil
+
+		return nil
+	}
+
+	return myError.ErrDeliveryNotFound
+}
+
+// PurgeDeliveries удаляет до limit завершенных доставок (succeeded и failed), последняя попытка которых была
+// раньше finishedBefore, и возвращает количество удаленных. Ожидающие доставки не удаляются
+func (repo *MemRepo) PurgeDeliveries(_ context.Context, finishedBefore time.Time, limit int) (int, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	purged := 0
+	repo.deliveries = slices.DeleteFunc(repo.deliveries, func(row *memRow[entity.WebhookDelivery]) bool {
+		if purged == limit || row.value.Status == entity.DeliveryPending {
+			return false
+		}
+
+		finishedAt := row.value.NextAttemptAt
+		if row.value.DeliveredAt != nil {
+			finishedAt = *row.value.DeliveredAt
+		}
+
+		if !finishedAt.Before(finishedBefore) {
+			return false
+		}
+
+		purged++
+		return true
+	})
+
+	return purged, nil
+}
+
+// findWebhook возвращает строку вебхука тенанта по id или nil
+func (repo *MemRepo) findWebhook(tenantID uuid.UUID, id int64) *memRow[entity.Webhook] {
+	for _, row := range repo.webhooks {
+		if row.tenantID == tenantID && row.value.Id == id {
+			return row
+		}
+	}
+
+	return nil
+}
+
+// cloneWebhook возвращает копию вебхука, не разделяющую с ним память
+func cloneWebhook(w *entity.Webhook) *entity.Webhook {
+	c := *w
+	c.Events = append([]entity.EventType{}, w.Events...)
+
+	return &c
+}
+
+// cloneDelivery возвращает копию записи о доставке, не разделяющую с ней память
+func cloneDelivery(d *entity.WebhookDelivery) *entity.WebhookDelivery {
+	c := *d
+	c.Payload = append([]byte{}, d.Payload...)
+	c.LastStatusCode = clonePtr(d.LastStatusCode)
+	c.LastError = clonePtr(d.LastError)
+	c.DeliveredAt = clonePtr(d.DeliveredAt)
+
+	return &c
+}
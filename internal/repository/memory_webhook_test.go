@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"testing"
+)
+
+// TestMemRepoClaimInactiveWebhook - доставки выключенного вебхука не выбираются для отправки
+func TestMemRepoClaimInactiveWebhook(t *testing.T) {
+	repo := NewMemRepo()
+	testClaimInactiveWebhook(t, repo, func(webhookID int64, active bool) {
+		repo.mu.Lock()
+		defer repo.mu.Unlock()
+
+		for _, row := range repo.webhooks {
+			if row.value.Id == webhookID {
+				row.value.Active = active
+			}
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/Ararat25/subscription-aggregation-service/internal/tenant"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ClaimOutbox выбирает неопубликованные события в порядке записи и откладывает их на время lease,
+// чтобы параллельные ретрансляторы не публиковали одно событие одновременно
+func (repo *PGRepo) ClaimOutbox(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*entity.OutboxMessage, error) {
+	var messages []*entity.OutboxMessage
+	err := repo.inSystemTx(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx,
+			`UPDATE outbox
+             SET next_attempt_at = $2
+             WHERE id IN (SELECT id FROM outbox
+                          WHERE published_at IS NULL AND next_attempt_at <= $1
+                          ORDER BY id
+                          LIMIT $3
+                          FOR UPDATE SKIP LOCKED)
+             RETURNING id, tenant_id, payload, attempts`,
+			now, now.Add(lease), limit)
+		if err != nil {
+			return err
+		}
+
+		messages, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (*entity.OutboxMessage, error) {
+			var (
+				msg      entity.OutboxMessage
+				tenantID uuid.UUID
+				payload  []byte
+			)
+			err := row.Scan(&msg.Id, &tenantID, &payload, &msg.Attempts)
+			if err != nil {
+				return nil, err
+			}
+
+			err = json.Unmarshal(payload, &msg.Event)
+			if err != nil {
+				return nil, err
+			}
+			// события, записанные до появления тенантов, не содержат tenant_id
+			msg.Event.TenantId = tenantID
+
+			return &msg, nil
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// UPDATE ... RETURNING не гарантирует порядок строк
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].Id < messages[j].Id
+	})
+
+	return messages, nil
+}
+
+// MarkOutboxPublished отмечает событие как опубликованное
+func (repo *PGRepo) MarkOutboxPublished(ctx context.Context, id int64) error {
+	return repo.inSystemTx(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx,
+			`UPDATE outbox SET published_at = now(), last_error = NULL WHERE id = $1`, id)
+		return err
+	})
+}
+
+// MarkOutboxFailed сохраняет ошибку публикации и время следующей попытки
+func (repo *PGRepo) MarkOutboxFailed(ctx context.Context, id int64, errMsg string, nextAttemptAt time.Time) error {
+	return repo.inSystemTx(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx,
+			`UPDATE outbox SET attempts = attempts + 1, last_error = $1, next_attempt_at = $2 WHERE id = $3`,
+			errMsg, nextAttemptAt, id)
+		return err
+	})
+}
+
+// PurgeOutbox удаляет до limit событий, опубликованных раньше publishedBefore, и возвращает количество удаленных
+func (repo *PGRepo) PurgeOutbox(ctx context.Context, publishedBefore time.Time, limit int) (int, error) {
+	var purged int
+	err := repo.inSystemTx(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx,
+			`DELETE FROM outbox
+             WHERE id IN (SELECT id FROM outbox
+                          WHERE published_at < $1
+                          ORDER BY published_at
+                          LIMIT $2
+                          FOR UPDATE SKIP LOCKED)`,
+			publishedBefore, limit)
+		purged = int(tag.RowsAffected())
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+// insertOutbox записывает событие о подписке в outbox в рамках транзакции изменения
+func insertOutbox(ctx context.Context, tx pgx.Tx, eventType entity.EventType, sub *entity.Subscription) error {
+	event := entity.NewEvent(eventType, sub)
+	event.TenantId, _ = tenant.FromContext(ctx)
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO outbox (tenant_id, event_id, event_type, payload) VALUES (current_tenant_id(), $1, $2, $3)`,
+		event.Id, string(event.Type), payload)
+	return err
+}
+
+// insertChangeEvents записывает событие subscription.updated, а если у подписки появилась дата окончания -
+// еще и subscription.ended
+func insertChangeEvents(ctx context.Context, tx pgx.Tx, before, after *entity.Subscription) error {
+	err := insertOutbox(ctx, tx, entity.EventSubscriptionUpdated, after)
+	if err != nil {
+		return err
+	}
+
+	if isEnded(before, after) {
+		return insertOutbox(ctx, tx, entity.EventSubscriptionEnded, after)
+	}
+
+	return nil
+}
+
+// isEnded проверяет, что в результате изменения у подписки появилась новая дата окончания
+func isEnded(before, after *entity.Subscription) bool {
+	if after.EndDate == nil {
+		return false
+	}
+
+	return before.EndDate == nil || !before.EndDate.Equal(*after.EndDate)
+}
@@ -0,0 +1,34 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/config"
+	"github.com/Ararat25/subscription-aggregation-service/internal/repository"
+	"github.com/Ararat25/subscription-aggregation-service/internal/repository/repotest"
+	"github.com/caarlos0/env/v6"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPGRepo проверяет хранилище Postgres общим набором тестов поведения хранилища. Тест выполняется, только если
+// задан TEST_DB_HOST: параметры подключения берутся из переменных TEST_DB_* с теми же именами, что и DB_*.
+// База должна быть с примененными миграциями, тест создает в ней своих тенантов и данные не удаляет
+func TestPGRepo(t *testing.T) {
+	if os.Getenv("TEST_DB_HOST") == "" {
+		t.Skip("TEST_DB_HOST is not set")
+	}
+
+	var conf config.DatabaseConfig
+	require.NoError(t, env.Parse(&conf, env.Options{Prefix: "TEST_DB_"}))
+
+	ctx := context.Background()
+	db := &repository.PGRepo{}
+	require.NoError(t, db.ConnectDB(ctx, conf))
+	t.Cleanup(func() {
+		_ = db.Close(ctx)
+	})
+
+	repotest.Run(t, db)
+}
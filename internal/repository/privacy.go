@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// erasureReceiptColumns - колонки квитанции об удалении в порядке, ожидаемом erasureReceiptFields
+const erasureReceiptColumns = `id, tenant_id, subject_hash, erased_at, subscriptions, archived_subscriptions, monthly_costs,
+             api_keys, events, webhook_deliveries, digest`
+
+// eventUserCondition - условие на события outbox и доставки вебхуков о подписках пользователя $1
+const eventUserCondition = `payload -> 'data' ->> 'user_id' = $1::text`
+
+// anonymizedPayload - событие с обезличенными данными подписки: id пользователя заменяется нулевым UUID,
+// причина отмены удаляется
+const anonymizedPayload = `jsonb_set(payload, '{data,user_id}', to_jsonb($2::text)) #- '{data,cancellation_reason}'`
+
+// ExportUserData возвращает все данные тенанта о пользователе: подписки, в том числе архивные, помесячные итоги,
+// события outbox и доставки вебхуков о его подписках и API-ключи, действующие от его имени
+func (repo *PGRepo) ExportUserData(ctx context.Context, userID uuid.UUID) (*entity.UserData, error) {
+	data := &entity.UserData{UserId: userID}
+	err := repo.inTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		data.Subscriptions, err = queryUserSubscriptions(ctx, tx, "subscriptions", userID)
+		if err != nil {
+			return err
+		}
+
+		data.ArchivedSubscriptions, err = queryUserSubscriptions(ctx, tx, "subscriptions_archive", userID)
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(ctx,
+			`SELECT month, service_name, total, subscriptions FROM monthly_costs
+             WHERE user_id = $1 AND `+tenantCondition+`
+             ORDER BY month, service_name`, userID)
+		if err != nil {
+			return err
+		}
+
+		data.MonthlyCosts, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (*entity.UserMonthlyCost, error) {
+			var c entity.UserMonthlyCost
+			err := row.Scan(&c.Month, &c.ServiceName, &c.Total, &c.Subscriptions)
+			return &c, err
+		})
+		if err != nil {
+			return err
+		}
+
+		rows, err = tx.Query(ctx,
+			`SELECT tenant_id, payload FROM outbox WHERE `+eventUserCondition+` AND `+tenantCondition+` ORDER BY id`,
+			userID.String())
+		if err != nil {
+			return err
+		}
+
+		data.Events, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (*entity.Event, error) {
+			var (
+				event    entity.Event
+				tenantID uuid.UUID
+			)
+			err := row.Scan(&tenantID, &event)
+			// события, записанные до появления тенантов, не содержат tenant_id
+			event.TenantId = tenantID
+			return &event, err
+		})
+		if err != nil {
+			return err
+		}
+
+		rows, err = tx.Query(ctx,
+			`SELECT id, webhook_id, event_id, event_type, payload, status, attempts,
+                last_status_code, last_error, next_attempt_at, delivered_at, created_at
+             FROM webhook_deliveries
+             WHERE `+eventUserCondition+` AND `+tenantCondition+`
+             ORDER BY id`, userID.String())
+		if err != nil {
+			return err
+		}
+
+		data.Deliveries, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (*entity.WebhookDelivery, error) {
+			var d entity.WebhookDelivery
+			err := row.Scan(&d.Id, &d.WebhookId, &d.EventId, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+				&d.LastStatusCode, &d.LastError, &d.NextAttemptAt, &d.DeliveredAt, &d.CreatedAt)
+			return &d, err
+		})
+		if err != nil {
+			return err
+		}
+
+		rows, err = tx.Query(ctx,
+			`SELECT `+apiKeyColumns+` FROM api_keys WHERE user_id = $1 AND `+tenantCondition+` ORDER BY id`, userID)
+		if err != nil {
+			return err
+		}
+
+		data.APIKeys, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (*entity.APIKey, error) {
+			return scanAPIKey(row)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// EraseUserData удаляет подписки пользователя, в том числе архивные, его помесячные итоги и API-ключи,
+// а в событиях outbox и доставках вебхуков о его подписках заменяет id пользователя нулевым UUID и удаляет
+// причину отмены. События об удалении подписок не создаются. Количество удаленных и обезличенных записей
+// и тенант записываются в receipt, квитанция подписывается sign и сохраняется в той же транзакции
+func (repo *PGRepo) EraseUserData(ctx context.Context, userID uuid.UUID, receipt *entity.ErasureReceipt, sign func(*entity.ErasureReceipt) string) error {
+	return repo.inWriteTx(ctx, func(tx pgx.Tx) error {
+		// итоги удаляются вместе с подписками, поэтому их нельзя продлевать на новый месяц посередине удаления
+		_, err := tx.Exec(ctx, `SELECT through_month FROM monthly_costs_state FOR SHARE`)
+		if err != nil {
+			return err
+		}
+
+		steps := []struct {
+			count *int
+			query string
+		}{
+			{&receipt.Subscriptions, `DELETE FROM subscriptions WHERE user_id = $1 AND ` + tenantCondition},
+			{&receipt.ArchivedSubscriptions, `DELETE FROM subscriptions_archive WHERE user_id = $1 AND ` + tenantCondition},
+			{&receipt.MonthlyCosts, `DELETE FROM monthly_costs WHERE user_id = $1 AND ` + tenantCondition},
+			{&receipt.APIKeys, `DELETE FROM api_keys WHERE user_id = $1 AND ` + tenantCondition},
+		}
+		for _, step := range steps {
+			cmdTag, err := tx.Exec(ctx, step.query, userID)
+			if err != nil {
+				return err
+			}
+
+			*step.count = int(cmdTag.RowsAffected())
+		}
+
+		anonymized := []struct {
+			count *int
+			table string
+		}{
+			{&receipt.Events, "outbox"},
+			{&receipt.Deliveries, "webhook_deliveries"},
+		}
+		for _, a := range anonymized {
+			cmdTag, err := tx.Exec(ctx,
+				`UPDATE `+a.table+` SET payload = `+anonymizedPayload+` WHERE `+eventUserCondition+` AND `+tenantCondition,
+				userID.String(), uuid.Nil.String())
+			if err != nil {
+				return err
+			}
+
+			*a.count = int(cmdTag.RowsAffected())
+		}
+
+		// удаление из архива не вызывает триггер subscriptions, а архивные подписки входят в сводку пользователя,
+		// поэтому кэш ответов тенанта сбрасывается явно
+		if receipt.Subscriptions == 0 && receipt.ArchivedSubscriptions > 0 {
+			_, err = tx.Exec(ctx,
+				`INSERT INTO table_versions AS tv (tenant_id, table_name, version, updated_at)
+                 VALUES (current_tenant_id(), 'subscriptions', 1, clock_timestamp())
+                 ON CONFLICT (tenant_id, table_name) DO UPDATE
+                     SET version = tv.version + 1, updated_at = clock_timestamp()`)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = tx.QueryRow(ctx, `SELECT current_tenant_id()`).Scan(&receipt.TenantId)
+		if err != nil {
+			return err
+		}
+		receipt.Digest = sign(receipt)
+
+		_, err = tx.Exec(ctx,
+			`INSERT INTO erasure_receipts (`+erasureReceiptColumns+`)
+             VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+			receipt.Id, receipt.TenantId, receipt.SubjectHash, receipt.ErasedAt, receipt.Subscriptions,
+			receipt.ArchivedSubscriptions, receipt.MonthlyCosts, receipt.APIKeys, receipt.Events, receipt.Deliveries,
+			receipt.Digest)
+		return err
+	})
+}
+
+// ReadErasureReceipt возвращает квитанцию об удалении данных по id
+func (repo *PGRepo) ReadErasureReceipt(ctx context.Context, id uuid.UUID) (*entity.ErasureReceipt, error) {
+	var receipt entity.ErasureReceipt
+	err := repo.inTx(ctx, func(tx pgx.Tx) error {
+		return tx.QueryRow(ctx,
+			`SELECT `+erasureReceiptColumns+` FROM erasure_receipts WHERE id = $1 AND `+tenantCondition, id).
+			Scan(erasureReceiptFields(&receipt)...)
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, myError.ErrErasureReceiptNotFound
+		}
+		return nil, err
+	}
+
+	return &receipt, nil
+}
+
+// queryUserSubscriptions возвращает подписки пользователя из таблицы подписок или их архива в порядке id
+func queryUserSubscriptions(ctx context.Context, tx pgx.Tx, table string, userID uuid.UUID) ([]*entity.Subscription, error) {
+	rows, err := tx.Query(ctx,
+		`SELECT `+subscriptionColumns+` FROM `+table+` WHERE user_id = $1 AND `+tenantCondition+` ORDER BY id`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (*entity.Subscription, error) {
+		return scanSubscription(row)
+	})
+}
+
+// erasureReceiptFields возвращает указатели на поля квитанции в порядке колонок erasureReceiptColumns для чтения
+func erasureReceiptFields(r *entity.ErasureReceipt) []any {
+	return []any{&r.Id, &r.TenantId, &r.SubjectHash, &r.ErasedAt, &r.Subscriptions, &r.ArchivedSubscriptions,
+		&r.MonthlyCosts, &r.APIKeys, &r.Events, &r.Deliveries, &r.Digest}
+}
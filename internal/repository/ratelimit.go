@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// TakeRateLimitToken пополняет корзину key со скоростью rate токенов в секунду (не больше burst) и берет из нее токен,
+// если он есть. Возвращает количество оставшихся токенов и признак того, что токен взят.
+// Блокировка строки при ON CONFLICT делает операцию атомарной для параллельных экземпляров сервиса
+func (repo *PGRepo) TakeRateLimitToken(ctx context.Context, key string, rate float64, burst int) (float64, bool, error) {
+	var (
+		tokens  float64
+		allowed bool
+	)
+
+	err := repo.pool.QueryRow(ctx,
+		`INSERT INTO rate_limits AS rl (key, tokens, allowed, updated_at)
+             VALUES ($1, GREATEST($3::int - 1, 0), $3::int >= 1, now())
+             ON CONFLICT (key) DO UPDATE
+             SET (tokens, allowed, updated_at) = (
+                 SELECT r.refilled - CASE WHEN r.refilled >= 1 THEN 1 ELSE 0 END, r.refilled >= 1, now()
+                 FROM (SELECT LEAST($3::int, rl.tokens + EXTRACT(EPOCH FROM now() - rl.updated_at)::float8 * $2::float8) AS refilled) r
+             )
+             RETURNING tokens, allowed`,
+		key, rate, burst).Scan(&tokens, &allowed)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return tokens, allowed, nil
+}
+
+// DeleteIdleRateLimits удаляет корзины, к которым не обращались с момента before
+func (repo *PGRepo) DeleteIdleRateLimits(ctx context.Context, before time.Time) error {
+	_, err := repo.pool.Exec(ctx, `DELETE FROM rate_limits WHERE updated_at < $1`, before)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/auth"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/Ararat25/subscription-aggregation-service/internal/logger"
+	"github.com/Ararat25/subscription-aggregation-service/internal/tenant"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// replica - реплика бд, с которой читаются подписки и стоимость
+type replica struct {
+	host    string        // адрес реплики из DB_REPLICA_HOSTS
+	pool    *pgxpool.Pool // пул соединений с репликой
+	healthy atomic.Bool   // реплика ответила на последнюю проверку и последний запрос
+}
+
+// replicaRouter выбирает реплику для чтения. Клиент, недавно изменявший данные, читает с основной бд,
+// чтобы увидеть свои изменения, пока реплики их еще не получили
+type replicaRouter struct {
+	replicas   []*replica         // реплики в порядке DB_REPLICA_HOSTS
+	stickiness time.Duration      // сколько после записи клиент читает с основной бд
+	now        func() time.Time   // источник текущего времени
+	cancel     context.CancelFunc // останавливает проверку реплик
+
+	mu        sync.Mutex           // защищает lastWrite
+	lastWrite map[string]time.Time // время последней записи по клиентам
+}
+
+// newReplicaRouter создает маршрутизатор чтений по репликам. Реплики считаются недоступными до первой проверки
+func newReplicaRouter(replicas []*replica, stickiness time.Duration) *replicaRouter {
+	return &replicaRouter{
+		replicas:   replicas,
+		stickiness: stickiness,
+		now:        time.Now,
+		lastWrite:  make(map[string]time.Time),
+	}
+}
+
+// pick возвращает реплику для чтения клиента из контекста или nil, если читать нужно с основной бд:
+// клиент недавно изменял данные или доступных реплик нет. Клиент читает с одной и той же реплики, пока она
+// доступна, поэтому версия данных и сами данные, прочитанные друг за другом, не расходятся назад во времени
+func (r *replicaRouter) pick(ctx context.Context) *replica {
+	if r == nil {
+		return nil
+	}
+
+	key := replicaClientKey(ctx)
+	if r.sticky(key) {
+		return nil
+	}
+
+	healthy := make([]*replica, 0, len(r.replicas))
+	for _, rep := range r.replicas {
+		if rep.healthy.Load() {
+			healthy = append(healthy, rep)
+		}
+	}
+
+	if len(healthy) == 0 {
+		return nil
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+
+	return healthy[h.Sum32()%uint32(len(healthy))]
+}
+
+// markWrite запоминает, что клиент из контекста изменил данные
+func (r *replicaRouter) markWrite(ctx context.Context) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lastWrite[replicaClientKey(ctx)] = r.now()
+}
+
+// sticky проверяет, что клиент key изменял данные не раньше чем stickiness назад
+func (r *replicaRouter) sticky(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	wroteAt, ok := r.lastWrite[key]
+	return ok && r.now().Sub(wroteAt) < r.stickiness
+}
+
+// run проверяет доступность реплик раз в period до отмены ctx и удаляет устаревшие отметки о записи
+func (r *replicaRouter) run(ctx context.Context, period time.Duration) {
+	r.check(ctx, period)
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.check(ctx, period)
+			r.forgetWrites()
+		}
+	}
+}
+
+// check проверяет доступность каждой реплики запросом с таймаутом timeout
+func (r *replicaRouter) check(ctx context.Context, timeout time.Duration) {
+	for _, rep := range r.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := rep.pool.Ping(pingCtx)
+		cancel()
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		rep.setHealthy(err)
+	}
+}
+
+// forgetWrites удаляет отметки о записи, после которых прошло больше stickiness
+func (r *replicaRouter) forgetWrites() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	for key, wroteAt := range r.lastWrite {
+		if now.Sub(wroteAt) >= r.stickiness {
+			delete(r.lastWrite, key)
+		}
+	}
+}
+
+// close останавливает проверку реплик и закрывает их пулы соединений
+func (r *replicaRouter) close() {
+	if r == nil {
+		return
+	}
+
+	r.cancel()
+	for _, rep := range r.replicas {
+		rep.pool.Close()
+	}
+}
+
+// setHealthy обновляет доступность реплики по результату запроса к ней и записывает в лог ее изменение
+func (rep *replica) setHealthy(err error) {
+	healthy := err == nil
+	if rep.healthy.Swap(healthy) == healthy {
+		return
+	}
+
+	if healthy {
+		logger.Log.Info("Read replica is available", zap.String("host", rep.host))
+	} else {
+		logger.Log.Warn("Read replica is unavailable, reading from primary", zap.String("host", rep.host), zap.Error(err))
+	}
+}
+
+// inReadTx выполняет читающую транзакцию fn на реплике, выбранной для клиента, а если реплики не подходят -
+// на основной бд. Если реплика не ответила, она считается недоступной и чтение повторяется на основной бд.
+// Запрос, отмененный на реплике из-за применения изменений с основной бд, тоже повторяется на ней
+func (repo *PGRepo) inReadTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	if repo.tx != nil {
+		return repo.inTx(ctx, fn)
+	}
+
+	rep := repo.replicas.pick(ctx)
+	if rep == nil {
+		return repo.inTx(ctx, fn)
+	}
+
+	replicaRepo := &PGRepo{pool: rep.pool}
+	err := replicaRepo.inTx(ctx, fn)
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+
+	if isReplicaFailure(err) {
+		rep.setHealthy(err)
+		return repo.inTx(ctx, fn)
+	}
+
+	if isRetryableTxError(err) {
+		return repo.inTx(ctx, fn)
+	}
+
+	return err
+}
+
+// inWriteTx выполняет fn в транзакции тенанта, как inTx, и после успешной записи направляет чтения клиента
+// на основную бд на время DB_REPLICA_STICKINESS
+func (repo *PGRepo) inWriteTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	err := repo.inTx(ctx, fn)
+	if err != nil {
+		return err
+	}
+
+	repo.replicas.markWrite(ctx)
+
+	return nil
+}
+
+// isReplicaFailure проверяет, что ошибка вызвана недоступностью бд, а не самим запросом или его результатом
+func isReplicaFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return !errors.As(err, &pgErr) && !errors.Is(err, pgx.ErrNoRows) && !errors.Is(err, myError.ErrTenantRequired)
+}
+
+// replicaClientKey возвращает клиента из контекста, для которого запоминаются записи: аутентифицированного
+// клиента тенанта, а при выключенной аутентификации - весь тенант
+func replicaClientKey(ctx context.Context) string {
+	tenantID, _ := tenant.FromContext(ctx)
+
+	key := tenantID.String()
+	if id, ok := auth.FromContext(ctx); ok {
+		key += "/" + id.Subject
+	}
+
+	return key
+}
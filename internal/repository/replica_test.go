@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/auth"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/Ararat25/subscription-aggregation-service/internal/tenant"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestReplicaRouterPick тестирует выбор реплики для чтения
+func TestReplicaRouterPick(t *testing.T) {
+	now := time.Date(2025, time.March, 1, 12, 0, 0, 0, time.UTC)
+	first, second := &replica{host: "replica-1"}, &replica{host: "replica-2"}
+	first.healthy.Store(true)
+	second.healthy.Store(true)
+
+	router := newReplicaRouter([]*replica{first, second}, 5*time.Second)
+	router.now = func() time.Time { return now }
+
+	tenantCtx := tenant.WithID(context.Background(), uuid.New())
+	alice := auth.WithIdentity(tenantCtx, &auth.Identity{Subject: "alice"})
+	bob := auth.WithIdentity(tenantCtx, &auth.Identity{Subject: "bob"})
+
+	// Тестовый пример 1: Без реплик чтение идет на основную бд
+	var noReplicas *replicaRouter
+	assert.Nil(t, noReplicas.pick(alice))
+
+	// Тестовый пример 2: Клиент читает с одной и той же реплики
+	picked := router.pick(alice)
+	assert.NotNil(t, picked)
+	for i := 0; i < 10; i++ {
+		assert.Same(t, picked, router.pick(alice))
+	}
+
+	// Тестовый пример 3: После записи клиент читает с основной бд, пока не пройдет stickiness, другие клиенты - с реплик
+	router.markWrite(alice)
+	assert.Nil(t, router.pick(alice))
+	assert.NotNil(t, router.pick(bob))
+
+	now = now.Add(5 * time.Second)
+	assert.Same(t, picked, router.pick(alice))
+
+	// Тестовый пример 4: Устаревшие отметки о записи удаляются
+	router.markWrite(bob)
+	now = now.Add(time.Second)
+	router.forgetWrites()
+	assert.Len(t, router.lastWrite, 1)
+	assert.Contains(t, router.lastWrite, replicaClientKey(bob))
+
+	// Тестовый пример 5: Недоступная реплика не выбирается, без доступных реплик чтение идет на основную бд
+	picked.healthy.Store(false)
+	other := router.pick(alice)
+	assert.NotNil(t, other)
+	assert.NotSame(t, picked, other)
+
+	other.healthy.Store(false)
+	assert.Nil(t, router.pick(alice))
+}
+
+// TestIsReplicaFailure тестирует определение недоступности реплики по ошибке запроса
+func TestIsReplicaFailure(t *testing.T) {
+	// Тестовый пример 1: Ошибка соединения - реплика недоступна
+	assert.True(t, isReplicaFailure(errors.New("failed to connect to host")))
+
+	// Тестовый пример 2: Ошибки запроса и его результата не говорят о недоступности реплики
+	assert.False(t, isReplicaFailure(&pgconn.PgError{Code: "42P01"}))
+	assert.False(t, isReplicaFailure(pgx.ErrNoRows))
+	assert.False(t, isReplicaFailure(myError.ErrTenantRequired))
+}
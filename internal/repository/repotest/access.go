@@ -0,0 +1,134 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/Ararat25/subscription-aggregation-service/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testAPIKeys проверяет хранение, поиск и отзыв API-ключей
+func testAPIKeys(t *testing.T, storage repository.Storage) {
+	ctx, tenantID := newTenant(t, storage)
+	otherCtx, _ := newTenant(t, storage)
+
+	userID := uuid.New()
+	key := &entity.APIKey{
+		Name: "nightly-export", Prefix: "sas_test", KeyHash: uuid.NewString(),
+		Scopes: []entity.Scope{entity.ScopeRead}, UserId: &userID,
+	}
+	id, err := storage.CreateAPIKey(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, tenantID, key.TenantId)
+	assert.False(t, key.CreatedAt.IsZero())
+
+	// Тестовый пример 1: Ключ находится по хэшу без тенанта в контексте и определяет тенанта
+	found, err := storage.FindAPIKey(context.Background(), key.KeyHash)
+	require.NoError(t, err)
+	assert.Equal(t, id, found.Id)
+	assert.Equal(t, tenantID, found.TenantId)
+	assert.Equal(t, key.Scopes, found.Scopes)
+	assert.Equal(t, &userID, found.UserId)
+
+	_, err = storage.FindAPIKey(context.Background(), uuid.NewString())
+	assert.ErrorIs(t, err, myError.ErrAPIKeyNotFound)
+
+	// Тестовый пример 2: Список ключей виден только тенанту ключа
+	keys, err := storage.ListAPIKeys(ctx)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, id, keys[0].Id)
+
+	keys, err = storage.ListAPIKeys(otherCtx)
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	// Тестовый пример 3: Время использования сохраняется
+	usedAt := time.Date(2025, time.August, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, storage.TouchAPIKey(context.Background(), id, usedAt))
+
+	found, err = storage.FindAPIKey(context.Background(), key.KeyHash)
+	require.NoError(t, err)
+	require.NotNil(t, found.LastUsedAt)
+	assert.True(t, usedAt.Equal(*found.LastUsedAt))
+
+	// Тестовый пример 4: Повторный отзыв не меняет время отзыва
+	assert.ErrorIs(t, storage.RevokeAPIKey(otherCtx, id), myError.ErrAPIKeyNotFound)
+	require.NoError(t, storage.RevokeAPIKey(ctx, id))
+
+	found, err = storage.FindAPIKey(context.Background(), key.KeyHash)
+	require.NoError(t, err)
+	require.NotNil(t, found.RevokedAt)
+	revokedAt := *found.RevokedAt
+
+	require.NoError(t, storage.RevokeAPIKey(ctx, id))
+	found, err = storage.FindAPIKey(context.Background(), key.KeyHash)
+	require.NoError(t, err)
+	assert.True(t, revokedAt.Equal(*found.RevokedAt))
+}
+
+// testTenants проверяет создание тенантов и стоимость подписок по тенантам
+func testTenants(t *testing.T, storage repository.Storage) {
+	ctx, tenantID := newTenant(t, storage)
+	_, emptyID := newTenant(t, storage)
+
+	createSubscription(t, ctx, storage, &entity.Subscription{
+		ServiceName: "Netflix", Price: 499, UserId: uuid.New(), StartDate: month(2024, time.January),
+	})
+
+	// Тестовый пример 1: Название тенанта уникально
+	tenants, err := storage.ListTenants(context.Background())
+	require.NoError(t, err)
+
+	var name string
+	for _, tn := range tenants {
+		if tn.Id == tenantID {
+			name = tn.Name
+		}
+	}
+	require.NotEmpty(t, name)
+	assert.ErrorIs(t, storage.CreateTenant(context.Background(), &entity.Tenant{Name: name}), myError.ErrTenantNameTaken)
+
+	// Тестовый пример 2: Стоимость считается по каждому тенанту, включая тенантов без подписок
+	costs, err := storage.TenantCosts(context.Background(), month(2024, time.January), month(2024, time.January))
+	require.NoError(t, err)
+
+	totals := make(map[uuid.UUID]int)
+	for _, c := range costs {
+		totals[c.TenantId] = c.TotalCost
+	}
+	assert.Equal(t, 499, totals[tenantID])
+	assert.Contains(t, totals, emptyID)
+	assert.Zero(t, totals[emptyID])
+}
+
+// testRateLimits проверяет корзины токенов ограничения частоты запросов
+func testRateLimits(t *testing.T, storage repository.Storage) {
+	ctx := context.Background()
+	key := "repotest:" + uuid.NewString()
+
+	// Тестовый пример 1: Запросы в пределах емкости корзины разрешены, затем корзина пуста
+	for _, want := range []bool{true, true, false} {
+		_, allowed, err := storage.TakeRateLimitToken(ctx, key, 0.001, 2)
+		require.NoError(t, err)
+		assert.Equal(t, want, allowed)
+	}
+
+	// Тестовый пример 2: Корзины разных ключей независимы
+	tokens, allowed, err := storage.TakeRateLimitToken(ctx, key+":other", 0.001, 2)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.InDelta(t, 1, tokens, 0.01)
+
+	// Тестовый пример 3: Неиспользуемые корзины удаляются и начинаются заново
+	require.NoError(t, storage.DeleteIdleRateLimits(ctx, time.Now().Add(time.Minute)))
+	_, allowed, err = storage.TakeRateLimitToken(ctx, key, 0.001, 2)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+}
@@ -0,0 +1,109 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/Ararat25/subscription-aggregation-service/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testArchive проверяет перенос закончившихся подписок в архив и учет архивных подписок в стоимости.
+// Архивация затрагивает все тенанты, поэтому подписки теста закончились раньше подписок остальных тестов
+func testArchive(t *testing.T, storage repository.Storage) {
+	ctx, _ := newTenant(t, storage)
+	otherCtx, otherID := newTenant(t, storage)
+	userID := uuid.New()
+
+	first := createSubscription(t, ctx, storage, &entity.Subscription{
+		ServiceName: "Netflix", Price: 100, UserId: userID, StartDate: month(1999, time.January), EndDate: ptr(month(1999, time.March)),
+		Category: ptr("streaming"), Tags: []string{"family"}})
+	second := createSubscription(t, ctx, storage, &entity.Subscription{
+		ServiceName: "Spotify", Price: 200, UserId: userID, StartDate: month(1999, time.February), EndDate: ptr(month(1999, time.December))})
+	recent := createSubscription(t, ctx, storage, &entity.Subscription{
+		ServiceName: "Okko", Price: 400, UserId: userID, StartDate: month(1999, time.March), EndDate: ptr(month(2000, time.January))})
+	other := createSubscription(t, otherCtx, storage, &entity.Subscription{
+		ServiceName: "Netflix", Price: 800, UserId: uuid.New(), StartDate: month(1999, time.January), EndDate: ptr(month(1999, time.January))})
+
+	version, err := storage.SubscriptionsVersion(ctx)
+	require.NoError(t, err)
+
+	// Тестовый пример 1: Подписки, закончившиеся раньше месяца, переносятся пачками не больше limit
+	archived, err := storage.ArchiveSubscriptions(ctx, month(2000, time.January), 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, archived)
+
+	archived, err = storage.ArchiveSubscriptions(ctx, month(2000, time.January), 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, archived)
+
+	archived, err = storage.ArchiveSubscriptions(ctx, month(2000, time.January), 2)
+	require.NoError(t, err)
+	assert.Zero(t, archived)
+
+	// Тестовый пример 2: Архивные подписки пропадают из списка и чтения, версия данных меняется
+	subs, err := storage.ListSubscriptions(ctx, &entity.SubscriptionFilter{UserId: &userID})
+	require.NoError(t, err)
+	require.Len(t, subs, 1)
+	assert.Equal(t, recent.Id, subs[0].Id)
+
+	_, err = storage.ReadSubscription(ctx, int64(first.Id))
+	assert.ErrorIs(t, err, myError.ErrSubscriptionNotFound)
+
+	bumped, err := storage.SubscriptionsVersion(ctx)
+	require.NoError(t, err)
+	assert.Greater(t, bumped.Version, version.Version)
+
+	// Тестовый пример 3: История подписок содержит архивные подписки тенанта в порядке id
+	history, err := storage.ListSubscriptionHistory(ctx, &entity.SubscriptionFilter{UserId: &userID})
+	require.NoError(t, err)
+	assert.Equal(t, []*entity.Subscription{first, second, recent}, history)
+
+	history, err = storage.ListSubscriptionHistory(ctx, &entity.SubscriptionFilter{Category: ptr("streaming"), Tags: []string{"family"}})
+	require.NoError(t, err)
+	assert.Equal(t, []*entity.Subscription{first}, history)
+
+	history, err = storage.ListSubscriptionHistory(otherCtx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []*entity.Subscription{other}, history)
+
+	// Тестовый пример 4: Стоимость за период и ее разбивка учитывают архивные подписки
+	total, err := storage.TotalCost(ctx, month(1999, time.March), month(1999, time.March), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 100+200+400, total)
+
+	total, err = storage.TotalCost(ctx, month(1999, time.January), month(1999, time.June), &entity.CostFilter{
+		SubscriptionFilter: entity.SubscriptionFilter{Tags: []string{"family"}}})
+	require.NoError(t, err)
+	assert.Equal(t, 100, total)
+
+	groups, err := storage.CostBreakdown(ctx, month(1999, time.February), month(1999, time.February), nil, entity.GroupByCategory)
+	require.NoError(t, err)
+	assert.Equal(t, []*entity.CostGroup{{TotalCost: 200}, {Key: ptr("streaming"), TotalCost: 100}}, groups)
+
+	costs, err := storage.TenantCosts(context.Background(), month(1999, time.January), month(1999, time.January))
+	require.NoError(t, err)
+
+	totals := make(map[uuid.UUID]int)
+	for _, c := range costs {
+		totals[c.TenantId] = c.TotalCost
+	}
+	assert.Equal(t, 800, totals[otherID])
+
+	// Тестовый пример 5: Помесячные итоги совпадают с расчетом по подпискам вместе с архивными
+	assertMonthlyCostsConsistent(t, ctx, storage)
+
+	_, err = storage.RebuildMonthlyCosts(ctx)
+	require.NoError(t, err)
+	assertMonthlyCostsConsistent(t, ctx, storage)
+
+	// Тестовый пример 6: id архивных подписок не выдаются новым
+	created := createSubscription(t, ctx, storage, &entity.Subscription{
+		ServiceName: "Kion", Price: 300, UserId: userID, StartDate: month(2024, time.January)})
+	assert.Greater(t, created.Id, recent.Id)
+}
@@ -0,0 +1,94 @@
+package repotest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/Ararat25/subscription-aggregation-service/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCatalog проверяет каталог сервисов, уникальность написаний и перенос названия в подписки
+func testCatalog(t *testing.T, storage repository.Storage) {
+	ctx, _ := newTenant(t, storage)
+
+	netflix := &entity.Service{Name: "Netflix", Aliases: []string{"NFLX", " netflix "}, Category: ptr("streaming"), DefaultPrice: ptr(499)}
+	id, err := storage.CreateService(ctx, netflix)
+	require.NoError(t, err)
+	netflix.Id = id
+
+	// Тестовый пример 1: Сервис читается по id и находится по названию и псевдониму без учета регистра
+	got, err := storage.ReadService(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, netflix, got)
+
+	for _, name := range []string{"netflix", "  nflx "} {
+		got, err = storage.ResolveService(ctx, name)
+		require.NoError(t, err)
+		assert.Equal(t, id, got.Id, name)
+	}
+
+	_, err = storage.ResolveService(ctx, "spotify")
+	assert.ErrorIs(t, err, myError.ErrServiceNotFound)
+
+	// Тестовый пример 2: Написание, занятое другим сервисом
+	_, err = storage.CreateService(ctx, &entity.Service{Name: "Netflix Premium", Aliases: []string{"NETFLIX"}})
+	assert.ErrorIs(t, err, myError.ErrServiceNameTaken)
+
+	// Тестовый пример 3: Список упорядочен по названию без учета регистра
+	appleID, err := storage.CreateService(ctx, &entity.Service{Name: "apple tv", Aliases: []string{}})
+	require.NoError(t, err)
+
+	services, err := storage.ListServices(ctx)
+	require.NoError(t, err)
+	require.Len(t, services, 2)
+	assert.Equal(t, appleID, services[0].Id)
+	assert.Equal(t, id, services[1].Id)
+
+	// Тестовый пример 4: Новое каноническое название переносится в подписки сервиса
+	sub := createSubscription(t, ctx, storage, &entity.Subscription{
+		ServiceName: "Netflix", Price: 499, UserId: uuid.New(), StartDate: month(2024, time.January), ServiceId: &id,
+	})
+
+	renamed := *netflix
+	renamed.Name = "Netflix Premium"
+	renamed.Aliases = []string{}
+	require.NoError(t, storage.UpdateService(ctx, &renamed))
+
+	gotSub, err := storage.ReadSubscription(ctx, int64(sub.Id))
+	require.NoError(t, err)
+	assert.Equal(t, "Netflix Premium", gotSub.ServiceName)
+
+	_, err = storage.ResolveService(ctx, "nflx")
+	assert.ErrorIs(t, err, myError.ErrServiceNotFound)
+
+	// Тестовый пример 5: Изменение с занятым написанием не применяется
+	conflict := renamed
+	conflict.Aliases = []string{"Apple TV"}
+	assert.ErrorIs(t, storage.UpdateService(ctx, &conflict), myError.ErrServiceNameTaken)
+
+	got, err = storage.ResolveService(ctx, "netflix premium")
+	require.NoError(t, err)
+	assert.Equal(t, &renamed, got)
+
+	// Тестовый пример 6: Подписки удаленного сервиса сохраняют название, но теряют ссылку на каталог
+	require.NoError(t, storage.DeleteService(ctx, id))
+
+	gotSub, err = storage.ReadSubscription(ctx, int64(sub.Id))
+	require.NoError(t, err)
+	assert.Equal(t, "Netflix Premium", gotSub.ServiceName)
+	assert.Nil(t, gotSub.ServiceId)
+
+	_, err = storage.ReadService(ctx, id)
+	assert.ErrorIs(t, err, myError.ErrServiceNotFound)
+	_, err = storage.ResolveService(ctx, "netflix premium")
+	assert.ErrorIs(t, err, myError.ErrServiceNotFound)
+
+	// Тестовый пример 7: Операции с несуществующим сервисом
+	assert.ErrorIs(t, storage.DeleteService(ctx, id), myError.ErrServiceNotFound)
+	assert.ErrorIs(t, storage.UpdateService(ctx, &renamed), myError.ErrServiceNotFound)
+}
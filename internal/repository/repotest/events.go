@@ -0,0 +1,249 @@
+package repotest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/Ararat25/subscription-aggregation-service/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// claimLimit - размер выборки outbox и доставок, заведомо покрывающий записи других тестов
+const claimLimit = 10000
+
+// testOutbox проверяет запись событий вместе с изменениями подписок и их публикацию
+func testOutbox(t *testing.T, storage repository.Storage) {
+	ctx, tenantID := newTenant(t, storage)
+
+	sub := createSubscription(t, ctx, storage, &entity.Subscription{
+		ServiceName: "Netflix", Price: 499, UserId: uuid.New(), StartDate: month(2024, time.January),
+	})
+	_, err := storage.CancelSubscription(ctx, int64(sub.Id), month(2024, time.March), nil)
+	require.NoError(t, err)
+	require.NoError(t, storage.DeleteSubscription(ctx, int64(sub.Id)))
+
+	// Тестовый пример 1: События тенанта выбираются в порядке записи
+	messages := claimTenantOutbox(t, storage, tenantID, time.Now().Add(time.Minute))
+	require.Len(t, messages, 4)
+
+	var types []entity.EventType
+	for _, msg := range messages {
+		types = append(types, msg.Event.Type)
+		assert.Equal(t, sub.Id, msg.Event.Data.Id)
+		assert.Equal(t, "Netflix", msg.Event.Data.ServiceName)
+	}
+	assert.Equal(t, []entity.EventType{
+		entity.EventSubscriptionCreated,
+		entity.EventSubscriptionUpdated,
+		entity.EventSubscriptionEnded,
+		entity.EventSubscriptionDeleted,
+	}, types)
+
+	// Тестовый пример 2: Выбранные события отложены на время аренды, опубликованные больше не выбираются
+	assert.Empty(t, claimTenantOutbox(t, storage, tenantID, time.Now().Add(time.Minute)))
+
+	for _, msg := range messages {
+		require.NoError(t, storage.MarkOutboxPublished(context.Background(), msg.Id))
+	}
+	assert.Empty(t, claimTenantOutbox(t, storage, tenantID, time.Now().Add(time.Hour)))
+
+	// Тестовый пример 3: Неудачная публикация откладывается до следующей попытки
+	createSubscription(t, ctx, storage, &entity.Subscription{
+		ServiceName: "Spotify", Price: 299, UserId: uuid.New(), StartDate: month(2024, time.January),
+	})
+	messages = claimTenantOutbox(t, storage, tenantID, time.Now().Add(time.Minute))
+	require.Len(t, messages, 1)
+
+	retryAt := time.Now().Add(3 * time.Hour)
+	require.NoError(t, storage.MarkOutboxFailed(context.Background(), messages[0].Id, "timeout", retryAt))
+	assert.Empty(t, claimTenantOutbox(t, storage, tenantID, time.Now().Add(2*time.Hour)))
+
+	messages = claimTenantOutbox(t, storage, tenantID, retryAt)
+	require.Len(t, messages, 1)
+	assert.Equal(t, 1, messages[0].Attempts)
+	require.NoError(t, storage.MarkOutboxPublished(context.Background(), messages[0].Id))
+
+	// Тестовый пример 4: Удаляются только события, опубликованные раньше срока, неопубликованные остаются.
+	// Удаление общее для всех тенантов, поэтому заодно удаляет опубликованные события других тестов
+	pending := createSubscription(t, ctx, storage, &entity.Subscription{
+		ServiceName: "Okko", Price: 199, UserId: uuid.New(), StartDate: month(2024, time.January),
+	})
+
+	_, err = storage.PurgeOutbox(context.Background(), time.Now().Add(-time.Hour), claimLimit)
+	require.NoError(t, err)
+	data, err := storage.ExportUserData(ctx, sub.UserId)
+	require.NoError(t, err)
+	assert.Len(t, data.Events, 4)
+
+	purged, err := storage.PurgeOutbox(context.Background(), time.Now().Add(time.Minute), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	for purged > 0 {
+		purged, err = storage.PurgeOutbox(context.Background(), time.Now().Add(time.Minute), claimLimit)
+		require.NoError(t, err)
+	}
+
+	data, err = storage.ExportUserData(ctx, sub.UserId)
+	require.NoError(t, err)
+	assert.Empty(t, data.Events)
+
+	messages = claimTenantOutbox(t, storage, tenantID, time.Now().Add(time.Minute))
+	require.Len(t, messages, 1)
+	assert.Equal(t, pending.Id, messages[0].Event.Data.Id)
+	require.NoError(t, storage.MarkOutboxPublished(context.Background(), messages[0].Id))
+}
+
+// claimTenantOutbox выбирает события outbox на момент now и возвращает события тенанта tenantID
+func claimTenantOutbox(t *testing.T, storage repository.Storage, tenantID uuid.UUID, now time.Time) []*entity.OutboxMessage {
+	t.Helper()
+
+	messages, err := storage.ClaimOutbox(context.Background(), now, time.Minute, claimLimit)
+	require.NoError(t, err)
+
+	var own []*entity.OutboxMessage
+	for _, msg := range messages {
+		if msg.Event.TenantId == tenantID {
+			own = append(own, msg)
+		}
+	}
+
+	return own
+}
+
+// testWebhooks проверяет вебхуки и журнал их доставок
+func testWebhooks(t *testing.T, storage repository.Storage) {
+	ctx, tenantID := newTenant(t, storage)
+	otherCtx, otherID := newTenant(t, storage)
+
+	webhook := &entity.Webhook{
+		URL: "https://example.com/hooks", Secret: "my-very-long-secret", Active: true,
+		Events: []entity.EventType{entity.EventSubscriptionCreated},
+	}
+	webhookID, err := storage.CreateWebhook(ctx, webhook)
+	require.NoError(t, err)
+	assert.False(t, webhook.CreatedAt.IsZero())
+
+	_, err = storage.CreateWebhook(ctx, &entity.Webhook{
+		URL: "https://example.com/inactive", Secret: "my-very-long-secret", Active: false,
+		Events: []entity.EventType{entity.EventSubscriptionCreated},
+	})
+	require.NoError(t, err)
+
+	webhooks, err := storage.ListWebhooks(ctx)
+	require.NoError(t, err)
+	require.Len(t, webhooks, 2)
+	assert.Equal(t, webhookID, webhooks[0].Id)
+	assert.Equal(t, webhook.Events, webhooks[0].Events)
+
+	// Тестовый пример 1: Доставка создается для активных вебхуков тенанта события, подписанных на его тип, один раз
+	event := entity.NewEvent(entity.EventSubscriptionCreated, &entity.Subscription{ServiceName: "Netflix", Tags: []string{}})
+	event.TenantId = tenantID
+	payload, err := json.Marshal(event)
+	require.NoError(t, err)
+
+	created, err := storage.CreateDeliveries(context.Background(), event, payload)
+	require.NoError(t, err)
+	assert.Equal(t, 1, created)
+
+	created, err = storage.CreateDeliveries(context.Background(), event, payload)
+	require.NoError(t, err)
+	assert.Zero(t, created)
+
+	other := *event
+	other.Id = uuid.New()
+	other.TenantId = otherID
+	created, err = storage.CreateDeliveries(context.Background(), &other, payload)
+	require.NoError(t, err)
+	assert.Zero(t, created)
+
+	other.TenantId = tenantID
+	other.Type = entity.EventSubscriptionDeleted
+	created, err = storage.CreateDeliveries(context.Background(), &other, payload)
+	require.NoError(t, err)
+	assert.Zero(t, created)
+
+	// Тестовый пример 2: Наступившая доставка выбирается вместе с адресом и секретом вебхука
+	var delivery *entity.WebhookDelivery
+	due, err := storage.ClaimDueDeliveries(context.Background(), time.Now().Add(time.Minute), time.Minute, claimLimit)
+	require.NoError(t, err)
+	for _, d := range due {
+		if d.WebhookId == webhookID {
+			delivery = d
+		}
+	}
+	require.NotNil(t, delivery)
+	assert.Equal(t, event.Id, delivery.EventId)
+	assert.Equal(t, entity.DeliveryPending, delivery.Status)
+	assert.Equal(t, webhook.URL, delivery.URL)
+	assert.Equal(t, webhook.Secret, delivery.Secret)
+	assert.JSONEq(t, string(payload), string(delivery.Payload))
+
+	// Тестовый пример 3: Результат попытки сохраняется в журнале
+	delivery.Status = entity.DeliveryFailed
+	delivery.Attempts = 3
+	delivery.LastStatusCode = ptr(500)
+	delivery.LastError = ptr("internal error")
+	require.NoError(t, storage.UpdateDelivery(context.Background(), delivery))
+
+	deliveries, err := storage.ListDeliveries(ctx, webhookID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, entity.DeliveryFailed, deliveries[0].Status)
+	assert.Equal(t, 3, deliveries[0].Attempts)
+	assert.Equal(t, ptr(500), deliveries[0].LastStatusCode)
+
+	// Тестовый пример 4: Повторная отправка доступна только тенанту вебхука
+	assert.ErrorIs(t, storage.RedeliverDelivery(otherCtx, delivery.Id), myError.ErrDeliveryNotFound)
+	require.NoError(t, storage.RedeliverDelivery(ctx, delivery.Id))
+
+	deliveries, err = storage.ListDeliveries(ctx, webhookID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, entity.DeliveryPending, deliveries[0].Status)
+	assert.Zero(t, deliveries[0].Attempts)
+
+	_, err = storage.ListDeliveries(otherCtx, webhookID)
+	assert.ErrorIs(t, err, myError.ErrWebhookNotFound)
+
+	// Тестовый пример 5: Завершенные доставки удаляются по времени последней попытки, ожидающие остаются.
+	// Время попыток задано в прошлом, чтобы удаление не затронуло доставки других тестов
+	finishedAt := time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC)
+	delivery.Status = entity.DeliveryPending
+	delivery.NextAttemptAt = finishedAt
+	require.NoError(t, storage.UpdateDelivery(context.Background(), delivery))
+
+	purged, err := storage.PurgeDeliveries(context.Background(), finishedAt.Add(time.Hour), claimLimit)
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+
+	delivery.Status = entity.DeliveryFailed
+	require.NoError(t, storage.UpdateDelivery(context.Background(), delivery))
+
+	purged, err = storage.PurgeDeliveries(context.Background(), finishedAt, claimLimit)
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+
+	purged, err = storage.PurgeDeliveries(context.Background(), finishedAt.Add(time.Hour), claimLimit)
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	deliveries, err = storage.ListDeliveries(ctx, webhookID)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+
+	// Тестовый пример 6: Вебхук удаляется вместе с журналом доставок
+	assert.ErrorIs(t, storage.DeleteWebhook(otherCtx, webhookID), myError.ErrWebhookNotFound)
+	require.NoError(t, storage.DeleteWebhook(ctx, webhookID))
+	assert.ErrorIs(t, storage.DeleteWebhook(ctx, webhookID), myError.ErrWebhookNotFound)
+
+	_, err = storage.ListDeliveries(ctx, webhookID)
+	assert.ErrorIs(t, err, myError.ErrWebhookNotFound)
+	assert.ErrorIs(t, storage.UpdateDelivery(context.Background(), delivery), myError.ErrDeliveryNotFound)
+}
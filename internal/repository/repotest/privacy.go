@@ -0,0 +1,161 @@
+package repotest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/Ararat25/subscription-aggregation-service/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPrivacy проверяет выгрузку и удаление данных пользователя. Архивная подписка теста закончилась раньше
+// подписок теста архивации, чтобы ее перенос не задел подписки других тестов
+func testPrivacy(t *testing.T, storage repository.Storage) {
+	ctx, tenantID := newTenant(t, storage)
+	otherCtx, _ := newTenant(t, storage)
+	userID := uuid.New()
+	signer := entity.NewReceiptSigner([]byte("repotest-receipt-key"))
+
+	netflix := createSubscription(t, ctx, storage, &entity.Subscription{
+		ServiceName: "Netflix", Price: 100, UserId: userID, StartDate: month(2024, time.January)})
+	netflix, err := storage.CancelSubscription(ctx, int64(netflix.Id), month(2024, time.April), ptr("too expensive"))
+	require.NoError(t, err)
+
+	okko := createSubscription(t, ctx, storage, &entity.Subscription{
+		ServiceName: "Okko", Price: 200, UserId: userID, StartDate: month(1998, time.January), EndDate: ptr(month(1998, time.February))})
+	archived, err := storage.ArchiveSubscriptions(context.Background(), month(1999, time.January), claimLimit)
+	require.NoError(t, err)
+	require.Equal(t, 1, archived)
+
+	neighbour := createSubscription(t, ctx, storage, &entity.Subscription{
+		ServiceName: "Spotify", Price: 400, UserId: uuid.New(), StartDate: month(2024, time.January)})
+	foreign := createSubscription(t, otherCtx, storage, &entity.Subscription{
+		ServiceName: "Netflix", Price: 800, UserId: userID, StartDate: month(2024, time.January)})
+
+	keyHash := uuid.NewString()
+	keyID, err := storage.CreateAPIKey(ctx, &entity.APIKey{
+		Name: "personal", Prefix: "sas_test", KeyHash: keyHash, Scopes: []entity.Scope{entity.ScopeRead}, UserId: &userID})
+	require.NoError(t, err)
+
+	webhookID, err := storage.CreateWebhook(ctx, &entity.Webhook{
+		URL: "https://example.com/hooks", Secret: "my-very-long-secret", Active: true,
+		Events: []entity.EventType{entity.EventSubscriptionEnded}})
+	require.NoError(t, err)
+
+	event := entity.NewEvent(entity.EventSubscriptionEnded, netflix)
+	event.TenantId = tenantID
+	payload, err := json.Marshal(event)
+	require.NoError(t, err)
+
+	created, err := storage.CreateDeliveries(context.Background(), event, payload)
+	require.NoError(t, err)
+	require.Equal(t, 1, created)
+
+	// Тестовый пример 1: Выгрузка содержит все данные пользователя в тенанте
+	data, err := storage.ExportUserData(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, userID, data.UserId)
+	assert.Equal(t, []*entity.Subscription{netflix}, data.Subscriptions)
+	assert.Equal(t, []*entity.Subscription{okko}, data.ArchivedSubscriptions)
+
+	require.Len(t, data.MonthlyCosts, 2+4)
+	assert.Equal(t, &entity.UserMonthlyCost{Month: month(1998, time.January), ServiceName: "Okko", Total: 200, Subscriptions: 1},
+		data.MonthlyCosts[0])
+	assert.Equal(t, &entity.UserMonthlyCost{Month: month(2024, time.April), ServiceName: "Netflix", Total: 100, Subscriptions: 1},
+		data.MonthlyCosts[5])
+
+	// создание двух подписок и отмена, после которой записываются subscription.updated и subscription.ended
+	require.Len(t, data.Events, 4)
+	for _, e := range data.Events {
+		assert.Equal(t, tenantID, e.TenantId)
+		assert.Equal(t, userID, e.Data.UserId)
+	}
+	assert.Equal(t, entity.EventSubscriptionCreated, data.Events[0].Type)
+
+	require.Len(t, data.Deliveries, 1)
+	assert.Equal(t, webhookID, data.Deliveries[0].WebhookId)
+	assert.Equal(t, event.Id, data.Deliveries[0].EventId)
+
+	require.Len(t, data.APIKeys, 1)
+	assert.Equal(t, keyID, data.APIKeys[0].Id)
+
+	version, err := storage.SubscriptionsVersion(ctx)
+	require.NoError(t, err)
+
+	// Тестовый пример 2: Удаление возвращает количество удаленных и обезличенных записей в подписанной квитанции
+	receipt := &entity.ErasureReceipt{
+		Id:          uuid.New(),
+		SubjectHash: signer.SubjectHash(userID),
+		ErasedAt:    time.Now().UTC().Truncate(time.Microsecond),
+	}
+	require.NoError(t, storage.EraseUserData(ctx, userID, receipt, signer.Sign))
+	assert.Equal(t, tenantID, receipt.TenantId)
+	assert.Equal(t, 1, receipt.Subscriptions)
+	assert.Equal(t, 1, receipt.ArchivedSubscriptions)
+	assert.Equal(t, 6, receipt.MonthlyCosts)
+	assert.Equal(t, 1, receipt.APIKeys)
+	assert.Equal(t, 4, receipt.Events)
+	assert.Equal(t, 1, receipt.Deliveries)
+	assert.True(t, signer.Verify(receipt))
+
+	bumped, err := storage.SubscriptionsVersion(ctx)
+	require.NoError(t, err)
+	assert.Greater(t, bumped.Version, version.Version)
+
+	// Тестовый пример 3: После удаления о пользователе ничего не выгружается, остальные данные не затронуты
+	data, err = storage.ExportUserData(ctx, userID)
+	require.NoError(t, err)
+	assert.Empty(t, data.Subscriptions)
+	assert.Empty(t, data.ArchivedSubscriptions)
+	assert.Empty(t, data.MonthlyCosts)
+	assert.Empty(t, data.Events)
+	assert.Empty(t, data.Deliveries)
+	assert.Empty(t, data.APIKeys)
+
+	read, err := storage.ReadSubscription(ctx, int64(neighbour.Id))
+	require.NoError(t, err)
+	assert.Equal(t, neighbour, read)
+
+	read, err = storage.ReadSubscription(otherCtx, int64(foreign.Id))
+	require.NoError(t, err)
+	assert.Equal(t, foreign, read)
+
+	_, err = storage.FindAPIKey(context.Background(), keyHash)
+	assert.ErrorIs(t, err, myError.ErrAPIKeyNotFound)
+
+	assertMonthlyCostsConsistent(t, ctx, storage)
+
+	// Тестовый пример 4: События и доставки остаются обезличенными: без id пользователя и причины отмены
+	anonymous, err := storage.ExportUserData(ctx, uuid.Nil)
+	require.NoError(t, err)
+	require.Len(t, anonymous.Events, 4)
+	for _, e := range anonymous.Events {
+		assert.Nil(t, e.Data.CancellationReason)
+		assert.NotEmpty(t, e.Data.ServiceName)
+	}
+
+	require.Len(t, anonymous.Deliveries, 1)
+	assert.NotContains(t, string(anonymous.Deliveries[0].Payload), "too expensive")
+	assert.NotContains(t, string(anonymous.Deliveries[0].Payload), userID.String())
+
+	// Тестовый пример 5: Квитанция читается только в тенанте удаления и не изменяется
+	stored, err := storage.ReadErasureReceipt(ctx, receipt.Id)
+	require.NoError(t, err)
+	assert.True(t, receipt.ErasedAt.Equal(stored.ErasedAt))
+	assert.True(t, signer.Verify(stored))
+
+	stored.ErasedAt = receipt.ErasedAt
+	assert.Equal(t, receipt, stored)
+
+	_, err = storage.ReadErasureReceipt(otherCtx, receipt.Id)
+	assert.ErrorIs(t, err, myError.ErrErasureReceiptNotFound)
+
+	_, err = storage.ReadErasureReceipt(ctx, uuid.New())
+	assert.ErrorIs(t, err, myError.ErrErasureReceiptNotFound)
+}
@@ -0,0 +1,71 @@
+// Package repotest содержит общий набор тестов поведения хранилища. Его проходят все реализации
+// repository.Storage, чтобы хранилище можно было заменить без изменения поведения сервиса
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/Ararat25/subscription-aggregation-service/internal/repository"
+	"github.com/Ararat25/subscription-aggregation-service/internal/tenant"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// Run запускает набор тестов для хранилища storage. Каждый тест работает в собственном новом тенанте,
+// поэтому хранилище может быть общим для всех тестов и уже содержать данные
+func Run(t *testing.T, storage repository.Storage) {
+	t.Run("Subscriptions", func(t *testing.T) { testSubscriptions(t, storage) })
+	t.Run("TenantIsolation", func(t *testing.T) { testTenantIsolation(t, storage) })
+	t.Run("TotalCost", func(t *testing.T) { testTotalCost(t, storage) })
+	t.Run("CostBreakdown", func(t *testing.T) { testCostBreakdown(t, storage) })
+	t.Run("Search", func(t *testing.T) { testSearch(t, storage) })
+	t.Run("Catalog", func(t *testing.T) { testCatalog(t, storage) })
+	t.Run("MonthlyCosts", func(t *testing.T) { testMonthlyCosts(t, storage) })
+	t.Run("Outbox", func(t *testing.T) { testOutbox(t, storage) })
+	t.Run("Webhooks", func(t *testing.T) { testWebhooks(t, storage) })
+	t.Run("APIKeys", func(t *testing.T) { testAPIKeys(t, storage) })
+	t.Run("Tenants", func(t *testing.T) { testTenants(t, storage) })
+	t.Run("RateLimits", func(t *testing.T) { testRateLimits(t, storage) })
+	t.Run("Transactions", func(t *testing.T) { testTransactions(t, storage) })
+	t.Run("Archive", func(t *testing.T) { testArchive(t, storage) })
+	t.Run("Privacy", func(t *testing.T) { testPrivacy(t, storage) })
+}
+
+// newTenant создает тенанта с уникальным названием и возвращает контекст с ним и его id
+func newTenant(t *testing.T, storage repository.Storage) (context.Context, uuid.UUID) {
+	t.Helper()
+
+	tn := &entity.Tenant{Name: "repotest-" + uuid.NewString()}
+	require.NoError(t, storage.CreateTenant(context.Background(), tn))
+
+	return tenant.WithID(context.Background(), tn.Id), tn.Id
+}
+
+// createSubscription добавляет подписку и возвращает ее с заполненным id. Теги, как и сервис,
+// передаются в хранилище пустым срезом вместо nil
+func createSubscription(t *testing.T, ctx context.Context, storage repository.Storage, s *entity.Subscription) *entity.Subscription {
+	t.Helper()
+
+	if s.Tags == nil {
+		s.Tags = []string{}
+	}
+
+	id, err := storage.CreateSubscription(ctx, s)
+	require.NoError(t, err)
+	s.Id = int(id)
+
+	return s
+}
+
+// month возвращает первое число месяца, в таком виде даты подписок хранятся в бд
+func month(year int, m time.Month) time.Time {
+	return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// ptr возвращает указатель на значение
+func ptr[T any](v T) *T {
+	return &v
+}
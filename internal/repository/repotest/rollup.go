@@ -0,0 +1,130 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/Ararat25/subscription-aggregation-service/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testMonthlyCosts проверяет, что помесячные итоги стоимости следуют за изменениями подписок и дают ту же
+// стоимость за месяц, что и расчет по подпискам
+func testMonthlyCosts(t *testing.T, storage repository.Storage) {
+	ctx, _ := newTenant(t, storage)
+	userID := uuid.New()
+
+	netflix := createSubscription(t, ctx, storage, &entity.Subscription{
+		ServiceName: "Netflix", Price: 100, UserId: userID, StartDate: month(2024, time.January)})
+	spotify := createSubscription(t, ctx, storage, &entity.Subscription{
+		ServiceName: "Spotify", Price: 200, UserId: userID, StartDate: month(2024, time.January), EndDate: ptr(month(2024, time.March))})
+	other := createSubscription(t, ctx, storage, &entity.Subscription{
+		ServiceName: "Netflix", Price: 400, UserId: uuid.New(), StartDate: month(2024, time.February), EndDate: ptr(month(2024, time.February))})
+
+	monthCost := func(m time.Time, filter *entity.CostFilter) int {
+		t.Helper()
+
+		total, err := storage.TotalCost(ctx, m, m, filter)
+		require.NoError(t, err)
+		return total
+	}
+	userFilter := &entity.CostFilter{SubscriptionFilter: entity.SubscriptionFilter{UserId: &userID}}
+	netflixFilter := &entity.CostFilter{ServiceName: &entity.ServiceNameFilter{Name: "Netflix", Mode: entity.MatchExact}}
+
+	// Тестовый пример 1: Итоги созданных подписок совпадают с расчетом по подпискам
+	check := assertMonthlyCostsConsistent(t, ctx, storage)
+	assert.Equal(t, 3+3+1, check.Rows-monthsSince(month(2024, time.April), check.ThroughMonth))
+
+	assert.Equal(t, 100+200+400, monthCost(month(2024, time.February), nil))
+	assert.Equal(t, 300, monthCost(month(2024, time.February), userFilter))
+	assert.Equal(t, 500, monthCost(month(2024, time.February), netflixFilter))
+	assert.Equal(t, 100, monthCost(month(2024, time.April), nil))
+
+	// Тестовый пример 2: Изменение, отмена и удаление подписок переносят их стоимость в итогах
+	spotify.Price = 250
+	spotify.EndDate = ptr(month(2024, time.May))
+	require.NoError(t, storage.UpdateSubscription(ctx, spotify))
+
+	_, err := storage.CancelSubscription(ctx, int64(netflix.Id), month(2024, time.April), nil)
+	require.NoError(t, err)
+
+	require.NoError(t, storage.DeleteSubscription(ctx, int64(other.Id)))
+
+	check = assertMonthlyCostsConsistent(t, ctx, storage)
+	assert.Equal(t, 4+5, check.Rows)
+	assert.Equal(t, 350, monthCost(month(2024, time.February), nil))
+	assert.Equal(t, 250, monthCost(month(2024, time.May), userFilter))
+	assert.Zero(t, monthCost(month(2024, time.May), netflixFilter))
+
+	// Тестовый пример 3: Переименование сервиса в каталоге переносит стоимость его подписок на новое название
+	serviceID, err := storage.CreateService(ctx, &entity.Service{Name: "Kion", Aliases: []string{}})
+	require.NoError(t, err)
+
+	createSubscription(t, ctx, storage, &entity.Subscription{
+		ServiceName: "Kion", Price: 300, UserId: userID, StartDate: month(2024, time.June), EndDate: ptr(month(2024, time.June)),
+		ServiceId: &serviceID})
+	require.NoError(t, storage.UpdateService(ctx, &entity.Service{Id: serviceID, Name: "Kion Plus", Aliases: []string{}}))
+
+	assertMonthlyCostsConsistent(t, ctx, storage)
+	assert.Equal(t, 300, monthCost(month(2024, time.June), &entity.CostFilter{ServiceName: &entity.ServiceNameFilter{Name: "Kion Plus"}}))
+	assert.Zero(t, monthCost(month(2024, time.June), &entity.CostFilter{ServiceName: &entity.ServiceNameFilter{Name: "Kion"}}))
+
+	// Тестовый пример 4: Отмененная транзакция не меняет итоги
+	errAbort := errors.New("abort")
+	err = storage.WithTx(ctx, func(tx repository.Repo) error {
+		_, err := tx.CreateSubscription(ctx, &entity.Subscription{
+			ServiceName: "Okko", Price: 500, UserId: userID, StartDate: month(2024, time.June), Tags: []string{}})
+		if err != nil {
+			return err
+		}
+
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+
+	assertMonthlyCostsConsistent(t, ctx, storage)
+	assert.Equal(t, 300, monthCost(month(2024, time.June), nil))
+
+	// Тестовый пример 5: Месяц после продления итогов считается по подпискам
+	createSubscription(t, ctx, storage, &entity.Subscription{
+		ServiceName: "Okko", Price: 500, UserId: userID, StartDate: month(2100, time.January)})
+	assert.Equal(t, 500, monthCost(month(2100, time.January), nil))
+
+	// Тестовый пример 6: Пересборка и продление итогов сохраняют их согласованность
+	rebuilt, err := storage.RebuildMonthlyCosts(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, check.Rows+1, rebuilt.Rows)
+	assert.Equal(t, check.ThroughMonth, rebuilt.ThroughMonth)
+
+	through, err := storage.AdvanceMonthlyCosts(ctx)
+	require.NoError(t, err)
+	assert.False(t, through.Before(check.ThroughMonth))
+
+	again, err := storage.AdvanceMonthlyCosts(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, through, again)
+
+	assertMonthlyCostsConsistent(t, ctx, storage)
+}
+
+// assertMonthlyCostsConsistent проверяет, что помесячные итоги тенанта совпадают с расчетом по подпискам,
+// и возвращает результат сверки
+func assertMonthlyCostsConsistent(t *testing.T, ctx context.Context, storage repository.Storage) *entity.MonthlyCostsCheck {
+	t.Helper()
+
+	check, err := storage.CheckMonthlyCosts(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, check.Mismatches)
+
+	return check
+}
+
+// monthsSince возвращает количество месяцев с from по to включительно
+func monthsSince(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month()) + 1
+}
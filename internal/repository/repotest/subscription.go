@@ -0,0 +1,319 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/Ararat25/subscription-aggregation-service/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testSubscriptions проверяет создание, чтение, изменение, отмену и удаление подписок
+func testSubscriptions(t *testing.T, storage repository.Storage) {
+	ctx, _ := newTenant(t, storage)
+	userID := uuid.New()
+
+	// Тестовый пример 1: Подписки получают возрастающие id, дата сохраняется без времени
+	first := createSubscription(t, ctx, storage, &entity.Subscription{
+		ServiceName: "Netflix", Price: 499, UserId: userID, StartDate: time.Date(2024, time.March, 1, 15, 4, 5, 0, time.UTC),
+		Category: ptr("streaming"), Tags: []string{"family"},
+	})
+	second := createSubscription(t, ctx, storage, &entity.Subscription{
+		ServiceName: "Spotify", Price: 299, UserId: userID, StartDate: month(2024, time.April), Tags: []string{},
+	})
+	assert.Positive(t, first.Id)
+	assert.Greater(t, second.Id, first.Id)
+
+	got, err := storage.ReadSubscription(ctx, int64(first.Id))
+	require.NoError(t, err)
+	assert.Equal(t, &entity.Subscription{
+		Id: first.Id, ServiceName: "Netflix", Price: 499, UserId: userID, StartDate: month(2024, time.March),
+		Category: ptr("streaming"), Tags: []string{"family"},
+	}, got)
+
+	// Тестовый пример 2: Отмена задает дату окончания и причину
+	cancelled, err := storage.CancelSubscription(ctx, int64(first.Id), month(2024, time.June), ptr("too expensive"))
+	require.NoError(t, err)
+	assert.Equal(t, ptr(month(2024, time.June)), cancelled.EndDate)
+	assert.Equal(t, ptr("too expensive"), cancelled.CancellationReason)
+	assert.NotNil(t, cancelled.CancelledAt)
+
+	// Тестовый пример 3: Изменение с датой окончания сохраняет причину отмены
+	updated := *got
+	updated.Price = 599
+	updated.EndDate = ptr(month(2024, time.July))
+	require.NoError(t, storage.UpdateSubscription(ctx, &updated))
+
+	got, err = storage.ReadSubscription(ctx, int64(first.Id))
+	require.NoError(t, err)
+	assert.Equal(t, 599, got.Price)
+	assert.Equal(t, ptr(month(2024, time.July)), got.EndDate)
+	assert.Equal(t, ptr("too expensive"), got.CancellationReason)
+
+	// Тестовый пример 4: Снятие даты окончания сбрасывает причину отмены
+	updated.EndDate = nil
+	require.NoError(t, storage.UpdateSubscription(ctx, &updated))
+
+	got, err = storage.ReadSubscription(ctx, int64(first.Id))
+	require.NoError(t, err)
+	assert.Nil(t, got.EndDate)
+	assert.Nil(t, got.CancellationReason)
+	assert.Nil(t, got.CancelledAt)
+
+	// Тестовый пример 5: Список с фильтрами по пользователю, категории и тегам
+	subs, err := storage.ListSubscriptions(ctx, &entity.SubscriptionFilter{UserId: &userID})
+	require.NoError(t, err)
+	assert.Len(t, subs, 2)
+
+	subs, err = storage.ListSubscriptions(ctx, &entity.SubscriptionFilter{Category: ptr("streaming"), Tags: []string{"family"}})
+	require.NoError(t, err)
+	require.Len(t, subs, 1)
+	assert.Equal(t, first.Id, subs[0].Id)
+
+	subs, err = storage.ListSubscriptions(ctx, &entity.SubscriptionFilter{Tags: []string{"family", "work"}})
+	require.NoError(t, err)
+	assert.Empty(t, subs)
+
+	// Тестовый пример 6: Удаленная подписка не находится
+	require.NoError(t, storage.DeleteSubscription(ctx, int64(second.Id)))
+	_, err = storage.ReadSubscription(ctx, int64(second.Id))
+	assert.ErrorIs(t, err, myError.ErrSubscriptionNotFound)
+
+	// Тестовый пример 7: Операции с несуществующей подпиской
+	missing := &entity.Subscription{Id: second.Id, ServiceName: "Spotify", Price: 1, UserId: userID, StartDate: month(2024, time.April)}
+	assert.ErrorIs(t, storage.UpdateSubscription(ctx, missing), myError.ErrSubscriptionNotFound)
+	assert.ErrorIs(t, storage.DeleteSubscription(ctx, int64(second.Id)), myError.ErrSubscriptionNotFound)
+	_, err = storage.CancelSubscription(ctx, int64(second.Id), month(2024, time.May), nil)
+	assert.ErrorIs(t, err, myError.ErrSubscriptionNotFound)
+
+	// Тестовый пример 8: Каждое изменение увеличивает счетчик изменений
+	before, err := storage.SubscriptionsVersion(ctx)
+	require.NoError(t, err)
+	createSubscription(t, ctx, storage, &entity.Subscription{ServiceName: "Kion", Price: 199, UserId: userID, StartDate: month(2024, time.May)})
+	after, err := storage.SubscriptionsVersion(ctx)
+	require.NoError(t, err)
+	assert.Greater(t, after.Version, before.Version)
+}
+
+// testTenantIsolation проверяет, что данные тенанта не видны другим тенантам и запросам без тенанта
+func testTenantIsolation(t *testing.T, storage repository.Storage) {
+	ctx, _ := newTenant(t, storage)
+	otherCtx, _ := newTenant(t, storage)
+
+	sub := createSubscription(t, ctx, storage, &entity.Subscription{
+		ServiceName: "Netflix", Price: 499, UserId: uuid.New(), StartDate: month(2024, time.January),
+	})
+
+	// Тестовый пример 1: Подписка другого тенанта не находится
+	_, err := storage.ReadSubscription(otherCtx, int64(sub.Id))
+	assert.ErrorIs(t, err, myError.ErrSubscriptionNotFound)
+	assert.ErrorIs(t, storage.DeleteSubscription(otherCtx, int64(sub.Id)), myError.ErrSubscriptionNotFound)
+
+	subs, err := storage.ListSubscriptions(otherCtx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, subs)
+
+	total, err := storage.TotalCost(otherCtx, month(2024, time.January), month(2024, time.January), nil)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+
+	// Тестовый пример 2: Без тенанта в контексте хранилище отказывает
+	_, err = storage.CreateSubscription(context.Background(), sub)
+	assert.ErrorIs(t, err, myError.ErrTenantRequired)
+	_, err = storage.ReadSubscription(context.Background(), int64(sub.Id))
+	assert.ErrorIs(t, err, myError.ErrTenantRequired)
+	_, err = storage.ListSubscriptions(context.Background(), nil)
+	assert.ErrorIs(t, err, myError.ErrTenantRequired)
+}
+
+// testTotalCost проверяет условие пересечения подписки с периодом и фильтры суммарной стоимости
+func testTotalCost(t *testing.T, storage repository.Storage) {
+	ctx, _ := newTenant(t, storage)
+	userID := uuid.New()
+
+	for _, s := range []*entity.Subscription{
+		{ServiceName: "Netflix", Price: 100, UserId: userID, StartDate: month(2024, time.January), EndDate: ptr(month(2024, time.March)),
+			Category: ptr("streaming"), Tags: []string{"family"}},
+		{ServiceName: "Spotify", Price: 200, UserId: userID, StartDate: month(2024, time.May), Category: ptr("music")},
+		{ServiceName: "Kion", Price: 400, UserId: uuid.New(), StartDate: month(2023, time.January), EndDate: ptr(month(2023, time.June))},
+		{ServiceName: "netflix ", Price: 800, UserId: uuid.New(), StartDate: month(2024, time.June), EndDate: ptr(month(2024, time.June))},
+		{ServiceName: "Okko", Price: 1600, UserId: userID, StartDate: month(2024, time.February), EndDate: ptr(month(2024, time.April))},
+	} {
+		createSubscription(t, ctx, storage, s)
+	}
+
+	from, to := month(2024, time.January), month(2024, time.June)
+
+	// Тестовый пример 1: Учитываются подписки, действующие в начале или в конце периода либо начинающиеся
+	// в один из этих месяцев; подписка без даты окончания действует по текущий день
+	total, err := storage.TotalCost(ctx, from, to, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 100+200+800, total)
+
+	// Тестовый пример 2: Фильтры по пользователю, категории и тегам
+	total, err = storage.TotalCost(ctx, from, to, &entity.CostFilter{SubscriptionFilter: entity.SubscriptionFilter{UserId: &userID}})
+	require.NoError(t, err)
+	assert.Equal(t, 300, total)
+
+	total, err = storage.TotalCost(ctx, from, to, &entity.CostFilter{SubscriptionFilter: entity.SubscriptionFilter{Category: ptr("music")}})
+	require.NoError(t, err)
+	assert.Equal(t, 200, total)
+
+	total, err = storage.TotalCost(ctx, from, to, &entity.CostFilter{SubscriptionFilter: entity.SubscriptionFilter{Tags: []string{"family"}}})
+	require.NoError(t, err)
+	assert.Equal(t, 100, total)
+
+	// Тестовый пример 3: Режимы сравнения названия сервиса
+	for mode, want := range map[entity.MatchMode]int{
+		entity.MatchExact:  100,
+		entity.MatchIgnore: 900,
+		entity.MatchFuzzy:  900,
+	} {
+		total, err = storage.TotalCost(ctx, from, to, &entity.CostFilter{ServiceName: &entity.ServiceNameFilter{Name: "Netflix", Mode: mode}})
+		require.NoError(t, err)
+		assert.Equal(t, want, total, mode)
+	}
+
+	// Тестовый пример 4: Период без подписок
+	total, err = storage.TotalCost(ctx, month(2000, time.January), month(2000, time.December), nil)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+
+	// Тестовый пример 5: Подписка без даты окончания действует по дату из фильтра, в том числе для месяца,
+	// стоимость за который берется из помесячных итогов
+	july := month(2024, time.July)
+	for today, want := range map[time.Time]int{
+		time.Date(2024, time.June, 30, 0, 0, 0, 0, time.UTC): 0,
+		time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC):  200,
+	} {
+		filter := &entity.CostFilter{SubscriptionFilter: entity.SubscriptionFilter{UserId: &userID}, Today: today}
+		total, err = storage.TotalCost(ctx, july, july, filter)
+		require.NoError(t, err)
+		assert.Equal(t, want, total, today)
+
+		groups, err := storage.CostBreakdown(ctx, july, july, filter, entity.GroupByCategory)
+		require.NoError(t, err)
+		assert.Equal(t, want, sumGroups(groups), today)
+	}
+}
+
+// sumGroups возвращает суммарную стоимость групп разбивки
+func sumGroups(groups []*entity.CostGroup) int {
+	total := 0
+	for _, g := range groups {
+		total += g.TotalCost
+	}
+
+	return total
+}
+
+// testCostBreakdown проверяет разбивку стоимости по категориям и тегам
+func testCostBreakdown(t *testing.T, storage repository.Storage) {
+	ctx, _ := newTenant(t, storage)
+	start := month(2024, time.January)
+
+	for _, s := range []*entity.Subscription{
+		{ServiceName: "Netflix", Price: 500, StartDate: start, Category: ptr("streaming"), Tags: []string{"family", "tv"}},
+		{ServiceName: "Kion", Price: 300, StartDate: start, Category: ptr("streaming"), Tags: []string{"tv"}},
+		{ServiceName: "Spotify", Price: 300, StartDate: start, Category: ptr("music"), Tags: []string{}},
+		{ServiceName: "Notion", Price: 1000, StartDate: start, Tags: []string{}},
+	} {
+		s.UserId = uuid.New()
+		createSubscription(t, ctx, storage, s)
+	}
+
+	// Тестовый пример 1: По категориям, подписки без категории - в группе null
+	groups, err := storage.CostBreakdown(ctx, start, start, nil, entity.GroupByCategory)
+	require.NoError(t, err)
+	assert.Equal(t, []*entity.CostGroup{
+		{Key: nil, TotalCost: 1000},
+		{Key: ptr("streaming"), TotalCost: 800},
+		{Key: ptr("music"), TotalCost: 300},
+	}, groups)
+
+	// Тестовый пример 2: По тегам, подписка учитывается в группе каждого тега, при равной стоимости
+	// группы упорядочены по ключу, группа null - последней
+	groups, err = storage.CostBreakdown(ctx, start, start, nil, entity.GroupByTag)
+	require.NoError(t, err)
+	assert.Equal(t, []*entity.CostGroup{
+		{Key: nil, TotalCost: 1300},
+		{Key: ptr("tv"), TotalCost: 800},
+		{Key: ptr("family"), TotalCost: 500},
+	}, groups)
+
+	groups, err = storage.CostBreakdown(ctx, start, start, &entity.CostFilter{
+		SubscriptionFilter: entity.SubscriptionFilter{Category: ptr("streaming")},
+	}, entity.GroupByCategory)
+	require.NoError(t, err)
+	assert.Equal(t, []*entity.CostGroup{{Key: ptr("streaming"), TotalCost: 800}}, groups)
+
+	// Тестовый пример 3: Неизвестный разрез
+	_, err = storage.CostBreakdown(ctx, start, start, nil, entity.GroupBy("user"))
+	assert.Error(t, err)
+}
+
+// testSearch проверяет поиск подписок и автодополнение названий сервисов
+func testSearch(t *testing.T, storage repository.Storage) {
+	ctx, _ := newTenant(t, storage)
+	userID := uuid.New()
+	start := month(2024, time.January)
+
+	for _, s := range []*entity.Subscription{
+		{ServiceName: "Netflix", UserId: userID},
+		{ServiceName: "Netflix", UserId: uuid.New()},
+		{ServiceName: " netflix ", UserId: uuid.New()},
+		{ServiceName: "Nteflix", UserId: uuid.New()},
+		{ServiceName: "Spotify", UserId: userID},
+	} {
+		s.Price = 100
+		s.StartDate = start
+		createSubscription(t, ctx, storage, s)
+	}
+
+	// Тестовый пример 1: Совпадения по началу названия идут раньше похожих по триграммам
+	matches, err := storage.SearchSubscriptions(ctx, "  NETFLIX", nil, 10)
+	require.NoError(t, err)
+	require.Len(t, matches, 4)
+	for i, m := range matches[:3] {
+		assert.Contains(t, []string{"Netflix", " netflix "}, m.Subscription.ServiceName, i)
+		assert.Equal(t, 1.0, m.Score, i)
+	}
+	assert.Equal(t, "Nteflix", matches[3].Subscription.ServiceName)
+	assert.Less(t, matches[3].Score, 1.0)
+
+	matches, err = storage.SearchSubscriptions(ctx, "netf", nil, 10)
+	require.NoError(t, err)
+	assert.Len(t, matches, 3)
+
+	// Тестовый пример 2: Ограничение количества и поиск по подпискам пользователя
+	matches, err = storage.SearchSubscriptions(ctx, "netflix", nil, 2)
+	require.NoError(t, err)
+	assert.Len(t, matches, 2)
+
+	matches, err = storage.SearchSubscriptions(ctx, "netflix", &userID, 10)
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, userID, matches[0].Subscription.UserId)
+
+	matches, err = storage.SearchSubscriptions(ctx, "youtube", nil, 10)
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+
+	// Тестовый пример 3: Написания объединяются, в ответ попадает самое частое
+	suggestions, err := storage.SuggestServiceNames(ctx, "netflix", nil, 10)
+	require.NoError(t, err)
+	require.Len(t, suggestions, 2)
+	assert.Equal(t, "Netflix", suggestions[0].ServiceName)
+	assert.Equal(t, 3, suggestions[0].Subscriptions)
+	assert.Equal(t, "Nteflix", suggestions[1].ServiceName)
+	assert.Equal(t, 1, suggestions[1].Subscriptions)
+
+	suggestions, err = storage.SuggestServiceNames(ctx, "youtube", nil, 10)
+	require.NoError(t, err)
+	assert.Empty(t, suggestions)
+}
@@ -0,0 +1,101 @@
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/Ararat25/subscription-aggregation-service/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testTransactions проверяет, что вызовы хранилища внутри WithTx применяются вместе или не применяются вовсе
+func testTransactions(t *testing.T, storage repository.Storage) {
+	ctx, _ := newTenant(t, storage)
+	userID := uuid.New()
+	errAbort := errors.New("abort")
+
+	newSub := func(name string) *entity.Subscription {
+		return &entity.Subscription{ServiceName: name, Price: 100, UserId: userID, StartDate: month(2025, 1), Tags: []string{}}
+	}
+
+	// Тестовый пример 1: Изменения видны внутри транзакции и сохраняются после ее фиксации
+	err := storage.WithTx(ctx, func(tx repository.Repo) error {
+		id, err := tx.CreateSubscription(ctx, newSub("Netflix"))
+		if err != nil {
+			return err
+		}
+
+		sub, err := tx.ReadSubscription(ctx, id)
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, "Netflix", sub.ServiceName)
+
+		_, err = tx.CreateSubscription(ctx, newSub("Spotify"))
+		return err
+	})
+	require.NoError(t, err)
+	assertServiceNames(t, ctx, storage, userID, "Netflix", "Spotify")
+
+	// Тестовый пример 2: Ошибка fn отменяет все изменения транзакции и возвращается из WithTx
+	err = storage.WithTx(ctx, func(tx repository.Repo) error {
+		_, err := tx.CreateSubscription(ctx, newSub("YouTube"))
+		if err != nil {
+			return err
+		}
+
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+	assertServiceNames(t, ctx, storage, userID, "Netflix", "Spotify")
+
+	// Тестовый пример 3: Ошибка вложенной транзакции отменяет только ее изменения
+	err = storage.WithTx(ctx, func(tx repository.Repo) error {
+		_, err := tx.CreateSubscription(ctx, newSub("Kinopoisk"))
+		if err != nil {
+			return err
+		}
+
+		err = tx.WithTx(ctx, func(nested repository.Repo) error {
+			_, err := nested.CreateSubscription(ctx, newSub("Okko"))
+			if err != nil {
+				return err
+			}
+
+			return errAbort
+		})
+		if !errors.Is(err, errAbort) {
+			return err
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+	assertServiceNames(t, ctx, storage, userID, "Netflix", "Spotify", "Kinopoisk")
+
+	// Тестовый пример 4: Без тенанта в контексте вызовы внутри транзакции возвращают ошибку
+	err = storage.WithTx(context.Background(), func(tx repository.Repo) error {
+		_, err := tx.CreateSubscription(context.Background(), newSub("Netflix"))
+		return err
+	})
+	assert.ErrorIs(t, err, myError.ErrTenantRequired)
+}
+
+// assertServiceNames проверяет названия сервисов подписок пользователя в порядке id
+func assertServiceNames(t *testing.T, ctx context.Context, storage repository.Storage, userID uuid.UUID, names ...string) {
+	t.Helper()
+
+	subs, err := storage.ListSubscriptions(ctx, &entity.SubscriptionFilter{UserId: &userID})
+	require.NoError(t, err)
+
+	found := make([]string, 0, len(subs))
+	for _, s := range subs {
+		found = append(found, s.ServiceName)
+	}
+	assert.Equal(t, names, found)
+}
@@ -0,0 +1,325 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// monthlyCostsLiveQuery - итоги стоимости тенанта за каждый месяц, рассчитанные по подпискам так же,
+// как их ведут изменения подписок: подписка без даты окончания учитывается по месяц through_month
+const monthlyCostsLiveQuery = `SELECT m::date AS month, user_id, service_name, SUM(price) AS total, count(*) AS subscriptions
+         FROM subscription_history, monthly_costs_state st,
+              generate_series(start_date, COALESCE(end_date, st.through_month), interval '1 month') m
+         WHERE ` + tenantCondition + `
+         GROUP BY 1, 2, 3`
+
+// monthlyCostKey - ключ строки помесячных итогов стоимости
+type monthlyCostKey struct {
+	tenantID    uuid.UUID // тенант подписок
+	month       time.Time // первое число месяца
+	userID      uuid.UUID // id пользователя
+	serviceName string    // название сервиса
+}
+
+// monthlyCostTotals - значения строки помесячных итогов стоимости
+type monthlyCostTotals struct {
+	total         int // суммарная стоимость подписок
+	subscriptions int // количество подписок
+}
+
+// rollupCostMonth проверяет, что стоимость за период можно взять из помесячных итогов, и возвращает месяц периода.
+// Итоги ведутся по пользователям и точным названиям сервисов, поэтому подходят период из одного месяца
+// и фильтр только по пользователю и точному названию сервиса
+func rollupCostMonth(from, to time.Time, filter *entity.CostFilter) (time.Time, bool) {
+	month := toDate(from)
+	if !month.Equal(toDate(to)) || month.Day() != 1 {
+		return time.Time{}, false
+	}
+
+	if filter == nil {
+		return month, true
+	}
+
+	// итоги продлеваются на новый месяц по часам бд, а в фильтре может быть более ранняя дата
+	if !filter.Today.IsZero() && month.After(monthOf(filter.Today)) {
+		return time.Time{}, false
+	}
+
+	// пустой режим, как и в serviceNameCondition, означает точное совпадение
+	if filter.ServiceName != nil && filter.ServiceName.Mode != "" && filter.ServiceName.Mode != entity.MatchExact {
+		return time.Time{}, false
+	}
+
+	return month, filter.Category == nil && len(filter.Tags) == 0
+}
+
+// addMonthlyCosts добавляет подписку в итоги за месяцы с ее начала по дату окончания (sign = 1) или вычитает
+// ее из них (sign = -1) в транзакции ее изменения. Строки итогов без подписок удаляются. Месяц through_month
+// блокируется на чтение до конца транзакции, чтобы итоги не продлили на новый месяц посередине изменения
+func addMonthlyCosts(ctx context.Context, tx pgx.Tx, s *entity.Subscription, sign int) error {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO monthly_costs AS mc (tenant_id, month, user_id, service_name, total, subscriptions)
+         SELECT current_tenant_id(), m::date, $1, $2, $3::bigint * $4, $4
+         FROM (SELECT through_month FROM monthly_costs_state FOR SHARE) st,
+              generate_series($5::date, COALESCE($6::date, st.through_month), interval '1 month') m
+         ON CONFLICT (tenant_id, month, user_id, service_name) DO UPDATE
+             SET total         = mc.total + excluded.total,
+                 subscriptions = mc.subscriptions + excluded.subscriptions`,
+		s.UserId, s.ServiceName, s.Price, sign, s.StartDate, s.EndDate)
+	if err != nil || sign > 0 {
+		return err
+	}
+
+	_, err = tx.Exec(ctx,
+		`DELETE FROM monthly_costs WHERE user_id = $1 AND service_name = $2 AND subscriptions = 0 AND `+tenantCondition,
+		s.UserId, s.ServiceName)
+	return err
+}
+
+// replaceMonthlyCosts переносит подписку в итогах из состояния before в состояние after
+func replaceMonthlyCosts(ctx context.Context, tx pgx.Tx, before, after *entity.Subscription) error {
+	err := addMonthlyCosts(ctx, tx, before, -1)
+	if err != nil {
+		return err
+	}
+
+	return addMonthlyCosts(ctx, tx, after, 1)
+}
+
+// monthlyCostsTotal возвращает стоимость подписок за месяц из итогов. Если итоги еще не продлены на этот месяц,
+// возвращает false: стоимость нужно рассчитать по подпискам
+func monthlyCostsTotal(ctx context.Context, tx pgx.Tx, month time.Time, filter *entity.CostFilter) (int, bool, error) {
+	var through time.Time
+	err := tx.QueryRow(ctx, `SELECT through_month FROM monthly_costs_state`).Scan(&through)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if month.After(through) {
+		return 0, false, nil
+	}
+
+	query, args := appendCostFilter(`SELECT COALESCE(SUM(total), 0) FROM monthly_costs WHERE month = $1 AND `+tenantCondition,
+		[]interface{}{month}, filter)
+
+	var total int
+	err = tx.QueryRow(ctx, query, args...).Scan(&total)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return total, true, nil
+}
+
+// RebuildMonthlyCosts заново рассчитывает помесячные итоги стоимости тенанта по его подпискам. На время
+// пересборки изменения подписок всех тенантов ждут ее окончания
+func (repo *PGRepo) RebuildMonthlyCosts(ctx context.Context) (*entity.MonthlyCostsRebuild, error) {
+	var res entity.MonthlyCostsRebuild
+	err := repo.inWriteTx(ctx, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, `SELECT through_month FROM monthly_costs_state FOR UPDATE`).Scan(&res.ThroughMonth)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `DELETE FROM monthly_costs WHERE `+tenantCondition)
+		if err != nil {
+			return err
+		}
+
+		cmdTag, err := tx.Exec(ctx,
+			`INSERT INTO monthly_costs (tenant_id, month, user_id, service_name, total, subscriptions)
+             SELECT current_tenant_id(), month, user_id, service_name, total, subscriptions
+             FROM (`+monthlyCostsLiveQuery+`) live`)
+		if err != nil {
+			return err
+		}
+
+		res.Rows = int(cmdTag.RowsAffected())
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// CheckMonthlyCosts сверяет помесячные итоги стоимости тенанта с расчетом по его подпискам
+func (repo *PGRepo) CheckMonthlyCosts(ctx context.Context) (*entity.MonthlyCostsCheck, error) {
+	res := entity.MonthlyCostsCheck{Mismatches: []*entity.MonthlyCostMismatch{}}
+	err := repo.inTx(ctx, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx,
+			`SELECT st.through_month, (SELECT count(*) FROM monthly_costs WHERE `+tenantCondition+`)
+             FROM monthly_costs_state st`).Scan(&res.ThroughMonth, &res.Rows)
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(ctx,
+			`SELECT month, user_id, service_name,
+                    COALESCE(rollup.total, 0), COALESCE(live.total, 0),
+                    COALESCE(rollup.subscriptions, 0), COALESCE(live.subscriptions, 0)
+             FROM (`+monthlyCostsLiveQuery+`) live
+                  FULL JOIN (SELECT month, user_id, service_name, total, subscriptions
+                             FROM monthly_costs WHERE `+tenantCondition+`) rollup
+                  USING (month, user_id, service_name)
+             WHERE live.total IS DISTINCT FROM rollup.total
+                OR live.subscriptions IS DISTINCT FROM rollup.subscriptions
+             ORDER BY month, user_id, service_name`)
+		if err != nil {
+			return err
+		}
+
+		mismatches, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*entity.MonthlyCostMismatch, error) {
+			var m entity.MonthlyCostMismatch
+			err := row.Scan(&m.Month, &m.UserId, &m.ServiceName, &m.RollupTotal, &m.LiveTotal,
+				&m.RollupSubscriptions, &m.LiveSubscriptions)
+			return &m, err
+		})
+		if err != nil {
+			return err
+		}
+
+		res.Mismatches = append(res.Mismatches, mismatches...)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// AdvanceMonthlyCosts продлевает итоги всех тенантов по текущий месяц: подписки без даты окончания
+// добавляются в месяцы после through_month. Возвращает месяц, по который продлены итоги
+func (repo *PGRepo) AdvanceMonthlyCosts(ctx context.Context) (time.Time, error) {
+	var through time.Time
+	err := repo.inSystemTx(ctx, func(tx pgx.Tx) error {
+		var current time.Time
+		err := tx.QueryRow(ctx,
+			`SELECT through_month, date_trunc('month', CURRENT_DATE)::date FROM monthly_costs_state FOR UPDATE`).
+			Scan(&through, &current)
+		if err != nil {
+			return err
+		}
+
+		if !current.After(through) {
+			return nil
+		}
+
+		_, err = tx.Exec(ctx,
+			`INSERT INTO monthly_costs AS mc (tenant_id, month, user_id, service_name, total, subscriptions)
+             SELECT tenant_id, m::date, user_id, service_name, SUM(price), count(*)
+             FROM subscriptions,
+                  generate_series(GREATEST(start_date, $1::date + interval '1 month'), $2::date, interval '1 month') m
+             WHERE end_date IS NULL
+             GROUP BY tenant_id, m, user_id, service_name
+             ON CONFLICT (tenant_id, month, user_id, service_name) DO UPDATE
+                 SET total         = mc.total + excluded.total,
+                     subscriptions = mc.subscriptions + excluded.subscriptions`,
+			through, current)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `UPDATE monthly_costs_state SET through_month = $1`, current)
+		if err != nil {
+			return err
+		}
+
+		through = current
+
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return through, nil
+}
+
+// subscriptionMonths возвращает месяцы, в которых подписка учитывается в итогах: с ее начала по дату окончания,
+// а подписка без даты окончания - по месяц through
+func subscriptionMonths(s *entity.Subscription, through time.Time) []time.Time {
+	last := through
+	if s.EndDate != nil {
+		last = toDate(*s.EndDate)
+	}
+
+	var months []time.Time
+	for m := toDate(s.StartDate); !m.After(last); m = m.AddDate(0, 1, 0) {
+		months = append(months, m)
+	}
+
+	return months
+}
+
+// addMonthlyCostTotals добавляет подписку тенанта в итоги totals (sign = 1) или вычитает ее из них (sign = -1),
+// как addMonthlyCosts, и удаляет строки без подписок
+func addMonthlyCostTotals(totals map[monthlyCostKey]*monthlyCostTotals, tenantID uuid.UUID, s *entity.Subscription, through time.Time, sign int) {
+	for _, m := range subscriptionMonths(s, through) {
+		key := monthlyCostKey{tenantID: tenantID, month: m, userID: s.UserId, serviceName: s.ServiceName}
+
+		t, ok := totals[key]
+		if !ok {
+			t = &monthlyCostTotals{}
+			totals[key] = t
+		}
+
+		t.total += s.Price * sign
+		t.subscriptions += sign
+		if t.subscriptions == 0 {
+			delete(totals, key)
+		}
+	}
+}
+
+// diffMonthlyCosts возвращает расхождения итогов rollup с итогами live, рассчитанными по подпискам,
+// по возрастанию месяца, пользователя и сервиса, как CheckMonthlyCosts у PGRepo
+func diffMonthlyCosts(rollup, live map[monthlyCostKey]*monthlyCostTotals) []*entity.MonthlyCostMismatch {
+	mismatches := []*entity.MonthlyCostMismatch{}
+	add := func(key monthlyCostKey) {
+		r, l := rollup[key], live[key]
+		if r != nil && l != nil && *r == *l {
+			return
+		}
+
+		m := &entity.MonthlyCostMismatch{Month: key.month, UserId: key.userID, ServiceName: key.serviceName}
+		if r != nil {
+			m.RollupTotal, m.RollupSubscriptions = r.total, r.subscriptions
+		}
+		if l != nil {
+			m.LiveTotal, m.LiveSubscriptions = l.total, l.subscriptions
+		}
+		mismatches = append(mismatches, m)
+	}
+
+	for key := range rollup {
+		add(key)
+	}
+	for key := range live {
+		if _, ok := rollup[key]; !ok {
+			add(key)
+		}
+	}
+
+	sort.Slice(mismatches, func(i, j int) bool {
+		a, b := mismatches[i], mismatches[j]
+		if !a.Month.Equal(b.Month) {
+			return a.Month.Before(b.Month)
+		}
+		if a.UserId != b.UserId {
+			return a.UserId.String() < b.UserId.String()
+		}
+		return a.ServiceName < b.ServiceName
+	})
+
+	return mismatches
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// likeEscaper экранирует спецсимволы шаблона LIKE
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchSubscriptions ищет подписки по названию сервиса без учета регистра: подходят названия, начинающиеся с запроса,
+// и названия, похожие на запрос по триграммам. Сначала идут совпадения по началу названия, затем - по убыванию сходства.
+// Если userID не nil, ищутся только подписки этого пользователя
+func (repo *PGRepo) SearchSubscriptions(ctx context.Context, query string, userID *uuid.UUID, limit int) ([]*entity.SubscriptionMatch, error) {
+	q := strings.ToLower(strings.TrimSpace(query))
+
+	var matches []*entity.SubscriptionMatch
+	err := repo.inTx(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx,
+			`SELECT `+subscriptionColumns+`, similarity(lower(service_name), $1) AS score
+         FROM subscriptions
+         WHERE `+tenantCondition+`
+           AND (lower(service_name) % $1 OR lower(btrim(service_name)) LIKE $2)
+           AND ($4::uuid IS NULL OR user_id = $4)
+         ORDER BY lower(btrim(service_name)) LIKE $2 DESC, score DESC, id
+         LIMIT $3`,
+			q, prefixPattern(q), limit, userID)
+		if err != nil {
+			return err
+		}
+
+		matches, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (*entity.SubscriptionMatch, error) {
+			var (
+				s     entity.Subscription
+				score float64
+			)
+			err := row.Scan(append(subscriptionFields(&s), &score)...)
+			return &entity.SubscriptionMatch{Subscription: &s, Score: score}, err
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	return matches, nil
+}
+
+// SuggestServiceNames возвращает варианты автодополнения названия сервиса. Названия, отличающиеся только регистром
+// и пробелами по краям, объединяются, в ответ попадает самое частое написание.
+// Если userID не nil, учитываются только подписки этого пользователя
+func (repo *PGRepo) SuggestServiceNames(ctx context.Context, query string, userID *uuid.UUID, limit int) ([]*entity.ServiceSuggestion, error) {
+	q := strings.ToLower(strings.TrimSpace(query))
+
+	suggestions := []*entity.ServiceSuggestion{}
+	err := repo.inTx(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx,
+			`SELECT mode() WITHIN GROUP (ORDER BY btrim(service_name)) AS name,
+                count(*) AS subscriptions,
+                max(similarity(lower(service_name), $1)) AS score
+         FROM subscriptions
+         WHERE `+tenantCondition+`
+           AND (lower(service_name) % $1 OR lower(btrim(service_name)) LIKE $2)
+           AND ($4::uuid IS NULL OR user_id = $4)
+         GROUP BY lower(btrim(service_name))
+         ORDER BY bool_or(lower(btrim(service_name)) LIKE $2) DESC, score DESC, subscriptions DESC, name
+         LIMIT $3`,
+			q, prefixPattern(q), limit, userID)
+		if err != nil {
+			return err
+		}
+
+		suggestions, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (*entity.ServiceSuggestion, error) {
+			var s entity.ServiceSuggestion
+			err := row.Scan(&s.ServiceName, &s.Subscriptions, &s.Score)
+			return &s, err
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return suggestions, nil
+}
+
+// serviceNameCondition возвращает условие фильтрации по названию сервиса для параметра с номером n
+func serviceNameCondition(mode entity.MatchMode, n int) string {
+	switch mode {
+	case entity.MatchIgnore:
+		return fmt.Sprintf(`lower(btrim(service_name)) = lower(btrim($%d))`, n)
+	case entity.MatchFuzzy:
+		return fmt.Sprintf(`lower(service_name) %% lower(btrim($%d))`, n)
+	default:
+		return fmt.Sprintf(`service_name = $%d`, n)
+	}
+}
+
+// prefixPattern возвращает шаблон LIKE для поиска по началу строки
+func prefixPattern(s string) string {
+	return likeEscaper.Replace(s) + "%"
+}
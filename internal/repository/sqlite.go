@@ -0,0 +1,313 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/config"
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/Ararat25/subscription-aggregation-service/internal/tenant"
+	"github.com/Ararat25/subscription-aggregation-service/migrations"
+	"github.com/google/uuid"
+)
+
+// sqliteDriver - имя драйвера database/sql для SQLite с функциями, которых нет в SQLite, но которые есть в Postgres
+const sqliteDriver = "sqlite3_subscriptions"
+
+// sqliteDateFormat - формат хранения дат: строки этого вида сравниваются так же, как даты
+const sqliteDateFormat = "2006-01-02"
+
+// sqliteTimeFormat - формат хранения времени в UTC. Дробная часть фиксированной длины сохраняет
+// порядок строк таким же, как порядок времени
+const sqliteTimeFormat = "2006-01-02 15:04:05.000000000"
+
+// SQLiteRepo - хранилище в файле SQLite для однопользовательских и edge-развертываний без Postgres.
+// Схема создается встроенными миграциями при подключении, стоимость, поиск и ошибки те же, что у PGRepo.
+// Все запросы идут через одно соединение, поэтому транзакции выполняются по очереди
+type SQLiteRepo struct {
+	db  *sql.DB          // соединение с файлом бд
+	tx  *sql.Tx          // транзакция WithTx, в которой выполняются запросы, или nil
+	now func() time.Time // источник текущего времени
+}
+
+// NewSQLiteRepo возвращает хранилище SQLite, к которому еще нужно подключиться через ConnectDB
+func NewSQLiteRepo() *SQLiteRepo {
+	return &SQLiteRepo{now: time.Now}
+}
+
+// ConnectDB открывает файл бд conf.SQLitePath, создавая его при необходимости, и применяет к нему миграции
+func (repo *SQLiteRepo) ConnectDB(ctx context.Context, conf config.DatabaseConfig) error {
+	if !slices.Contains(sql.Drivers(), sqliteDriver) {
+		return fmt.Errorf("sqlite storage requires a build with cgo enabled")
+	}
+
+	dsn := fmt.Sprintf("file:%s?_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate",
+		url.PathEscape(conf.SQLitePath))
+
+	db, err := sql.Open(sqliteDriver, dsn)
+	if err != nil {
+		return fmt.Errorf("unable to open database: %w", err)
+	}
+	// SQLite допускает одного писателя, а с одним соединением транзакции не ждут снятия блокировки файла
+	db.SetMaxOpenConns(1)
+
+	err = migrateSQLite(ctx, db)
+	if err != nil {
+		_ = db.Close()
+		return fmt.Errorf("unable to migrate database: %w", err)
+	}
+
+	repo.db = db
+
+	return nil
+}
+
+// PoolStats возвращает состояние соединений с файлом бд
+func (repo *SQLiteRepo) PoolStats(_ context.Context) (*entity.PoolStats, error) {
+	s := repo.db.Stats()
+
+	return &entity.PoolStats{
+		MaxConns:                int32(s.MaxOpenConnections),
+		TotalConns:              int32(s.OpenConnections),
+		AcquiredConns:           int32(s.InUse),
+		IdleConns:               int32(s.Idle),
+		AcquireDuration:         s.WaitDuration.Seconds(),
+		EmptyAcquireCount:       s.WaitCount,
+		MaxLifetimeDestroyCount: s.MaxLifetimeClosed,
+		MaxIdleDestroyCount:     s.MaxIdleClosed + s.MaxIdleTimeClosed,
+	}, nil
+}
+
+// Close закрывает соединение с файлом бд. Хранилище транзакции WithTx закрыть нельзя
+func (repo *SQLiteRepo) Close(_ context.Context) error {
+	if repo.tx != nil {
+		return fmt.Errorf("unable to close storage inside a transaction")
+	}
+
+	return repo.db.Close()
+}
+
+// WithTx выполняет fn в одной транзакции: вызовы хранилища tx внутри fn либо применяются все, либо, если fn
+// вернула ошибку, не применяется ни один. Транзакции SQLite выполняются по очереди, поэтому всегда сериализуемы
+// и не требуют повторов; вызывать внутри fn само хранилище, а не tx, нельзя - соединение занято транзакцией.
+// Вызов WithTx у хранилища tx открывает точку сохранения
+func (repo *SQLiteRepo) WithTx(ctx context.Context, fn func(tx Repo) error) error {
+	return repo.inSystemTx(ctx, func(tx *sql.Tx) error {
+		return fn(&SQLiteRepo{db: repo.db, tx: tx, now: repo.now})
+	})
+}
+
+// inTx выполняет fn в транзакции и фиксирует ее, если fn не вернула ошибку. В fn передается тенант из контекста,
+// которым запросы ограничивают строки. Без тенанта в контексте возвращает myError.ErrTenantRequired
+func (repo *SQLiteRepo) inTx(ctx context.Context, fn func(tx *sql.Tx, tenantID uuid.UUID) error) error {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return myError.ErrTenantRequired
+	}
+
+	return repo.inSystemTx(ctx, func(tx *sql.Tx) error {
+		return fn(tx, tenantID)
+	})
+}
+
+// inSystemTx выполняет fn в транзакции с доступом к данным всех тенантов. Используется фоновыми задачами,
+// поиском API-ключа при аутентификации и управлением тенантами. Внутри WithTx вместо транзакции
+// открывается точка сохранения
+func (repo *SQLiteRepo) inSystemTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	if repo.tx != nil {
+		return repo.inSavepoint(ctx, fn)
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	err = fn(tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// inSavepoint выполняет fn в точке сохранения транзакции WithTx: ошибка fn отменяет только ее изменения
+func (repo *SQLiteRepo) inSavepoint(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	_, err := repo.tx.ExecContext(ctx, `SAVEPOINT nested`)
+	if err != nil {
+		return err
+	}
+
+	err = fn(repo.tx)
+	if err != nil {
+		// ROLLBACK TO оставляет точку сохранения открытой, RELEASE убирает ее
+		_, _ = repo.tx.ExecContext(ctx, `ROLLBACK TO nested`)
+		_, _ = repo.tx.ExecContext(ctx, `RELEASE nested`)
+		return err
+	}
+
+	_, err = repo.tx.ExecContext(ctx, `RELEASE nested`)
+	return err
+}
+
+// bumpVersion увеличивает счетчик изменений подписок тенанта, как триггер subscriptions_bump_version
+func (repo *SQLiteRepo) bumpVersion(ctx context.Context, tx *sql.Tx, tenantID uuid.UUID) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO table_versions (tenant_id, table_name, version, updated_at)
+         VALUES (:tenant, 'subscriptions', 1, :now)
+         ON CONFLICT (tenant_id, table_name) DO UPDATE SET version = version + 1, updated_at = excluded.updated_at`,
+		sql.Named("tenant", tenantID), sql.Named("now", sqliteTime(repo.now())))
+	return err
+}
+
+// migrateSQLite применяет к бд встроенные миграции migrations.SQLite, которые еще не применены.
+// Примененные версии записываются в таблицу schema_migrations, каждая миграция выполняется в своей транзакции
+func migrateSQLite(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx,
+		`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY, applied_at TIMESTAMP NOT NULL)`)
+	if err != nil {
+		return err
+	}
+
+	files, err := fs.Glob(migrations.SQLite, "sqlite/*.up.sql")
+	if err != nil {
+		return err
+	}
+
+	type migration struct {
+		version int64
+		file    string
+	}
+
+	var pending []migration
+	for _, file := range files {
+		prefix, _, _ := strings.Cut(strings.TrimPrefix(file, "sqlite/"), "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid migration file name %q", file)
+		}
+
+		var applied bool
+		err = db.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = :version)`,
+			sql.Named("version", version)).Scan(&applied)
+		if err != nil {
+			return err
+		}
+
+		if !applied {
+			pending = append(pending, migration{version: version, file: file})
+		}
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].version < pending[j].version
+	})
+
+	for _, m := range pending {
+		script, err := fs.ReadFile(migrations.SQLite, m.file)
+		if err != nil {
+			return err
+		}
+
+		err = applySQLiteMigration(ctx, db, m.version, string(script))
+		if err != nil {
+			return fmt.Errorf("migration %s: %w", m.file, err)
+		}
+	}
+
+	return nil
+}
+
+// applySQLiteMigration выполняет скрипт миграции и записывает ее версию в одной транзакции
+func applySQLiteMigration(ctx context.Context, db *sql.DB, version int64, script string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.ExecContext(ctx, script)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO schema_migrations (version, applied_at) VALUES (:version, :now)`,
+		sql.Named("version", version), sql.Named("now", sqliteTime(time.Now())))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// sqliteDate возвращает дату в формате хранения
+func sqliteDate(t time.Time) string {
+	return t.Format(sqliteDateFormat)
+}
+
+// sqliteDatePtr возвращает необязательную дату в формате хранения или nil
+func sqliteDatePtr(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+
+	return sqliteDate(*t)
+}
+
+// sqliteTime возвращает время в формате хранения
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeFormat)
+}
+
+// sqliteTimePtr возвращает необязательное время в формате хранения или nil
+func sqliteTimePtr(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+
+	return sqliteTime(*t)
+}
+
+// sqliteJSON возвращает значение в формате JSON для колонок, хранящих массивы
+func sqliteJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// jsonColumn читает колонку в формате JSON в значение по указателю dst
+type jsonColumn struct {
+	dst any // указатель на значение колонки
+}
+
+// Scan реализует sql.Scanner
+func (c jsonColumn) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), c.dst)
+	case []byte:
+		return json.Unmarshal(v, c.dst)
+	default:
+		return fmt.Errorf("unsupported json column type %T", src)
+	}
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/google/uuid"
+)
+
+// CreateAPIKey добавляет API-ключ в бд и возвращает id
+func (repo *SQLiteRepo) CreateAPIKey(ctx context.Context, k *entity.APIKey) (int64, error) {
+	if k == nil {
+		return 0, fmt.Errorf("invalid argument error")
+	}
+
+	scopes, err := sqliteJSON(scopesToStrings(k.Scopes))
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+	err = repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		createdAt := repo.now()
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO api_keys (tenant_id, name, prefix, key_hash, scopes, user_id, expires_at, created_at)
+             VALUES (:tenant, :name, :prefix, :key_hash, :scopes, :user_id, :expires_at, :now)
+             RETURNING id`,
+			sql.Named("tenant", tenantID), sql.Named("name", k.Name), sql.Named("prefix", k.Prefix),
+			sql.Named("key_hash", k.KeyHash), sql.Named("scopes", scopes), sql.Named("user_id", k.UserId),
+			sql.Named("expires_at", sqliteTimePtr(k.ExpiresAt)), sql.Named("now", sqliteTime(createdAt))).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		k.CreatedAt = createdAt
+		k.TenantId = tenantID
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// ListAPIKeys возвращает список всех API-ключей тенанта, включая отозванные
+func (repo *SQLiteRepo) ListAPIKeys(ctx context.Context) ([]*entity.APIKey, error) {
+	keys := []*entity.APIKey{}
+	err := repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		rows, err := tx.QueryContext(ctx,
+			`SELECT `+apiKeyColumns+` FROM api_keys WHERE `+sqliteTenantCondition+` ORDER BY id`,
+			sql.Named("tenant", tenantID))
+		if err != nil {
+			return err
+		}
+
+		found, err := collectSQLiteRows(rows, scanSQLiteAPIKey)
+		if err != nil {
+			return err
+		}
+
+		keys = append(keys, found...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает API-ключ. Повторный отзыв не меняет время отзыва
+func (repo *SQLiteRepo) RevokeAPIKey(ctx context.Context, id int64) error {
+	return repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		res, err := tx.ExecContext(ctx,
+			`UPDATE api_keys SET revoked_at = COALESCE(revoked_at, :now) WHERE id = :id AND `+sqliteTenantCondition,
+			sql.Named("now", sqliteTime(repo.now())), sql.Named("id", id), sql.Named("tenant", tenantID))
+		if err != nil {
+			return err
+		}
+
+		return sqliteAffected(res, myError.ErrAPIKeyNotFound)
+	})
+}
+
+// FindAPIKey возвращает API-ключ по хэшу, в том числе отозванный или истекший. Ключ ищется среди всех тенантов:
+// тенант запроса определяется по найденному ключу
+func (repo *SQLiteRepo) FindAPIKey(ctx context.Context, keyHash string) (*entity.APIKey, error) {
+	var k *entity.APIKey
+	err := repo.inSystemTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		k, err = scanSQLiteAPIKey(tx.QueryRowContext(ctx,
+			`SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = :key_hash`, sql.Named("key_hash", keyHash)))
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, myError.ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// TouchAPIKey записывает время последнего использования API-ключа
+func (repo *SQLiteRepo) TouchAPIKey(ctx context.Context, id int64, usedAt time.Time) error {
+	return repo.inSystemTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			`UPDATE api_keys SET last_used_at = :used_at WHERE id = :id`,
+			sql.Named("used_at", sqliteTime(usedAt)), sql.Named("id", id))
+		return err
+	})
+}
+
+// scanSQLiteAPIKey читает API-ключ из строки результата с колонками apiKeyColumns
+func scanSQLiteAPIKey(row sqliteRow) (*entity.APIKey, error) {
+	var k entity.APIKey
+	err := row.Scan(&k.Id, &k.Name, &k.Prefix, &k.KeyHash, jsonColumn{dst: &k.Scopes}, &k.UserId, &k.ExpiresAt,
+		&k.LastUsedAt, &k.RevokedAt, &k.CreatedAt, &k.TenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &k, nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/google/uuid"
+)
+
+// sqliteSubscriptionHistory - подписки вместе с архивными для расчета стоимости. Представление называется
+// в запросе subscriptions, чтобы условия фильтров, ссылающиеся на subscriptions.tags, работали и с ним
+const sqliteSubscriptionHistory = `subscription_history AS subscriptions`
+
+// ArchiveSubscriptions переносит в архив не больше limit подписок всех тенантов, закончившихся раньше
+// месяца endedBefore, и возвращает количество перенесенных. Помесячные итоги не меняются
+func (repo *SQLiteRepo) ArchiveSubscriptions(ctx context.Context, endedBefore time.Time, limit int) (int, error) {
+	var archived int
+	err := repo.inSystemTx(ctx, func(tx *sql.Tx) error {
+		// оба оператора выбирают одни и те же подписки: между ними в транзакции таблица не меняется
+		const batch = `id IN (SELECT id FROM subscriptions WHERE end_date < :before ORDER BY id LIMIT :limit)`
+		args := []any{sql.Named("before", sqliteDate(endedBefore)), sql.Named("limit", limit)}
+
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO subscriptions_archive (tenant_id, `+subscriptionColumns+`, archived_at)
+             SELECT tenant_id, `+subscriptionColumns+`, :now FROM subscriptions WHERE `+batch,
+			append(args, sql.Named("now", sqliteTime(repo.now())))...)
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.QueryContext(ctx, `DELETE FROM subscriptions WHERE `+batch+` RETURNING tenant_id`, args...)
+		if err != nil {
+			return err
+		}
+
+		tenants, err := collectSQLiteRows(rows, func(row sqliteRow) (uuid.UUID, error) {
+			var tenantID uuid.UUID
+			err := row.Scan(&tenantID)
+			return tenantID, err
+		})
+		if err != nil {
+			return err
+		}
+
+		archived = len(tenants)
+
+		// подписки пропадают из списков, поэтому кэш ответов тенанта нужно сбросить, как делает триггер в Postgres
+		bumped := make(map[uuid.UUID]bool)
+		for _, tenantID := range tenants {
+			if bumped[tenantID] {
+				continue
+			}
+			bumped[tenantID] = true
+
+			err = repo.bumpVersion(ctx, tx, tenantID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	return archived, err
+}
+
+// ListSubscriptionHistory возвращает подписки вместе с архивными с фильтрацией по пользователю, категории и тегам
+func (repo *SQLiteRepo) ListSubscriptionHistory(ctx context.Context, filter *entity.SubscriptionFilter) ([]*entity.Subscription, error) {
+	var subs []*entity.Subscription
+	err := repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		query, args, err := appendSQLiteSubscriptionFilter(`SELECT `+subscriptionColumns+` FROM `+sqliteSubscriptionHistory+` WHERE `+sqliteTenantCondition,
+			[]any{sql.Named("tenant", tenantID)}, filter)
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.QueryContext(ctx, query+` ORDER BY id`, args...)
+		if err != nil {
+			return err
+		}
+
+		subs, err = collectSQLiteRows(rows, scanSQLiteSubscription)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/google/uuid"
+)
+
+// CreateService добавляет сервис в каталог и возвращает id
+func (repo *SQLiteRepo) CreateService(ctx context.Context, s *entity.Service) (int64, error) {
+	if s == nil {
+		return 0, fmt.Errorf("invalid argument error")
+	}
+
+	aliases, err := sqliteJSON(cloneStrings(s.Aliases))
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+	err = repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO services (tenant_id, name, aliases, category, default_price, website)
+             VALUES (:tenant, :name, :aliases, :category, :default_price, :website)
+             RETURNING id`,
+			sql.Named("tenant", tenantID), sql.Named("name", s.Name), sql.Named("aliases", aliases),
+			sql.Named("category", s.Category), sql.Named("default_price", s.DefaultPrice),
+			sql.Named("website", s.Website)).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		return insertSQLiteServiceNames(ctx, tx, tenantID, id, s)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// ReadService возвращает сервис из каталога по id
+func (repo *SQLiteRepo) ReadService(ctx context.Context, id int64) (*entity.Service, error) {
+	var s *entity.Service
+	err := repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		var err error
+		s, err = scanSQLiteService(tx.QueryRowContext(ctx,
+			`SELECT `+serviceColumns+` FROM services WHERE id = :id AND `+sqliteTenantCondition,
+			sql.Named("id", id), sql.Named("tenant", tenantID)))
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, myError.ErrServiceNotFound
+		}
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// UpdateService обновляет сервис в каталоге. При смене канонического названия оно переносится в подписки сервиса,
+// для каждой измененной подписки записывается событие subscription.updated
+func (repo *SQLiteRepo) UpdateService(ctx context.Context, s *entity.Service) error {
+	if s == nil {
+		return fmt.Errorf("invalid argument error")
+	}
+
+	aliases, err := sqliteJSON(cloneStrings(s.Aliases))
+	if err != nil {
+		return err
+	}
+
+	return repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		res, err := tx.ExecContext(ctx,
+			`UPDATE services
+             SET name = :name, aliases = :aliases, category = :category, default_price = :default_price, website = :website
+             WHERE id = :id AND `+sqliteTenantCondition,
+			sql.Named("name", s.Name), sql.Named("aliases", aliases), sql.Named("category", s.Category),
+			sql.Named("default_price", s.DefaultPrice), sql.Named("website", s.Website),
+			sql.Named("id", s.Id), sql.Named("tenant", tenantID))
+		if err != nil {
+			return err
+		}
+
+		err = sqliteAffected(res, myError.ErrServiceNotFound)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`DELETE FROM service_names WHERE service_id = :id AND `+sqliteTenantCondition,
+			sql.Named("id", s.Id), sql.Named("tenant", tenantID))
+		if err != nil {
+			return err
+		}
+
+		err = insertSQLiteServiceNames(ctx, tx, tenantID, s.Id, s)
+		if err != nil {
+			return err
+		}
+
+		// прежние названия нужны, чтобы перенести стоимость подписок в итогах на новое название
+		rows, err := tx.QueryContext(ctx,
+			`SELECT `+subscriptionColumns+` FROM subscriptions
+             WHERE service_id = :id AND service_name <> :name AND `+sqliteTenantCondition,
+			sql.Named("name", s.Name), sql.Named("id", s.Id), sql.Named("tenant", tenantID))
+		if err != nil {
+			return err
+		}
+
+		before, err := collectSQLiteRows(rows, scanSQLiteSubscription)
+		if err != nil {
+			return err
+		}
+
+		for _, sub := range before {
+			after := *sub
+			after.ServiceName = s.Name
+
+			err = repo.replaceMonthlyCosts(ctx, tx, tenantID, sub, &after)
+			if err != nil {
+				return err
+			}
+		}
+
+		rows, err = tx.QueryContext(ctx,
+			`UPDATE subscriptions SET service_name = :name
+             WHERE service_id = :id AND service_name <> :name AND `+sqliteTenantCondition+`
+             RETURNING `+subscriptionColumns,
+			sql.Named("name", s.Name), sql.Named("id", s.Id), sql.Named("tenant", tenantID))
+		if err != nil {
+			return err
+		}
+
+		renamed, err := collectSQLiteRows(rows, scanSQLiteSubscription)
+		if err != nil {
+			return err
+		}
+
+		for _, sub := range renamed {
+			err = repo.insertOutbox(ctx, tx, tenantID, entity.EventSubscriptionUpdated, sub)
+			if err != nil {
+				return err
+			}
+		}
+
+		return repo.bumpVersion(ctx, tx, tenantID)
+	})
+}
+
+// DeleteService удаляет сервис из каталога. Подписки сервиса сохраняют название, но теряют ссылку на каталог
+func (repo *SQLiteRepo) DeleteService(ctx context.Context, id int64) error {
+	return repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		res, err := tx.ExecContext(ctx,
+			`DELETE FROM services WHERE id = :id AND `+sqliteTenantCondition,
+			sql.Named("id", id), sql.Named("tenant", tenantID))
+		if err != nil {
+			return err
+		}
+
+		err = sqliteAffected(res, myError.ErrServiceNotFound)
+		if err != nil {
+			return err
+		}
+
+		// ссылку на каталог в подписках снимает внешний ключ ON DELETE SET NULL
+		return repo.bumpVersion(ctx, tx, tenantID)
+	})
+}
+
+// ListServices возвращает каталог сервисов тенанта, упорядоченный по названию
+func (repo *SQLiteRepo) ListServices(ctx context.Context) ([]*entity.Service, error) {
+	services := []*entity.Service{}
+	err := repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		rows, err := tx.QueryContext(ctx,
+			`SELECT `+serviceColumns+` FROM services WHERE `+sqliteTenantCondition+` ORDER BY lower(name), id`,
+			sql.Named("tenant", tenantID))
+		if err != nil {
+			return err
+		}
+
+		found, err := collectSQLiteRows(rows, scanSQLiteService)
+		if err != nil {
+			return err
+		}
+
+		services = append(services, found...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return services, nil
+}
+
+// ResolveService ищет сервис, каноническое название или псевдоним которого совпадает с name
+// без учета регистра и пробелов по краям
+func (repo *SQLiteRepo) ResolveService(ctx context.Context, name string) (*entity.Service, error) {
+	var s *entity.Service
+	err := repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		var err error
+		s, err = scanSQLiteService(tx.QueryRowContext(ctx,
+			`SELECT `+serviceColumns+` FROM services
+             WHERE id = (SELECT service_id FROM service_names WHERE name_key = lower(trim(:name)) AND `+sqliteTenantCondition+`)
+               AND `+sqliteTenantCondition,
+			sql.Named("name", name), sql.Named("tenant", tenantID)))
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, myError.ErrServiceNotFound
+		}
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// insertSQLiteServiceNames сохраняет нормализованные написания названия сервиса. Если написание уже занято другим
+// сервисом, возвращает myError.ErrServiceNameTaken
+func insertSQLiteServiceNames(ctx context.Context, tx *sql.Tx, tenantID uuid.UUID, id int64, s *entity.Service) error {
+	names, err := sqliteJSON(append([]string{s.Name}, s.Aliases...))
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO service_names (tenant_id, name_key, service_id)
+         SELECT DISTINCT :tenant, lower(trim(value)), :id FROM json_each(:names)`,
+		sql.Named("tenant", tenantID), sql.Named("id", id), sql.Named("names", names))
+	if err != nil {
+		if isSQLiteUniqueViolation(err) {
+			return myError.ErrServiceNameTaken
+		}
+		return err
+	}
+
+	return nil
+}
+
+// scanSQLiteService читает сервис из строки результата с колонками serviceColumns
+func scanSQLiteService(row sqliteRow) (*entity.Service, error) {
+	var s entity.Service
+	err := row.Scan(&s.Id, &s.Name, jsonColumn{dst: &s.Aliases}, &s.Category, &s.DefaultPrice, &s.Website)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
@@ -0,0 +1,44 @@
+//go:build cgo
+
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+func init() {
+	sql.Register(sqliteDriver, &sqlite3.SQLiteDriver{ConnectHook: registerSQLiteFuncs})
+}
+
+// registerSQLiteFuncs добавляет в соединение функции, которые запросы используют так же, как в Postgres:
+// lower с поддержкой Unicode вместо встроенной, понимающей только ASCII, и сходство строк по триграммам
+// pg_trgm - similarity и similar, аналог оператора %
+func registerSQLiteFuncs(conn *sqlite3.SQLiteConn) error {
+	err := conn.RegisterFunc("lower", strings.ToLower, true)
+	if err != nil {
+		return err
+	}
+
+	err = conn.RegisterFunc("similarity", func(a, b string) float64 {
+		return float64(trigramSimilarity(a, b))
+	}, true)
+	if err != nil {
+		return err
+	}
+
+	return conn.RegisterFunc("similar", trigramSimilar, true)
+}
+
+// isSQLiteUniqueViolation проверяет, что ошибка - нарушение уникальности или первичного ключа
+func isSQLiteUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+
+	return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique || sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
+}
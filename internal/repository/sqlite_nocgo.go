@@ -0,0 +1,10 @@
+//go:build !cgo
+
+package repository
+
+// Драйвер SQLite написан на C, поэтому без cgo он не регистрируется, и SQLiteRepo.ConnectDB возвращает ошибку
+
+// isSQLiteUniqueViolation всегда возвращает false: без драйвера SQLite его ошибок не бывает
+func isSQLiteUniqueViolation(_ error) bool {
+	return false
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/google/uuid"
+)
+
+// ClaimOutbox выбирает неопубликованные события в порядке записи и откладывает их на время lease.
+// Транзакции SQLite выполняются по очереди, поэтому одно событие не выбирается двумя ретрансляторами
+func (repo *SQLiteRepo) ClaimOutbox(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*entity.OutboxMessage, error) {
+	var messages []*entity.OutboxMessage
+	err := repo.inSystemTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx,
+			`SELECT id, tenant_id, payload, attempts FROM outbox
+             WHERE published_at IS NULL AND next_attempt_at <= :now
+             ORDER BY id
+             LIMIT :limit`,
+			sql.Named("now", sqliteTime(now)), sql.Named("limit", limit))
+		if err != nil {
+			return err
+		}
+
+		messages, err = collectSQLiteRows(rows, func(row sqliteRow) (*entity.OutboxMessage, error) {
+			var (
+				msg      entity.OutboxMessage
+				tenantID uuid.UUID
+				payload  []byte
+			)
+			err := row.Scan(&msg.Id, &tenantID, &payload, &msg.Attempts)
+			if err != nil {
+				return nil, err
+			}
+
+			err = json.Unmarshal(payload, &msg.Event)
+			if err != nil {
+				return nil, err
+			}
+			msg.Event.TenantId = tenantID
+
+			return &msg, nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, msg := range messages {
+			_, err = tx.ExecContext(ctx,
+				`UPDATE outbox SET next_attempt_at = :next_attempt_at WHERE id = :id`,
+				sql.Named("next_attempt_at", sqliteTime(now.Add(lease))), sql.Named("id", msg.Id))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// MarkOutboxPublished отмечает событие как опубликованное
+func (repo *SQLiteRepo) MarkOutboxPublished(ctx context.Context, id int64) error {
+	return repo.inSystemTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			`UPDATE outbox SET published_at = :now, last_error = NULL WHERE id = :id`,
+			sql.Named("now", sqliteTime(repo.now())), sql.Named("id", id))
+		return err
+	})
+}
+
+// MarkOutboxFailed сохраняет ошибку публикации и время следующей попытки
+func (repo *SQLiteRepo) MarkOutboxFailed(ctx context.Context, id int64, errMsg string, nextAttemptAt time.Time) error {
+	return repo.inSystemTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			`UPDATE outbox SET attempts = attempts + 1, last_error = :error, next_attempt_at = :next_attempt_at WHERE id = :id`,
+			sql.Named("error", errMsg), sql.Named("next_attempt_at", sqliteTime(nextAttemptAt)), sql.Named("id", id))
+		return err
+	})
+}
+
+// PurgeOutbox удаляет до limit событий, опубликованных раньше publishedBefore, и возвращает количество удаленных
+func (repo *SQLiteRepo) PurgeOutbox(ctx context.Context, publishedBefore time.Time, limit int) (int, error) {
+	var purged int
+	err := repo.inSystemTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			`DELETE FROM outbox
+             WHERE id IN (SELECT id FROM outbox
+                          WHERE published_at < :before
+                          ORDER BY published_at
+                          LIMIT :limit)`,
+			sql.Named("before", sqliteTime(publishedBefore)), sql.Named("limit", limit))
+		if err != nil {
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+		purged = int(affected)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
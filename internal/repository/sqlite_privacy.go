@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/google/uuid"
+)
+
+// sqliteEventUserCondition - условие на события outbox и доставки вебхуков о подписках пользователя :user
+const sqliteEventUserCondition = `json_extract(payload, '$.data.user_id') = :user`
+
+// ExportUserData возвращает все данные тенанта о пользователе: подписки, в том числе архивные, помесячные итоги,
+// события outbox и доставки вебхуков о его подписках и API-ключи, действующие от его имени
+func (repo *SQLiteRepo) ExportUserData(ctx context.Context, userID uuid.UUID) (*entity.UserData, error) {
+	data := &entity.UserData{UserId: userID}
+	err := repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		args := []any{sql.Named("user", userID.String()), sql.Named("tenant", tenantID)}
+
+		var err error
+		data.Subscriptions, err = querySQLiteUserSubscriptions(ctx, tx, "subscriptions", args)
+		if err != nil {
+			return err
+		}
+
+		data.ArchivedSubscriptions, err = querySQLiteUserSubscriptions(ctx, tx, "subscriptions_archive", args)
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.QueryContext(ctx,
+			`SELECT month, service_name, total, subscriptions FROM monthly_costs
+             WHERE user_id = :user AND `+sqliteTenantCondition+`
+             ORDER BY month, service_name`, args...)
+		if err != nil {
+			return err
+		}
+
+		data.MonthlyCosts, err = collectSQLiteRows(rows, func(row sqliteRow) (*entity.UserMonthlyCost, error) {
+			var c entity.UserMonthlyCost
+			err := row.Scan(&c.Month, &c.ServiceName, &c.Total, &c.Subscriptions)
+			c.Month = toDate(c.Month)
+			return &c, err
+		})
+		if err != nil {
+			return err
+		}
+
+		rows, err = tx.QueryContext(ctx,
+			`SELECT payload FROM outbox WHERE `+sqliteEventUserCondition+` AND `+sqliteTenantCondition+` ORDER BY id`, args...)
+		if err != nil {
+			return err
+		}
+
+		data.Events, err = collectSQLiteRows(rows, func(row sqliteRow) (*entity.Event, error) {
+			var payload []byte
+			err := row.Scan(&payload)
+			if err != nil {
+				return nil, err
+			}
+
+			var event entity.Event
+			err = json.Unmarshal(payload, &event)
+			event.TenantId = tenantID
+			return &event, err
+		})
+		if err != nil {
+			return err
+		}
+
+		rows, err = tx.QueryContext(ctx,
+			`SELECT `+deliveryColumns+`
+             FROM webhook_deliveries
+             WHERE `+sqliteEventUserCondition+` AND `+sqliteTenantCondition+`
+             ORDER BY id`, args...)
+		if err != nil {
+			return err
+		}
+
+		data.Deliveries, err = collectSQLiteRows(rows, func(row sqliteRow) (*entity.WebhookDelivery, error) {
+			var d entity.WebhookDelivery
+			err := row.Scan(sqliteDeliveryFields(&d)...)
+			return &d, err
+		})
+		if err != nil {
+			return err
+		}
+
+		rows, err = tx.QueryContext(ctx,
+			`SELECT `+apiKeyColumns+` FROM api_keys WHERE user_id = :user AND `+sqliteTenantCondition+` ORDER BY id`, args...)
+		if err != nil {
+			return err
+		}
+
+		data.APIKeys, err = collectSQLiteRows(rows, scanSQLiteAPIKey)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// EraseUserData удаляет подписки пользователя, в том числе архивные, его помесячные итоги и API-ключи,
+// а в событиях outbox и доставках вебхуков о его подписках заменяет id пользователя нулевым UUID и удаляет
+// причину отмены, как EraseUserData у PGRepo
+func (repo *SQLiteRepo) EraseUserData(ctx context.Context, userID uuid.UUID, receipt *entity.ErasureReceipt, sign func(*entity.ErasureReceipt) string) error {
+	return repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		args := []any{sql.Named("user", userID.String()), sql.Named("tenant", tenantID)}
+
+		steps := []struct {
+			count *int
+			query string
+		}{
+			{&receipt.Subscriptions, `DELETE FROM subscriptions WHERE user_id = :user AND ` + sqliteTenantCondition},
+			{&receipt.ArchivedSubscriptions, `DELETE FROM subscriptions_archive WHERE user_id = :user AND ` + sqliteTenantCondition},
+			{&receipt.MonthlyCosts, `DELETE FROM monthly_costs WHERE user_id = :user AND ` + sqliteTenantCondition},
+			{&receipt.APIKeys, `DELETE FROM api_keys WHERE user_id = :user AND ` + sqliteTenantCondition},
+			{&receipt.Events, `UPDATE outbox
+                 SET payload = json_remove(json_set(payload, '$.data.user_id', :anonymous), '$.data.cancellation_reason')
+                 WHERE ` + sqliteEventUserCondition + ` AND ` + sqliteTenantCondition},
+			{&receipt.Deliveries, `UPDATE webhook_deliveries
+                 SET payload = json_remove(json_set(payload, '$.data.user_id', :anonymous), '$.data.cancellation_reason')
+                 WHERE ` + sqliteEventUserCondition + ` AND ` + sqliteTenantCondition},
+		}
+		for _, step := range steps {
+			res, err := tx.ExecContext(ctx, step.query, append(args, sql.Named("anonymous", uuid.Nil.String()))...)
+			if err != nil {
+				return err
+			}
+
+			affected, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+			*step.count = int(affected)
+		}
+
+		if receipt.Subscriptions > 0 || receipt.ArchivedSubscriptions > 0 {
+			err := repo.bumpVersion(ctx, tx, tenantID)
+			if err != nil {
+				return err
+			}
+		}
+
+		receipt.TenantId = tenantID
+		receipt.Digest = sign(receipt)
+
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO erasure_receipts (`+erasureReceiptColumns+`)
+             VALUES (:id, :tenant, :subject_hash, :erased_at, :subscriptions, :archived_subscriptions, :monthly_costs,
+                     :api_keys, :events, :webhook_deliveries, :digest)`,
+			sql.Named("id", receipt.Id), sql.Named("tenant", tenantID), sql.Named("subject_hash", receipt.SubjectHash),
+			sql.Named("erased_at", sqliteTime(receipt.ErasedAt)), sql.Named("subscriptions", receipt.Subscriptions),
+			sql.Named("archived_subscriptions", receipt.ArchivedSubscriptions), sql.Named("monthly_costs", receipt.MonthlyCosts),
+			sql.Named("api_keys", receipt.APIKeys), sql.Named("events", receipt.Events),
+			sql.Named("webhook_deliveries", receipt.Deliveries), sql.Named("digest", receipt.Digest))
+		return err
+	})
+}
+
+// ReadErasureReceipt возвращает квитанцию об удалении данных по id
+func (repo *SQLiteRepo) ReadErasureReceipt(ctx context.Context, id uuid.UUID) (*entity.ErasureReceipt, error) {
+	var receipt entity.ErasureReceipt
+	err := repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		return tx.QueryRowContext(ctx,
+			`SELECT `+erasureReceiptColumns+` FROM erasure_receipts WHERE id = :id AND `+sqliteTenantCondition,
+			sql.Named("id", id), sql.Named("tenant", tenantID)).Scan(erasureReceiptFields(&receipt)...)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, myError.ErrErasureReceiptNotFound
+		}
+		return nil, err
+	}
+
+	return &receipt, nil
+}
+
+// querySQLiteUserSubscriptions возвращает подписки пользователя из таблицы подписок или их архива в порядке id
+func querySQLiteUserSubscriptions(ctx context.Context, tx *sql.Tx, table string, args []any) ([]*entity.Subscription, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT `+subscriptionColumns+` FROM `+table+` WHERE user_id = :user AND `+sqliteTenantCondition+` ORDER BY id`,
+		args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectSQLiteRows(rows, scanSQLiteSubscription)
+}
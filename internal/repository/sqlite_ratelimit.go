@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// TakeRateLimitToken пополняет корзину key со скоростью rate токенов в секунду (не больше burst) и берет из нее токен,
+// если он есть. Возвращает количество оставшихся токенов и признак того, что токен взят
+func (repo *SQLiteRepo) TakeRateLimitToken(ctx context.Context, key string, rate float64, burst int) (float64, bool, error) {
+	var (
+		tokens  float64
+		allowed bool
+	)
+
+	err := repo.inSystemTx(ctx, func(tx *sql.Tx) error {
+		now := repo.now()
+
+		var updatedAt time.Time
+		err := tx.QueryRowContext(ctx,
+			`SELECT tokens, updated_at FROM rate_limits WHERE key = :key`, sql.Named("key", key)).Scan(&tokens, &updatedAt)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			tokens = float64(burst)
+		case err != nil:
+			return err
+		default:
+			tokens = min(float64(burst), tokens+now.Sub(updatedAt).Seconds()*rate)
+		}
+
+		allowed = tokens >= 1
+		if allowed {
+			tokens--
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO rate_limits (key, tokens, allowed, updated_at) VALUES (:key, :tokens, :allowed, :now)
+             ON CONFLICT (key) DO UPDATE SET tokens = excluded.tokens, allowed = excluded.allowed, updated_at = excluded.updated_at`,
+			sql.Named("key", key), sql.Named("tokens", tokens), sql.Named("allowed", allowed), sql.Named("now", sqliteTime(now)))
+		return err
+	})
+	if err != nil {
+		return 0, false, err
+	}
+
+	return tokens, allowed, nil
+}
+
+// DeleteIdleRateLimits удаляет корзины, к которым не обращались с момента before
+func (repo *SQLiteRepo) DeleteIdleRateLimits(ctx context.Context, before time.Time) error {
+	_, err := repo.db.ExecContext(ctx,
+		`DELETE FROM rate_limits WHERE updated_at < :before`, sql.Named("before", sqliteTime(before)))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
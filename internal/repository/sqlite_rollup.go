@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/google/uuid"
+)
+
+// addMonthlyCosts добавляет подписку в итоги за месяцы с ее начала по дату окончания (sign = 1) или вычитает
+// ее из них (sign = -1) в транзакции ее изменения, как addMonthlyCosts у PGRepo
+func (repo *SQLiteRepo) addMonthlyCosts(ctx context.Context, tx *sql.Tx, tenantID uuid.UUID, s *entity.Subscription, sign int) error {
+	through, err := sqliteThroughMonth(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range subscriptionMonths(s, through) {
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO monthly_costs (tenant_id, month, user_id, service_name, total, subscriptions)
+             VALUES (:tenant, :month, :user_id, :service_name, :total, :subscriptions)
+             ON CONFLICT (tenant_id, month, user_id, service_name) DO UPDATE
+                 SET total = total + excluded.total, subscriptions = subscriptions + excluded.subscriptions`,
+			sql.Named("tenant", tenantID), sql.Named("month", sqliteDate(m)), sql.Named("user_id", s.UserId),
+			sql.Named("service_name", s.ServiceName), sql.Named("total", s.Price*sign), sql.Named("subscriptions", sign))
+		if err != nil {
+			return err
+		}
+	}
+
+	if sign > 0 {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`DELETE FROM monthly_costs
+         WHERE user_id = :user_id AND service_name = :service_name AND subscriptions = 0 AND `+sqliteTenantCondition,
+		sql.Named("user_id", s.UserId), sql.Named("service_name", s.ServiceName), sql.Named("tenant", tenantID))
+	return err
+}
+
+// replaceMonthlyCosts переносит подписку в итогах из состояния before в состояние after
+func (repo *SQLiteRepo) replaceMonthlyCosts(ctx context.Context, tx *sql.Tx, tenantID uuid.UUID, before, after *entity.Subscription) error {
+	err := repo.addMonthlyCosts(ctx, tx, tenantID, before, -1)
+	if err != nil {
+		return err
+	}
+
+	return repo.addMonthlyCosts(ctx, tx, tenantID, after, 1)
+}
+
+// monthlyCostsTotal возвращает стоимость подписок за месяц из итогов, как monthlyCostsTotal у PGRepo
+func (repo *SQLiteRepo) monthlyCostsTotal(ctx context.Context, tx *sql.Tx, tenantID uuid.UUID, month time.Time, filter *entity.CostFilter) (int, bool, error) {
+	through, err := sqliteThroughMonth(ctx, tx)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if month.After(through) {
+		return 0, false, nil
+	}
+
+	query, args, err := appendSQLiteCostFilter(
+		`SELECT COALESCE(SUM(total), 0) FROM monthly_costs WHERE month = :month AND `+sqliteTenantCondition,
+		[]any{sql.Named("month", sqliteDate(month)), sql.Named("tenant", tenantID)}, filter)
+	if err != nil {
+		return 0, false, err
+	}
+
+	var total int
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&total)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return total, true, nil
+}
+
+// RebuildMonthlyCosts заново рассчитывает помесячные итоги стоимости тенанта по его подпискам
+func (repo *SQLiteRepo) RebuildMonthlyCosts(ctx context.Context) (*entity.MonthlyCostsRebuild, error) {
+	var res entity.MonthlyCostsRebuild
+	err := repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		through, live, err := sqliteLiveMonthlyCosts(ctx, tx, tenantID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`DELETE FROM monthly_costs WHERE `+sqliteTenantCondition, sql.Named("tenant", tenantID))
+		if err != nil {
+			return err
+		}
+
+		for key, t := range live {
+			_, err = tx.ExecContext(ctx,
+				`INSERT INTO monthly_costs (tenant_id, month, user_id, service_name, total, subscriptions)
+                 VALUES (:tenant, :month, :user_id, :service_name, :total, :subscriptions)`,
+				sql.Named("tenant", tenantID), sql.Named("month", sqliteDate(key.month)), sql.Named("user_id", key.userID),
+				sql.Named("service_name", key.serviceName), sql.Named("total", t.total), sql.Named("subscriptions", t.subscriptions))
+			if err != nil {
+				return err
+			}
+		}
+
+		res.Rows = len(live)
+		res.ThroughMonth = through
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// CheckMonthlyCosts сверяет помесячные итоги стоимости тенанта с расчетом по его подпискам
+func (repo *SQLiteRepo) CheckMonthlyCosts(ctx context.Context) (*entity.MonthlyCostsCheck, error) {
+	var res entity.MonthlyCostsCheck
+	err := repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		through, live, err := sqliteLiveMonthlyCosts(ctx, tx, tenantID)
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.QueryContext(ctx,
+			`SELECT month, user_id, service_name, total, subscriptions FROM monthly_costs WHERE `+sqliteTenantCondition,
+			sql.Named("tenant", tenantID))
+		if err != nil {
+			return err
+		}
+
+		rollup := make(map[monthlyCostKey]*monthlyCostTotals)
+		_, err = collectSQLiteRows(rows, func(row sqliteRow) (struct{}, error) {
+			key := monthlyCostKey{tenantID: tenantID}
+			var t monthlyCostTotals
+			err := row.Scan(&key.month, &key.userID, &key.serviceName, &t.total, &t.subscriptions)
+			key.month = toDate(key.month)
+			rollup[key] = &t
+			return struct{}{}, err
+		})
+		if err != nil {
+			return err
+		}
+
+		res.Rows = len(rollup)
+		res.ThroughMonth = through
+		res.Mismatches = diffMonthlyCosts(rollup, live)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// AdvanceMonthlyCosts продлевает итоги всех тенантов по текущий месяц: подписки без даты окончания
+// добавляются в месяцы после through_month. Возвращает месяц, по который продлены итоги
+func (repo *SQLiteRepo) AdvanceMonthlyCosts(ctx context.Context) (time.Time, error) {
+	var through time.Time
+	err := repo.inSystemTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		through, err = sqliteThroughMonth(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		current := monthOf(repo.now())
+		if !current.After(through) {
+			return nil
+		}
+
+		rows, err := tx.QueryContext(ctx,
+			`SELECT tenant_id, `+subscriptionColumns+` FROM subscriptions WHERE end_date IS NULL`)
+		if err != nil {
+			return err
+		}
+
+		type tenantSubscription struct {
+			tenantID uuid.UUID
+			sub      *entity.Subscription
+		}
+
+		open, err := collectSQLiteRows(rows, func(row sqliteRow) (tenantSubscription, error) {
+			ts := tenantSubscription{sub: &entity.Subscription{}}
+			err := row.Scan(append([]any{&ts.tenantID}, sqliteSubscriptionFields(ts.sub)...)...)
+			return ts, err
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, ts := range open {
+			for _, m := range subscriptionMonths(ts.sub, current) {
+				if !m.After(through) {
+					continue
+				}
+
+				_, err = tx.ExecContext(ctx,
+					`INSERT INTO monthly_costs (tenant_id, month, user_id, service_name, total, subscriptions)
+                     VALUES (:tenant, :month, :user_id, :service_name, :total, 1)
+                     ON CONFLICT (tenant_id, month, user_id, service_name) DO UPDATE
+                         SET total = total + excluded.total, subscriptions = subscriptions + excluded.subscriptions`,
+					sql.Named("tenant", ts.tenantID), sql.Named("month", sqliteDate(m)), sql.Named("user_id", ts.sub.UserId),
+					sql.Named("service_name", ts.sub.ServiceName), sql.Named("total", ts.sub.Price))
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		_, err = tx.ExecContext(ctx,
+			`UPDATE monthly_costs_state SET through_month = :month`, sql.Named("month", sqliteDate(current)))
+		if err != nil {
+			return err
+		}
+
+		through = current
+
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return through, nil
+}
+
+// sqliteThroughMonth возвращает месяц, по который в итогах учтены подписки без даты окончания
+func sqliteThroughMonth(ctx context.Context, tx *sql.Tx) (time.Time, error) {
+	var through time.Time
+	err := tx.QueryRowContext(ctx, `SELECT through_month FROM monthly_costs_state`).Scan(&through)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return toDate(through), nil
+}
+
+// sqliteLiveMonthlyCosts рассчитывает помесячные итоги стоимости тенанта по его подпискам и возвращает их
+// вместе с месяцем, по который в них учтены подписки без даты окончания
+func sqliteLiveMonthlyCosts(ctx context.Context, tx *sql.Tx, tenantID uuid.UUID) (time.Time, map[monthlyCostKey]*monthlyCostTotals, error) {
+	through, err := sqliteThroughMonth(ctx, tx)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT `+subscriptionColumns+` FROM subscription_history WHERE `+sqliteTenantCondition, sql.Named("tenant", tenantID))
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+
+	subs, err := collectSQLiteRows(rows, scanSQLiteSubscription)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+
+	live := make(map[monthlyCostKey]*monthlyCostTotals)
+	for _, s := range subs {
+		addMonthlyCostTotals(live, tenantID, s, through, 1)
+	}
+
+	return through, live, nil
+}
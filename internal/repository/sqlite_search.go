@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/google/uuid"
+)
+
+// sqliteSearchCondition - условие поиска по названию сервиса, как в SearchSubscriptions PGRepo:
+// сходство по триграммам с :q или совпадение начала названия с шаблоном :prefix
+const sqliteSearchCondition = `(similar(lower(service_name), :q) OR lower(trim(service_name)) LIKE :prefix ESCAPE '\')
+           AND (:user_id IS NULL OR user_id = :user_id)`
+
+// SearchSubscriptions ищет подписки по названию сервиса без учета регистра: подходят названия, начинающиеся с запроса,
+// и названия, похожие на запрос по триграммам. Сначала идут совпадения по началу названия, затем - по убыванию сходства.
+// Если userID не nil, ищутся только подписки этого пользователя
+func (repo *SQLiteRepo) SearchSubscriptions(ctx context.Context, query string, userID *uuid.UUID, limit int) ([]*entity.SubscriptionMatch, error) {
+	q := strings.ToLower(strings.TrimSpace(query))
+
+	var matches []*entity.SubscriptionMatch
+	err := repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		rows, err := tx.QueryContext(ctx,
+			`SELECT `+subscriptionColumns+`, similarity(lower(service_name), :q) AS score
+         FROM subscriptions
+         WHERE `+sqliteTenantCondition+`
+           AND `+sqliteSearchCondition+`
+         ORDER BY lower(trim(service_name)) LIKE :prefix ESCAPE '\' DESC, score DESC, id
+         LIMIT :limit`,
+			sqliteSearchArgs(tenantID, q, userID, limit)...)
+		if err != nil {
+			return err
+		}
+
+		matches, err = collectSQLiteRows(rows, func(row sqliteRow) (*entity.SubscriptionMatch, error) {
+			var (
+				s     entity.Subscription
+				score float64
+			)
+			err := row.Scan(append(sqliteSubscriptionFields(&s), &score)...)
+			return &entity.SubscriptionMatch{Subscription: &s, Score: score}, err
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+// SuggestServiceNames возвращает варианты автодополнения названия сервиса. Названия, отличающиеся только регистром
+// и пробелами по краям, объединяются, в ответ попадает самое частое написание.
+// Если userID не nil, учитываются только подписки этого пользователя
+func (repo *SQLiteRepo) SuggestServiceNames(ctx context.Context, query string, userID *uuid.UUID, limit int) ([]*entity.ServiceSuggestion, error) {
+	q := strings.ToLower(strings.TrimSpace(query))
+
+	suggestions := []*entity.ServiceSuggestion{}
+	err := repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		// в SQLite нет агрегата mode(): самое частое написание группы, а из одинаково частых - первое
+		// по порядку сортировки, выбирается нумерацией написаний
+		rows, err := tx.QueryContext(ctx,
+			`WITH matched AS (
+             SELECT lower(trim(service_name)) AS name_key,
+                    trim(service_name) AS spelling,
+                    similarity(lower(service_name), :q) AS score,
+                    lower(trim(service_name)) LIKE :prefix ESCAPE '\' AS prefix
+             FROM subscriptions
+             WHERE `+sqliteTenantCondition+`
+               AND `+sqliteSearchCondition+`
+         ),
+         spellings AS (
+             SELECT name_key, spelling,
+                    row_number() OVER (PARTITION BY name_key ORDER BY count(*) DESC, spelling) AS spelling_rank
+             FROM matched
+             GROUP BY name_key, spelling
+         )
+         SELECT s.spelling AS name, count(*) AS subscriptions, max(m.score) AS score
+         FROM matched m
+         JOIN spellings s ON s.name_key = m.name_key AND s.spelling_rank = 1
+         GROUP BY m.name_key, s.spelling
+         ORDER BY max(m.prefix) DESC, score DESC, subscriptions DESC, name
+         LIMIT :limit`,
+			sqliteSearchArgs(tenantID, q, userID, limit)...)
+		if err != nil {
+			return err
+		}
+
+		found, err := collectSQLiteRows(rows, func(row sqliteRow) (*entity.ServiceSuggestion, error) {
+			var s entity.ServiceSuggestion
+			err := row.Scan(&s.ServiceName, &s.Subscriptions, &s.Score)
+			return &s, err
+		})
+		if err != nil {
+			return err
+		}
+
+		suggestions = append(suggestions, found...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return suggestions, nil
+}
+
+// sqliteServiceNameCondition возвращает условие фильтрации по названию сервиса из параметра :service_name,
+// как serviceNameCondition
+func sqliteServiceNameCondition(mode entity.MatchMode) string {
+	switch mode {
+	case entity.MatchIgnore:
+		return `lower(trim(service_name)) = lower(trim(:service_name))`
+	case entity.MatchFuzzy:
+		return `similar(lower(service_name), lower(trim(:service_name)))`
+	default:
+		return `service_name = :service_name`
+	}
+}
+
+// sqliteSearchArgs возвращает параметры запросов поиска по названию сервиса
+func sqliteSearchArgs(tenantID uuid.UUID, q string, userID *uuid.UUID, limit int) []any {
+	return []any{
+		sql.Named("tenant", tenantID),
+		sql.Named("q", q),
+		sql.Named("prefix", prefixPattern(q)),
+		sql.Named("user_id", userID),
+		sql.Named("limit", limit),
+	}
+}
@@ -0,0 +1,460 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/google/uuid"
+)
+
+// sqliteTenantCondition - условие принадлежности строки тенанту из параметра :tenant
+const sqliteTenantCondition = `tenant_id = :tenant`
+
+// sqliteCostPeriodCondition - условие пересечения подписки с периодом [:from, :to], как costPeriodCondition.
+// Текущая дата передается параметром :today
+const sqliteCostPeriodCondition = `((:from BETWEEN start_date AND COALESCE(end_date, :today))
+		OR (:to BETWEEN start_date AND COALESCE(end_date, :today))
+		OR (:from = start_date OR :to = start_date))`
+
+// CreateSubscription добавляет подписку в бд вместе с событием subscription.created и возвращает id
+func (repo *SQLiteRepo) CreateSubscription(ctx context.Context, s *entity.Subscription) (int64, error) {
+	if s == nil {
+		return 0, fmt.Errorf("invalid argument error")
+	}
+
+	tags, err := sqliteJSON(cloneStrings(s.Tags))
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+	err = repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO subscriptions (tenant_id, service_name, price, user_id, start_date, end_date, service_id, category, tags)
+             VALUES (:tenant, :service_name, :price, :user_id, :start_date, :end_date, :service_id, :category, :tags)
+             RETURNING id`,
+			sql.Named("tenant", tenantID), sql.Named("service_name", s.ServiceName), sql.Named("price", s.Price),
+			sql.Named("user_id", s.UserId), sql.Named("start_date", sqliteDate(s.StartDate)),
+			sql.Named("end_date", sqliteDatePtr(s.EndDate)), sql.Named("service_id", s.ServiceId),
+			sql.Named("category", s.Category), sql.Named("tags", tags)).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		created := *s
+		created.Id = int(id)
+
+		err = repo.addMonthlyCosts(ctx, tx, tenantID, &created, 1)
+		if err != nil {
+			return err
+		}
+
+		err = repo.insertOutbox(ctx, tx, tenantID, entity.EventSubscriptionCreated, &created)
+		if err != nil {
+			return err
+		}
+
+		return repo.bumpVersion(ctx, tx, tenantID)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// ReadSubscription возвращает подписку по id
+func (repo *SQLiteRepo) ReadSubscription(ctx context.Context, id int64) (*entity.Subscription, error) {
+	var s *entity.Subscription
+	err := repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		var err error
+		s, err = readSQLiteSubscription(ctx, tx, tenantID, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// UpdateSubscription обновляет данные подписки и записывает событие subscription.updated,
+// а если у подписки появилась дата окончания - еще и subscription.ended
+func (repo *SQLiteRepo) UpdateSubscription(ctx context.Context, s *entity.Subscription) error {
+	if s == nil {
+		return fmt.Errorf("invalid argument error: subscription is nil")
+	}
+
+	if s.Id == 0 {
+		return fmt.Errorf("invalid argument error: missing subscription ID")
+	}
+
+	tags, err := sqliteJSON(cloneStrings(s.Tags))
+	if err != nil {
+		return err
+	}
+
+	return repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		before, err := readSQLiteSubscription(ctx, tx, tenantID, int64(s.Id))
+		if err != nil {
+			return err
+		}
+
+		// при снятии даты окончания подписка снова активна, и причина отмены больше не актуальна
+		after, err := scanSQLiteSubscription(tx.QueryRowContext(ctx,
+			`UPDATE subscriptions
+             SET service_name = :service_name, price = :price, user_id = :user_id, start_date = :start_date,
+                 end_date = :end_date, service_id = :service_id, category = :category, tags = :tags,
+                 cancellation_reason = CASE WHEN :end_date IS NULL THEN NULL ELSE cancellation_reason END,
+                 cancelled_at = CASE WHEN :end_date IS NULL THEN NULL ELSE cancelled_at END
+             WHERE id = :id AND `+sqliteTenantCondition+`
+             RETURNING `+subscriptionColumns,
+			sql.Named("service_name", s.ServiceName), sql.Named("price", s.Price), sql.Named("user_id", s.UserId),
+			sql.Named("start_date", sqliteDate(s.StartDate)), sql.Named("end_date", sqliteDatePtr(s.EndDate)),
+			sql.Named("service_id", s.ServiceId), sql.Named("category", s.Category), sql.Named("tags", tags),
+			sql.Named("id", s.Id), sql.Named("tenant", tenantID)))
+		if err != nil {
+			return err
+		}
+
+		err = repo.replaceMonthlyCosts(ctx, tx, tenantID, before, after)
+		if err != nil {
+			return err
+		}
+
+		err = repo.insertChangeEvents(ctx, tx, tenantID, before, after)
+		if err != nil {
+			return err
+		}
+
+		return repo.bumpVersion(ctx, tx, tenantID)
+	})
+}
+
+// DeleteSubscription удаляет подписку и записывает событие subscription.deleted
+func (repo *SQLiteRepo) DeleteSubscription(ctx context.Context, id int64) error {
+	return repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		s, err := scanSQLiteSubscription(tx.QueryRowContext(ctx,
+			`DELETE FROM subscriptions WHERE id = :id AND `+sqliteTenantCondition+` RETURNING `+subscriptionColumns,
+			sql.Named("id", id), sql.Named("tenant", tenantID)))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return myError.ErrSubscriptionNotFound
+			}
+			return err
+		}
+
+		err = repo.addMonthlyCosts(ctx, tx, tenantID, s, -1)
+		if err != nil {
+			return err
+		}
+
+		err = repo.insertOutbox(ctx, tx, tenantID, entity.EventSubscriptionDeleted, s)
+		if err != nil {
+			return err
+		}
+
+		return repo.bumpVersion(ctx, tx, tenantID)
+	})
+}
+
+// CancelSubscription устанавливает дату окончания подписки и сохраняет причину отмены
+func (repo *SQLiteRepo) CancelSubscription(ctx context.Context, id int64, endDate time.Time, reason *string) (*entity.Subscription, error) {
+	var after *entity.Subscription
+	err := repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		before, err := readSQLiteSubscription(ctx, tx, tenantID, id)
+		if err != nil {
+			return err
+		}
+
+		after, err = scanSQLiteSubscription(tx.QueryRowContext(ctx,
+			`UPDATE subscriptions SET end_date = :end_date, cancellation_reason = :reason, cancelled_at = :now
+             WHERE id = :id AND `+sqliteTenantCondition+`
+             RETURNING `+subscriptionColumns,
+			sql.Named("end_date", sqliteDate(endDate)), sql.Named("reason", reason), sql.Named("now", sqliteTime(repo.now())),
+			sql.Named("id", id), sql.Named("tenant", tenantID)))
+		if err != nil {
+			return err
+		}
+
+		err = repo.replaceMonthlyCosts(ctx, tx, tenantID, before, after)
+		if err != nil {
+			return err
+		}
+
+		err = repo.insertChangeEvents(ctx, tx, tenantID, before, after)
+		if err != nil {
+			return err
+		}
+
+		return repo.bumpVersion(ctx, tx, tenantID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// ListSubscriptions возвращает список подписок с фильтрацией по пользователю, категории и тегам
+func (repo *SQLiteRepo) ListSubscriptions(ctx context.Context, filter *entity.SubscriptionFilter) ([]*entity.Subscription, error) {
+	var subs []*entity.Subscription
+	err := repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		query, args, err := appendSQLiteSubscriptionFilter(`SELECT `+subscriptionColumns+` FROM subscriptions WHERE `+sqliteTenantCondition,
+			[]any{sql.Named("tenant", tenantID)}, filter)
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.QueryContext(ctx, query+` ORDER BY id`, args...)
+		if err != nil {
+			return err
+		}
+
+		subs, err = collectSQLiteRows(rows, scanSQLiteSubscription)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+// TotalCost возвращает суммарную стоимость подписок за определенный период с фильтрацией.
+// Стоимость за один месяц по пользователю и точному названию сервиса берется из помесячных итогов
+func (repo *SQLiteRepo) TotalCost(ctx context.Context, from, to time.Time, filter *entity.CostFilter) (int, error) {
+	month, fromRollup := rollupCostMonth(from, to, filter)
+
+	var total int
+	err := repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		if fromRollup {
+			var (
+				ok  bool
+				err error
+			)
+			total, ok, err = repo.monthlyCostsTotal(ctx, tx, tenantID, month, filter)
+			if err != nil || ok {
+				return err
+			}
+		}
+
+		query, args, err := appendSQLiteCostFilter(
+			`SELECT COALESCE(SUM(price), 0) FROM `+sqliteSubscriptionHistory+` WHERE `+sqliteTenantCondition+` AND `+sqliteCostPeriodCondition,
+			append(repo.costPeriodArgs(from, to, filter), sql.Named("tenant", tenantID)), filter)
+		if err != nil {
+			return err
+		}
+
+		return tx.QueryRowContext(ctx, query, args...).Scan(&total)
+	})
+	return total, err
+}
+
+// CostBreakdown возвращает суммарную стоимость подписок за определенный период в разрезе категорий или тегов.
+// Подписка с несколькими тегами учитывается в группе каждого тега
+func (repo *SQLiteRepo) CostBreakdown(ctx context.Context, from, to time.Time, filter *entity.CostFilter, groupBy entity.GroupBy) ([]*entity.CostGroup, error) {
+	// колонка группы не называется key, как в PGRepo: так называется колонка json_each
+	var query string
+	switch groupBy {
+	case entity.GroupByCategory:
+		query = `SELECT category AS group_key, SUM(price) AS total FROM ` + sqliteSubscriptionHistory + `
+                 WHERE ` + sqliteTenantCondition + ` AND ` + sqliteCostPeriodCondition
+	case entity.GroupByTag:
+		query = `SELECT tag.value AS group_key, SUM(price) AS total
+                 FROM ` + sqliteSubscriptionHistory + ` LEFT JOIN json_each(subscriptions.tags) AS tag
+                 WHERE ` + sqliteTenantCondition + ` AND ` + sqliteCostPeriodCondition
+	default:
+		return nil, fmt.Errorf("invalid argument error: unknown group by %q", groupBy)
+	}
+
+	var groups []*entity.CostGroup
+	err := repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		query, args, err := appendSQLiteCostFilter(query, append(repo.costPeriodArgs(from, to, filter), sql.Named("tenant", tenantID)), filter)
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.QueryContext(ctx, query+` GROUP BY group_key ORDER BY total DESC, group_key NULLS LAST`, args...)
+		if err != nil {
+			return err
+		}
+
+		groups, err = collectSQLiteRows(rows, func(row sqliteRow) (*entity.CostGroup, error) {
+			var g entity.CostGroup
+			err := row.Scan(&g.Key, &g.TotalCost)
+			return &g, err
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+// SubscriptionsVersion возвращает счетчик изменений подписок тенанта
+func (repo *SQLiteRepo) SubscriptionsVersion(ctx context.Context) (*entity.TableVersion, error) {
+	var v entity.TableVersion
+	err := repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		return tx.QueryRowContext(ctx,
+			`SELECT version, updated_at FROM table_versions WHERE table_name = 'subscriptions' AND `+sqliteTenantCondition,
+			sql.Named("tenant", tenantID)).Scan(&v.Version, &v.UpdatedAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+// costPeriodArgs возвращает параметры условия sqliteCostPeriodCondition. Текущая дата берется из фильтра,
+// а если она в нем не указана - из часов хранилища
+func (repo *SQLiteRepo) costPeriodArgs(from, to time.Time, filter *entity.CostFilter) []any {
+	return []any{
+		sql.Named("from", sqliteDate(from)),
+		sql.Named("to", sqliteDate(to)),
+		sql.Named("today", sqliteDate(costToday(filter, repo.now()))),
+	}
+}
+
+// insertOutbox записывает событие о подписке в outbox в рамках транзакции изменения
+func (repo *SQLiteRepo) insertOutbox(ctx context.Context, tx *sql.Tx, tenantID uuid.UUID, eventType entity.EventType, sub *entity.Subscription) error {
+	event := entity.NewEvent(eventType, sub)
+	event.TenantId = tenantID
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	now := sqliteTime(repo.now())
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO outbox (tenant_id, event_id, event_type, payload, next_attempt_at, created_at)
+         VALUES (:tenant, :event_id, :event_type, :payload, :now, :now)`,
+		sql.Named("tenant", tenantID), sql.Named("event_id", event.Id), sql.Named("event_type", string(event.Type)),
+		sql.Named("payload", string(payload)), sql.Named("now", now))
+	return err
+}
+
+// insertChangeEvents записывает событие subscription.updated, а если у подписки появилась дата окончания -
+// еще и subscription.ended
+func (repo *SQLiteRepo) insertChangeEvents(ctx context.Context, tx *sql.Tx, tenantID uuid.UUID, before, after *entity.Subscription) error {
+	err := repo.insertOutbox(ctx, tx, tenantID, entity.EventSubscriptionUpdated, after)
+	if err != nil {
+		return err
+	}
+
+	if isEnded(before, after) {
+		return repo.insertOutbox(ctx, tx, tenantID, entity.EventSubscriptionEnded, after)
+	}
+
+	return nil
+}
+
+// readSQLiteSubscription возвращает подписку тенанта по id или myError.ErrSubscriptionNotFound
+func readSQLiteSubscription(ctx context.Context, tx *sql.Tx, tenantID uuid.UUID, id int64) (*entity.Subscription, error) {
+	s, err := scanSQLiteSubscription(tx.QueryRowContext(ctx,
+		`SELECT `+subscriptionColumns+` FROM subscriptions WHERE id = :id AND `+sqliteTenantCondition,
+		sql.Named("id", id), sql.Named("tenant", tenantID)))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, myError.ErrSubscriptionNotFound
+		}
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// appendSQLiteCostFilter дописывает в запрос условия фильтра стоимости, как appendCostFilter
+func appendSQLiteCostFilter(query string, args []any, filter *entity.CostFilter) (string, []any, error) {
+	if filter == nil {
+		return query, args, nil
+	}
+
+	if filter.ServiceName != nil {
+		args = append(args, sql.Named("service_name", filter.ServiceName.Name))
+		query += ` AND ` + sqliteServiceNameCondition(filter.ServiceName.Mode)
+	}
+
+	return appendSQLiteSubscriptionFilter(query, args, &filter.SubscriptionFilter)
+}
+
+// appendSQLiteSubscriptionFilter дописывает в запрос условия фильтра по пользователю, категории и тегам,
+// как appendSubscriptionFilter
+func appendSQLiteSubscriptionFilter(query string, args []any, filter *entity.SubscriptionFilter) (string, []any, error) {
+	if filter == nil {
+		return query, args, nil
+	}
+
+	if filter.UserId != nil {
+		args = append(args, sql.Named("user_id", *filter.UserId))
+		query += ` AND user_id = :user_id`
+	}
+
+	if filter.Category != nil {
+		args = append(args, sql.Named("category", *filter.Category))
+		query += ` AND category = :category`
+	}
+
+	if len(filter.Tags) > 0 {
+		tags, err := sqliteJSON(filter.Tags)
+		if err != nil {
+			return "", nil, err
+		}
+
+		// подписка содержит все теги фильтра, как tags @> фильтр в Postgres
+		args = append(args, sql.Named("tags", tags))
+		query += ` AND NOT EXISTS (SELECT 1 FROM json_each(:tags) AS wanted
+                                   WHERE wanted.value NOT IN (SELECT has.value FROM json_each(subscriptions.tags) AS has))`
+	}
+
+	return query, args, nil
+}
+
+// sqliteRow - строка результата запроса, *sql.Row или *sql.Rows
+type sqliteRow interface {
+	Scan(dest ...any) error
+}
+
+// collectSQLiteRows читает все строки результата функцией scan и закрывает результат
+func collectSQLiteRows[T any](rows *sql.Rows, scan func(row sqliteRow) (T, error)) ([]T, error) {
+	defer rows.Close()
+
+	var res []T
+	for rows.Next() {
+		v, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, v)
+	}
+
+	return res, rows.Err()
+}
+
+// scanSQLiteSubscription читает подписку из строки результата с колонками subscriptionColumns
+func scanSQLiteSubscription(row sqliteRow) (*entity.Subscription, error) {
+	var s entity.Subscription
+	err := row.Scan(sqliteSubscriptionFields(&s)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// sqliteSubscriptionFields возвращает указатели на поля подписки в порядке колонок subscriptionColumns.
+// Теги хранятся в колонке в формате JSON
+func sqliteSubscriptionFields(s *entity.Subscription) []any {
+	return []any{&s.Id, &s.ServiceName, &s.Price, &s.UserId, &s.StartDate, &s.EndDate,
+		&s.CancellationReason, &s.CancelledAt, &s.ServiceId, &s.Category, jsonColumn{dst: &s.Tags}}
+}
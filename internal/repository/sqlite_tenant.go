@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/google/uuid"
+)
+
+// CreateTenant добавляет тенанта в бд и заполняет его id и время создания
+func (repo *SQLiteRepo) CreateTenant(ctx context.Context, t *entity.Tenant) error {
+	if t == nil {
+		return fmt.Errorf("invalid argument error")
+	}
+
+	id, createdAt := uuid.New(), repo.now()
+	err := repo.inSystemTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO tenants (id, name, created_at) VALUES (:id, :name, :now)`,
+			sql.Named("id", id), sql.Named("name", t.Name), sql.Named("now", sqliteTime(createdAt)))
+		if err != nil {
+			if isSQLiteUniqueViolation(err) {
+				return myError.ErrTenantNameTaken
+			}
+			return err
+		}
+
+		// счетчик изменений нужен кэшу ответов до первого изменения подписок тенанта
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO table_versions (tenant_id, table_name, updated_at) VALUES (:id, 'subscriptions', :now)`,
+			sql.Named("id", id), sql.Named("now", sqliteTime(createdAt)))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	t.Id = id
+	t.CreatedAt = createdAt
+
+	return nil
+}
+
+// ListTenants возвращает список тенантов в порядке создания
+func (repo *SQLiteRepo) ListTenants(ctx context.Context) ([]*entity.Tenant, error) {
+	tenants := []*entity.Tenant{}
+	err := repo.inSystemTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `SELECT id, name, created_at FROM tenants ORDER BY created_at, name`)
+		if err != nil {
+			return err
+		}
+
+		found, err := collectSQLiteRows(rows, func(row sqliteRow) (*entity.Tenant, error) {
+			var t entity.Tenant
+			err := row.Scan(&t.Id, &t.Name, &t.CreatedAt)
+			return &t, err
+		})
+		if err != nil {
+			return err
+		}
+
+		tenants = append(tenants, found...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tenants, nil
+}
+
+// TenantCosts возвращает суммарную стоимость подписок каждого тенанта за период, включая тенантов без подписок
+func (repo *SQLiteRepo) TenantCosts(ctx context.Context, from, to time.Time) ([]*entity.TenantCost, error) {
+	costs := []*entity.TenantCost{}
+	err := repo.inSystemTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx,
+			`SELECT t.id, t.name, COALESCE(SUM(s.price), 0) AS total
+             FROM tenants t
+             LEFT JOIN subscription_history s ON s.tenant_id = t.id AND `+sqliteCostPeriodCondition+`
+             GROUP BY t.id, t.name
+             ORDER BY total DESC, t.name`,
+			repo.costPeriodArgs(from, to, nil)...)
+		if err != nil {
+			return err
+		}
+
+		found, err := collectSQLiteRows(rows, func(row sqliteRow) (*entity.TenantCost, error) {
+			var c entity.TenantCost
+			err := row.Scan(&c.TenantId, &c.Name, &c.TotalCost)
+			return &c, err
+		})
+		if err != nil {
+			return err
+		}
+
+		costs = append(costs, found...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return costs, nil
+}
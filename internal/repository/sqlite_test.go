@@ -0,0 +1,33 @@
+//go:build cgo
+
+package repository_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/config"
+	"github.com/Ararat25/subscription-aggregation-service/internal/repository"
+	"github.com/Ararat25/subscription-aggregation-service/internal/repository/repotest"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSQLiteRepo проверяет хранилище SQLite общим набором тестов поведения хранилища. Бд создается во временном
+// каталоге, повторное подключение к ней не применяет миграции заново. Драйвер SQLite требует cgo
+func TestSQLiteRepo(t *testing.T) {
+	ctx := context.Background()
+	conf := config.DatabaseConfig{SQLitePath: filepath.Join(t.TempDir(), "subscriptions.db")}
+
+	db := repository.NewSQLiteRepo()
+	require.NoError(t, db.ConnectDB(ctx, conf))
+	require.NoError(t, db.Close(ctx))
+
+	db = repository.NewSQLiteRepo()
+	require.NoError(t, db.ConnectDB(ctx, conf))
+	t.Cleanup(func() {
+		_ = db.Close(ctx)
+	})
+
+	repotest.Run(t, db)
+}
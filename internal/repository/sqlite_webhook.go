@@ -0,0 +1,305 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/google/uuid"
+)
+
+// deliveryColumns - колонки доставки в порядке, ожидаемом sqliteDeliveryFields
+const deliveryColumns = `id, webhook_id, event_id, event_type, payload, status, attempts,
+                last_status_code, last_error, next_attempt_at, delivered_at, created_at`
+
+// CreateWebhook добавляет вебхук в бд и возвращает id
+func (repo *SQLiteRepo) CreateWebhook(ctx context.Context, w *entity.Webhook) (int64, error) {
+	if w == nil {
+		return 0, fmt.Errorf("invalid argument error")
+	}
+
+	events, err := sqliteJSON(eventTypesToStrings(w.Events))
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+	err = repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		createdAt := repo.now()
+		err := tx.QueryRowContext(ctx,
+			`INSERT INTO webhooks (tenant_id, url, secret, events, active, created_at)
+             VALUES (:tenant, :url, :secret, :events, :active, :now)
+             RETURNING id`,
+			sql.Named("tenant", tenantID), sql.Named("url", w.URL), sql.Named("secret", w.Secret),
+			sql.Named("events", events), sql.Named("active", w.Active), sql.Named("now", sqliteTime(createdAt))).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		w.CreatedAt = createdAt
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// ListWebhooks возвращает список всех вебхуков тенанта
+func (repo *SQLiteRepo) ListWebhooks(ctx context.Context) ([]*entity.Webhook, error) {
+	webhooks := []*entity.Webhook{}
+	err := repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		rows, err := tx.QueryContext(ctx,
+			`SELECT id, url, secret, events, active, created_at FROM webhooks WHERE `+sqliteTenantCondition+` ORDER BY id`,
+			sql.Named("tenant", tenantID))
+		if err != nil {
+			return err
+		}
+
+		found, err := collectSQLiteRows(rows, func(row sqliteRow) (*entity.Webhook, error) {
+			var w entity.Webhook
+			err := row.Scan(&w.Id, &w.URL, &w.Secret, jsonColumn{dst: &w.Events}, &w.Active, &w.CreatedAt)
+			return &w, err
+		})
+		if err != nil {
+			return err
+		}
+
+		webhooks = append(webhooks, found...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// DeleteWebhook удаляет вебхук вместе с журналом его доставок
+func (repo *SQLiteRepo) DeleteWebhook(ctx context.Context, id int64) error {
+	return repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		res, err := tx.ExecContext(ctx,
+			`DELETE FROM webhooks WHERE id = :id AND `+sqliteTenantCondition,
+			sql.Named("id", id), sql.Named("tenant", tenantID))
+		if err != nil {
+			return err
+		}
+
+		return sqliteAffected(res, myError.ErrWebhookNotFound)
+	})
+}
+
+// CreateDeliveries создает записи о доставке события для всех активных вебхуков тенанта события,
+// подписанных на его тип, и возвращает количество созданных записей
+func (repo *SQLiteRepo) CreateDeliveries(ctx context.Context, event *entity.Event, payload []byte) (int, error) {
+	if event == nil {
+		return 0, fmt.Errorf("invalid argument error")
+	}
+
+	var created int
+	err := repo.inSystemTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO webhook_deliveries (tenant_id, webhook_id, event_id, event_type, payload, next_attempt_at, created_at)
+             SELECT tenant_id, id, :event_id, :event_type, :payload, :now, :now FROM webhooks
+             WHERE tenant_id = :tenant AND active AND EXISTS (SELECT 1 FROM json_each(events) WHERE value = :event_type)
+             ON CONFLICT (webhook_id, event_id) DO NOTHING`,
+			sql.Named("event_id", event.Id), sql.Named("event_type", string(event.Type)), sql.Named("payload", string(payload)),
+			sql.Named("now", sqliteTime(repo.now())), sql.Named("tenant", event.TenantId))
+		if err != nil {
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+		created = int(affected)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return created, nil
+}
+
+// ClaimDueDeliveries выбирает доставки, время отправки которых наступило, и откладывает их на время lease.
+// Доставки выключенных вебхуков не выбираются. Транзакции SQLite выполняются по очереди, поэтому выборка и перенос попытки не пересекаются с другими обработчиками
+func (repo *SQLiteRepo) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*entity.WebhookDelivery, error) {
+	var deliveries []*entity.WebhookDelivery
+	err := repo.inSystemTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx,
+			`SELECT d.id, d.webhook_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+                d.last_status_code, d.last_error, d.next_attempt_at, d.delivered_at, d.created_at,
+                w.url, w.secret
+             FROM webhook_deliveries d
+             JOIN webhooks w ON w.id = d.webhook_id
+             WHERE d.status = 'pending' AND d.next_attempt_at <= :now AND w.active
+             ORDER BY d.next_attempt_at, d.id
+             LIMIT :limit`,
+			sql.Named("now", sqliteTime(now)), sql.Named("limit", limit))
+		if err != nil {
+			return err
+		}
+
+		deliveries, err = collectSQLiteRows(rows, func(row sqliteRow) (*entity.WebhookDelivery, error) {
+			var d entity.WebhookDelivery
+			err := row.Scan(append(sqliteDeliveryFields(&d), &d.URL, &d.Secret)...)
+			return &d, err
+		})
+		if err != nil {
+			return err
+		}
+
+		nextAttemptAt := now.Add(lease)
+		for _, d := range deliveries {
+			_, err = tx.ExecContext(ctx,
+				`UPDATE webhook_deliveries SET next_attempt_at = :next_attempt_at WHERE id = :id`,
+				sql.Named("next_attempt_at", sqliteTime(nextAttemptAt)), sql.Named("id", d.Id))
+			if err != nil {
+				return err
+			}
+			d.NextAttemptAt = nextAttemptAt
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// UpdateDelivery сохраняет результат попытки доставки
+func (repo *SQLiteRepo) UpdateDelivery(ctx context.Context, d *entity.WebhookDelivery) error {
+	if d == nil {
+		return fmt.Errorf("invalid argument error")
+	}
+
+	return repo.inSystemTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			`UPDATE webhook_deliveries
+             SET status = :status, attempts = :attempts, last_status_code = :last_status_code, last_error = :last_error,
+                 next_attempt_at = :next_attempt_at, delivered_at = :delivered_at
+             WHERE id = :id`,
+			sql.Named("status", string(d.Status)), sql.Named("attempts", d.Attempts),
+			sql.Named("last_status_code", d.LastStatusCode), sql.Named("last_error", d.LastError),
+			sql.Named("next_attempt_at", sqliteTime(d.NextAttemptAt)), sql.Named("delivered_at", sqliteTimePtr(d.DeliveredAt)),
+			sql.Named("id", d.Id))
+		if err != nil {
+			return err
+		}
+
+		return sqliteAffected(res, myError.ErrDeliveryNotFound)
+	})
+}
+
+// ListDeliveries возвращает журнал доставок вебхука, начиная с последних
+func (repo *SQLiteRepo) ListDeliveries(ctx context.Context, webhookID int64) ([]*entity.WebhookDelivery, error) {
+	deliveries := []*entity.WebhookDelivery{}
+	err := repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		var exists bool
+		err := tx.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM webhooks WHERE id = :id AND `+sqliteTenantCondition+`)`,
+			sql.Named("id", webhookID), sql.Named("tenant", tenantID)).Scan(&exists)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return myError.ErrWebhookNotFound
+		}
+
+		rows, err := tx.QueryContext(ctx,
+			`SELECT `+deliveryColumns+`
+             FROM webhook_deliveries
+             WHERE webhook_id = :id AND `+sqliteTenantCondition+`
+             ORDER BY id DESC`,
+			sql.Named("id", webhookID), sql.Named("tenant", tenantID))
+		if err != nil {
+			return err
+		}
+
+		found, err := collectSQLiteRows(rows, func(row sqliteRow) (*entity.WebhookDelivery, error) {
+			var d entity.WebhookDelivery
+			err := row.Scan(sqliteDeliveryFields(&d)...)
+			return &d, err
+		})
+		if err != nil {
+			return err
+		}
+
+		deliveries = append(deliveries, found...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// RedeliverDelivery ставит доставку в очередь на немедленную повторную отправку
+func (repo *SQLiteRepo) RedeliverDelivery(ctx context.Context, id int64) error {
+	return repo.inTx(ctx, func(tx *sql.Tx, tenantID uuid.UUID) error {
+		res, err := tx.ExecContext(ctx,
+			`UPDATE webhook_deliveries
+             SET status = 'pending', attempts = 0, next_attempt_at = :now, delivered_at = NULL
+             WHERE id = :id AND `+sqliteTenantCondition,
+			sql.Named("now", sqliteTime(repo.now())), sql.Named("id", id), sql.Named("tenant", tenantID))
+		if err != nil {
+			return err
+		}
+
+		return sqliteAffected(res, myError.ErrDeliveryNotFound)
+	})
+}
+
+// PurgeDeliveries удаляет до limit завершенных доставок (succeeded и failed), последняя попытка которых была
+// раньше finishedBefore, и возвращает количество удаленных. Ожидающие доставки не удаляются
+func (repo *SQLiteRepo) PurgeDeliveries(ctx context.Context, finishedBefore time.Time, limit int) (int, error) {
+	var purged int
+	err := repo.inSystemTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx,
+			`DELETE FROM webhook_deliveries
+             WHERE id IN (SELECT id FROM webhook_deliveries
+                          WHERE status <> 'pending' AND COALESCE(delivered_at, next_attempt_at) < :before
+                          ORDER BY COALESCE(delivered_at, next_attempt_at)
+                          LIMIT :limit)`,
+			sql.Named("before", sqliteTime(finishedBefore)), sql.Named("limit", limit))
+		if err != nil {
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+		purged = int(affected)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+// sqliteDeliveryFields возвращает указатели на поля доставки в порядке колонок deliveryColumns
+func sqliteDeliveryFields(d *entity.WebhookDelivery) []any {
+	return []any{&d.Id, &d.WebhookId, &d.EventId, &d.EventType, (*[]byte)(&d.Payload), &d.Status, &d.Attempts,
+		&d.LastStatusCode, &d.LastError, &d.NextAttemptAt, &d.DeliveredAt, &d.CreatedAt}
+}
+
+// sqliteAffected возвращает notFound, если запрос не изменил ни одной строки
+func sqliteAffected(res sql.Result, notFound error) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return notFound
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+//go:build cgo
+
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSQLiteRepoClaimInactiveWebhook - доставки выключенного вебхука не выбираются для отправки
+func TestSQLiteRepoClaimInactiveWebhook(t *testing.T) {
+	ctx := context.Background()
+	repo := NewSQLiteRepo()
+	require.NoError(t, repo.ConnectDB(ctx, config.DatabaseConfig{SQLitePath: filepath.Join(t.TempDir(), "subscriptions.db")}))
+	t.Cleanup(func() {
+		_ = repo.Close(ctx)
+	})
+
+	testClaimInactiveWebhook(t, repo, func(webhookID int64, active bool) {
+		_, err := repo.db.ExecContext(ctx, `UPDATE webhooks SET active = ? WHERE id = ?`, active, webhookID)
+		require.NoError(t, err)
+	})
+}
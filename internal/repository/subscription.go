@@ -6,50 +6,168 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/Ararat25/subscription-aggregation-service/internal/config"
 	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
 	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
-	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// subscriptionColumns - колонки подписки в порядке, ожидаемом scanSubscription
+const subscriptionColumns = `id, service_name, price, user_id, start_date, end_date, cancellation_reason, cancelled_at, service_id, category, tags`
+
+// tenantCondition - условие принадлежности строки тенанту транзакции. Запросы ограничиваются им явно,
+// политики RLS в бд проверяют то же самое еще раз
+const tenantCondition = `tenant_id = current_tenant_id()`
+
+// costPeriodCondition - условие пересечения подписки с периодом [$1, $2]. Подписка без даты окончания действует
+// по дату $3, а если она NULL - по текущую дату бд
+const costPeriodCondition = `(($1 BETWEEN start_date AND COALESCE(end_date, $3::date, CURRENT_DATE))
+		OR ($2 BETWEEN start_date AND COALESCE(end_date, $3::date, CURRENT_DATE))
+		OR ($1 = start_date OR $2 = start_date))`
+
+// connectRetryInterval - пауза между попытками подключиться к бд при запуске
+const connectRetryInterval = time.Second
+
 // PGRepo - структура для базы данных
 type PGRepo struct {
-	conn *pgx.Conn // соединение с бд
+	pool         *pgxpool.Pool  // пул соединений с бд
+	tx           pgx.Tx         // транзакция WithTx, в которой выполняются запросы, или nil
+	txIsolation  pgx.TxIsoLevel // уровень изоляции транзакций WithTx
+	txMaxRetries int            // сколько раз WithTx повторяет транзакцию после конфликта сериализации
+	replicas     *replicaRouter // реплики для чтения подписок и стоимости или nil, если реплик нет
 }
 
-// ConnectDB производит соединение с бд
-func (repo *PGRepo) ConnectDB(ctx context.Context, dbHost string, dbUser string, dbPassword string, dbName string, dbPort int) error {
-	dsn := fmt.Sprintf(
-		"postgres://%s:%s@%s:%d/%s",
-		dbUser,
-		dbPassword,
-		dbHost,
-		dbPort,
-		dbName,
-	)
+// ConnectDB создает пул соединений с бд и ждет доступности бд не дольше conf.ConnectTimeout.
+// Соединения, разорванные во время работы (например, при перезапуске Postgres), пул заменяет новыми сам.
+// Реплики из conf.ReplicaHosts не задерживают запуск: недоступная реплика начнет получать чтения, когда ответит
+// на проверку
+func (repo *PGRepo) ConnectDB(ctx context.Context, conf config.DatabaseConfig) error {
+	if conf.MaxConns < 1 || conf.MinConns < 0 || conf.MinConns > conf.MaxConns {
+		return fmt.Errorf("DB_MAX_CONNS must be positive and DB_MIN_CONNS must be between 0 and DB_MAX_CONNS")
+	}
+
+	isolation, ok := txIsolationLevels[conf.TxIsolation]
+	if !ok || conf.TxMaxRetries < 0 {
+		return fmt.Errorf("DB_TX_ISOLATION must be read_committed, repeatable_read or serializable and DB_TX_MAX_RETRIES must not be negative")
+	}
+
+	pool, err := newPool(ctx, conf, conf.PostgresDSN())
+	if err != nil {
+		return err
+	}
+
+	connectCtx, cancel := context.WithTimeout(ctx, conf.ConnectTimeout)
+	defer cancel()
+
+	for {
+		err = pool.Ping(connectCtx)
+		if err == nil {
+			break
+		}
 
-	conn, err := pgx.Connect(ctx, dsn)
+		select {
+		case <-connectCtx.Done():
+			pool.Close()
+			return fmt.Errorf("unable to connect to database: %w", err)
+		case <-time.After(connectRetryInterval):
+		}
+	}
+
+	replicas, err := connectReplicas(ctx, conf)
 	if err != nil {
-		return fmt.Errorf("unable to connect to database: %w", err)
+		pool.Close()
+		return err
 	}
 
-	repo.conn = conn
+	repo.pool = pool
+	repo.txIsolation = isolation
+	repo.txMaxRetries = conf.TxMaxRetries
+	repo.replicas = replicas
 
 	return nil
 }
 
-// CreateSubscription добавляет подписку в бд и возвращает id
+// newPool создает пул соединений с бд dsn с настройками пула из conf
+func newPool(ctx context.Context, conf config.DatabaseConfig, dsn string) (*pgxpool.Pool, error) {
+	poolConf, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database config: %w", err)
+	}
+	poolConf.MaxConns = conf.MaxConns
+	poolConf.MinConns = conf.MinConns
+	poolConf.MaxConnLifetime = conf.MaxConnLifetime
+	poolConf.MaxConnIdleTime = conf.MaxConnIdleTime
+	poolConf.HealthCheckPeriod = conf.HealthCheckPeriod
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConf)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create connection pool: %w", err)
+	}
+
+	return pool, nil
+}
+
+// connectReplicas создает пулы соединений с репликами из conf.ReplicaHosts и запускает проверку их доступности.
+// Без реплик возвращает nil
+func connectReplicas(ctx context.Context, conf config.DatabaseConfig) (*replicaRouter, error) {
+	if len(conf.ReplicaHosts) == 0 {
+		return nil, nil
+	}
+
+	if conf.ReplicaStickiness < 0 || conf.ReplicaCheckPeriod <= 0 {
+		return nil, fmt.Errorf("DB_REPLICA_STICKINESS must not be negative and DB_REPLICA_CHECK_PERIOD must be positive")
+	}
+
+	replicas := make([]*replica, 0, len(conf.ReplicaHosts))
+	for _, host := range conf.ReplicaHosts {
+		pool, err := newPool(ctx, conf, conf.ReplicaDSN(host))
+		if err != nil {
+			for _, rep := range replicas {
+				rep.pool.Close()
+			}
+			return nil, fmt.Errorf("replica %s: %w", host, err)
+		}
+
+		replicas = append(replicas, &replica{host: host, pool: pool})
+	}
+
+	router := newReplicaRouter(replicas, conf.ReplicaStickiness)
+
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	router.cancel = cancel
+	go router.run(runCtx, conf.ReplicaCheckPeriod)
+
+	return router, nil
+}
+
+// CreateSubscription добавляет подписку в бд вместе с событием subscription.created и возвращает id
 func (repo *PGRepo) CreateSubscription(ctx context.Context, s *entity.Subscription) (int64, error) {
 	if s == nil {
 		return 0, fmt.Errorf("invalid argument error")
 	}
 
 	var id int64
-	err := repo.conn.QueryRow(ctx,
-		`INSERT INTO subscriptions (service_name, price, user_id, start_date, end_date)
-             VALUES ($1, $2, $3, $4, $5)
+	err := repo.inWriteTx(ctx, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx,
+			`INSERT INTO subscriptions (tenant_id, service_name, price, user_id, start_date, end_date, service_id, category, tags)
+             VALUES (current_tenant_id(), $1, $2, $3, $4, $5, $6, $7, $8)
              RETURNING id`,
-		s.ServiceName, s.Price, s.UserId, s.StartDate, s.EndDate).Scan(&id)
+			s.ServiceName, s.Price, s.UserId, s.StartDate, s.EndDate, s.ServiceId, s.Category, s.Tags).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		created := *s
+		created.Id = int(id)
+
+		err = addMonthlyCosts(ctx, tx, &created, 1)
+		if err != nil {
+			return err
+		}
+
+		return insertOutbox(ctx, tx, entity.EventSubscriptionCreated, &created)
+	})
 	if err != nil {
 		return 0, err
 	}
@@ -59,11 +177,13 @@ func (repo *PGRepo) CreateSubscription(ctx context.Context, s *entity.Subscripti
 
 // ReadSubscription возвращает подписку по id
 func (repo *PGRepo) ReadSubscription(ctx context.Context, id int64) (*entity.Subscription, error) {
-	row := repo.conn.QueryRow(ctx,
-		`SELECT id, service_name, price, user_id, start_date, end_date FROM subscriptions WHERE id = $1`, id)
-
-	var s entity.Subscription
-	err := row.Scan(&s.Id, &s.ServiceName, &s.Price, &s.UserId, &s.StartDate, &s.EndDate)
+	var s *entity.Subscription
+	err := repo.inReadTx(ctx, func(tx pgx.Tx) error {
+		var err error
+		s, err = scanSubscription(tx.QueryRow(ctx,
+			`SELECT `+subscriptionColumns+` FROM subscriptions WHERE id = $1 AND `+tenantCondition, id))
+		return err
+	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, myError.ErrSubscriptionNotFound
@@ -71,10 +191,11 @@ func (repo *PGRepo) ReadSubscription(ctx context.Context, id int64) (*entity.Sub
 		return nil, err
 	}
 
-	return &s, nil
+	return s, nil
 }
 
-// UpdateSubscription обновляет данные подписки
+// UpdateSubscription обновляет данные подписки и записывает событие subscription.updated,
+// а если у подписки появилась дата окончания - еще и subscription.ended
 func (repo *PGRepo) UpdateSubscription(ctx context.Context, s *entity.Subscription) error {
 	if s == nil {
 		return fmt.Errorf("invalid argument error: subscription is nil")
@@ -84,94 +205,300 @@ func (repo *PGRepo) UpdateSubscription(ctx context.Context, s *entity.Subscripti
 		return fmt.Errorf("invalid argument error: missing subscription ID")
 	}
 
-	cmdTag, err := repo.conn.Exec(ctx,
-		`UPDATE subscriptions SET service_name = $1, price = $2, user_id = $3, start_date = $4, end_date = $5 WHERE id = $6`,
-		s.ServiceName, s.Price, s.UserId, s.StartDate, s.EndDate, s.Id,
-	)
-	if err != nil {
-		return err
-	}
+	return repo.inWriteTx(ctx, func(tx pgx.Tx) error {
+		before, err := scanSubscription(tx.QueryRow(ctx,
+			`SELECT `+subscriptionColumns+` FROM subscriptions WHERE id = $1 AND `+tenantCondition+` FOR UPDATE`, s.Id))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return myError.ErrSubscriptionNotFound
+			}
+			return err
+		}
 
-	if cmdTag.RowsAffected() == 0 {
-		return myError.ErrSubscriptionNotFound
-	}
+		// при снятии даты окончания подписка снова активна, и причина отмены больше не актуальна
+		after, err := scanSubscription(tx.QueryRow(ctx,
+			`UPDATE subscriptions
+             SET service_name = $1, price = $2, user_id = $3, start_date = $4, end_date = $5, service_id = $7,
+                 category = $8, tags = $9,
+                 cancellation_reason = CASE WHEN $5::date IS NULL THEN NULL ELSE cancellation_reason END,
+                 cancelled_at = CASE WHEN $5::date IS NULL THEN NULL ELSE cancelled_at END
+             WHERE id = $6 AND `+tenantCondition+`
+             RETURNING `+subscriptionColumns,
+			s.ServiceName, s.Price, s.UserId, s.StartDate, s.EndDate, s.Id, s.ServiceId, s.Category, s.Tags,
+		))
+		if err != nil {
+			return err
+		}
 
-	return nil
+		err = replaceMonthlyCosts(ctx, tx, before, after)
+		if err != nil {
+			return err
+		}
+
+		return insertChangeEvents(ctx, tx, before, after)
+	})
 }
 
-// DeleteSubscription удаляет подписку
+// DeleteSubscription удаляет подписку и записывает событие subscription.deleted
 func (repo *PGRepo) DeleteSubscription(ctx context.Context, id int64) error {
-	cmdTag, err := repo.conn.Exec(ctx,
-		`DELETE FROM subscriptions WHERE id = $1`, id)
-	if err != nil {
-		return err
-	}
+	return repo.inWriteTx(ctx, func(tx pgx.Tx) error {
+		s, err := scanSubscription(tx.QueryRow(ctx,
+			`DELETE FROM subscriptions WHERE id = $1 AND `+tenantCondition+` RETURNING `+subscriptionColumns, id))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return myError.ErrSubscriptionNotFound
+			}
+			return err
+		}
 
-	if cmdTag.RowsAffected() == 0 {
-		return myError.ErrSubscriptionNotFound
-	}
+		err = addMonthlyCosts(ctx, tx, s, -1)
+		if err != nil {
+			return err
+		}
 
-	return nil
+		return insertOutbox(ctx, tx, entity.EventSubscriptionDeleted, s)
+	})
 }
 
-// ListSubscriptions возвращает список всех подписок
-func (repo *PGRepo) ListSubscriptions(ctx context.Context) ([]*entity.Subscription, error) {
-	rows, err := repo.conn.Query(ctx,
-		`SELECT id, service_name, price, user_id, start_date, end_date FROM subscriptions`)
+// CancelSubscription устанавливает дату окончания подписки и сохраняет причину отмены
+func (repo *PGRepo) CancelSubscription(ctx context.Context, id int64, endDate time.Time, reason *string) (*entity.Subscription, error) {
+	var after *entity.Subscription
+	err := repo.inWriteTx(ctx, func(tx pgx.Tx) error {
+		before, err := scanSubscription(tx.QueryRow(ctx,
+			`SELECT `+subscriptionColumns+` FROM subscriptions WHERE id = $1 AND `+tenantCondition+` FOR UPDATE`, id))
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return myError.ErrSubscriptionNotFound
+			}
+			return err
+		}
+
+		after, err = scanSubscription(tx.QueryRow(ctx,
+			`UPDATE subscriptions SET end_date = $1, cancellation_reason = $2, cancelled_at = now()
+             WHERE id = $3 AND `+tenantCondition+`
+             RETURNING `+subscriptionColumns,
+			endDate, reason, id))
+		if err != nil {
+			return err
+		}
+
+		err = replaceMonthlyCosts(ctx, tx, before, after)
+		if err != nil {
+			return err
+		}
+
+		return insertChangeEvents(ctx, tx, before, after)
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+
+	return after, nil
+}
+
+// ListSubscriptions возвращает список подписок с фильтрацией по пользователю, категории и тегам
+func (repo *PGRepo) ListSubscriptions(ctx context.Context, filter *entity.SubscriptionFilter) ([]*entity.Subscription, error) {
+	query, args := appendSubscriptionFilter(`SELECT `+subscriptionColumns+` FROM subscriptions WHERE `+tenantCondition, nil, filter)
 
 	var subs []*entity.Subscription
-	for rows.Next() {
-		var s entity.Subscription
-		err = rows.Scan(&s.Id, &s.ServiceName, &s.Price, &s.UserId, &s.StartDate, &s.EndDate)
+	err := repo.inReadTx(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, args...)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		subs = append(subs, &s)
+
+		subs, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (*entity.Subscription, error) {
+			return scanSubscription(row)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(subs) == 0 {
+		return nil, nil
 	}
+
 	return subs, nil
 }
 
-// TotalCost возвращает суммарную стоимость подписок за определенный период с фильтрацией по id пользователя и/или названию сервиса
-func (repo *PGRepo) TotalCost(ctx context.Context, from, to time.Time, userID *uuid.UUID, serviceName *string) (int, error) {
+// TotalCost возвращает суммарную стоимость подписок за определенный период с фильтрацией.
+// Стоимость за один месяц по пользователю и точному названию сервиса берется из помесячных итогов
+func (repo *PGRepo) TotalCost(ctx context.Context, from, to time.Time, filter *entity.CostFilter) (int, error) {
+	query, args := appendCostFilter(`SELECT COALESCE(SUM(price), 0) FROM subscription_history WHERE `+tenantCondition+` AND `+costPeriodCondition,
+		[]interface{}{from, to, costTodayArg(filter)}, filter)
+	month, fromRollup := rollupCostMonth(from, to, filter)
+
 	var total int
-	query := `
-		SELECT COALESCE(SUM(price), 0)
-		FROM subscriptions
-		WHERE (($1 BETWEEN start_date AND COALESCE(end_date, CURRENT_DATE))
-		OR ($2 BETWEEN start_date AND COALESCE(end_date, CURRENT_DATE))
-		OR ($1 = start_date OR $2 = start_date))
-	`
-	args := []interface{}{from, to}
+	err := repo.inReadTx(ctx, func(tx pgx.Tx) error {
+		if fromRollup {
+			var (
+				ok  bool
+				err error
+			)
+			total, ok, err = monthlyCostsTotal(ctx, tx, month, filter)
+			if err != nil || ok {
+				return err
+			}
+		}
+
+		return tx.QueryRow(ctx, query, args...).Scan(&total)
+	})
+	return total, err
+}
 
-	if userID != nil {
-		query += ` AND user_id = $3`
-		args = append(args, *userID)
+// CostBreakdown возвращает суммарную стоимость подписок за определенный период в разрезе категорий или тегов.
+// Подписка с несколькими тегами учитывается в группе каждого тега
+func (repo *PGRepo) CostBreakdown(ctx context.Context, from, to time.Time, filter *entity.CostFilter, groupBy entity.GroupBy) ([]*entity.CostGroup, error) {
+	var query string
+	switch groupBy {
+	case entity.GroupByCategory:
+		query = `SELECT category AS key, SUM(price) AS total FROM subscription_history WHERE ` + tenantCondition + ` AND ` + costPeriodCondition
+	case entity.GroupByTag:
+		query = `SELECT tag AS key, SUM(price) AS total
+                 FROM subscription_history LEFT JOIN LATERAL unnest(tags) AS tag ON true
+                 WHERE ` + tenantCondition + ` AND ` + costPeriodCondition
+	default:
+		return nil, fmt.Errorf("invalid argument error: unknown group by %q", groupBy)
 	}
 
-	if serviceName != nil {
-		if userID == nil {
-			query += ` AND service_name = $3`
-			args = append(args, *serviceName)
-		} else {
-			query += ` AND service_name = $4`
-			args = append(args, *serviceName)
+	query, args := appendCostFilter(query, []interface{}{from, to, costTodayArg(filter)}, filter)
+	query += ` GROUP BY key ORDER BY total DESC, key NULLS LAST`
+
+	var groups []*entity.CostGroup
+	err := repo.inReadTx(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, query, args...)
+		if err != nil {
+			return err
 		}
+
+		groups, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (*entity.CostGroup, error) {
+			var g entity.CostGroup
+			err := row.Scan(&g.Key, &g.TotalCost)
+			return &g, err
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	err := repo.conn.QueryRow(ctx, query, args...).Scan(&total)
-	return total, err
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	return groups, nil
 }
 
-// Close закрывает соединение с бд
-func (repo *PGRepo) Close(ctx context.Context) error {
-	err := repo.conn.Close(ctx)
+// SubscriptionsVersion возвращает счетчик изменений таблицы подписок, который ведет триггер subscriptions_bump_version
+func (repo *PGRepo) SubscriptionsVersion(ctx context.Context) (*entity.TableVersion, error) {
+	var v entity.TableVersion
+	err := repo.inReadTx(ctx, func(tx pgx.Tx) error {
+		return tx.QueryRow(ctx,
+			`SELECT version, updated_at FROM table_versions WHERE table_name = 'subscriptions' AND `+tenantCondition).
+			Scan(&v.Version, &v.UpdatedAt)
+	})
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+// PoolStats возвращает состояние пула соединений с бд
+func (repo *PGRepo) PoolStats(_ context.Context) (*entity.PoolStats, error) {
+	s := repo.pool.Stat()
+
+	return &entity.PoolStats{
+		MaxConns:                s.MaxConns(),
+		TotalConns:              s.TotalConns(),
+		AcquiredConns:           s.AcquiredConns(),
+		IdleConns:               s.IdleConns(),
+		ConstructingConns:       s.ConstructingConns(),
+		AcquireCount:            s.AcquireCount(),
+		AcquireDuration:         s.AcquireDuration().Seconds(),
+		EmptyAcquireCount:       s.EmptyAcquireCount(),
+		CanceledAcquireCount:    s.CanceledAcquireCount(),
+		NewConnsCount:           s.NewConnsCount(),
+		MaxLifetimeDestroyCount: s.MaxLifetimeDestroyCount(),
+		MaxIdleDestroyCount:     s.MaxIdleDestroyCount(),
+	}, nil
+}
+
+// Close закрывает все соединения пула, дожидаясь возврата занятых. Хранилище транзакции WithTx закрыть нельзя
+func (repo *PGRepo) Close(_ context.Context) error {
+	if repo.tx != nil {
+		return fmt.Errorf("unable to close storage inside a transaction")
 	}
 
+	repo.replicas.close()
+	repo.pool.Close()
+
 	return nil
 }
+
+// appendCostFilter дописывает в запрос условия фильтра стоимости, нумеруя параметры после уже переданных args
+func appendCostFilter(query string, args []interface{}, filter *entity.CostFilter) (string, []interface{}) {
+	if filter == nil {
+		return query, args
+	}
+
+	if filter.ServiceName != nil {
+		args = append(args, filter.ServiceName.Name)
+		query += ` AND ` + serviceNameCondition(filter.ServiceName.Mode, len(args))
+	}
+
+	return appendSubscriptionFilter(query, args, &filter.SubscriptionFilter)
+}
+
+// costTodayArg возвращает параметр $3 условия costPeriodCondition: дату фильтра или nil, если она не указана
+func costTodayArg(filter *entity.CostFilter) *time.Time {
+	if filter == nil || filter.Today.IsZero() {
+		return nil
+	}
+
+	today := toDate(filter.Today)
+	return &today
+}
+
+// appendSubscriptionFilter дописывает в запрос условия фильтра по пользователю, категории и тегам
+func appendSubscriptionFilter(query string, args []interface{}, filter *entity.SubscriptionFilter) (string, []interface{}) {
+	if filter == nil {
+		return query, args
+	}
+
+	if filter.UserId != nil {
+		args = append(args, *filter.UserId)
+		query += fmt.Sprintf(` AND user_id = $%d`, len(args))
+	}
+
+	if filter.Category != nil {
+		args = append(args, *filter.Category)
+		query += fmt.Sprintf(` AND category = $%d`, len(args))
+	}
+
+	if len(filter.Tags) > 0 {
+		args = append(args, filter.Tags)
+		query += fmt.Sprintf(` AND tags @> $%d::text[]`, len(args))
+	}
+
+	return query, args
+}
+
+// scanSubscription читает подписку из строки результата с колонками subscriptionColumns
+func scanSubscription(row pgx.Row) (*entity.Subscription, error) {
+	var s entity.Subscription
+	err := row.Scan(subscriptionFields(&s)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
+
+// subscriptionFields возвращает указатели на поля подписки в порядке колонок subscriptionColumns
+func subscriptionFields(s *entity.Subscription) []any {
+	return []any{&s.Id, &s.ServiceName, &s.Price, &s.UserId, &s.StartDate, &s.EndDate,
+		&s.CancellationReason, &s.CancelledAt, &s.ServiceId, &s.Category, &s.Tags}
+}
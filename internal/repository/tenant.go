@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/Ararat25/subscription-aggregation-service/internal/tenant"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// CreateTenant добавляет тенанта в бд и заполняет его id и время создания
+func (repo *PGRepo) CreateTenant(ctx context.Context, t *entity.Tenant) error {
+	if t == nil {
+		return fmt.Errorf("invalid argument error")
+	}
+
+	return repo.inSystemTx(ctx, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx,
+			`INSERT INTO tenants (name) VALUES ($1) RETURNING id, created_at`, t.Name).Scan(&t.Id, &t.CreatedAt)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+				return myError.ErrTenantNameTaken
+			}
+			return err
+		}
+
+		// счетчик изменений нужен кэшу ответов до первого изменения подписок тенанта
+		_, err = tx.Exec(ctx,
+			`INSERT INTO table_versions (tenant_id, table_name) VALUES ($1, 'subscriptions')`, t.Id)
+		return err
+	})
+}
+
+// ListTenants возвращает список тенантов в порядке создания
+func (repo *PGRepo) ListTenants(ctx context.Context) ([]*entity.Tenant, error) {
+	tenants := []*entity.Tenant{}
+	err := repo.inSystemTx(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, `SELECT id, name, created_at FROM tenants ORDER BY created_at, name`)
+		if err != nil {
+			return err
+		}
+
+		tenants, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (*entity.Tenant, error) {
+			var t entity.Tenant
+			err := row.Scan(&t.Id, &t.Name, &t.CreatedAt)
+			return &t, err
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tenants, nil
+}
+
+// TenantCosts возвращает суммарную стоимость подписок каждого тенанта за период, включая тенантов без подписок
+func (repo *PGRepo) TenantCosts(ctx context.Context, from, to time.Time) ([]*entity.TenantCost, error) {
+	costs := []*entity.TenantCost{}
+	err := repo.inSystemTx(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx,
+			`SELECT t.id, t.name, COALESCE(SUM(s.price), 0) AS total
+             FROM tenants t
+             LEFT JOIN subscription_history s ON s.tenant_id = t.id AND `+costPeriodCondition+`
+             GROUP BY t.id, t.name
+             ORDER BY total DESC, t.name`,
+			from, to, nil)
+		if err != nil {
+			return err
+		}
+
+		costs, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (*entity.TenantCost, error) {
+			var c entity.TenantCost
+			err := row.Scan(&c.TenantId, &c.Name, &c.TotalCost)
+			return &c, err
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return costs, nil
+}
+
+// inTx выполняет fn в транзакции тенанта из контекста и фиксирует ее, если fn не вернула ошибку.
+// Тенант передается в бд параметром app.tenant_id, по которому запросы и политики RLS ограничивают строки.
+// Без тенанта в контексте возвращает myError.ErrTenantRequired
+func (repo *PGRepo) inTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return myError.ErrTenantRequired
+	}
+
+	return repo.begin(ctx,
+		`SELECT set_config('app.tenant_id', $1, true), set_config('app.all_tenants', '', true)`, tenantID.String(), fn)
+}
+
+// inSystemTx выполняет fn в транзакции с доступом к данным всех тенантов. Используется фоновыми задачами,
+// поиском API-ключа при аутентификации и управлением тенантами
+func (repo *PGRepo) inSystemTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	return repo.begin(ctx,
+		`SELECT set_config('app.all_tenants', $1, true), set_config('app.tenant_id', '', true)`, "on", fn)
+}
+
+// begin открывает транзакцию, выставляет в ней параметры запросом setup и выполняет fn. Внутри WithTx вместо
+// транзакции открывается точка сохранения, а setup сбрасывает параметры, оставшиеся от предыдущих вызовов
+func (repo *PGRepo) begin(ctx context.Context, setup string, value string, fn func(tx pgx.Tx) error) error {
+	var (
+		tx  pgx.Tx
+		err error
+	)
+	if repo.tx != nil {
+		tx, err = repo.tx.Begin(ctx)
+	} else {
+		tx, err = repo.pool.Begin(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	_, err = tx.Exec(ctx, setup, value)
+	if err != nil {
+		return err
+	}
+
+	err = fn(tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
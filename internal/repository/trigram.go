@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"strings"
+	"unicode"
+)
+
+// similarityThreshold - порог сходства, начиная с которого строки похожи для оператора % расширения pg_trgm
+const similarityThreshold float32 = 0.3
+
+// trigrams возвращает множество триграмм строки так же, как pg_trgm: строка приводится к нижнему регистру
+// и делится на слова из букв и цифр, каждое слово дополняется двумя пробелами в начале и одним в конце
+func trigrams(s string) map[string]struct{} {
+	set := make(map[string]struct{})
+
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = struct{}{}
+		}
+	}
+
+	return set
+}
+
+// trigramSimilarity вычисляет сходство строк по триграммам так же, как функция similarity расширения pg_trgm:
+// доля общих триграмм среди всех триграмм обеих строк. Вычисление ведется в float32, как в pg_trgm,
+// чтобы значения совпадали с возвращаемыми Postgres
+func trigramSimilarity(a, b string) float32 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	common := 0
+	for t := range ta {
+		if _, ok := tb[t]; ok {
+			common++
+		}
+	}
+
+	return float32(common) / float32(len(ta)+len(tb)-common)
+}
+
+// trigramSimilar проверяет, что строки похожи по триграммам, как оператор % расширения pg_trgm
+func trigramSimilar(a, b string) bool {
+	return trigramSimilarity(a, b) >= similarityThreshold
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// коды ошибок postgres, после которых транзакцию можно выполнить заново
+const (
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+// txRetryBackoff - базовая пауза перед повтором транзакции, с каждой попыткой она растет
+const txRetryBackoff = 10 * time.Millisecond
+
+// txIsolationLevels - уровни изоляции транзакций WithTx по значениям DB_TX_ISOLATION
+var txIsolationLevels = map[string]pgx.TxIsoLevel{
+	"read_committed":  pgx.ReadCommitted,
+	"repeatable_read": pgx.RepeatableRead,
+	"serializable":    pgx.Serializable,
+}
+
+// WithTx выполняет fn в одной транзакции с уровнем изоляции DB_TX_ISOLATION: вызовы хранилища tx внутри fn
+// либо применяются все, либо, если fn вернула ошибку, не применяется ни один. После конфликта сериализации
+// или взаимной блокировки fn выполняется заново, не больше DB_TX_MAX_RETRIES раз, поэтому fn не должна
+// иметь побочных эффектов вне tx. Вызов WithTx у хранилища tx открывает точку сохранения и не повторяется
+func (repo *PGRepo) WithTx(ctx context.Context, fn func(tx Repo) error) error {
+	if repo.tx != nil {
+		return repo.withSavepoint(ctx, fn)
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := repo.runTx(ctx, fn)
+		if err == nil {
+			repo.replicas.markWrite(ctx)
+			return nil
+		}
+
+		if attempt >= repo.txMaxRetries || !isRetryableTxError(err) {
+			return err
+		}
+
+		// случайная добавка к паузе разводит по времени транзакции, конфликтующие друг с другом
+		backoff := txRetryBackoff * time.Duration(attempt+1)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff + rand.N(backoff)):
+		}
+	}
+}
+
+// runTx выполняет одну попытку транзакции WithTx
+func (repo *PGRepo) runTx(ctx context.Context, fn func(tx Repo) error) error {
+	tx, err := repo.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: repo.txIsolation})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	err = fn(repo.withTx(tx))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// withSavepoint выполняет fn в точке сохранения текущей транзакции: ошибка fn отменяет только ее изменения
+func (repo *PGRepo) withSavepoint(ctx context.Context, fn func(tx Repo) error) error {
+	sp, err := repo.tx.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = sp.Rollback(ctx)
+	}()
+
+	err = fn(repo.withTx(sp))
+	if err != nil {
+		return err
+	}
+
+	return sp.Commit(ctx)
+}
+
+// withTx возвращает хранилище, выполняющее запросы в транзакции tx
+func (repo *PGRepo) withTx(tx pgx.Tx) *PGRepo {
+	clone := *repo
+	clone.tx = tx
+
+	return &clone
+}
+
+// isRetryableTxError проверяет, что транзакция отменена конфликтом с другими транзакциями и ее можно повторить
+func isRetryableTxError(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == serializationFailure || pgErr.Code == deadlockDetected)
+}
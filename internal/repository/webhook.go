@@ -0,0 +1,278 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	myError "github.com/Ararat25/subscription-aggregation-service/internal/error"
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateWebhook добавляет вебхук в бд и возвращает id
+func (repo *PGRepo) CreateWebhook(ctx context.Context, w *entity.Webhook) (int64, error) {
+	if w == nil {
+		return 0, fmt.Errorf("invalid argument error")
+	}
+
+	var id int64
+	err := repo.inTx(ctx, func(tx pgx.Tx) error {
+		return tx.QueryRow(ctx,
+			`INSERT INTO webhooks (tenant_id, url, secret, events, active)
+             VALUES (current_tenant_id(), $1, $2, $3, $4)
+             RETURNING id, created_at`,
+			w.URL, w.Secret, eventTypesToStrings(w.Events), w.Active).Scan(&id, &w.CreatedAt)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// ListWebhooks возвращает список всех вебхуков тенанта
+func (repo *PGRepo) ListWebhooks(ctx context.Context) ([]*entity.Webhook, error) {
+	webhooks := []*entity.Webhook{}
+	err := repo.inTx(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx,
+			`SELECT id, url, secret, events, active, created_at FROM webhooks WHERE `+tenantCondition+` ORDER BY id`)
+		if err != nil {
+			return err
+		}
+
+		webhooks, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (*entity.Webhook, error) {
+			var (
+				w      entity.Webhook
+				events []string
+			)
+			err := row.Scan(&w.Id, &w.URL, &w.Secret, &events, &w.Active, &w.CreatedAt)
+			if err != nil {
+				return nil, err
+			}
+			w.Events = stringsToEventTypes(events)
+
+			return &w, nil
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// DeleteWebhook удаляет вебхук вместе с журналом его доставок
+func (repo *PGRepo) DeleteWebhook(ctx context.Context, id int64) error {
+	return repo.inTx(ctx, func(tx pgx.Tx) error {
+		cmdTag, err := tx.Exec(ctx, `DELETE FROM webhooks WHERE id = $1 AND `+tenantCondition, id)
+		if err != nil {
+			return err
+		}
+
+		if cmdTag.RowsAffected() == 0 {
+			return myError.ErrWebhookNotFound
+		}
+
+		return nil
+	})
+}
+
+// CreateDeliveries создает записи о доставке события для всех активных вебхуков тенанта события,
+// подписанных на его тип, и возвращает количество созданных записей
+func (repo *PGRepo) CreateDeliveries(ctx context.Context, event *entity.Event, payload []byte) (int, error) {
+	if event == nil {
+		return 0, fmt.Errorf("invalid argument error")
+	}
+
+	var created int
+	err := repo.inSystemTx(ctx, func(tx pgx.Tx) error {
+		cmdTag, err := tx.Exec(ctx,
+			`INSERT INTO webhook_deliveries (tenant_id, webhook_id, event_id, event_type, payload)
+             SELECT tenant_id, id, $1, $2, $3 FROM webhooks WHERE tenant_id = $4 AND active AND $2 = ANY(events)
+             ON CONFLICT (webhook_id, event_id) DO NOTHING`,
+			event.Id, string(event.Type), payload, event.TenantId)
+		if err != nil {
+			return err
+		}
+
+		created = int(cmdTag.RowsAffected())
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return created, nil
+}
+
+// ClaimDueDeliveries выбирает доставки, время отправки которых наступило, и откладывает их на время lease,
+// чтобы параллельные обработчики не отправили одно событие дважды. Доставки выключенных вебхуков не выбираются
+// и не занимают место в выборке, пока вебхук не включат снова
+func (repo *PGRepo) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*entity.WebhookDelivery, error) {
+	var deliveries []*entity.WebhookDelivery
+	err := repo.inSystemTx(ctx, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx,
+			`UPDATE webhook_deliveries d
+             SET next_attempt_at = $2
+             FROM webhooks w
+             WHERE w.id = d.webhook_id
+               AND w.active
+               AND d.id IN (SELECT pd.id FROM webhook_deliveries pd
+                            JOIN webhooks pw ON pw.id = pd.webhook_id
+                            WHERE pd.status = 'pending' AND pd.next_attempt_at <= $1 AND pw.active
+                            ORDER BY pd.next_attempt_at
+                            LIMIT $3
+                            FOR UPDATE OF pd SKIP LOCKED)
+             RETURNING d.id, d.webhook_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+                       d.last_status_code, d.last_error, d.next_attempt_at, d.delivered_at, d.created_at,
+                       w.url, w.secret`,
+			now, now.Add(lease), limit)
+		if err != nil {
+			return err
+		}
+
+		deliveries, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (*entity.WebhookDelivery, error) {
+			var d entity.WebhookDelivery
+			err := row.Scan(&d.Id, &d.WebhookId, &d.EventId, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+				&d.LastStatusCode, &d.LastError, &d.NextAttemptAt, &d.DeliveredAt, &d.CreatedAt,
+				&d.URL, &d.Secret)
+			return &d, err
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(deliveries) == 0 {
+		return nil, nil
+	}
+
+	return deliveries, nil
+}
+
+// UpdateDelivery сохраняет результат попытки доставки
+func (repo *PGRepo) UpdateDelivery(ctx context.Context, d *entity.WebhookDelivery) error {
+	if d == nil {
+		return fmt.Errorf("invalid argument error")
+	}
+
+	return repo.inSystemTx(ctx, func(tx pgx.Tx) error {
+		cmdTag, err := tx.Exec(ctx,
+			`UPDATE webhook_deliveries
+             SET status = $1, attempts = $2, last_status_code = $3, last_error = $4, next_attempt_at = $5, delivered_at = $6
+             WHERE id = $7`,
+			string(d.Status), d.Attempts, d.LastStatusCode, d.LastError, d.NextAttemptAt, d.DeliveredAt, d.Id)
+		if err != nil {
+			return err
+		}
+
+		if cmdTag.RowsAffected() == 0 {
+			return myError.ErrDeliveryNotFound
+		}
+
+		return nil
+	})
+}
+
+// ListDeliveries возвращает журнал доставок вебхука, начиная с последних
+func (repo *PGRepo) ListDeliveries(ctx context.Context, webhookID int64) ([]*entity.WebhookDelivery, error) {
+	deliveries := []*entity.WebhookDelivery{}
+	err := repo.inTx(ctx, func(tx pgx.Tx) error {
+		var exists bool
+		err := tx.QueryRow(ctx,
+			`SELECT EXISTS (SELECT 1 FROM webhooks WHERE id = $1 AND `+tenantCondition+`)`, webhookID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return myError.ErrWebhookNotFound
+		}
+
+		rows, err := tx.Query(ctx,
+			`SELECT id, webhook_id, event_id, event_type, payload, status, attempts,
+                last_status_code, last_error, next_attempt_at, delivered_at, created_at
+             FROM webhook_deliveries
+             WHERE webhook_id = $1 AND `+tenantCondition+`
+             ORDER BY id DESC`, webhookID)
+		if err != nil {
+			return err
+		}
+
+		deliveries, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (*entity.WebhookDelivery, error) {
+			var d entity.WebhookDelivery
+			err := row.Scan(&d.Id, &d.WebhookId, &d.EventId, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+				&d.LastStatusCode, &d.LastError, &d.NextAttemptAt, &d.DeliveredAt, &d.CreatedAt)
+			return &d, err
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// RedeliverDelivery ставит доставку в очередь на немедленную повторную отправку
+func (repo *PGRepo) RedeliverDelivery(ctx context.Context, id int64) error {
+	return repo.inTx(ctx, func(tx pgx.Tx) error {
+		cmdTag, err := tx.Exec(ctx,
+			`UPDATE webhook_deliveries
+             SET status = 'pending', attempts = 0, next_attempt_at = now(), delivered_at = NULL
+             WHERE id = $1 AND `+tenantCondition, id)
+		if err != nil {
+			return err
+		}
+
+		if cmdTag.RowsAffected() == 0 {
+			return myError.ErrDeliveryNotFound
+		}
+
+		return nil
+	})
+}
+
+// PurgeDeliveries удаляет до limit завершенных доставок (succeeded и failed), последняя попытка которых была
+// раньше finishedBefore, и возвращает количество удаленных. Ожидающие доставки не удаляются
+func (repo *PGRepo) PurgeDeliveries(ctx context.Context, finishedBefore time.Time, limit int) (int, error) {
+	var purged int
+	err := repo.inSystemTx(ctx, func(tx pgx.Tx) error {
+		tag, err := tx.Exec(ctx,
+			`DELETE FROM webhook_deliveries
+             WHERE id IN (SELECT id FROM webhook_deliveries
+                          WHERE status <> 'pending' AND COALESCE(delivered_at, next_attempt_at) < $1
+                          ORDER BY COALESCE(delivered_at, next_attempt_at)
+                          LIMIT $2
+                          FOR UPDATE SKIP LOCKED)`,
+			finishedBefore, limit)
+		purged = int(tag.RowsAffected())
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+// eventTypesToStrings преобразует типы событий в строки для хранения в массиве
+func eventTypesToStrings(events []entity.EventType) []string {
+	res := make([]string, 0, len(events))
+	for _, e := range events {
+		res = append(res, string(e))
+	}
+	return res
+}
+
+// stringsToEventTypes преобразует строки из бд в типы событий
+func stringsToEventTypes(events []string) []entity.EventType {
+	res := make([]entity.EventType, 0, len(events))
+	for _, e := range events {
+		res = append(res, entity.EventType(e))
+	}
+	return res
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Ararat25/subscription-aggregation-service/internal/entity"
+	"github.com/Ararat25/subscription-aggregation-service/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testClaimInactiveWebhook проверяет, что доставки вебхука, выключенного функцией setActive в обход хранилища,
+// не выбираются и не занимают место в выборке, а после включения вебхука выбираются снова
+func testClaimInactiveWebhook(t *testing.T, storage Storage, setActive func(webhookID int64, active bool)) {
+	tn := &entity.Tenant{Name: "claim-inactive"}
+	require.NoError(t, storage.CreateTenant(context.Background(), tn))
+	ctx := tenant.WithID(context.Background(), tn.Id)
+
+	newWebhook := func(url string) int64 {
+		id, err := storage.CreateWebhook(ctx, &entity.Webhook{
+			URL: url, Secret: "my-very-long-secret", Active: true,
+			Events: []entity.EventType{entity.EventSubscriptionCreated},
+		})
+		require.NoError(t, err)
+		return id
+	}
+	inactiveID := newWebhook("https://example.com/inactive")
+
+	event := entity.NewEvent(entity.EventSubscriptionCreated, &entity.Subscription{ServiceName: "Netflix", Tags: []string{}})
+	event.TenantId = tn.Id
+	payload, err := json.Marshal(event)
+	require.NoError(t, err)
+	_, err = storage.CreateDeliveries(context.Background(), event, payload)
+	require.NoError(t, err)
+
+	activeID := newWebhook("https://example.com/active")
+	event = entity.NewEvent(entity.EventSubscriptionCreated, &entity.Subscription{ServiceName: "Netflix", Tags: []string{}})
+	event.TenantId = tn.Id
+	_, err = storage.CreateDeliveries(context.Background(), event, payload)
+	require.NoError(t, err)
+
+	setActive(inactiveID, false)
+	now := time.Now().Add(time.Minute)
+
+	// Тестовый пример 1: Доставка выключенного вебхука не выбирается и не занимает место в выборке
+	due, err := storage.ClaimDueDeliveries(context.Background(), now, time.Minute, 1)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, activeID, due[0].WebhookId)
+
+	// Тестовый пример 2: После включения вебхука его доставка выбирается снова
+	setActive(inactiveID, true)
+	due, err = storage.ClaimDueDeliveries(context.Background(), now, time.Minute, 1)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, inactiveID, due[0].WebhookId)
+}
@@ -0,0 +1,25 @@
+package tenant
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// DefaultID - тенант по умолчанию: ему принадлежат данные, созданные до появления тенантов,
+// и запросы, выполняемые без аутентификации
+var DefaultID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+// tenantKey - ключ id тенанта в контексте запроса
+type tenantKey struct{}
+
+// WithID возвращает контекст с тенантом id, данными которого ограничены запросы к хранилищу
+func WithID(ctx context.Context, id uuid.UUID) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// FromContext возвращает id тенанта из контекста запроса
+func FromContext(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(tenantKey{}).(uuid.UUID)
+	return id, ok && id != uuid.Nil
+}
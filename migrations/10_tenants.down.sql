@@ -0,0 +1,57 @@
+-- откат тенантов необратим для данных: строки всех тенантов остаются в таблицах и становятся общими,
+-- а написания названий сервисов и счетчики изменений сохраняются только у тенанта по умолчанию
+CREATE OR REPLACE FUNCTION bump_table_version() RETURNS TRIGGER AS
+$$
+BEGIN
+    UPDATE table_versions
+    SET version    = version + 1,
+        updated_at = clock_timestamp()
+    WHERE table_name = TG_TABLE_NAME;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX idx_subscriptions_tenant_user;
+
+-- политики снимаются до удаления строк: под FORCE ROW LEVEL SECURITY строки других тенантов не видны и владельцу
+DO
+$$
+    DECLARE
+        t TEXT;
+    BEGIN
+        FOREACH t IN ARRAY ARRAY ['subscriptions', 'services', 'service_names', 'webhooks', 'webhook_deliveries',
+            'outbox', 'table_versions', 'api_keys']
+            LOOP
+                EXECUTE format('DROP POLICY tenant_isolation ON %I', t);
+                EXECUTE format('ALTER TABLE %I NO FORCE ROW LEVEL SECURITY', t);
+                EXECUTE format('ALTER TABLE %I DISABLE ROW LEVEL SECURITY', t);
+            END LOOP;
+    END
+$$;
+
+DELETE FROM service_names
+WHERE tenant_id <> '00000000-0000-0000-0000-000000000001';
+
+DELETE FROM table_versions
+WHERE tenant_id <> '00000000-0000-0000-0000-000000000001';
+
+-- первичные ключи с tenant_id и индексы по нему удаляются вместе с колонкой
+ALTER TABLE subscriptions DROP COLUMN tenant_id;
+ALTER TABLE services DROP COLUMN tenant_id;
+ALTER TABLE service_names DROP COLUMN tenant_id;
+ALTER TABLE webhooks DROP COLUMN tenant_id;
+ALTER TABLE webhook_deliveries DROP COLUMN tenant_id;
+ALTER TABLE outbox DROP COLUMN tenant_id;
+ALTER TABLE table_versions DROP COLUMN tenant_id;
+ALTER TABLE api_keys DROP COLUMN tenant_id;
+
+ALTER TABLE service_names
+    ADD PRIMARY KEY (name_key);
+
+ALTER TABLE table_versions
+    ADD PRIMARY KEY (table_name);
+
+DROP FUNCTION tenant_visible(UUID);
+DROP FUNCTION current_tenant_id();
+
+DROP TABLE tenants;
@@ -0,0 +1,78 @@
+-- тенанты - компании, данные которых хранятся в одной базе и изолированы друг от друга
+CREATE TABLE tenants
+(
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name       TEXT        NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- тенант по умолчанию: ему принадлежат уже существующие данные и запросы без аутентификации
+INSERT INTO tenants (id, name)
+VALUES ('00000000-0000-0000-0000-000000000001', 'default');
+
+-- тенант запроса задается в транзакции параметром app.tenant_id, фоновые задачи по всем тенантам
+-- выставляют app.all_tenants = on
+CREATE FUNCTION current_tenant_id() RETURNS UUID AS
+$$
+SELECT NULLIF(current_setting('app.tenant_id', true), '')::uuid;
+$$ LANGUAGE sql STABLE;
+
+CREATE FUNCTION tenant_visible(tenant UUID) RETURNS BOOLEAN AS
+$$
+SELECT coalesce(current_setting('app.all_tenants', true), '') = 'on' OR tenant = current_tenant_id();
+$$ LANGUAGE sql STABLE;
+
+-- существующие строки переходят тенанту по умолчанию, новые по умолчанию получают тенанта транзакции
+DO
+$$
+    DECLARE
+        t TEXT;
+    BEGIN
+        FOREACH t IN ARRAY ARRAY ['subscriptions', 'services', 'service_names', 'webhooks', 'webhook_deliveries',
+            'outbox', 'table_versions', 'api_keys']
+            LOOP
+                EXECUTE format('ALTER TABLE %I ADD COLUMN tenant_id UUID NOT NULL
+                                    DEFAULT ''00000000-0000-0000-0000-000000000001'' REFERENCES tenants (id)', t);
+                EXECUTE format('ALTER TABLE %I ALTER COLUMN tenant_id SET DEFAULT current_tenant_id()', t);
+                EXECUTE format('CREATE INDEX %I ON %I (tenant_id)', 'idx_' || t || '_tenant_id', t);
+
+                -- политика действует и на владельца таблиц, под которым работает сервис. Суперпользователь
+                -- и роли с BYPASSRLS политики обходят, поэтому сервис должен подключаться под обычной ролью
+                EXECUTE format('ALTER TABLE %I ENABLE ROW LEVEL SECURITY', t);
+                EXECUTE format('ALTER TABLE %I FORCE ROW LEVEL SECURITY', t);
+                EXECUTE format('CREATE POLICY tenant_isolation ON %I
+                                    USING (tenant_visible(tenant_id)) WITH CHECK (tenant_visible(tenant_id))', t);
+            END LOOP;
+    END
+$$;
+
+-- уникальность названий сервисов и счетчики изменений - внутри тенанта
+ALTER TABLE service_names
+    DROP CONSTRAINT service_names_pkey,
+    ADD PRIMARY KEY (tenant_id, name_key);
+
+ALTER TABLE table_versions
+    DROP CONSTRAINT table_versions_pkey,
+    ADD PRIMARY KEY (tenant_id, table_name);
+
+CREATE INDEX idx_subscriptions_tenant_user ON subscriptions (tenant_id, user_id);
+
+-- версия увеличивается у тенанта транзакции; оператор без тенанта (фоновая задача) сбрасывает кэш всех тенантов
+CREATE OR REPLACE FUNCTION bump_table_version() RETURNS TRIGGER AS
+$$
+BEGIN
+    IF current_tenant_id() IS NULL THEN
+        UPDATE table_versions
+        SET version    = version + 1,
+            updated_at = clock_timestamp()
+        WHERE table_name = TG_TABLE_NAME;
+    ELSE
+        INSERT INTO table_versions AS tv (tenant_id, table_name, version, updated_at)
+        VALUES (current_tenant_id(), TG_TABLE_NAME, 1, clock_timestamp())
+        ON CONFLICT (tenant_id, table_name) DO UPDATE
+            SET version    = tv.version + 1,
+                updated_at = clock_timestamp();
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,2 @@
+DROP TABLE monthly_costs_state;
+DROP TABLE monthly_costs;
@@ -0,0 +1,41 @@
+-- помесячные итоги стоимости подписок по пользователям и сервисам. Подписка учитывается в каждом месяце
+-- с начала по дату окончания, а подписка без даты окончания - по месяц through_month из monthly_costs_state.
+-- Итоги изменяются в одной транзакции с подписками, поэтому стоимость за месяц можно брать из них
+CREATE TABLE monthly_costs
+(
+    tenant_id     UUID    NOT NULL DEFAULT current_tenant_id() REFERENCES tenants (id),
+    month         DATE    NOT NULL,
+    user_id       UUID    NOT NULL,
+    service_name  TEXT    NOT NULL,
+    total         BIGINT  NOT NULL,
+    subscriptions INTEGER NOT NULL,
+    PRIMARY KEY (tenant_id, month, user_id, service_name)
+);
+
+ALTER TABLE monthly_costs ENABLE ROW LEVEL SECURITY;
+ALTER TABLE monthly_costs FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON monthly_costs
+    USING (tenant_visible(tenant_id)) WITH CHECK (tenant_visible(tenant_id));
+
+-- месяц, по который в итогах учтены подписки без даты окончания, общий для всех тенантов. Изменения подписок
+-- блокируют строку на чтение, продление итогов на новый месяц и их пересборка - на запись
+CREATE TABLE monthly_costs_state
+(
+    singleton     BOOLEAN PRIMARY KEY DEFAULT true CHECK (singleton),
+    through_month DATE NOT NULL
+);
+
+INSERT INTO monthly_costs_state (through_month)
+VALUES (date_trunc('month', CURRENT_DATE));
+
+-- итоги по уже существующим подпискам всех тенантов
+SELECT set_config('app.all_tenants', 'on', true);
+
+INSERT INTO monthly_costs (tenant_id, month, user_id, service_name, total, subscriptions)
+SELECT s.tenant_id, m::date, s.user_id, s.service_name, SUM(s.price), count(*)
+FROM subscriptions s,
+     monthly_costs_state st,
+     generate_series(s.start_date, COALESCE(s.end_date, st.through_month), interval '1 month') m
+GROUP BY s.tenant_id, m, s.user_id, s.service_name;
+
+SELECT set_config('app.all_tenants', '', true);
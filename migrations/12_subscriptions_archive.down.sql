@@ -0,0 +1,15 @@
+-- архивные подписки возвращаются в subscriptions, чтобы откат не терял данные
+DROP VIEW subscription_history;
+
+SELECT set_config('app.all_tenants', 'on', true);
+
+INSERT INTO subscriptions (tenant_id, id, service_name, price, user_id, start_date, end_date, cancellation_reason,
+                           cancelled_at, service_id, category, tags)
+SELECT a.tenant_id, a.id, a.service_name, a.price, a.user_id, a.start_date, a.end_date, a.cancellation_reason,
+       a.cancelled_at, sv.id, a.category, a.tags
+FROM subscriptions_archive a
+         LEFT JOIN services sv ON sv.id = a.service_id;
+
+SELECT set_config('app.all_tenants', '', true);
+
+DROP TABLE subscriptions_archive;
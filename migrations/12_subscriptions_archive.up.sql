@@ -0,0 +1,38 @@
+-- архив подписок, закончившихся давно: фоновая задача переносит их из subscriptions, чтобы горячая таблица
+-- и ее индексы не росли за счет строк, которые уже не изменяются. id сохраняются, service_id остается таким,
+-- каким был при переносе
+CREATE TABLE subscriptions_archive
+(
+    id                  BIGINT PRIMARY KEY,
+    tenant_id           UUID        NOT NULL REFERENCES tenants (id),
+    service_name        TEXT        NOT NULL,
+    price               INTEGER     NOT NULL,
+    user_id             UUID        NOT NULL,
+    start_date          DATE        NOT NULL,
+    end_date            DATE        NOT NULL,
+    cancellation_reason TEXT,
+    cancelled_at        TIMESTAMPTZ,
+    service_id          BIGINT,
+    category            TEXT,
+    tags                TEXT[]      NOT NULL DEFAULT '{}',
+    archived_at         TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_subscriptions_archive_tenant_user ON subscriptions_archive (tenant_id, user_id);
+CREATE INDEX idx_subscriptions_archive_tenant_dates ON subscriptions_archive (tenant_id, start_date, end_date);
+
+ALTER TABLE subscriptions_archive ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscriptions_archive FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON subscriptions_archive
+    USING (tenant_visible(tenant_id)) WITH CHECK (tenant_visible(tenant_id));
+
+-- все подписки вместе с архивными, по ним считается стоимость за период. Представление читает таблицы с правами
+-- владельца, на которого политики действуют благодаря FORCE ROW LEVEL SECURITY
+CREATE VIEW subscription_history AS
+SELECT tenant_id, id, service_name, price, user_id, start_date, end_date, cancellation_reason, cancelled_at,
+       service_id, category, tags
+FROM subscriptions
+UNION ALL
+SELECT tenant_id, id, service_name, price, user_id, start_date, end_date, cancellation_reason, cancelled_at,
+       service_id, category, tags
+FROM subscriptions_archive;
@@ -0,0 +1,3 @@
+DROP INDEX idx_webhook_deliveries_user_id;
+DROP INDEX idx_outbox_user_id;
+DROP TABLE erasure_receipts;
@@ -0,0 +1,28 @@
+-- квитанции об удалении данных пользователей. Вместо id пользователя хранится subject_hash - HMAC-SHA256 от его id,
+-- digest - HMAC-SHA256 от остальных полей, по которому проверяется, что квитанция не изменилась после выдачи.
+-- Обе подписи вычисляются ключом PRIVACY_RECEIPT_KEY, которого нет в бд, поэтому их нельзя ни пересчитать, ни подделать
+CREATE TABLE erasure_receipts
+(
+    id                     UUID PRIMARY KEY,
+    tenant_id              UUID        NOT NULL DEFAULT current_tenant_id() REFERENCES tenants (id),
+    subject_hash           TEXT        NOT NULL,
+    erased_at              TIMESTAMPTZ NOT NULL,
+    subscriptions          INTEGER     NOT NULL,
+    archived_subscriptions INTEGER     NOT NULL,
+    monthly_costs          INTEGER     NOT NULL,
+    api_keys               INTEGER     NOT NULL,
+    events                 INTEGER     NOT NULL,
+    webhook_deliveries     INTEGER     NOT NULL,
+    digest                 TEXT        NOT NULL
+);
+
+CREATE INDEX idx_erasure_receipts_tenant_subject ON erasure_receipts (tenant_id, subject_hash);
+
+ALTER TABLE erasure_receipts ENABLE ROW LEVEL SECURITY;
+ALTER TABLE erasure_receipts FORCE ROW LEVEL SECURITY;
+CREATE POLICY tenant_isolation ON erasure_receipts
+    USING (tenant_visible(tenant_id)) WITH CHECK (tenant_visible(tenant_id));
+
+-- события выбираются по пользователю подписки при выгрузке и обезличивании его данных
+CREATE INDEX idx_outbox_user_id ON outbox ((payload -> 'data' ->> 'user_id'));
+CREATE INDEX idx_webhook_deliveries_user_id ON webhook_deliveries ((payload -> 'data' ->> 'user_id'));
@@ -0,0 +1,2 @@
+DROP INDEX idx_webhook_deliveries_finished;
+DROP INDEX idx_outbox_published_at;
@@ -0,0 +1,5 @@
+-- опубликованные события и завершенные доставки вебхуков удаляются по истечении срока хранения,
+-- индексы позволяют выбирать их без просмотра всей таблицы
+CREATE INDEX idx_outbox_published_at ON outbox (published_at) WHERE published_at IS NOT NULL;
+CREATE INDEX idx_webhook_deliveries_finished ON webhook_deliveries ((COALESCE(delivered_at, next_attempt_at)))
+    WHERE status <> 'pending';
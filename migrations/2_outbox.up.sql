@@ -0,0 +1,17 @@
+CREATE TABLE outbox
+(
+    id              BIGSERIAL PRIMARY KEY,
+    event_id        UUID        NOT NULL UNIQUE,
+    event_type      TEXT        NOT NULL,
+    payload         JSONB       NOT NULL,
+    attempts        INTEGER     NOT NULL DEFAULT 0,
+    last_error      TEXT,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    published_at    TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_outbox_pending ON outbox (id) WHERE published_at IS NULL;
+
+-- события из outbox доставляются не менее одного раза, поэтому повторная публикация не должна дублировать доставки вебхуков
+CREATE UNIQUE INDEX idx_webhook_deliveries_webhook_event ON webhook_deliveries (webhook_id, event_id);
@@ -0,0 +1,3 @@
+ALTER TABLE subscriptions
+    ADD COLUMN cancellation_reason TEXT,
+    ADD COLUMN cancelled_at        TIMESTAMPTZ;
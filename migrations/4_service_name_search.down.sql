@@ -0,0 +1,4 @@
+DROP INDEX idx_subscriptions_service_name_trgm;
+DROP INDEX idx_subscriptions_service_name_lower;
+
+-- расширение pg_trgm не удаляется: оно могло быть установлено до миграции и использоваться не только сервисом
@@ -0,0 +1,7 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX idx_subscriptions_service_name_lower
+    ON subscriptions (lower(btrim(service_name)));
+
+CREATE INDEX idx_subscriptions_service_name_trgm
+    ON subscriptions USING gin (lower(service_name) gin_trgm_ops);
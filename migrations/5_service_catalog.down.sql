@@ -0,0 +1,6 @@
+-- названия в подписках остаются каноническими названиями каталога: исходные написания не сохранялись
+ALTER TABLE subscriptions
+    DROP COLUMN service_id;
+
+DROP TABLE service_names;
+DROP TABLE services;
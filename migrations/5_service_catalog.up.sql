@@ -0,0 +1,42 @@
+CREATE TABLE services
+(
+    id            BIGSERIAL PRIMARY KEY,
+    name          TEXT   NOT NULL,
+    aliases       TEXT[] NOT NULL DEFAULT '{}',
+    category      TEXT,
+    default_price INTEGER CHECK (default_price IS NULL OR default_price > 0),
+    website       TEXT
+);
+
+-- все написания названия (каноническое и псевдонимы) в нормализованном виде, по ним название из подписки
+-- сопоставляется с каталогом. Первичный ключ не дает двум сервисам претендовать на одно написание
+CREATE TABLE service_names
+(
+    name_key   TEXT PRIMARY KEY,
+    service_id BIGINT NOT NULL REFERENCES services (id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_service_names_service_id ON service_names (service_id);
+
+ALTER TABLE subscriptions
+    ADD COLUMN service_id BIGINT REFERENCES services (id) ON DELETE SET NULL;
+
+CREATE INDEX idx_subscriptions_service_id ON subscriptions (service_id);
+
+-- заполнение каталога из существующих подписок: написания, отличающиеся только регистром и пробелами по краям,
+-- становятся одним сервисом с самым частым написанием в качестве канонического
+INSERT INTO services (name)
+SELECT mode() WITHIN GROUP (ORDER BY btrim(service_name))
+FROM subscriptions
+GROUP BY lower(btrim(service_name));
+
+INSERT INTO service_names (name_key, service_id)
+SELECT lower(btrim(name)), id
+FROM services;
+
+UPDATE subscriptions s
+SET service_id   = sn.service_id,
+    service_name = sv.name
+FROM service_names sn
+         JOIN services sv ON sv.id = sn.service_id
+WHERE sn.name_key = lower(btrim(s.service_name));
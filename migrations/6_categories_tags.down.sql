@@ -0,0 +1,4 @@
+-- индексы по колонкам удаляются вместе с ними
+ALTER TABLE subscriptions
+    DROP COLUMN category,
+    DROP COLUMN tags;
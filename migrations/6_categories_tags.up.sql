@@ -0,0 +1,17 @@
+ALTER TABLE subscriptions
+    ADD COLUMN category TEXT,
+    ADD COLUMN tags     TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX idx_subscriptions_category ON subscriptions (category);
+CREATE INDEX idx_subscriptions_tags ON subscriptions USING gin (tags);
+
+-- категории хранятся в нижнем регистре, чтобы "SaaS" и "saas" попадали в одну группу
+UPDATE services
+SET category = lower(btrim(category))
+WHERE category IS NOT NULL;
+
+UPDATE subscriptions s
+SET category = sv.category
+FROM services sv
+WHERE sv.id = s.service_id
+  AND sv.category IS NOT NULL;
@@ -0,0 +1,4 @@
+DROP TRIGGER subscriptions_bump_version ON subscriptions;
+DROP FUNCTION bump_table_version();
+
+DROP TABLE table_versions;
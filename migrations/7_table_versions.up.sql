@@ -0,0 +1,28 @@
+-- счетчик изменений таблиц, из которого строятся валидаторы HTTP-кэша (ETag, Last-Modified)
+CREATE TABLE table_versions
+(
+    table_name TEXT PRIMARY KEY,
+    version    BIGINT      NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO table_versions (table_name)
+VALUES ('subscriptions');
+
+-- триггер уровня оператора: один оператор увеличивает версию один раз, сколько бы строк он ни изменил
+CREATE FUNCTION bump_table_version() RETURNS TRIGGER AS
+$$
+BEGIN
+    UPDATE table_versions
+    SET version    = version + 1,
+        updated_at = clock_timestamp()
+    WHERE table_name = TG_TABLE_NAME;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER subscriptions_bump_version
+    AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE
+    ON subscriptions
+    FOR EACH STATEMENT
+EXECUTE FUNCTION bump_table_version();
@@ -0,0 +1,10 @@
+-- состояние корзин токенов для ограничения частоты запросов, общее для всех экземпляров сервиса
+CREATE UNLOGGED TABLE rate_limits
+(
+    key        TEXT PRIMARY KEY,
+    tokens     DOUBLE PRECISION NOT NULL,
+    allowed    BOOLEAN          NOT NULL,
+    updated_at TIMESTAMPTZ      NOT NULL
+);
+
+CREATE INDEX idx_rate_limits_updated_at ON rate_limits (updated_at);
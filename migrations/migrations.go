@@ -0,0 +1,16 @@
+// Package migrations содержит схемы бд, встроенные в бинарник. Миграции Postgres в корне каталога применяются
+// командой migrate сервиса, автоматически при запуске или утилитой migrate, миграции SQLite применяются
+// хранилищем SQLite при подключении
+package migrations
+
+import "embed"
+
+// Postgres - миграции схемы Postgres, пары файлов <версия>_<название>.up.sql и <версия>_<название>.down.sql
+//
+//go:embed *.sql
+var Postgres embed.FS
+
+// SQLite - миграции схемы SQLite, файлы вида <версия>_<название>.up.sql в каталоге sqlite
+//
+//go:embed sqlite/*.up.sql
+var SQLite embed.FS
@@ -0,0 +1,142 @@
+-- схема хранилища SQLite: те же таблицы, что и в Postgres после всех миграций. Тенанты изолируются условиями
+-- в запросах, массивы хранятся в JSON, даты - строками YYYY-MM-DD, время - строками в UTC одинаковой длины,
+-- поэтому и те и другие сравниваются как строки
+CREATE TABLE tenants
+(
+    id         TEXT PRIMARY KEY,
+    name       TEXT      NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL
+);
+
+-- тенант по умолчанию: ему принадлежат запросы без аутентификации
+INSERT INTO tenants (id, name, created_at)
+VALUES ('00000000-0000-0000-0000-000000000001', 'default', strftime('%Y-%m-%d %H:%M:%f', 'now'));
+
+CREATE TABLE table_versions
+(
+    tenant_id  TEXT      NOT NULL REFERENCES tenants (id),
+    table_name TEXT      NOT NULL,
+    version    INTEGER   NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (tenant_id, table_name)
+);
+
+INSERT INTO table_versions (tenant_id, table_name, updated_at)
+VALUES ('00000000-0000-0000-0000-000000000001', 'subscriptions', strftime('%Y-%m-%d %H:%M:%f', 'now'));
+
+CREATE TABLE services
+(
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id     TEXT NOT NULL REFERENCES tenants (id),
+    name          TEXT NOT NULL,
+    aliases       TEXT NOT NULL DEFAULT '[]',
+    category      TEXT,
+    default_price INTEGER CHECK (default_price IS NULL OR default_price > 0),
+    website       TEXT
+);
+
+CREATE INDEX idx_services_tenant_id ON services (tenant_id);
+
+CREATE TABLE service_names
+(
+    tenant_id  TEXT    NOT NULL REFERENCES tenants (id),
+    name_key   TEXT    NOT NULL,
+    service_id INTEGER NOT NULL REFERENCES services (id) ON DELETE CASCADE,
+    PRIMARY KEY (tenant_id, name_key)
+);
+
+CREATE INDEX idx_service_names_service_id ON service_names (service_id);
+
+CREATE TABLE subscriptions
+(
+    id                  INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id           TEXT    NOT NULL REFERENCES tenants (id),
+    service_name        TEXT    NOT NULL,
+    price               INTEGER NOT NULL CHECK (price >= 0),
+    user_id             TEXT    NOT NULL,
+    start_date          DATE    NOT NULL CHECK (strftime('%d', start_date) = '01'),
+    end_date            DATE CHECK (end_date IS NULL OR strftime('%d', end_date) = '01'),
+    cancellation_reason TEXT,
+    cancelled_at        TIMESTAMP,
+    service_id          INTEGER REFERENCES services (id) ON DELETE SET NULL,
+    category            TEXT,
+    tags                TEXT    NOT NULL DEFAULT '[]'
+);
+
+CREATE INDEX idx_subscriptions_tenant_user ON subscriptions (tenant_id, user_id);
+CREATE INDEX idx_subscriptions_service_id ON subscriptions (service_id);
+
+CREATE TABLE webhooks
+(
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id  TEXT      NOT NULL REFERENCES tenants (id),
+    url        TEXT      NOT NULL,
+    secret     TEXT      NOT NULL,
+    events     TEXT      NOT NULL,
+    active     BOOLEAN   NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE webhook_deliveries
+(
+    id               INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id        TEXT      NOT NULL REFERENCES tenants (id),
+    webhook_id       INTEGER   NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_id         TEXT      NOT NULL,
+    event_type       TEXT      NOT NULL,
+    payload          TEXT      NOT NULL,
+    status           TEXT      NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts         INTEGER   NOT NULL DEFAULT 0,
+    last_status_code INTEGER,
+    last_error       TEXT,
+    next_attempt_at  TIMESTAMP NOT NULL,
+    delivered_at     TIMESTAMP,
+    created_at       TIMESTAMP NOT NULL,
+    UNIQUE (webhook_id, event_id)
+);
+
+CREATE INDEX idx_webhook_deliveries_pending ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+
+CREATE TABLE outbox
+(
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id       TEXT      NOT NULL REFERENCES tenants (id),
+    event_id        TEXT      NOT NULL UNIQUE,
+    event_type      TEXT      NOT NULL,
+    payload         TEXT      NOT NULL,
+    attempts        INTEGER   NOT NULL DEFAULT 0,
+    last_error      TEXT,
+    next_attempt_at TIMESTAMP NOT NULL,
+    published_at    TIMESTAMP,
+    created_at      TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_outbox_pending ON outbox (id) WHERE published_at IS NULL;
+
+CREATE TABLE api_keys
+(
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id    TEXT      NOT NULL REFERENCES tenants (id),
+    name         TEXT      NOT NULL,
+    prefix       TEXT      NOT NULL,
+    key_hash     TEXT      NOT NULL UNIQUE,
+    scopes       TEXT      NOT NULL,
+    user_id      TEXT,
+    expires_at   TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at   TIMESTAMP,
+    created_at   TIMESTAMP NOT NULL,
+    -- ключ без scope admin действует от имени конкретного пользователя. Подзапросы в CHECK запрещены,
+    -- поэтому scope ищется в JSON-массиве как строка
+    CHECK (instr(scopes, '"admin"') > 0 OR user_id IS NOT NULL)
+);
+
+CREATE TABLE rate_limits
+(
+    key        TEXT PRIMARY KEY,
+    tokens     REAL      NOT NULL,
+    allowed    BOOLEAN   NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_rate_limits_updated_at ON rate_limits (updated_at);
@@ -0,0 +1,37 @@
+-- помесячные итоги стоимости подписок по пользователям и сервисам, как в миграции Postgres 11_monthly_costs
+CREATE TABLE monthly_costs
+(
+    tenant_id     TEXT    NOT NULL REFERENCES tenants (id),
+    month         DATE    NOT NULL,
+    user_id       TEXT    NOT NULL,
+    service_name  TEXT    NOT NULL,
+    total         INTEGER NOT NULL,
+    subscriptions INTEGER NOT NULL,
+    PRIMARY KEY (tenant_id, month, user_id, service_name)
+);
+
+-- месяц, по который в итогах учтены подписки без даты окончания
+CREATE TABLE monthly_costs_state
+(
+    singleton     INTEGER PRIMARY KEY CHECK (singleton = 1),
+    through_month DATE NOT NULL
+);
+
+INSERT INTO monthly_costs_state (singleton, through_month)
+VALUES (1, date('now', 'start of month'));
+
+WITH RECURSIVE months (id, month) AS (SELECT id, start_date
+                                     FROM subscriptions
+                                     UNION ALL
+                                     SELECT months.id, date(months.month, '+1 month')
+                                     FROM months
+                                              JOIN subscriptions s ON s.id = months.id
+                                     WHERE date(months.month, '+1 month') <= COALESCE(s.end_date,
+                                                                                      (SELECT through_month FROM monthly_costs_state)))
+INSERT
+INTO monthly_costs (tenant_id, month, user_id, service_name, total, subscriptions)
+SELECT s.tenant_id, months.month, s.user_id, s.service_name, SUM(s.price), count(*)
+FROM months
+         JOIN subscriptions s ON s.id = months.id
+WHERE months.month <= COALESCE(s.end_date, (SELECT through_month FROM monthly_costs_state))
+GROUP BY s.tenant_id, months.month, s.user_id, s.service_name;
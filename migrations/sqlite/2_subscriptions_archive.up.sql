@@ -0,0 +1,28 @@
+-- архив давно закончившихся подписок и подписки вместе с архивными, как в миграции Postgres 12_subscriptions_archive
+CREATE TABLE subscriptions_archive
+(
+    id                  INTEGER PRIMARY KEY,
+    tenant_id           TEXT      NOT NULL REFERENCES tenants (id),
+    service_name        TEXT      NOT NULL,
+    price               INTEGER   NOT NULL,
+    user_id             TEXT      NOT NULL,
+    start_date          DATE      NOT NULL,
+    end_date            DATE      NOT NULL,
+    cancellation_reason TEXT,
+    cancelled_at        TIMESTAMP,
+    service_id          INTEGER,
+    category            TEXT,
+    tags                TEXT      NOT NULL DEFAULT '[]',
+    archived_at         TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_subscriptions_archive_tenant_user ON subscriptions_archive (tenant_id, user_id);
+
+CREATE VIEW subscription_history AS
+SELECT tenant_id, id, service_name, price, user_id, start_date, end_date, cancellation_reason, cancelled_at,
+       service_id, category, tags
+FROM subscriptions
+UNION ALL
+SELECT tenant_id, id, service_name, price, user_id, start_date, end_date, cancellation_reason, cancelled_at,
+       service_id, category, tags
+FROM subscriptions_archive;
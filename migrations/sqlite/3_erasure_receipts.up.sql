@@ -0,0 +1,17 @@
+-- квитанции об удалении данных пользователей, как в миграции Postgres 13_erasure_receipts
+CREATE TABLE erasure_receipts
+(
+    id                     TEXT PRIMARY KEY,
+    tenant_id              TEXT      NOT NULL REFERENCES tenants (id),
+    subject_hash           TEXT      NOT NULL,
+    erased_at              TIMESTAMP NOT NULL,
+    subscriptions          INTEGER   NOT NULL,
+    archived_subscriptions INTEGER   NOT NULL,
+    monthly_costs          INTEGER   NOT NULL,
+    api_keys               INTEGER   NOT NULL,
+    events                 INTEGER   NOT NULL,
+    webhook_deliveries     INTEGER   NOT NULL,
+    digest                 TEXT      NOT NULL
+);
+
+CREATE INDEX idx_erasure_receipts_tenant_subject ON erasure_receipts (tenant_id, subject_hash);
@@ -0,0 +1,4 @@
+-- индексы для удаления опубликованных событий и завершенных доставок, как в миграции Postgres 14_event_retention
+CREATE INDEX idx_outbox_published_at ON outbox (published_at) WHERE published_at IS NOT NULL;
+CREATE INDEX idx_webhook_deliveries_finished ON webhook_deliveries (COALESCE(delivered_at, next_attempt_at))
+    WHERE status <> 'pending';